@@ -3,14 +3,19 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"log"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/scrapfly/go-scrapfly"
 	"github.com/scrapfly/scrapfly-mcp/pkg/authenticableClient"
 	"github.com/scrapfly/scrapfly-mcp/pkg/provider"
 	scrapflyprovider "github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly"
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser"
 	"github.com/scrapfly/scrapfly-mcp/pkg/server"
 )
 
@@ -19,6 +24,12 @@ var (
 	apiKey   = flag.String("apikey", "", "if set, use this API key, instead of the one in the environment variable")
 	apiHost  = flag.String("host", "", "if set, override the Scrapfly API host (e.g. https://api.scrapfly.local for local dev cluster). Falls back to SCRAPFLY_API_HOST env var, then to the SDK default https://api.scrapfly.io.")
 	browserHost = flag.String("browser-host", "", "if set, override the Scrapfly Cloud Browser host (e.g. https://browser.scrapfly.local). Falls back to SCRAPFLY_BROWSER_HOST env var, then derives from -host by replacing the leading 'api.' with 'browser.', then to the SDK default https://browser.scrapfly.io.")
+	psiEntities = flag.String("psi-entities", "", "if set, path to a JSON file ({\"Entity\": [\"domain.com\", ...]}) extending the built-in third-party entity map used by the performance report. Falls back to SCRAPFLY_PSI_ENTITIES env var.")
+	browserIdleTimeout = flag.Duration("browser-idle-timeout", scrapflyprovider.DefaultBrowserIdleTimeout, "if set, release Cloud Browser sessions no tool has used for this long (e.g. 10m); sessions a human has taken over are kept. Off by default. Falls back to SCRAPFLY_BROWSER_IDLE_TIMEOUT env var.")
+	downloadDir = flag.String("download-dir", "", "if set, local directory Cloud Browser downloads can be saved to (cloud_browser_downloads save=true, /browser/download?save=1). Falls back to SCRAPFLY_DOWNLOAD_DIR env var.")
+	cdpURL = flag.String("cdp-url", "", "if set, cloud_browser_open connects to this CDP endpoint instead of a Scrapfly Cloud Browser: a ws:// URL, or the http://host:port of a Chrome started with --remote-debugging-port. Browser tools fall back to standard CDP where Cloud Browser features are missing. Falls back to SCRAPFLY_CDP_URL env var.")
+	corsOrigins = flag.String("cors-origins", "", "comma-separated origins allowed to call the authenticated HTTP server (/mcp and /browser/*), with credentials. Default allows any origin without credentials. Falls back to SCRAPFLY_CORS_ORIGINS env var.")
+	webmcpTimeouts = flag.String("webmcp-timeout", "", "if set, comma-separated TOOL=DURATION pairs overriding how long page WebMCP tool calls wait for a response (default 30s, max 5m), by page tool name; * applies to every other tool (e.g. checkout=2m,*=45s). Falls back to SCRAPFLY_WEBMCP_TIMEOUT env var.")
 	verifySSLFlag = flag.Bool("verify-ssl", true, "verify TLS certificates on outbound calls. Set false ONLY when targeting a self-signed dev host (api.scrapfly.local). Falls back to SCRAPFLY_VERIFY_SSL env var (`0`/`false` to disable).")
 )
 
@@ -39,6 +50,10 @@ func deriveBrowserHostFromAPI(apiHost string) string {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "mock-api" {
+		runMockAPI(os.Args[2:])
+		return
+	}
 	flag.Parse()
 
 	apikey := *apiKey
@@ -76,6 +91,35 @@ func main() {
 		verify = !(v == "0" || v == "false" || v == "False")
 	}
 
+	// Third-party entity map for PSI reports: -psi-entities > SCRAPFLY_PSI_ENTITIES.
+	// A bad file is logged and ignored — the built-in map still applies.
+	entitiesPath := *psiEntities
+	if entitiesPath == "" {
+		entitiesPath = os.Getenv("SCRAPFLY_PSI_ENTITIES")
+	}
+	if entitiesPath != "" {
+		if err := browser.LoadEntitiesFile(entitiesPath); err != nil {
+			log.Printf("[SCRAPFLY-MCP] Ignoring -psi-entities %s: %v", entitiesPath, err)
+		}
+	}
+
+	dir := *downloadDir
+	if dir == "" {
+		dir = os.Getenv("SCRAPFLY_DOWNLOAD_DIR")
+	}
+	if dir != "" {
+		if err := browser.SetDownloadDir(dir); err != nil {
+			log.Printf("[SCRAPFLY-MCP] Ignoring -download-dir %s: %v", dir, err)
+		}
+	}
+
+	// Generic CDP browser: -cdp-url > SCRAPFLY_CDP_URL. Browser sessions
+	// then need no API key; the other tools still do.
+	cdpEndpoint := *cdpURL
+	if cdpEndpoint == "" {
+		cdpEndpoint = os.Getenv("SCRAPFLY_CDP_URL")
+	}
+
 		// Determine HTTP address: -http flag takes precedence, then PORT env var
 	addr := *httpAddr
 	if addr == "" {
@@ -85,7 +129,7 @@ func main() {
 	}
 
 
-	if apikey == "" && addr == "" {
+	if apikey == "" && addr == "" && cdpEndpoint == "" {
 		log.Fatal("Either apikey (as an argument or as an environment variable) or httpdAddr must must be set.")
 	}
 
@@ -118,7 +162,11 @@ func main() {
 	}
 
 	clientGetter := func(p *scrapflyprovider.ScrapflyToolProvider, ctx context.Context) (*scrapfly.Client, error) {
-		return makeClient(), nil
+		c := makeClient()
+		if c == nil {
+			return nil, fmt.Errorf("no Scrapfly API key configured (set -apikey or SCRAPFLY_API_KEY)")
+		}
+		return c, nil
 	}
 
 	if apikey == "" && addr != "" {
@@ -131,6 +179,45 @@ func main() {
 		clientGetter,
 		nil)
 
+	// Idle reaping window: -browser-idle-timeout > SCRAPFLY_BROWSER_IDLE_TIMEOUT > off.
+	idleTimeout := *browserIdleTimeout
+	if v := os.Getenv("SCRAPFLY_BROWSER_IDLE_TIMEOUT"); v != "" && !isFlagSet("browser-idle-timeout") {
+		if d, err := time.ParseDuration(v); err == nil {
+			idleTimeout = d
+		} else {
+			log.Printf("[SCRAPFLY-MCP] Ignoring SCRAPFLY_BROWSER_IDLE_TIMEOUT=%q: %v", v, err)
+		}
+	}
+	scrapflyToolProvider.SetBrowserIdleTimeout(idleTimeout)
+
+	// Page WebMCP tool timeouts: -webmcp-timeout > SCRAPFLY_WEBMCP_TIMEOUT.
+	webmcpSpec := *webmcpTimeouts
+	if webmcpSpec == "" {
+		webmcpSpec = os.Getenv("SCRAPFLY_WEBMCP_TIMEOUT")
+	}
+	if webmcpSpec != "" {
+		if timeouts, err := scrapflyprovider.ParseWebMCPToolTimeouts(webmcpSpec); err != nil {
+			log.Printf("[SCRAPFLY-MCP] Ignoring -webmcp-timeout %s: %v", webmcpSpec, err)
+		} else {
+			scrapflyToolProvider.SetWebMCPToolTimeouts(timeouts)
+		}
+	}
+
+	if cdpEndpoint != "" {
+		scrapflyToolProvider.SetCDPEndpoint(cdpEndpoint)
+		log.Printf("[SCRAPFLY-MCP] Browser sessions use the CDP endpoint %s instead of the Scrapfly Cloud Browser", cdpEndpoint)
+	}
+
+	// Release open Cloud Browser sessions (pool slots) on the way out —
+	// on a signal in both modes, and when stdin closes in stdio mode.
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		<-sig
+		scrapflyToolProvider.Shutdown()
+		os.Exit(0)
+	}()
+
 	toolProvider := provider.NewToolProvider("scrapfly", scrapflyToolProvider)
 
 	server := server.NewScrapflyMCPServer(toolProvider)
@@ -140,10 +227,35 @@ func main() {
 	if addr != "" { // httpAddr is actually string parsed WITH port number. port only imply 0.0.0.0 eg :1123
 		server.WithHttpAddr(addr)
 		if apikey == "" {
-			server.WithStreamableServerFunction(authenticableClient.CorsAndAuthenticatedStreamableServerFunction)
+			cors := authenticableClient.DefaultCorsOptions
+			origins := *corsOrigins
+			if origins == "" {
+				origins = os.Getenv("SCRAPFLY_CORS_ORIGINS")
+			}
+			if origins != "" {
+				cors = authenticableClient.CorsOptions{AllowCredentials: true}
+				for _, o := range strings.Split(origins, ",") {
+					if o = strings.TrimSpace(o); o != "" {
+						cors.AllowedOrigins = append(cors.AllowedOrigins, o)
+					}
+				}
+			}
+			server.WithStreamableServerFunction(authenticableClient.CorsAndAuthenticatedStreamableServerFunctionWith(cors))
 		}
 		server.ServeStreamable()
 	} else {
 		server.ServeStdio()
+		scrapflyToolProvider.Shutdown()
 	}
 }
+
+// isFlagSet reports whether name was passed on the command line.
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
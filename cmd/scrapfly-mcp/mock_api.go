@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/scrapfly/scrapfly-mcp/pkg/mockapi"
+)
+
+// faultFlags collects repeated -fail specs.
+type faultFlags []string
+
+func (f *faultFlags) String() string     { return strings.Join(*f, " ") }
+func (f *faultFlags) Set(v string) error { *f = append(*f, v); return nil }
+
+// runMockAPI is `scrapfly-mcp mock-api`: a local mock of the Scrapfly API
+// (and Cloud Browser host) to run the server against offline or in CI.
+func runMockAPI(args []string) {
+	fs := flag.NewFlagSet("mock-api", flag.ExitOnError)
+	addr := fs.String("addr", "127.0.0.1:8765", "address to listen on")
+	fixtures := fs.String("fixtures", "", "directory of JSON fixtures to serve; with -record, the directory recorded fixtures are written to")
+	record := fs.String("record", "", "if set, record mode: forward API requests to this upstream (e.g. https://api.scrapfly.io) and save each response as a fixture under -fixtures")
+	apiKey := fs.String("apikey", "", "if set, reject requests made with any other API key (default accepts any key)")
+	latency := fs.Duration("latency", 0, "delay every API response by this much")
+	jitter := fs.Duration("jitter", 0, "add a random delay up to this much to every API response")
+	var faults faultFlags
+	fs.Var(&faults, "fail", "inject failures, repeatable: PATH=KIND[,retry=DURATION][,times=N][,rate=P], KIND being 429, a 5xx status or asp (e.g. /scrape=429,retry=5s,times=2 or *=503,rate=0.1)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: scrapfly-mcp mock-api [flags]\n\nServe a local mock of the Scrapfly API.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	srv := mockapi.NewServer()
+	defer srv.Close()
+	srv.Latency, srv.Jitter = *latency, *jitter
+	if *apiKey != "" {
+		srv.RequireAPIKey(*apiKey)
+	}
+	for _, spec := range faults {
+		path, f, err := mockapi.ParseFault(spec)
+		if err != nil {
+			log.Fatalf("[MOCK-API] -fail: %v", err)
+		}
+		srv.Inject(path, f)
+	}
+
+	switch {
+	case *record != "":
+		if *fixtures == "" {
+			log.Fatal("[MOCK-API] -record needs -fixtures, the directory to write fixtures to")
+		}
+		if err := srv.Record(*record, *fixtures); err != nil {
+			log.Fatalf("[MOCK-API] %v", err)
+		}
+		log.Printf("[MOCK-API] Recording %s into %s", *record, *fixtures)
+	case *fixtures != "":
+		n, err := srv.LoadFixtures(*fixtures)
+		if err != nil {
+			log.Fatalf("[MOCK-API] -fixtures: %v", err)
+		}
+		log.Printf("[MOCK-API] Loaded %d fixture(s) from %s", n, *fixtures)
+	}
+
+	host := "http://" + *addr
+	log.Printf("[MOCK-API] Listening on %s — point scrapfly-mcp at it with:", host)
+	log.Printf("[MOCK-API]   SCRAPFLY_API_HOST=%s SCRAPFLY_BROWSER_HOST=%s SCRAPFLY_API_KEY=mock scrapfly-mcp", host, host)
+	if err := http.ListenAndServe(*addr, srv); err != nil {
+		log.Printf("[MOCK-API] %v", err)
+		os.Exit(1)
+	}
+}
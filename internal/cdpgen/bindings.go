@@ -0,0 +1,375 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// externalRefs maps references into standard CDP domains onto the
+// chromedp/cdproto types, so generated code interoperates with them.
+var externalRefs = map[string]struct{ goType, importPath string }{
+	"Page.FrameId":           {"cdp.FrameID", "github.com/chromedp/cdproto/cdp"},
+	"DOM.BackendNodeId":      {"cdp.BackendNodeID", "github.com/chromedp/cdproto/cdp"},
+	"Accessibility.AXNodeId": {"accessibility.NodeID", "github.com/chromedp/cdproto/accessibility"},
+	"Runtime.StackTrace":     {"*runtime.StackTrace", "github.com/chromedp/cdproto/runtime"},
+}
+
+// initialisms are rendered upper-case in Go identifiers (golint style).
+var initialisms = map[string]string{
+	"id": "ID", "url": "URL", "ax": "AX", "css": "CSS", "xpath": "XPath",
+	"wpm": "WPM", "oopif": "OOPIF", "json": "JSON", "http": "HTTP", "html": "HTML",
+	"mcp": "MCP",
+}
+
+// goName converts a protocol name (camelCase, snake_case or kebab-case)
+// into an exported Go identifier.
+func goName(s string) string {
+	var words []string
+	var cur []rune
+	flush := func() {
+		if len(cur) > 0 {
+			words = append(words, string(cur))
+			cur = nil
+		}
+	}
+	rs := []rune(s)
+	for i, r := range rs {
+		switch {
+		case r == '_' || r == '-' || r == '.' || r == ' ':
+			flush()
+		case unicode.IsUpper(r) && i > 0 && (unicode.IsLower(rs[i-1]) || unicode.IsDigit(rs[i-1])):
+			flush()
+			cur = append(cur, r)
+		default:
+			cur = append(cur, r)
+		}
+	}
+	flush()
+	var b strings.Builder
+	for _, w := range words {
+		if up, ok := initialisms[strings.ToLower(w)]; ok {
+			b.WriteString(up)
+			continue
+		}
+		rw := []rune(w)
+		b.WriteRune(unicode.ToUpper(rw[0]))
+		b.WriteString(string(rw[1:]))
+	}
+	return b.String()
+}
+
+// comment renders a protocol description as a Go doc comment led by the
+// identifier, cdproto style: "// Name does the thing."
+func comment(b *strings.Builder, indent, lead, desc string) {
+	desc = strings.TrimSpace(desc)
+	if desc == "" {
+		fmt.Fprintf(b, "%s// %s.\n", indent, lead)
+		return
+	}
+	lines := strings.Split(desc, "\n")
+	fmt.Fprintf(b, "%s// %s %s\n", indent, lead, lowerLead(strings.TrimSpace(lines[0])))
+	for _, l := range lines[1:] {
+		l = strings.TrimRight(l, " ")
+		if l == "" {
+			fmt.Fprintf(b, "%s//\n", indent)
+		} else {
+			fmt.Fprintf(b, "%s// %s\n", indent, l)
+		}
+	}
+}
+
+// lowerLead lower-cases the first letter of a sentence unless it starts an
+// acronym or identifier ("URL of…", "AX node…").
+func lowerLead(s string) string {
+	rs := []rune(s)
+	if len(rs) < 2 || !unicode.IsUpper(rs[0]) || unicode.IsUpper(rs[1]) {
+		return s
+	}
+	rs[0] = unicode.ToLower(rs[0])
+	return string(rs)
+}
+
+type bindingGen struct {
+	d       *domain
+	types   map[string]*typeDef
+	imports map[string]bool
+	b       strings.Builder
+}
+
+func (g *bindingGen) w(format string, args ...any) { fmt.Fprintf(&g.b, format+"\n", args...) }
+
+// typeName is the Go name of a type declared in this domain.
+func (g *bindingGen) typeName(id string) string { return g.d.Domain + goName(id) }
+
+// isObject reports whether ref names a struct type of this domain.
+func (g *bindingGen) isObject(ref string) bool {
+	t, ok := g.types[ref]
+	return ok && t.Type == "object" && len(t.Properties) > 0
+}
+
+// goType resolves a parameter to its Go type. Objects are pointers; in
+// command parameters, optional non-string scalars are pointers too so an
+// explicit false / 0 reaches the browser instead of being omitted.
+func (g *bindingGen) goType(p *param, isParam bool) string {
+	if p.Ref != "" {
+		if ext, ok := externalRefs[p.Ref]; ok {
+			g.imports[ext.importPath] = true
+			return ext.goType
+		}
+		if strings.Contains(p.Ref, ".") {
+			g.imports["encoding/json"] = true
+			return "json.RawMessage"
+		}
+		if g.isObject(p.Ref) {
+			return "*" + g.typeName(p.Ref)
+		}
+		if _, ok := g.types[p.Ref]; ok {
+			return g.typeName(p.Ref)
+		}
+		g.imports["encoding/json"] = true
+		return "json.RawMessage"
+	}
+	var t string
+	switch p.Type {
+	case "string":
+		return "string"
+	case "integer":
+		t = "int64"
+	case "number":
+		t = "float64"
+	case "boolean":
+		t = "bool"
+	case "array":
+		if p.Items == nil {
+			g.imports["encoding/json"] = true
+			return "[]json.RawMessage"
+		}
+		return "[]" + g.goType(p.Items, false)
+	case "object":
+		if p.AdditionalProperties != nil {
+			return "map[string]" + g.goType(p.AdditionalProperties, false)
+		}
+		g.imports["encoding/json"] = true
+		return "json.RawMessage"
+	default:
+		g.imports["encoding/json"] = true
+		return "json.RawMessage"
+	}
+	if isParam && p.Optional {
+		return "*" + t
+	}
+	return t
+}
+
+func (g *bindingGen) fields(props []*param, isParam bool) {
+	for _, p := range props {
+		if p.Description != "" {
+			comment(&g.b, "\t", goName(p.Name), p.Description)
+		}
+		tag := p.Name
+		if p.Optional {
+			tag += ",omitempty"
+		}
+		g.w("\t%s %s `json:%q`", goName(p.Name), g.goType(p, isParam), tag)
+	}
+}
+
+func (g *bindingGen) genTypes() {
+	for _, t := range g.d.Types {
+		name := g.typeName(t.ID)
+		comment(&g.b, "", name, t.Description)
+		switch {
+		case t.Type == "string" && len(t.Enum) > 0:
+			g.w("type %s string", name)
+			g.w("")
+			g.w("// %s values.", name)
+			g.w("const (")
+			for _, v := range t.Enum {
+				g.w("\t%s%s %s = %q", name, goName(v), name, v)
+			}
+			g.w(")")
+		case t.Type == "object" && len(t.Properties) > 0:
+			g.w("type %s struct {", name)
+			g.fields(t.Properties, false)
+			g.w("}")
+		default:
+			g.w("type %s %s", name, g.goType(&param{Type: t.Type}, false))
+		}
+		g.w("")
+	}
+}
+
+func (g *bindingGen) genCommands() {
+	for _, c := range g.d.Commands {
+		method := g.d.Domain + "." + c.Name
+		name := g.d.Domain + goName(c.Name)
+		paramsType, returnsType := name+"Params", name+"Returns"
+
+		if len(c.Parameters) > 0 {
+			comment(&g.b, "", paramsType, "Parameters of "+method+".")
+			g.w("type %s struct {", paramsType)
+			g.fields(c.Parameters, true)
+			g.w("}")
+			g.w("")
+
+			// Constructor over the required parameters, With* for the rest.
+			var args, assigns []string
+			for _, p := range c.Parameters {
+				if p.Optional {
+					continue
+				}
+				arg := lowerFirst(goName(p.Name))
+				args = append(args, fmt.Sprintf("%s %s", arg, g.goType(p, true)))
+				assigns = append(assigns, fmt.Sprintf("%s: %s", goName(p.Name), arg))
+			}
+			g.w("// New%s builds %s parameters.", paramsType, method)
+			g.w("func New%s(%s) *%s {", paramsType, strings.Join(args, ", "), paramsType)
+			g.w("\treturn &%s{%s}", paramsType, strings.Join(assigns, ", "))
+			g.w("}")
+			g.w("")
+			for _, p := range c.Parameters {
+				if !p.Optional {
+					continue
+				}
+				field, arg := goName(p.Name), lowerFirst(goName(p.Name))
+				t := g.goType(p, true)
+				g.w("// With%s sets the optional %s parameter.", field, p.Name)
+				if p.Ref == "" && strings.HasPrefix(t, "*") {
+					// Optional scalar: stored as a pointer, taken by value.
+					g.w("func (p *%s) With%s(%s %s) *%s {", paramsType, field, arg, t[1:], paramsType)
+					g.w("\tp.%s = &%s", field, arg)
+				} else {
+					g.w("func (p *%s) With%s(%s %s) *%s {", paramsType, field, arg, t, paramsType)
+					g.w("\tp.%s = %s", field, arg)
+				}
+				g.w("\treturn p")
+				g.w("}")
+				g.w("")
+			}
+		}
+		if len(c.Returns) > 0 {
+			comment(&g.b, "", returnsType, "Return values of "+method+".")
+			g.w("type %s struct {", returnsType)
+			g.fields(c.Returns, false)
+			g.w("}")
+			g.w("")
+		}
+
+		comment(&g.b, "", name, c.Description)
+		sig, call := "ctx context.Context", "nil"
+		if len(c.Parameters) > 0 {
+			sig += ", params *" + paramsType
+			call = "params"
+		}
+		if len(c.Returns) > 0 {
+			g.w("func (s *Session) %s(%s) (*%s, error) {", name, sig, returnsType)
+			g.w("\tvar ret %s", returnsType)
+			g.w("\tif err := s.call(ctx, Command%s, %s, &ret); err != nil {", name, call)
+			g.w("\t\treturn nil, err")
+			g.w("\t}")
+			g.w("\treturn &ret, nil")
+		} else {
+			g.w("func (s *Session) %s(%s) error {", name, sig)
+			g.w("\treturn s.call(ctx, Command%s, %s, nil)", name, call)
+		}
+		g.w("}")
+		g.w("")
+	}
+}
+
+func (g *bindingGen) genEvents() {
+	for _, e := range g.d.Events {
+		name := g.d.Domain + goName(e.Name)
+		evType := "Event" + name
+		comment(&g.b, "", evType, e.Description)
+		g.w("type %s struct {", evType)
+		g.fields(e.Parameters, false)
+		g.w("}")
+		g.w("")
+		g.w("// On%s subscribes fn to %s.%s events. Return false from fn, or call", name, g.d.Domain, e.Name)
+		g.w("// the returned func, to unsubscribe.")
+		g.w("func (s *Session) On%s(fn func(*%s) bool) (unsubscribe func()) {", name, evType)
+		g.w("\treturn onEvent(s, EventName%s, fn)", name)
+		g.w("}")
+		g.w("")
+	}
+}
+
+func lowerFirst(s string) string {
+	// Leading initialisms lower-case as a whole: ID → id, URLFor → urlFor.
+	rs := []rune(s)
+	i := 0
+	for i < len(rs) && unicode.IsUpper(rs[i]) {
+		i++
+	}
+	if i > 1 && i < len(rs) {
+		i--
+	}
+	out := strings.ToLower(string(rs[:i])) + string(rs[i:])
+	if token.IsKeyword(out) {
+		return "v" + s
+	}
+	return out
+}
+
+// generateBindings emits the Go source for one domain.
+func generateBindings(pkg string, d *domain, sources string) string {
+	g := &bindingGen{d: d, types: map[string]*typeDef{}, imports: map[string]bool{"context": true}}
+	for _, t := range d.Types {
+		g.types[t.ID] = t
+	}
+
+	// Body first so imports are known.
+	g.w("// ── Types ───────────────────────────────────────────────────────────────────")
+	g.w("")
+	g.genTypes()
+	g.w("// ── Commands ────────────────────────────────────────────────────────────────")
+	g.w("")
+	g.genCommands()
+	if len(d.Events) > 0 {
+		g.w("// ── Events ──────────────────────────────────────────────────────────────────")
+		g.w("")
+		g.genEvents()
+	}
+	body := g.b.String()
+
+	var h strings.Builder
+	fmt.Fprintf(&h, "// Code generated by cdpgen from %s. DO NOT EDIT.\n\n", sources)
+	fmt.Fprintf(&h, "package %s\n\n", pkg)
+	imports := make([]string, 0, len(g.imports))
+	for imp := range g.imports {
+		imports = append(imports, imp)
+	}
+	sort.Slice(imports, func(i, j int) bool {
+		si, sj := !strings.Contains(imports[i], "."), !strings.Contains(imports[j], ".")
+		if si != sj {
+			return si
+		}
+		return imports[i] < imports[j]
+	})
+	h.WriteString("import (\n")
+	std := true
+	for _, imp := range imports {
+		if std && strings.Contains(imp, ".") {
+			h.WriteString("\n")
+			std = false
+		}
+		fmt.Fprintf(&h, "\t%q\n", imp)
+	}
+	h.WriteString(")\n\n")
+
+	// Method / event name constants.
+	h.WriteString("// " + d.Domain + " command and event method names.\n")
+	h.WriteString("const (\n")
+	for _, c := range d.Commands {
+		fmt.Fprintf(&h, "\tCommand%s%s = %q\n", d.Domain, goName(c.Name), d.Domain+"."+c.Name)
+	}
+	for _, e := range d.Events {
+		fmt.Fprintf(&h, "\tEventName%s%s = %q\n", d.Domain, goName(e.Name), d.Domain+"."+e.Name)
+	}
+	h.WriteString(")\n\n")
+	return h.String() + body
+}
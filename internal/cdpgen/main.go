@@ -0,0 +1,157 @@
+// Command cdpgen generates Go code from CDP protocol definitions for the
+// custom domains the Scrapfly browser speaks (Antibot, WebMCP,
+// ScrapiumBrowser) — the parts chromedp/cdproto doesn't cover.
+//
+// Two outputs, both from the same protocol files:
+//
+//	-mode bindings  typed types / params / returns / events plus Session
+//	                methods, one <domain>_gen.go file per domain, written
+//	                into -out (package browser).
+//	-mode schemas   the MCP tool schema map for the Antibot commands exposed
+//	                as tools (antibot_schemas_gen.go, package scrapflyprovider).
+//
+// Run through `go generate` (see the directives in the browser and
+// scrapflyprovider packages) or `make generate`.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ── Protocol model ─────────────────────────────────────────────────────────
+
+type protocol struct {
+	Domains []*domain `json:"domains"`
+}
+
+type domain struct {
+	Domain       string     `json:"domain"`
+	Description  string     `json:"description"`
+	Experimental bool       `json:"experimental"`
+	Dependencies []string   `json:"dependencies"`
+	Types        []*typeDef `json:"types"`
+	Commands     []*command `json:"commands"`
+	Events       []*event   `json:"events"`
+}
+
+type typeDef struct {
+	ID          string   `json:"id"`
+	Description string   `json:"description"`
+	Type        string   `json:"type"`
+	Enum        []string `json:"enum"`
+	Properties  []*param `json:"properties"`
+}
+
+type command struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Parameters  []*param `json:"parameters"`
+	Returns     []*param `json:"returns"`
+}
+
+type event struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Parameters  []*param `json:"parameters"`
+}
+
+type param struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Optional    bool   `json:"optional"`
+	Type        string `json:"type"`
+	Ref         string `json:"$ref"`
+	Items       *param `json:"items"`
+	// AdditionalProperties is a cdpgen extension (not in upstream CDP): an
+	// object parameter with this set is generated as map[string]<type>.
+	AdditionalProperties *param `json:"additionalProperties"`
+}
+
+type listFlag []string
+
+func (l *listFlag) String() string     { return strings.Join(*l, ",") }
+func (l *listFlag) Set(v string) error { *l = append(*l, v); return nil }
+
+func main() {
+	var protocols listFlag
+	flag.Var(&protocols, "protocol", "protocol JSON file (repeatable; later files add domains)")
+	mode := flag.String("mode", "bindings", "bindings | schemas")
+	domains := flag.String("domains", "Antibot,WebMCP,ScrapiumBrowser", "comma-separated domains to generate bindings for")
+	pkg := flag.String("pkg", "", "package name of the generated files (default: browser for bindings, scrapflyprovider for schemas)")
+	out := flag.String("out", ".", "output directory (bindings) or file (schemas)")
+	flag.Parse()
+
+	if len(protocols) == 0 {
+		log.Fatal("cdpgen: at least one -protocol is required")
+	}
+	byName := map[string]*domain{}
+	for _, path := range protocols {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatalf("cdpgen: %v", err)
+		}
+		var p protocol
+		if err := json.Unmarshal(raw, &p); err != nil {
+			log.Fatalf("cdpgen: parse %s: %v", path, err)
+		}
+		for _, d := range p.Domains {
+			byName[d.Domain] = d
+		}
+	}
+
+	switch *mode {
+	case "bindings":
+		if *pkg == "" {
+			*pkg = "browser"
+		}
+		for _, name := range strings.Split(*domains, ",") {
+			d, ok := byName[strings.TrimSpace(name)]
+			if !ok {
+				log.Fatalf("cdpgen: domain %q not found in %v", name, protocols)
+			}
+			src := generateBindings(*pkg, d, sourceNames(protocols))
+			file := filepath.Join(*out, strings.ToLower(d.Domain)+"_gen.go")
+			writeGo(file, src)
+		}
+	case "schemas":
+		if *pkg == "" {
+			*pkg = "scrapflyprovider"
+		}
+		d, ok := byName["Antibot"]
+		if !ok {
+			log.Fatal("cdpgen: Antibot domain not found in protocol")
+		}
+		writeGo(*out, generateSchemas(*pkg, d, sourceNames(protocols)))
+	default:
+		log.Fatalf("cdpgen: unknown -mode %q", *mode)
+	}
+}
+
+func sourceNames(paths []string) string {
+	names := make([]string, len(paths))
+	for i, p := range paths {
+		names[i] = filepath.Base(p)
+	}
+	return strings.Join(names, ", ")
+}
+
+// writeGo gofmts src and writes it to path. Unformattable output is still
+// written (with the error) so the offending line can be inspected.
+func writeGo(path, src string) {
+	formatted, err := format.Source([]byte(src))
+	if err != nil {
+		os.WriteFile(path, []byte(src), 0o644)
+		log.Fatalf("cdpgen: format %s: %v", path, err)
+	}
+	if err := os.WriteFile(path, formatted, 0o644); err != nil {
+		log.Fatalf("cdpgen: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "cdpgen: wrote %s\n", path)
+}
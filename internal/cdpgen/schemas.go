@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// webMCPCommands is the subset of Antibot commands exposed as MCP tools.
+var webMCPCommands = map[string]bool{
+	"fill": true, "clickOn": true, "typeText": true, "pressKey": true, "scroll": true, "hover": true,
+	"selectOption": true, "waitForElement": true, "isElementVisible": true, "locateElement": true,
+	"locateElementAcrossFrames": true, "moveTo": true, "dragAndDrop": true, "clickAndHold": true,
+	"clickAndSlide": true, "getFrames": true, "getMousePosition": true, "showCursor": true,
+}
+
+var schemaTypes = map[string]string{
+	"string":  "string",
+	"integer": "integer",
+	"number":  "number",
+	"boolean": "boolean",
+}
+
+// schemaDesc flattens a protocol description into a Go string literal body,
+// truncated to max runes.
+func schemaDesc(s string, max int) string {
+	s = strings.TrimSpace(strings.ReplaceAll(strings.ReplaceAll(s, `"`, `\"`), "\n", " "))
+	if r := []rune(s); len(r) > max {
+		s = string(r[:max-3]) + "..."
+	}
+	return s
+}
+
+func jsonType(t string) string {
+	if jt, ok := schemaTypes[t]; ok {
+		return jt
+	}
+	return "string"
+}
+
+func refSchema(ref string, types map[string]*typeDef) string {
+	switch ref {
+	case "Selector":
+		return "selectorSchema"
+	case "Coordinate":
+		return "coordinateSchema"
+	case "Page.FrameId", "Accessibility.AXNodeId":
+		return `map[string]any{"type": "string"}`
+	}
+	if t, ok := types[ref]; ok {
+		if t.Type == "string" && len(t.Enum) > 0 {
+			vals := make([]string, len(t.Enum))
+			for i, v := range t.Enum {
+				vals[i] = fmt.Sprintf("%q", v)
+			}
+			return fmt.Sprintf(`map[string]any{"type": "string", "enum": []string{%s}}`, strings.Join(vals, ", "))
+		}
+		return fmt.Sprintf(`map[string]any{"type": %q}`, jsonType(t.Type))
+	}
+	return `map[string]any{"type": "string"}`
+}
+
+func paramSchema(p *param, types map[string]*typeDef) string {
+	desc := schemaDesc(p.Description, 120)
+	if p.Ref != "" {
+		base := refSchema(p.Ref, types)
+		if p.Ref == "Selector" || p.Ref == "Coordinate" || desc == "" {
+			return base
+		}
+		return fmt.Sprintf(`withDesc(%s, "%s")`, base, desc)
+	}
+	if desc != "" {
+		return fmt.Sprintf(`map[string]any{"type": %q, "description": "%s"}`, jsonType(p.Type), desc)
+	}
+	return fmt.Sprintf(`map[string]any{"type": %q}`, jsonType(p.Type))
+}
+
+// generateSchemas emits the antibotToolSchemas map: one JSON Schema per
+// Antibot command in webMCPCommands, derived from its parameters.
+func generateSchemas(pkg string, d *domain, sources string) string {
+	types := map[string]*typeDef{}
+	for _, t := range d.Types {
+		types[t.ID] = t
+	}
+
+	var b strings.Builder
+	w := func(format string, args ...any) { fmt.Fprintf(&b, format+"\n", args...) }
+
+	w("// Code generated by cdpgen from %s. DO NOT EDIT.", sources)
+	w("")
+	w("package %s", pkg)
+	w("")
+	w(`// selectorSchema is the JSON Schema for the Antibot Selector type.
+var selectorSchema = map[string]any{
+	"type": "object",
+	"description": "Element selector. Use {type:\"axNodeId\", query:\"<id>\"} with the id from cloud_browser_snapshot (preferred). Also supports css, xpath, role, coord.",
+	"properties": map[string]any{
+		"type": map[string]any{
+			"type": "string",
+			"enum": []string{"axNodeId", "css", "xpath", "role", "coord"},
+			"description": "Selector type. Use axNodeId with id from snapshot (most reliable).",
+		},
+		"query": map[string]any{
+			"type": "string",
+			"description": "For axNodeId: the id from snapshot (e.g. 28). For css: CSS selector. For xpath: XPath. For coord: x,y.",
+		},
+	},
+	"required": []string{"type", "query"},
+}
+
+var coordinateSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"x": map[string]any{"type": "number"},
+		"y": map[string]any{"type": "number"},
+	},
+}
+
+func withDesc(schema map[string]any, desc string) map[string]any {
+	copy := make(map[string]any, len(schema)+1)
+	for k, v := range schema {
+		copy[k] = v
+	}
+	copy["description"] = desc
+	return copy
+}
+`)
+	w("// antibotToolSchemas maps Antibot CDP command names to MCP tool schema overrides.")
+	w("// Generated from the Antibot domain in %s.", sources)
+	w("var antibotToolSchemas = map[string]antibotToolOverride{")
+	for _, c := range d.Commands {
+		if !webMCPCommands[c.Name] {
+			continue
+		}
+		desc := schemaDesc(c.Description, 200)
+		for _, p := range c.Parameters {
+			if p.Ref == "Selector" {
+				desc += " Use cloud_browser_snapshot to find elements, then target with {type: axNodeId, query: <id>}."
+				break
+			}
+		}
+		w("\t%q: {", c.Name)
+		w("\t\tdescription: \"%s\",", desc)
+		if len(c.Parameters) == 0 {
+			w(`		schema: map[string]any{"type": "object", "properties": map[string]any{}},`)
+		} else {
+			w("\t\tschema: map[string]any{")
+			w(`			"type": "object",`)
+			w(`			"properties": map[string]any{`)
+			var required []string
+			for _, p := range c.Parameters {
+				w("\t\t\t\t%q: %s,", p.Name, paramSchema(p, types))
+				if !p.Optional {
+					required = append(required, fmt.Sprintf("%q", p.Name))
+				}
+			}
+			w("\t\t\t},")
+			if len(required) > 0 {
+				w("\t\t\t\"required\": []string{%s},", strings.Join(required, ", "))
+			}
+			w("\t\t},")
+		}
+		w("\t},")
+	}
+	w("}")
+	return b.String()
+}
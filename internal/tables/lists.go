@@ -0,0 +1,68 @@
+package tables
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ParseLists returns the <ul>, <ol> and <dl> lists of an HTML document or
+// fragment as tables: one "item" column for ul/ol, "term" and
+// "definition" for dl. Nested lists are returned on their own, like nested
+// tables.
+func ParseLists(doc string, opts Options) ([]Table, error) {
+	sels, err := selectElements(doc, "ul, ol, dl", opts)
+	if err != nil {
+		return nil, err
+	}
+	return collect(sels, opts, parseList)
+}
+
+func parseList(l *goquery.Selection, maxRows int) Table {
+	var t Table
+	add := func(row ...string) {
+		if isEmptyRow(row) {
+			return
+		}
+		if len(t.Rows) == maxRows {
+			t.Truncated = true
+			return
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	if goquery.NodeName(l) != "dl" {
+		t.Columns = []string{"item"}
+		l.ChildrenFiltered("li").Each(func(_ int, li *goquery.Selection) {
+			add(cellText(li, "ul", "ol", "dl"))
+		})
+		return t
+	}
+
+	// <dt>s followed by their <dd>s, possibly wrapped in <div>s. Several
+	// terms sharing definitions are joined.
+	t.Columns = []string{"term", "definition"}
+	var terms []string
+	inTerms := false
+	item := func(s *goquery.Selection) {
+		text := cellText(s, "ul", "ol", "dl")
+		if goquery.NodeName(s) == "dt" {
+			if !inTerms {
+				terms = terms[:0]
+			}
+			terms = append(terms, text)
+			inTerms = true
+			return
+		}
+		inTerms = false
+		add(strings.Join(terms, " / "), text)
+	}
+	l.Children().Each(func(_ int, c *goquery.Selection) {
+		switch goquery.NodeName(c) {
+		case "dt", "dd":
+			item(c)
+		case "div":
+			c.ChildrenFiltered("dt, dd").Each(func(_ int, s *goquery.Selection) { item(s) })
+		}
+	})
+	return t
+}
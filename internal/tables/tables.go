@@ -0,0 +1,386 @@
+// Package tables turns HTML tables and lists into rows and columns, locally
+// and without an API call, so an agent can read tabular data as JSON
+// records or CSV instead of walking it row by row.
+package tables
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+)
+
+// DefaultMaxRows bounds the rows kept per table when Options.MaxRows is 0.
+const DefaultMaxRows = 1000
+
+// Span limits from the HTML spec; anything larger is clamped.
+const (
+	maxColspan = 1000
+	maxRowspan = 65534
+)
+
+// maxColumns bounds a table's width: cells past it are left out, so a few
+// wide colspans can't blow up every row.
+const maxColumns = 200
+
+// Table is one parsed table or list. Rows are aligned to Columns: cells
+// spanning several columns or rows are repeated in each slot they cover.
+type Table struct {
+	Index     int        `json:"index"`            // 1-based, among the selected tables
+	Parent    int        `json:"parent,omitempty"` // Index of the enclosing table, for nested ones
+	Caption   string     `json:"caption,omitempty"`
+	Columns   []string   `json:"columns"`
+	Rows      [][]string `json:"-"`
+	Truncated bool       `json:"truncated,omitempty"` // rows past MaxRows or columns past maxColumns were left out
+}
+
+// Record is one row keyed by column name. It marshals to a JSON object
+// whose keys keep the column order, unlike a map.
+type Record struct {
+	Columns []string
+	Values  []string
+}
+
+// MarshalJSON writes the record as an object in column order.
+func (r Record) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, col := range r.Columns {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(col)
+		v, _ := json.Marshal(r.Values[i])
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Options selects what to parse.
+type Options struct {
+	// Selector is a CSS selector: the tables it matches and the tables
+	// inside the elements it matches are parsed. Empty means the whole
+	// document.
+	Selector string
+	// Index picks one table, 1-based, among the selected ones. 0 keeps
+	// them all.
+	Index int
+	// MaxRows bounds the data rows per table (DefaultMaxRows when 0).
+	MaxRows int
+}
+
+// Records returns the rows keyed by column name, in column order.
+func (t *Table) Records() []Record {
+	out := make([]Record, len(t.Rows))
+	for i, row := range t.Rows {
+		n := min(len(row), len(t.Columns))
+		out[i] = Record{Columns: t.Columns[:n], Values: row[:n]}
+	}
+	return out
+}
+
+// CSV renders the table with a header row.
+func (t *Table) CSV() (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(t.Columns)
+	w.WriteAll(t.Rows)
+	if err := w.Error(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Parse returns the tables of an HTML document or fragment, in document
+// order. A table nested in another's cell is returned on its own, with
+// Parent set, and left out of the enclosing cell's text.
+func Parse(doc string, opts Options) ([]Table, error) {
+	sels, err := selectElements(doc, "table", opts)
+	if err != nil {
+		return nil, err
+	}
+	return collect(sels, opts, parseTable)
+}
+
+// selectElements returns the elements matching tag (a selector list),
+// restricted to opts.Selector, in document order.
+func selectElements(doc, tag string, opts Options) ([]*goquery.Selection, error) {
+	d, err := goquery.NewDocumentFromReader(strings.NewReader(doc))
+	if err != nil {
+		return nil, err
+	}
+	all := d.Find(tag)
+	scope := map[*html.Node]bool{}
+	if opts.Selector != "" {
+		m, err := cascadia.Compile(opts.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector %q: %w", opts.Selector, err)
+		}
+		for _, n := range d.FindMatcher(m).Nodes {
+			scope[n] = true
+		}
+	}
+	var out []*goquery.Selection
+	all.Each(func(_ int, s *goquery.Selection) {
+		if opts.Selector == "" || inScope(s.Get(0), scope) {
+			out = append(out, s)
+		}
+	})
+	return out, nil
+}
+
+func inScope(n *html.Node, scope map[*html.Node]bool) bool {
+	for ; n != nil; n = n.Parent {
+		if scope[n] {
+			return true
+		}
+	}
+	return false
+}
+
+// collect parses the selected elements, numbers them and links nested ones
+// to their parent.
+func collect(sels []*goquery.Selection, opts Options, parse func(*goquery.Selection, int) Table) ([]Table, error) {
+	if opts.Index > len(sels) {
+		return nil, fmt.Errorf("index %d out of range: %d found", opts.Index, len(sels))
+	}
+	maxRows := opts.MaxRows
+	if maxRows <= 0 {
+		maxRows = DefaultMaxRows
+	}
+	index := make(map[*html.Node]int, len(sels))
+	for i, s := range sels {
+		index[s.Get(0)] = i + 1
+	}
+	var out []Table
+	for i, s := range sels {
+		if opts.Index > 0 && opts.Index != i+1 {
+			continue
+		}
+		t := parse(s, maxRows)
+		t.Index = i + 1
+		for n := s.Get(0).Parent; n != nil; n = n.Parent {
+			if idx, ok := index[n]; ok {
+				t.Parent = idx
+				break
+			}
+		}
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+// tableRow is a <tr> and whether it belongs to the table header.
+type tableRow struct {
+	sel  *goquery.Selection
+	head bool
+}
+
+// rowspanCarry is a cell still covering the rows below it.
+type rowspanCarry struct {
+	text string
+	left int
+}
+
+func parseTable(t *goquery.Selection, maxRows int) Table {
+	table := Table{Caption: cellText(t.ChildrenFiltered("caption"), "table")}
+
+	// The table's own rows only: nested tables' rows sit under a cell.
+	var rows []tableRow
+	t.Children().Each(func(_ int, c *goquery.Selection) {
+		switch goquery.NodeName(c) {
+		case "tr":
+			rows = append(rows, tableRow{sel: c})
+		case "thead", "tbody", "tfoot":
+			head := goquery.NodeName(c) == "thead"
+			c.ChildrenFiltered("tr").Each(func(_ int, tr *goquery.Selection) {
+				rows = append(rows, tableRow{sel: tr, head: head})
+			})
+		}
+	})
+
+	// Header rows: the <thead>, or else leading rows made only of <th>.
+	nHead := 0
+	for nHead < len(rows) && rows[nHead].head {
+		nHead++
+	}
+	if nHead == 0 {
+		for nHead < len(rows)-1 && onlyTH(rows[nHead].sel) {
+			nHead++
+		}
+	}
+
+	// Lay the cells out on a grid, spreading colspan and rowspan, up to the
+	// first data row past maxRows.
+	var carry []rowspanCarry
+	var head [][]string
+	width := 0
+	for i, r := range rows {
+		var row []string
+		col := 0
+		fillCarried := func() {
+			for col < len(carry) && carry[col].left > 0 {
+				row = setCell(row, col, carry[col].text)
+				carry[col].left--
+				col++
+			}
+		}
+		r.sel.ChildrenFiltered("td, th").Each(func(_ int, c *goquery.Selection) {
+			fillCarried()
+			text := cellText(c, "table")
+			colspan := spanAttr(c, "colspan", maxColspan)
+			rowspan := spanAttr(c, "rowspan", maxRowspan)
+			for range colspan {
+				if col >= maxColumns {
+					table.Truncated = true
+					break
+				}
+				row = setCell(row, col, text)
+				if rowspan > 1 {
+					for len(carry) <= col {
+						carry = append(carry, rowspanCarry{})
+					}
+					carry[col] = rowspanCarry{text: text, left: rowspan - 1}
+				}
+				col++
+			}
+		})
+		// Cells carried down past the last one of this row.
+		for c := col; c < len(carry); c++ {
+			if carry[c].left > 0 {
+				row = setCell(row, c, carry[c].text)
+				carry[c].left--
+			}
+		}
+		width = max(width, len(row))
+		if i < nHead {
+			head = append(head, row)
+			continue
+		}
+		if isEmptyRow(row) {
+			continue
+		}
+		if len(table.Rows) == maxRows {
+			table.Truncated = true
+			break
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	for i := range head {
+		head[i] = pad(head[i], width)
+	}
+	for i := range table.Rows {
+		table.Rows[i] = pad(table.Rows[i], width)
+	}
+	table.Columns = columnNames(head, width)
+	return table
+}
+
+// onlyTH reports whether a row has cells and all of them are <th>.
+func onlyTH(tr *goquery.Selection) bool {
+	cells := tr.ChildrenFiltered("td, th")
+	return cells.Length() > 0 && cells.Length() == cells.Filter("th").Length()
+}
+
+func pad(row []string, width int) []string {
+	for len(row) < width {
+		row = append(row, "")
+	}
+	return row
+}
+
+// columnNames joins the header rows of each column ("Price / USD" for a
+// two-row header), numbering unnamed columns and de-duplicating names.
+func columnNames(head [][]string, width int) []string {
+	names := make([]string, width)
+	seen := map[string]int{}
+	for col := range width {
+		var parts []string
+		for _, row := range head {
+			if text := row[col]; text != "" && (len(parts) == 0 || parts[len(parts)-1] != text) {
+				parts = append(parts, text)
+			}
+		}
+		name := strings.Join(parts, " / ")
+		if name == "" {
+			name = fmt.Sprintf("col%d", col+1)
+		}
+		seen[name]++
+		if n := seen[name]; n > 1 {
+			name = fmt.Sprintf("%s_%d", name, n)
+		}
+		names[col] = name
+	}
+	return names
+}
+
+func setCell(row []string, col int, text string) []string {
+	for len(row) <= col {
+		row = append(row, "")
+	}
+	row[col] = text
+	return row
+}
+
+func spanAttr(s *goquery.Selection, name string, limit int) int {
+	n, err := strconv.Atoi(strings.TrimSpace(s.AttrOr(name, "1")))
+	if err != nil || n < 1 {
+		// rowspan="0" (to the end of the section) is rare; treat it as 1.
+		return 1
+	}
+	return min(n, limit)
+}
+
+func isEmptyRow(row []string) bool {
+	for _, c := range row {
+		if c != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// cellText is the whitespace-normalized text of s, leaving out the
+// elements named in skip (nested tables or lists), scripts and styles.
+// Line breaks and block elements separate words.
+func cellText(s *goquery.Selection, skip ...string) string {
+	var sb strings.Builder
+	for _, n := range s.Nodes {
+		writeText(&sb, n, skip, true)
+	}
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+func writeText(sb *strings.Builder, n *html.Node, skip []string, root bool) {
+	switch n.Type {
+	case html.TextNode:
+		sb.WriteString(n.Data)
+		return
+	case html.ElementNode:
+		if !root {
+			for _, tag := range skip {
+				if n.Data == tag {
+					return
+				}
+			}
+		}
+		switch n.Data {
+		case "script", "style", "template":
+			return
+		case "br", "p", "div", "li", "dt", "dd", "tr", "td", "th":
+			sb.WriteByte(' ')
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writeText(sb, c, skip, false)
+	}
+}
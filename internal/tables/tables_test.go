@@ -0,0 +1,148 @@
+package tables
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseLayout(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		html    string
+		columns []string
+		rows    [][]string
+	}{
+		{
+			name:    "thead",
+			html:    `<table><thead><tr><th>Name</th><th>Price</th></tr></thead><tbody><tr><td>A</td><td>1</td></tr></tbody></table>`,
+			columns: []string{"Name", "Price"},
+			rows:    [][]string{{"A", "1"}},
+		},
+		{
+			name:    "leading th rows",
+			html:    `<table><tr><th>Name</th><th>Price</th></tr><tr><th>A</th><td>1</td></tr></table>`,
+			columns: []string{"Name", "Price"},
+			rows:    [][]string{{"A", "1"}},
+		},
+		{
+			name:    "no header",
+			html:    `<table><tr><td>A</td><td>1</td></tr><tr><td>B</td><td>2</td></tr></table>`,
+			columns: []string{"col1", "col2"},
+			rows:    [][]string{{"A", "1"}, {"B", "2"}},
+		},
+		{
+			name:    "only th rows keep the last as data",
+			html:    `<table><tr><th>Name</th></tr><tr><th>A</th></tr></table>`,
+			columns: []string{"Name"},
+			rows:    [][]string{{"A"}},
+		},
+		{
+			name: "two-row header with colspan",
+			html: `<table><thead><tr><th rowspan="2">Item</th><th colspan="2">Price</th></tr><tr><th>USD</th><th>EUR</th></tr></thead>
+				<tr><td>A</td><td>1</td><td>2</td></tr></table>`,
+			columns: []string{"Item", "Price / USD", "Price / EUR"},
+			rows:    [][]string{{"A", "1", "2"}},
+		},
+		{
+			name: "rowspan",
+			html: `<table><tr><th>Group</th><th>Item</th><th>Qty</th></tr>
+				<tr><td rowspan="2">G1</td><td>A</td><td>1</td></tr>
+				<tr><td>B</td><td>2</td></tr>
+				<tr><td>G2</td><td>C</td><td rowspan="3">3</td></tr>
+				<tr><td>G3</td><td>D</td></tr></table>`,
+			columns: []string{"Group", "Item", "Qty"},
+			rows:    [][]string{{"G1", "A", "1"}, {"G1", "B", "2"}, {"G2", "C", "3"}, {"G3", "D", "3"}},
+		},
+		{
+			name:    "colspan in data",
+			html:    `<table><tr><th>A</th><th>B</th><th>C</th></tr><tr><td colspan="2">x</td><td>y</td></tr><tr><td>z</td></tr></table>`,
+			columns: []string{"A", "B", "C"},
+			rows:    [][]string{{"x", "x", "y"}, {"z", "", ""}},
+		},
+		{
+			name:    "duplicate and empty headers",
+			html:    `<table><tr><th>Name</th><th>Name</th><th></th></tr><tr><td>a</td><td>b</td><td>c</td></tr></table>`,
+			columns: []string{"Name", "Name_2", "col3"},
+			rows:    [][]string{{"a", "b", "c"}},
+		},
+		{
+			name:    "empty rows dropped",
+			html:    `<table><tr><th>A</th></tr><tr><td> </td></tr><tr><td>x</td></tr></table>`,
+			columns: []string{"A"},
+			rows:    [][]string{{"x"}},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := Parse(tt.html, Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(found) != 1 {
+				t.Fatalf("parsed %d tables, want 1", len(found))
+			}
+			if got := found[0]; !reflect.DeepEqual(got.Columns, tt.columns) || !reflect.DeepEqual(got.Rows, tt.rows) {
+				t.Errorf("columns %q rows %q, want %q %q", got.Columns, got.Rows, tt.columns, tt.rows)
+			}
+		})
+	}
+}
+
+func TestParseNested(t *testing.T) {
+	found, err := Parse(`<table><tr><td>outer<table><tr><td>inner</td></tr></table></td></tr></table>`, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 2 || found[0].Rows[0][0] != "outer" || found[1].Parent != 1 || found[1].Rows[0][0] != "inner" {
+		t.Errorf("parsed %+v", found)
+	}
+}
+
+func TestParseMaxRows(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("<table><tr><th>N</th></tr>")
+	for i := range 10 {
+		fmt.Fprintf(&sb, "<tr><td>%d</td></tr>", i)
+	}
+	sb.WriteString("</table>")
+	found, err := Parse(sb.String(), Options{MaxRows: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := found[0]; len(got.Rows) != 3 || !got.Truncated {
+		t.Errorf("rows %q truncated %t, want 3 rows, truncated", got.Rows, got.Truncated)
+	}
+	found, _ = Parse(sb.String(), Options{MaxRows: 10})
+	if found[0].Truncated {
+		t.Error("a table with exactly max_rows rows is reported truncated")
+	}
+}
+
+func TestParseWidthCapped(t *testing.T) {
+	found, err := Parse(`<table><tr><td colspan="1000" rowspan="1000">wide</td><td>past</td></tr><tr><td>next</td></tr></table>`, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := found[0]
+	if len(got.Columns) != maxColumns || !got.Truncated {
+		t.Fatalf("%d columns, truncated %t; want %d, truncated", len(got.Columns), got.Truncated, maxColumns)
+	}
+	for _, row := range got.Rows {
+		if len(row) != maxColumns {
+			t.Errorf("row of %d cells, want %d", len(row), maxColumns)
+		}
+	}
+}
+
+func TestRecordsKeepColumnOrder(t *testing.T) {
+	table := Table{Columns: []string{"zeta", "alpha", "mid"}, Rows: [][]string{{"1", "2", "3"}}}
+	b, err := json.Marshal(table.Records())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `[{"zeta":"1","alpha":"2","mid":"3"}]`; string(b) != want {
+		t.Errorf("records = %s, want %s", b, want)
+	}
+}
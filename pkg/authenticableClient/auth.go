@@ -7,12 +7,15 @@ import (
 	"log"
 	"net/http"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/modelcontextprotocol/go-sdk/auth"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/scrapfly/go-scrapfly"
 	scrapflyprovider "github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly"
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser"
+	"github.com/scrapfly/scrapfly-mcp/pkg/server"
 )
 
 var digestRegex = regexp.MustCompile(`^[a-fA-F0-9]{32}$`)
@@ -117,21 +120,61 @@ func verify(req *http.Request, apikeyVerifier TokenVerifier) (_ *TokenInfo, errm
 	return tokenInfo, "", 0
 }
 
-func CorsMiddleware(handler http.Handler) http.Handler {
+// CorsOptions configures NewCorsMiddleware.
+type CorsOptions struct {
+	// AllowedOrigins lists the origins allowed to call the server. "*"
+	// allows any origin, without credentials.
+	AllowedOrigins []string
+	// AllowCredentials lets an explicitly allowed origin send credentials
+	// (cookies, HTTP auth). Never sent for "*".
+	AllowCredentials bool
+}
+
+// AllowsOrigin reports whether origin may call the server. Requests with
+// no Origin header don't come from a browser page and are allowed.
+func (o CorsOptions) AllowsOrigin(origin string) bool {
+	return origin == "" || slices.Contains(o.AllowedOrigins, "*") || slices.Contains(o.AllowedOrigins, origin)
+}
+
+// DefaultCorsOptions allows any origin. Authentication is a bearer token or
+// key query parameter, which don't need CORS credentials.
+var DefaultCorsOptions = CorsOptions{AllowedOrigins: []string{"*"}}
+
+// NewCorsMiddleware answers preflight requests and sets CORS headers for
+// the origins in opts. Requests from other origins get no CORS headers.
+func NewCorsMiddleware(opts CorsOptions) func(http.Handler) http.Handler {
+	anyOrigin := slices.Contains(opts.AllowedOrigins, "*")
 	return func(handler http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Access-Control-Allow-Credentials", "true")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, *")
-			w.Header().Set("Access-Control-Expose-Headers", "mcp-session-id, mcp-protocol-version")
+			origin := r.Header.Get("Origin")
+			w.Header().Add("Vary", "Origin")
+			switch {
+			case anyOrigin:
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			case origin != "" && slices.Contains(opts.AllowedOrigins, origin):
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				if opts.AllowCredentials {
+					w.Header().Set("Access-Control-Allow-Credentials", "true")
+				}
+			default:
+				origin = ""
+			}
+			if origin != "" || anyOrigin {
+				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, *")
+				w.Header().Set("Access-Control-Expose-Headers", "mcp-session-id, mcp-protocol-version, X-Content-SHA256, Content-Disposition")
+			}
 			if r.Method == "OPTIONS" {
 				w.WriteHeader(http.StatusOK)
 				return
 			}
 			handler.ServeHTTP(w, r)
 		})
-	}(handler)
+	}
+}
+
+func CorsMiddleware(handler http.Handler) http.Handler {
+	return NewCorsMiddleware(DefaultCorsOptions)(handler)
 }
 
 // Middleware to verify Scrapfly API key
@@ -151,12 +194,36 @@ func GetStreamableScrapflyClient(p *scrapflyprovider.ScrapflyToolProvider, ctx c
 }
 
 func CorsAndAuthenticatedStreamableServerFunction(mcpHandler *mcp.StreamableHTTPHandler, httpAddr *string) {
-	authenticationHandler := ScrapflyAuthMiddleware(mcpHandler)
-	corsHandler := CorsMiddleware(authenticationHandler)
-	http.HandleFunc("/mcp", corsHandler.ServeHTTP)
-	http.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
-	})
-	log.Fatal(http.ListenAndServe(*httpAddr, nil))
+	CorsAndAuthenticatedStreamableServerFunctionWith(DefaultCorsOptions)(mcpHandler, httpAddr)
 }
+
+// CorsAndAuthenticatedStreamableServerFunctionWith serves /mcp and the
+// /browser/* routes behind API key authentication and the given CORS
+// policy. Browser routes only see the caller's own sessions.
+func CorsAndAuthenticatedStreamableServerFunctionWith(cors CorsOptions) server.StreamableServerFunction {
+	return func(mcpHandler *mcp.StreamableHTTPHandler, httpAddr *string) {
+		corsMiddleware := NewCorsMiddleware(cors)
+		authMiddleware := RequireBearerToken(apikeyVerifier)
+		mux := http.NewServeMux()
+		mux.Handle("/mcp", corsMiddleware(authMiddleware(mcpHandler)))
+		mux.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("OK"))
+		})
+		server.RegisterBrowserEndpointsWith(mux, server.BrowserEndpointsOptions{
+			Middleware: func(h http.Handler) http.Handler { return corsMiddleware(authMiddleware(h)) },
+			Owner: func(r *http.Request) string {
+				if ti := TokenInfoFromRequest(r); ti != nil {
+					return browser.OwnerKey(ti.ApiKey)
+				}
+				return ownerNobody
+			},
+			CheckOrigin: func(r *http.Request) bool { return cors.AllowsOrigin(r.Header.Get("Origin")) },
+		})
+		log.Fatal(http.ListenAndServe(*httpAddr, mux))
+	}
+}
+
+// ownerNobody scopes a request with no token info to no sessions at all.
+// RequireBearerToken rejects such requests first; this is belt and braces.
+const ownerNobody = "-"
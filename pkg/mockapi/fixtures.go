@@ -0,0 +1,148 @@
+package mockapi
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Fixture is a canned response. Fixtures are JSON files — one per
+// response, as record mode writes them:
+//
+//	{
+//	  "path": "/scrape",
+//	  "url": "https://web-scraping.dev/product/1",
+//	  "query": {"format": "markdown"},
+//	  "status": 200,
+//	  "body": {"result": {"success": true, "status": "DONE", "content": "# Product 1", ...}}
+//	}
+//
+// A request matches when the path and method agree and the fixture's URL
+// and query parameters are present on it; the most specific match wins,
+// and among equals the latest added.
+type Fixture struct {
+	// Method matches the request method; empty matches any.
+	Method string `json:"method,omitempty"`
+	Path   string `json:"path"`
+	// URL matches the target URL: the url query parameter, or the url
+	// field of a JSON body (/classify, /unblock).
+	URL   string            `json:"url,omitempty"`
+	Query map[string]string `json:"query,omitempty"`
+
+	Status int               `json:"status,omitempty"` // default 200
+	Header map[string]string `json:"header,omitempty"`
+	// Body is a JSON response body; BodyBase64 any other (a screenshot).
+	Body       json.RawMessage `json:"body,omitempty"`
+	BodyBase64 string          `json:"body_base64,omitempty"`
+}
+
+// AddFixture adds a canned response.
+func (s *Server) AddFixture(fx Fixture) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fixtures = append(s.fixtures, fx)
+}
+
+// LoadFixtures adds every *.json fixture file under dir.
+func (s *Server) LoadFixtures(dir string) (int, error) {
+	var loaded []Fixture
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".json" {
+			return err
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var fx Fixture
+		if err := json.Unmarshal(b, &fx); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if fx.Path == "" {
+			return fmt.Errorf("%s: fixture has no path", path)
+		}
+		loaded = append(loaded, fx)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	s.fixtures = append(s.fixtures, loaded...)
+	s.mu.Unlock()
+	return len(loaded), nil
+}
+
+// match returns the fixture answering r, or nil.
+func (s *Server) match(r *http.Request, body []byte) *Fixture {
+	target := targetURL(r, body)
+	query := r.URL.Query()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var best *Fixture
+	bestScore := -1
+	for i := range s.fixtures {
+		fx := &s.fixtures[i]
+		if fx.Path != r.URL.Path || (fx.Method != "" && !strings.EqualFold(fx.Method, r.Method)) {
+			continue
+		}
+		if fx.URL != "" && fx.URL != target {
+			continue
+		}
+		score := len(fx.Query)
+		if fx.URL != "" {
+			score++
+		}
+		for k, v := range fx.Query {
+			if query.Get(k) != v {
+				score = -1
+				break
+			}
+		}
+		if score >= 0 && score >= bestScore {
+			best, bestScore = fx, score
+		}
+	}
+	return best
+}
+
+// targetURL is the URL a request is about: its url query parameter, or
+// the url field of its JSON body.
+func targetURL(r *http.Request, body []byte) string {
+	if u := r.URL.Query().Get("url"); u != "" {
+		return u
+	}
+	var b struct {
+		URL string `json:"url"`
+	}
+	json.Unmarshal(body, &b)
+	return b.URL
+}
+
+func (fx *Fixture) status() int {
+	if fx.Status == 0 {
+		return http.StatusOK
+	}
+	return fx.Status
+}
+
+func (fx *Fixture) write(w http.ResponseWriter) {
+	for k, v := range fx.Header {
+		w.Header().Set(k, v)
+	}
+	var body []byte
+	if fx.BodyBase64 != "" {
+		body, _ = base64.StdEncoding.DecodeString(fx.BodyBase64)
+	} else {
+		body = fx.Body
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", "application/json")
+		}
+	}
+	w.WriteHeader(fx.status())
+	w.Write(body)
+}
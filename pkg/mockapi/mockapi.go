@@ -0,0 +1,254 @@
+// Package mockapi runs a local mock of the Scrapfly API for offline work
+// and CI. It serves fixture-backed responses for /scrape, /screenshot,
+// /extraction, /account and /classify, and the Cloud Browser host's
+// /unblock and session endpoints, in the envelopes the go-scrapfly SDK and
+// this server's error handling (ToolErrFromError, check_if_blocked)
+// parse. Requests without a fixture get a generated answer for their
+// endpoint.
+//
+// Latency, error injection (429 with a retry delay, 5xx, ASP bypass
+// failures) and a record mode that captures fixtures from real traffic
+// make the tools' failure paths reproducible:
+//
+//	srv := mockapi.NewServer()
+//	srv.Inject("/scrape", mockapi.Fault{Status: 429, RetryAfter: 5 * time.Second, Times: 1})
+//	go http.ListenAndServe("127.0.0.1:8765", srv)
+//
+// Point the server at it with SCRAPFLY_API_HOST and SCRAPFLY_BROWSER_HOST
+// (or -host / -browser-host), or run `scrapfly-mcp mock-api`.
+package mockapi
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser/browsertest"
+)
+
+// API endpoints the mock serves.
+const (
+	PathScrape     = "/scrape"
+	PathScreenshot = "/screenshot"
+	PathExtraction = "/extraction"
+	PathAccount    = "/account"
+	PathClassify   = "/classify"
+	PathUnblock    = "/unblock"
+)
+
+// Server is a mock Scrapfly API. It is an http.Handler; its methods are
+// safe for concurrent use.
+type Server struct {
+	// Latency delays every API response, plus a random share of Jitter.
+	// Set them before serving.
+	Latency time.Duration
+	Jitter  time.Duration
+
+	// Browser is the fake Cloud Browser behind /unblock and the CDP
+	// WebSocket of the browser host (see browsertest).
+	Browser *browsertest.Server
+
+	mu       sync.Mutex
+	fixtures []Fixture
+	faults   map[string][]*Fault
+	apiKey   string
+	recorder *recorder
+	sessions int
+}
+
+// NewServer returns a mock API with no fixtures: every endpoint answers
+// with its generated default.
+func NewServer() *Server {
+	return &Server{
+		Browser: browsertest.NewServer(),
+		faults:  map[string][]*Fault{},
+	}
+}
+
+// Close stops the fake Cloud Browser.
+func (s *Server) Close() {
+	s.Browser.Close()
+}
+
+// RequireAPIKey makes requests with any other key fail with 401. By
+// default any non-empty key is accepted.
+func (s *Server) RequireAPIKey(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.apiKey = key
+}
+
+// ── Faults ──────────────────────────────────────────────────────────────
+
+// Fault is an injected failure.
+type Fault struct {
+	// Status is the HTTP status to fail with: 429, or a 5xx.
+	Status int
+	// ASP fails the request as an anti-bot bypass failure (422
+	// ERR::ASP::SHIELD_PROTECTION_FAILED) instead; Status is ignored.
+	ASP bool
+	// RetryAfter is sent with a 429, as Retry-After and retry_after.
+	RetryAfter time.Duration
+	// Times limits the fault to the next N matching requests; 0 is every
+	// request.
+	Times int
+	// Rate is the probability a matching request fails, 0 meaning always.
+	Rate float64
+}
+
+// Inject fails requests to path ("/scrape", or "*" for every endpoint)
+// with f. Faults on a path apply in the order they were injected.
+func (s *Server) Inject(path string, f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults[path] = append(s.faults[path], &f)
+}
+
+// ClearFaults removes every injected fault.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = map[string][]*Fault{}
+}
+
+// ParseFault parses a fault spec of the form
+//
+//	PATH=KIND[,retry=DURATION][,times=N][,rate=P]
+//
+// where KIND is an HTTP status (429, 500, 502, 503…) or "asp":
+// "/scrape=429,retry=5s,times=2", "*=503,rate=0.2", "/scrape=asp".
+func ParseFault(spec string) (string, Fault, error) {
+	var f Fault
+	path, rest, ok := strings.Cut(spec, "=")
+	if !ok || path == "" {
+		return "", f, fmt.Errorf("fault %q: want PATH=KIND[,retry=D][,times=N][,rate=P]", spec)
+	}
+	parts := strings.Split(rest, ",")
+	switch kind := strings.TrimSpace(parts[0]); {
+	case strings.EqualFold(kind, "asp"):
+		f.ASP = true
+	default:
+		if _, err := fmt.Sscanf(kind, "%d", &f.Status); err != nil || (f.Status != 429 && (f.Status < 500 || f.Status > 599)) {
+			return "", f, fmt.Errorf("fault %q: kind must be 429, a 5xx status or asp", spec)
+		}
+	}
+	for _, opt := range parts[1:] {
+		k, v, _ := strings.Cut(strings.TrimSpace(opt), "=")
+		var err error
+		switch k {
+		case "retry":
+			f.RetryAfter, err = time.ParseDuration(v)
+		case "times":
+			_, err = fmt.Sscanf(v, "%d", &f.Times)
+		case "rate":
+			_, err = fmt.Sscanf(v, "%g", &f.Rate)
+		default:
+			err = fmt.Errorf("unknown option")
+		}
+		if err != nil {
+			return "", f, fmt.Errorf("fault %q: %s: %v", spec, k, err)
+		}
+	}
+	return path, f, nil
+}
+
+// fault returns the fault the request to path should fail with, if any,
+// consuming one of its Times.
+func (s *Server) fault(path string) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range []string{path, "*"} {
+		for i, f := range s.faults[key] {
+			if f.Rate > 0 && rand.Float64() >= f.Rate {
+				continue
+			}
+			hit := *f
+			if f.Times > 0 {
+				if f.Times--; f.Times == 0 {
+					s.faults[key] = append(s.faults[key][:i:i], s.faults[key][i+1:]...)
+				}
+			}
+			return &hit
+		}
+	}
+	return nil
+}
+
+// ── Routing ─────────────────────────────────────────────────────────────
+
+// ServeHTTP routes a request: CDP WebSocket upgrades to the fake browser,
+// the API endpoints to their fixtures.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		s.Browser.ServeHTTP(w, r)
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if d := s.Latency; d > 0 || s.Jitter > 0 {
+		if s.Jitter > 0 {
+			d += rand.N(s.Jitter)
+		}
+		time.Sleep(d)
+	}
+
+	path := r.URL.Path
+	if !s.authorized(r) {
+		log.Printf("[MOCK-API] %s %s → 401", r.Method, path)
+		writeError(w, http.StatusUnauthorized, "ERR::AUTH::INVALID_API_KEY", "Invalid API key", false)
+		return
+	}
+	if f := s.fault(path); f != nil {
+		log.Printf("[MOCK-API] %s %s → injected %s", r.Method, path, f)
+		writeFault(w, r, f)
+		return
+	}
+	if s.recording() && isAPIPath(path) {
+		s.record(w, r, body)
+		return
+	}
+	if fx := s.match(r, body); fx != nil {
+		log.Printf("[MOCK-API] %s %s → fixture (%d)", r.Method, path, fx.status())
+		fx.write(w)
+		return
+	}
+	log.Printf("[MOCK-API] %s %s → default", r.Method, path)
+	s.defaultResponse(w, r, body)
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		key = r.URL.Query().Get("api_key")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return key != "" && (s.apiKey == "" || key == s.apiKey)
+}
+
+func isAPIPath(path string) bool {
+	switch path {
+	case PathScrape, PathScreenshot, PathExtraction, PathAccount, PathClassify:
+		return true
+	}
+	return false
+}
+
+// String describes f for logs.
+func (f *Fault) String() string {
+	if f.ASP {
+		return "ASP failure"
+	}
+	if f.RetryAfter > 0 {
+		return fmt.Sprintf("HTTP %d (retry after %s)", f.Status, f.RetryAfter)
+	}
+	return fmt.Sprintf("HTTP %d", f.Status)
+}
@@ -0,0 +1,162 @@
+package mockapi_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	scrapfly "github.com/scrapfly/go-scrapfly"
+	"github.com/scrapfly/scrapfly-mcp/pkg/mockapi"
+	scrapflyprovider "github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly"
+)
+
+func TestParseFault(t *testing.T) {
+	for _, tt := range []struct {
+		spec, path string
+		want       mockapi.Fault
+	}{
+		{"/scrape=429,retry=5s,times=2", "/scrape", mockapi.Fault{Status: 429, RetryAfter: 5 * time.Second, Times: 2}},
+		{"*=503,rate=0.2", "*", mockapi.Fault{Status: 503, Rate: 0.2}},
+		{"/scrape=asp", "/scrape", mockapi.Fault{ASP: true}},
+		{"/account=ASP, times=1", "/account", mockapi.Fault{ASP: true, Times: 1}},
+		{"/screenshot=500", "/screenshot", mockapi.Fault{Status: 500}},
+	} {
+		path, f, err := mockapi.ParseFault(tt.spec)
+		if err != nil {
+			t.Errorf("ParseFault(%q): %v", tt.spec, err)
+			continue
+		}
+		if path != tt.path || f != tt.want {
+			t.Errorf("ParseFault(%q) = %q, %+v; want %q, %+v", tt.spec, path, f, tt.path, tt.want)
+		}
+	}
+
+	for _, spec := range []string{
+		"/scrape",             // no kind
+		"=429",                // no path
+		"/scrape=404",         // not a failure the API sends
+		"/scrape=600",         // not a 5xx
+		"/scrape=slow",        // unknown kind
+		"/scrape=429,retry=5", // no unit
+		"/scrape=503,times=x", // not a number
+		"/scrape=503,burst=2", // unknown option
+	} {
+		if _, _, err := mockapi.ParseFault(spec); err == nil {
+			t.Errorf("ParseFault(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+// toolError is the payload ToolErrFromError puts in a failed tool result.
+type toolError struct {
+	Code          string `json:"code"`
+	Message       string `json:"message"`
+	RetryAfterMs  int    `json:"retry_after_ms"`
+	ResultContent string `json:"result_content"`
+}
+
+func newClient(t *testing.T, srv *mockapi.Server) *scrapfly.Client {
+	t.Helper()
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+	t.Cleanup(srv.Close)
+	client, err := scrapfly.NewWithHost("test-key", ts.URL, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func toolErrorOf(t *testing.T, err error) toolError {
+	t.Helper()
+	if err == nil {
+		t.Fatal("call succeeded, want the injected failure")
+	}
+	res := scrapflyprovider.ToolErrFromError("tool", err)
+	var out toolError
+	if e := json.Unmarshal([]byte(res.Content[0].(*mcp.TextContent).Text), &out); e != nil {
+		t.Fatalf("tool error payload: %v", e)
+	}
+	return out
+}
+
+func TestInjectedFaultsReachToolErrors(t *testing.T) {
+	scrape := func(client *scrapfly.Client) error {
+		_, err := client.Scrape(&scrapfly.ScrapeConfig{URL: "https://example.com/"})
+		return err
+	}
+	// The SDK retries a 5xx on /scrape itself and drops its body, so the
+	// 5xx envelopes go through /account, which it doesn't retry.
+	account := func(client *scrapfly.Client) error {
+		_, err := client.Account()
+		return err
+	}
+
+	for _, tt := range []struct {
+		name         string
+		path         string
+		fault        mockapi.Fault
+		call         func(*scrapfly.Client) error
+		code         string
+		retryAfterMs int
+	}{
+		{"throttled", mockapi.PathScrape, mockapi.Fault{Status: 429, RetryAfter: 5 * time.Second},
+			scrape, "ERR::THROTTLE::MAX_REQUEST_RATE_EXCEEDED", 5000},
+		{"throttled under a second", mockapi.PathScrape, mockapi.Fault{Status: 429, RetryAfter: 200 * time.Millisecond},
+			scrape, "ERR::THROTTLE::MAX_REQUEST_RATE_EXCEEDED", 1000},
+		{"internal error", mockapi.PathAccount, mockapi.Fault{Status: 500},
+			account, "ERR::API::INTERNAL_ERROR", 0},
+		{"unavailable", mockapi.PathAccount, mockapi.Fault{Status: 503},
+			account, "ERR::API::UNAVAILABLE", 0},
+		{"any endpoint", "*", mockapi.Fault{Status: 502},
+			account, "ERR::API::UNAVAILABLE", 0},
+		{"ASP on the scrape API", mockapi.PathScrape, mockapi.Fault{ASP: true},
+			scrape, "ERR::ASP::SHIELD_PROTECTION_FAILED", 0},
+		{"ASP elsewhere", mockapi.PathAccount, mockapi.Fault{ASP: true},
+			account, "ERR::ASP::SHIELD_PROTECTION_FAILED", 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := mockapi.NewServer()
+			client := newClient(t, srv)
+			srv.Inject(tt.path, tt.fault)
+
+			got := toolErrorOf(t, tt.call(client))
+			if got.Code != tt.code {
+				t.Errorf("code = %q, want %q (message %q)", got.Code, tt.code, got.Message)
+			}
+			if got.RetryAfterMs != tt.retryAfterMs {
+				t.Errorf("retry_after_ms = %d, want %d", got.RetryAfterMs, tt.retryAfterMs)
+			}
+		})
+	}
+}
+
+func TestASPFailureCarriesBlockPage(t *testing.T) {
+	srv := mockapi.NewServer()
+	client := newClient(t, srv)
+	srv.Inject(mockapi.PathScrape, mockapi.Fault{ASP: true})
+
+	_, err := client.Scrape(&scrapfly.ScrapeConfig{URL: "https://example.com/"})
+	got := toolErrorOf(t, err)
+	if got.ResultContent == "" || !strings.Contains(got.Message, "ASP") {
+		t.Errorf("ASP failure = %+v, want the block page and the shield message", got)
+	}
+}
+
+func TestFaultTimes(t *testing.T) {
+	srv := mockapi.NewServer()
+	client := newClient(t, srv)
+	srv.Inject(mockapi.PathAccount, mockapi.Fault{Status: 500, Times: 2})
+
+	for i := range 2 {
+		if _, err := client.Account(); err == nil {
+			t.Fatalf("request %d succeeded, want the injected 500", i+1)
+		}
+	}
+	if _, err := client.Account(); err != nil {
+		t.Errorf("request after the fault ran out: %v", err)
+	}
+}
@@ -0,0 +1,170 @@
+package mockapi
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// recorder forwards API requests to the real API and keeps each answer as
+// a fixture.
+type recorder struct {
+	upstream string
+	dir      string
+	client   *http.Client
+}
+
+// Record switches the server to record mode: API requests are forwarded
+// to upstream (https://api.scrapfly.io) with the caller's key, answered
+// with the real response, and each response is written to dir as a
+// fixture file, with the API key redacted. The Cloud Browser endpoints
+// stay mocked.
+func (s *Server) Record(upstream, dir string) error {
+	u, err := url.Parse(upstream)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("record upstream %q: want an absolute URL like https://api.scrapfly.io", upstream)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("record fixtures: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recorder = &recorder{
+		upstream: strings.TrimSuffix(upstream, "/"),
+		dir:      dir,
+		client:   &http.Client{Timeout: 3 * time.Minute},
+	}
+	return nil
+}
+
+func (s *Server) recording() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.recorder != nil
+}
+
+// recordHeaders are the response headers worth keeping in a fixture.
+var recordHeaders = []string{"Content-Type", "Retry-After", "X-Scrapfly-Upstream-Http-Code", "X-Scrapfly-Upstream-Url"}
+
+func (s *Server) record(w http.ResponseWriter, r *http.Request, body []byte) {
+	s.mu.Lock()
+	rec := s.recorder
+	s.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(r.Context(), r.Method, rec.upstream+r.URL.Path+"?"+r.URL.RawQuery, bytes.NewReader(body))
+	if err != nil {
+		writeError(w, http.StatusBadGateway, "ERR::MOCK::RECORD_FAILED", err.Error(), false)
+		return
+	}
+	for _, h := range []string{"Content-Type", "Content-Encoding", "Accept", "User-Agent"} {
+		if v := r.Header.Get(h); v != "" {
+			req.Header.Set(h, v)
+		}
+	}
+	resp, err := rec.client.Do(req)
+	if err != nil {
+		log.Printf("[MOCK-API] record %s %s: %v", r.Method, r.URL.Path, err)
+		writeError(w, http.StatusBadGateway, "ERR::MOCK::RECORD_FAILED", fmt.Sprintf("upstream request failed: %v", err), true)
+		return
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, "ERR::MOCK::RECORD_FAILED", fmt.Sprintf("upstream response: %v", err), true)
+		return
+	}
+
+	for _, h := range recordHeaders {
+		if v := resp.Header.Get(h); v != "" {
+			w.Header().Set(h, v)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	w.Write(respBody)
+
+	file, err := rec.save(fixtureFromExchange(r, body, resp, respBody))
+	if err != nil {
+		log.Printf("[MOCK-API] record %s %s: %v", r.Method, r.URL.Path, err)
+		return
+	}
+	log.Printf("[MOCK-API] %s %s → recorded %d to %s", r.Method, r.URL.Path, resp.StatusCode, file)
+}
+
+// fixtureFromExchange turns a forwarded request and its response into a
+// fixture matching the same request: every query parameter but the key.
+func fixtureFromExchange(r *http.Request, body []byte, resp *http.Response, respBody []byte) Fixture {
+	query := r.URL.Query()
+	key := query.Get("key")
+	fx := Fixture{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		URL:    targetURL(r, body),
+		Query:  map[string]string{},
+		Status: resp.StatusCode,
+		Header: map[string]string{},
+	}
+	for k := range query {
+		if k != "key" && k != "url" {
+			fx.Query[k] = query.Get(k)
+		}
+	}
+	for _, h := range recordHeaders {
+		if v := resp.Header.Get(h); v != "" {
+			fx.Header[h] = v
+		}
+	}
+	if key != "" {
+		respBody = bytes.ReplaceAll(respBody, []byte(key), []byte("REDACTED"))
+		respBody = bytes.ReplaceAll(respBody, []byte(url.QueryEscape(key)), []byte("REDACTED"))
+	}
+	if strings.Contains(resp.Header.Get("Content-Type"), "json") && json.Valid(respBody) {
+		fx.Body = respBody
+	} else {
+		fx.BodyBase64 = base64.StdEncoding.EncodeToString(respBody)
+	}
+	return fx
+}
+
+var slugRe = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+// save writes fx to <dir>/<endpoint>/<target>-<hash>.json, the hash
+// covering everything the fixture matches on.
+func (rec *recorder) save(fx Fixture) (string, error) {
+	keys := make([]string, 0, len(fx.Query))
+	for k := range fx.Query {
+		keys = append(keys, k+"="+fx.Query[k])
+	}
+	sort.Strings(keys)
+	sum := sha1.Sum([]byte(fx.Method + " " + fx.Path + " " + fx.URL + " " + strings.Join(keys, "&")))
+
+	name := "request"
+	if u, err := url.Parse(fx.URL); err == nil && u.Host != "" {
+		name = strings.Trim(slugRe.ReplaceAllString(u.Host+u.Path, "-"), "-")
+		if len(name) > 60 {
+			name = name[:60]
+		}
+	}
+	dir := filepath.Join(rec.dir, strings.Trim(slugRe.ReplaceAllString(fx.Path, "-"), "-"))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	file := filepath.Join(dir, name+"-"+hex.EncodeToString(sum[:4])+".json")
+	b, err := json.MarshalIndent(fx, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return file, os.WriteFile(file, append(b, '\n'), 0o644)
+}
@@ -0,0 +1,283 @@
+package mockapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	scrapfly "github.com/scrapfly/go-scrapfly"
+)
+
+// ── Error envelopes ─────────────────────────────────────────────────────
+
+// writeError writes the canonical Scrapfly error envelope the API returns
+// on a 4xx/5xx outside a scrape result.
+func writeError(w http.ResponseWriter, status int, code, message string, retryable bool) {
+	writeJSON(w, status, map[string]any{
+		"code":      code,
+		"message":   message,
+		"http_code": status,
+		"retryable": retryable,
+		"error_id":  errorID(),
+		"links":     map[string]string{"Related Error Doc": "https://scrapfly.io/docs/scrape-api/error/" + code},
+	})
+}
+
+// writeFault answers r with the injected failure f.
+func writeFault(w http.ResponseWriter, r *http.Request, f *Fault) {
+	switch {
+	case f.ASP && r.URL.Path == PathScrape:
+		writeASPFailure(w, r)
+	case f.ASP:
+		writeError(w, http.StatusUnprocessableEntity, "ERR::ASP::SHIELD_PROTECTION_FAILED",
+			"The ASP shield failed to bypass the anti-bot protection of the target. Retry, or change the proxy pool / country.", true)
+	case f.Status == http.StatusTooManyRequests:
+		secs := int(f.RetryAfter.Round(time.Second) / time.Second)
+		if f.RetryAfter > 0 && secs == 0 {
+			secs = 1
+		}
+		if secs > 0 {
+			w.Header().Set("Retry-After", fmt.Sprint(secs))
+		}
+		writeJSON(w, http.StatusTooManyRequests, map[string]any{
+			"code":        "ERR::THROTTLE::MAX_REQUEST_RATE_EXCEEDED",
+			"message":     "Your request rate exceeded your plan's limit. Slow down.",
+			"http_code":   http.StatusTooManyRequests,
+			"retryable":   true,
+			"retry_after": secs,
+			"error_id":    errorID(),
+		})
+	default:
+		code := "ERR::API::INTERNAL_ERROR"
+		if f.Status == http.StatusBadGateway || f.Status == http.StatusServiceUnavailable || f.Status == http.StatusGatewayTimeout {
+			code = "ERR::API::UNAVAILABLE"
+		}
+		writeError(w, f.Status, code, fmt.Sprintf("The API failed with HTTP %d. Retry later.", f.Status), true)
+	}
+}
+
+// writeASPFailure writes the scrape result envelope of a failed anti-bot
+// bypass: the upstream's block page as content, the error in result.error.
+func writeASPFailure(w http.ResponseWriter, r *http.Request) {
+	target := r.URL.Query().Get("url")
+	const code = "ERR::ASP::SHIELD_PROTECTION_FAILED"
+	result := scrapeResult(r, target)
+	result.Result.Success = false
+	result.Result.Status = code
+	result.Result.StatusCode = http.StatusForbidden
+	result.Result.Reason = "Forbidden"
+	result.Result.Content = blockPage
+	result.Result.ContentType = "text/html; charset=utf-8"
+	result.Result.Format = "text"
+	result.Result.Error = &scrapfly.APIErrorDetails{
+		Code:      code,
+		HTTPCode:  http.StatusUnprocessableEntity,
+		Message:   "The ASP shield failed to bypass the anti-bot protection of the target. Retry, or change the proxy pool / country.",
+		Retryable: true,
+		DocURL:    "https://scrapfly.io/docs/scrape-api/error/" + code,
+	}
+	writeJSON(w, http.StatusUnprocessableEntity, result)
+}
+
+// ── Default answers ─────────────────────────────────────────────────────
+
+// defaultResponse answers a request no fixture matched, with a generated
+// response in the endpoint's success envelope.
+func (s *Server) defaultResponse(w http.ResponseWriter, r *http.Request, body []byte) {
+	q := r.URL.Query()
+	switch path := r.URL.Path; {
+	case path == PathScrape:
+		writeJSON(w, http.StatusOK, scrapeResult(r, q.Get("url")))
+	case path == PathScreenshot:
+		writeScreenshot(w, q.Get("url"), q.Get("format"))
+	case path == PathExtraction:
+		data := map[string]any{"title": pageTitle(string(body))}
+		if u := q.Get("url"); u != "" {
+			data["url"] = u
+		}
+		writeJSON(w, http.StatusOK, &scrapfly.ExtractionResult{
+			Data:        data,
+			ContentType: "application/json",
+			DataQuality: map[string]any{"errors": []string{}, "fulfilled": true, "fulfillment_percent": 100},
+		})
+	case path == PathAccount:
+		writeJSON(w, http.StatusOK, account())
+	case path == PathClassify:
+		writeJSON(w, http.StatusOK, classify(body))
+	case path == PathUnblock:
+		writeJSON(w, http.StatusOK, s.unblock())
+	case strings.HasPrefix(path, "/session/"):
+		writeJSON(w, http.StatusOK, map[string]any{"success": true})
+	default:
+		writeError(w, http.StatusNotFound, "ERR::API::NOT_FOUND", fmt.Sprintf("No endpoint %s %s on the mock API", r.Method, path), false)
+	}
+}
+
+// mockTitle is the title of every generated page.
+const mockTitle = "Scrapfly mock page"
+
+// scrapeResult is a successful scrape of target, its content in the
+// requested format.
+func scrapeResult(r *http.Request, target string) *scrapfly.ScrapeResult {
+	q := r.URL.Query()
+	format, _, _ := strings.Cut(q.Get("format"), ":")
+	var content, contentType string
+	switch format {
+	case "markdown":
+		content = fmt.Sprintf("# %s\n\nMock content for %s.\n", mockTitle, target)
+		contentType = "text/markdown; charset=utf-8"
+	case "text":
+		content = fmt.Sprintf("%s\nMock content for %s.\n", mockTitle, target)
+		contentType = "text/plain; charset=utf-8"
+	default:
+		content = mockPage(target)
+		contentType = "text/html; charset=utf-8"
+	}
+	if format == "" {
+		format = "text"
+	}
+
+	result := &scrapfly.ScrapeResult{UUID: errorID()}
+	result.Config.URL = target
+	result.Config.Method = r.Method
+	result.Config.RenderJS = q.Get("render_js") == "true"
+	result.Config.ASP = q.Get("asp") == "true"
+	result.Context.URL = target
+	result.Context.CreatedAt = time.Now().UTC().Format("2006-01-02 15:04:05 UTC")
+	result.Context.Env = "MOCK"
+	result.Context.Cost = scrapfly.CostContext{Total: 1, Details: []scrapfly.CostDetail{{Amount: 1, Code: "PROXY_DATACENTER", Description: "Mock request"}}}
+	result.Result = scrapfly.ResultData{
+		Content:         content,
+		ContentType:     contentType,
+		Format:          format,
+		Status:          "DONE",
+		Success:         true,
+		StatusCode:      http.StatusOK,
+		Reason:          "OK",
+		URL:             target,
+		Size:            len(content),
+		LogURL:          "https://scrapfly.io/dashboard/monitoring/log/" + result.UUID,
+		ResponseHeaders: map[string]any{"content-type": "text/html; charset=utf-8"},
+	}
+	if q.Get("extraction_template") != "" || q.Get("extraction_prompt") != "" || q.Get("extraction_model") != "" {
+		result.Result.ExtractedData = &scrapfly.ExtractionResult{
+			Data:        map[string]any{"title": mockTitle, "url": target},
+			ContentType: "application/json",
+		}
+	}
+	return result
+}
+
+func mockPage(target string) string {
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html><head><title>%s</title></head>
+<body><h1>%s</h1><p>Mock content for <a href="%s">%s</a>.</p></body></html>`,
+		mockTitle, mockTitle, html.EscapeString(target), html.EscapeString(target))
+}
+
+// blockPage is the content of a scrape that failed the anti-bot bypass.
+const blockPage = `<!DOCTYPE html>
+<html><head><title>Just a moment...</title></head>
+<body><div id="cf-chl-widget">Checking if the site connection is secure</div></body></html>`
+
+// writeScreenshot answers /screenshot with a blank viewport-sized image.
+func writeScreenshot(w http.ResponseWriter, target, format string) {
+	img := image.NewGray(image.Rect(0, 0, 1280, 720))
+	for i := range img.Pix {
+		img.Pix[i] = 0xf0
+	}
+	var buf bytes.Buffer
+	contentType := "image/png"
+	if format == "jpg" || format == "jpeg" {
+		jpeg.Encode(&buf, img, nil)
+		contentType = "image/jpeg"
+	} else {
+		png.Encode(&buf, img)
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("x-scrapfly-upstream-http-code", "200")
+	w.Header().Set("x-scrapfly-upstream-url", target)
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+func account() *scrapfly.AccountData {
+	var a scrapfly.AccountData
+	a.Account.AccountID = "mock-account"
+	a.Account.Currency = "USD"
+	a.Account.Timezone = "UTC"
+	a.Project.Name = "default"
+	a.Project.AllowedNetworks = []string{}
+	a.Project.Tags = []string{}
+	a.Subscription.PlanName = "MOCK"
+	a.Subscription.MaxConcurrency = 5
+	a.Subscription.Period.Start = time.Now().UTC().AddDate(0, 0, -1).Format("2006-01-02 15:04:05")
+	a.Subscription.Period.End = time.Now().UTC().AddDate(0, 1, -1).Format("2006-01-02 15:04:05")
+	a.Subscription.Usage.Scrape.Limit = 1000000
+	a.Subscription.Usage.Scrape.Remaining = 1000000
+	a.Subscription.Usage.Scrape.ConcurrentLimit = 5
+	a.Subscription.Usage.Scrape.ConcurrentRemaining = 5
+	return &a
+}
+
+// classify flags the usual block signals — a 403/429/503 status or a
+// known challenge page — as blocked.
+func classify(body []byte) *scrapfly.ClassifyResult {
+	var req scrapfly.ClassifyRequest
+	json.Unmarshal(body, &req)
+	content := strings.ToLower(req.Body)
+	result := &scrapfly.ClassifyResult{Cost: 1}
+	for marker, antibot := range map[string]string{
+		"cf-chl": "cloudflare", "challenges.cloudflare.com": "cloudflare",
+		"datadome": "datadome", "_incapsula_": "incapsula", "px-captcha": "perimeterx",
+	} {
+		if strings.Contains(content, marker) {
+			result.Blocked, result.Antibot = true, antibot
+		}
+	}
+	switch req.StatusCode {
+	case http.StatusForbidden, http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		result.Blocked = true
+	}
+	return result
+}
+
+// unblock opens a session on the fake Cloud Browser.
+func (s *Server) unblock() *scrapfly.UnblockResult {
+	s.mu.Lock()
+	s.sessions++
+	n := s.sessions
+	s.mu.Unlock()
+	return &scrapfly.UnblockResult{
+		WSURL:     s.Browser.URL,
+		SessionID: fmt.Sprintf("mock-session-%d", n),
+		RunID:     fmt.Sprintf("mock-run-%d", n),
+	}
+}
+
+var titleRe = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+func pageTitle(doc string) string {
+	if m := titleRe.FindStringSubmatch(doc); m != nil {
+		return strings.TrimSpace(html.UnescapeString(m[1]))
+	}
+	return ""
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func errorID() string {
+	return fmt.Sprintf("mock-%d", time.Now().UnixNano())
+}
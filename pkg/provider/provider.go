@@ -1,6 +1,8 @@
 package provider
 
 import (
+	"context"
+
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/scrapfly/scrapfly-mcp/pkg/tools"
 )
@@ -30,6 +32,21 @@ type ServerAware interface {
 	SetMCPServer(server *mcp.Server)
 }
 
+// ResourceSubscriber is an optional interface for providers whose resources
+// change at runtime. The server accepts a resources/subscribe request when a
+// provider's SubscribeResource returns nil for its URI, and relays the
+// provider's own Server.ResourceUpdated calls to the subscribed clients.
+type ResourceSubscriber interface {
+	SubscribeResource(ctx context.Context, uri string) error
+	UnsubscribeResource(ctx context.Context, uri string) error
+}
+
+// ResourceSubscriber returns the provider as a ResourceSubscriber, if it is one.
+func (p *ToolProvider) ResourceSubscriber() (ResourceSubscriber, bool) {
+	rs, ok := p.toolProvider.(ResourceSubscriber)
+	return rs, ok
+}
+
 func (p *ToolProvider) RegisterAll(server *mcp.Server) (toolNames []string, promptNames []string, resourceNames []string) {
 	// If the provider implements ServerAware, inject the server reference
 	if sa, ok := p.toolProvider.(ServerAware); ok {
@@ -0,0 +1,379 @@
+package browser
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Ring buffer sizes for the console and network logs.
+const (
+	maxConsoleEntries = 500
+	maxNetworkEntries = 500
+)
+
+// ActivityKind says what changed in a session, for TrackActivity callers.
+type ActivityKind int
+
+const (
+	ActivityNavigated   ActivityKind = iota // the main frame committed a new document
+	ActivityPageChanged                     // the page finished loading or its AX tree changed
+	ActivityConsole                         // a console message, log entry or uncaught exception
+	ActivityNetwork                         // a request finished or failed
+	ActivityDownload                        // a download completed
+)
+
+// ConsoleEntry is one console API call, browser log entry or uncaught exception.
+type ConsoleEntry struct {
+	Time   time.Time `json:"time"`
+	Source string    `json:"source"` // "console", "log" or "exception"
+	Level  string    `json:"level"`
+	Text   string    `json:"text"`
+	URL    string    `json:"url,omitempty"`
+}
+
+// NetworkEntry is one request, updated as its response arrives.
+type NetworkEntry struct {
+	RequestID    string    `json:"request_id"`
+	Time         time.Time `json:"time"`
+	Method       string    `json:"method"`
+	URL          string    `json:"url"`
+	Type         string    `json:"type,omitempty"`
+	Status       int       `json:"status,omitempty"`
+	MIMEType     string    `json:"mime_type,omitempty"`
+	EncodedBytes int64     `json:"encoded_bytes,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	Finished     bool      `json:"finished"`
+}
+
+// activityLog holds a session's console and network history.
+type activityLog struct {
+	mu        sync.Mutex
+	console   []ConsoleEntry
+	network   []*NetworkEntry
+	byRequest map[string]*NetworkEntry
+
+	// In-flight requests (by request ID, with their loader ID) and the time
+	// of the last request start or end, for NetworkIdleFor.
+	inflight    map[string]string
+	lastNetwork time.Time
+
+	// Named points in time, for "since" checks (SetActivityMark).
+	marks map[string]time.Time
+}
+
+// ConsoleLog returns the session's recent console entries, oldest first.
+func (s *Session) ConsoleLog() []ConsoleEntry {
+	a := &s.activity
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	out := make([]ConsoleEntry, len(a.console))
+	copy(out, a.console)
+	return out
+}
+
+// NetworkLog returns the session's recent requests, oldest first.
+func (s *Session) NetworkLog() []NetworkEntry {
+	a := &s.activity
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	out := make([]NetworkEntry, len(a.network))
+	for i, e := range a.network {
+		out[i] = *e
+	}
+	return out
+}
+
+func (a *activityLog) addConsole(e ConsoleEntry) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.console) >= maxConsoleEntries {
+		a.console = a.console[1:]
+	}
+	a.console = append(a.console, e)
+}
+
+// request returns the entry for id, creating it if needed. Event handlers
+// run concurrently, so a response can be seen before its request.
+// Callers hold a.mu.
+func (a *activityLog) request(id string) *NetworkEntry {
+	if e, ok := a.byRequest[id]; ok {
+		return e
+	}
+	if a.byRequest == nil {
+		a.byRequest = map[string]*NetworkEntry{}
+	}
+	if len(a.network) >= maxNetworkEntries {
+		// An evicted request can't be marked done any more.
+		delete(a.byRequest, a.network[0].RequestID)
+		delete(a.inflight, a.network[0].RequestID)
+		a.network = a.network[1:]
+	}
+	e := &NetworkEntry{RequestID: id, Time: time.Now()}
+	a.network = append(a.network, e)
+	a.byRequest[id] = e
+	return e
+}
+
+func (a *activityLog) updateRequest(id string, update func(e *NetworkEntry)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	update(a.request(id))
+}
+
+// setInflight marks a request of loaderID started, or done. Callers hold
+// a.mu.
+func (a *activityLog) setInflight(id, loaderID string, inflight bool) {
+	if a.inflight == nil {
+		a.inflight = map[string]string{}
+	}
+	if inflight {
+		a.inflight[id] = loaderID
+	} else {
+		delete(a.inflight, id)
+	}
+	a.lastNetwork = time.Now()
+}
+
+// dropInflight forgets the in-flight requests that will never report back:
+// all of them after a reconnect (keep == ""), or those of documents other
+// than loader keep once the main frame has committed it.
+func (a *activityLog) dropInflight(keep string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for id, loaderID := range a.inflight {
+		if keep == "" || loaderID != keep {
+			delete(a.inflight, id)
+		}
+	}
+	a.lastNetwork = time.Now()
+}
+
+// NetworkIdleFor reports how long the session has had no request in
+// flight, or 0 while one is. Long-lived streams (EventSource) don't count.
+// Needs TrackActivity.
+func (s *Session) NetworkIdleFor() time.Duration {
+	a := &s.activity
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.inflight) > 0 {
+		return 0
+	}
+	return time.Since(a.lastNetwork)
+}
+
+// SetActivityMark records the current time under name, so later checks can
+// look only at console and network activity that happened after it.
+func (s *Session) SetActivityMark(name string) time.Time {
+	a := &s.activity
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.marks == nil {
+		a.marks = map[string]time.Time{}
+	}
+	now := time.Now()
+	a.marks[name] = now
+	return now
+}
+
+// ActivityMark returns the time recorded for name by SetActivityMark.
+func (s *Session) ActivityMark(name string) (time.Time, bool) {
+	a := &s.activity
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	t, ok := a.marks[name]
+	return t, ok
+}
+
+// TrackActivity records the session's console and network activity and
+// calls onChange for each kind of change as it happens. The handlers live
+// on the Session and survive reconnects; the Runtime, Log, Network,
+// Accessibility and Page domains must be enabled for events to arrive.
+// onChange runs on event goroutines and should not block.
+func (s *Session) TrackActivity(onChange func(ActivityKind)) {
+	s.OnEvent("Page.frameNavigated", func(_ string, params json.RawMessage) bool {
+		var event struct {
+			Frame struct {
+				ParentID string `json:"parentId"`
+				LoaderID string `json:"loaderId"`
+			} `json:"frame"`
+		}
+		if json.Unmarshal(params, &event) == nil && event.Frame.ParentID == "" {
+			// Requests of the previous document may never finish.
+			if event.Frame.LoaderID != "" {
+				s.activity.dropInflight(event.Frame.LoaderID)
+			}
+			onChange(ActivityNavigated)
+		}
+		return true
+	})
+	for _, method := range []string{"Page.loadEventFired", "Accessibility.loadComplete", "Accessibility.nodesUpdated"} {
+		s.OnEvent(method, func(string, json.RawMessage) bool {
+			onChange(ActivityPageChanged)
+			return true
+		})
+	}
+
+	// Console
+	s.OnEvent("Runtime.consoleAPICalled", func(_ string, params json.RawMessage) bool {
+		var event struct {
+			Type string `json:"type"`
+			Args []struct {
+				Type        string          `json:"type"`
+				Value       json.RawMessage `json:"value"`
+				Description string          `json:"description"`
+			} `json:"args"`
+		}
+		if json.Unmarshal(params, &event) != nil {
+			return true
+		}
+		parts := make([]string, 0, len(event.Args))
+		for _, arg := range event.Args {
+			var str string
+			switch {
+			case json.Unmarshal(arg.Value, &str) == nil:
+				parts = append(parts, str)
+			case len(arg.Value) > 0:
+				parts = append(parts, string(arg.Value))
+			case arg.Description != "":
+				parts = append(parts, arg.Description)
+			default:
+				parts = append(parts, arg.Type)
+			}
+		}
+		s.activity.addConsole(ConsoleEntry{
+			Time: time.Now(), Source: "console", Level: event.Type, Text: strings.Join(parts, " "),
+		})
+		onChange(ActivityConsole)
+		return true
+	})
+	s.OnEvent("Runtime.exceptionThrown", func(_ string, params json.RawMessage) bool {
+		var event struct {
+			ExceptionDetails struct {
+				Text      string `json:"text"`
+				URL       string `json:"url"`
+				Exception *struct {
+					Description string `json:"description"`
+				} `json:"exception"`
+			} `json:"exceptionDetails"`
+		}
+		if json.Unmarshal(params, &event) != nil {
+			return true
+		}
+		d := event.ExceptionDetails
+		text := d.Text
+		if d.Exception != nil && d.Exception.Description != "" {
+			text = d.Exception.Description
+		}
+		s.activity.addConsole(ConsoleEntry{
+			Time: time.Now(), Source: "exception", Level: "error", Text: text, URL: d.URL,
+		})
+		onChange(ActivityConsole)
+		return true
+	})
+	s.OnEvent("Log.entryAdded", func(_ string, params json.RawMessage) bool {
+		var event struct {
+			Entry struct {
+				Source string `json:"source"`
+				Level  string `json:"level"`
+				Text   string `json:"text"`
+				URL    string `json:"url"`
+			} `json:"entry"`
+		}
+		if json.Unmarshal(params, &event) != nil {
+			return true
+		}
+		e := event.Entry
+		s.activity.addConsole(ConsoleEntry{
+			Time: time.Now(), Source: "log", Level: e.Level, Text: fmt.Sprintf("[%s] %s", e.Source, e.Text), URL: e.URL,
+		})
+		onChange(ActivityConsole)
+		return true
+	})
+
+	// Network
+	s.OnEvent("Network.requestWillBeSent", func(_ string, params json.RawMessage) bool {
+		var event struct {
+			RequestID string `json:"requestId"`
+			LoaderID  string `json:"loaderId"`
+			Type      string `json:"type"`
+			Request   struct {
+				Method string `json:"method"`
+				URL    string `json:"url"`
+			} `json:"request"`
+		}
+		if json.Unmarshal(params, &event) == nil {
+			s.activity.updateRequest(event.RequestID, func(e *NetworkEntry) {
+				e.Method, e.URL, e.Type = event.Request.Method, event.Request.URL, event.Type
+				if !e.Finished && event.Type != "EventSource" {
+					s.activity.setInflight(event.RequestID, event.LoaderID, true)
+				}
+			})
+		}
+		return true
+	})
+	s.OnEvent("Network.responseReceived", func(_ string, params json.RawMessage) bool {
+		var event struct {
+			RequestID string `json:"requestId"`
+			Type      string `json:"type"`
+			Response  struct {
+				URL      string `json:"url"`
+				Status   int    `json:"status"`
+				MIMEType string `json:"mimeType"`
+			} `json:"response"`
+		}
+		if json.Unmarshal(params, &event) == nil {
+			s.activity.updateRequest(event.RequestID, func(e *NetworkEntry) {
+				e.Status, e.MIMEType = event.Response.Status, event.Response.MIMEType
+				if e.URL == "" {
+					e.URL, e.Type = event.Response.URL, event.Type
+				}
+			})
+		}
+		return true
+	})
+	s.OnEvent("Network.loadingFinished", func(_ string, params json.RawMessage) bool {
+		var event struct {
+			RequestID         string  `json:"requestId"`
+			EncodedDataLength float64 `json:"encodedDataLength"`
+		}
+		if json.Unmarshal(params, &event) == nil {
+			s.activity.updateRequest(event.RequestID, func(e *NetworkEntry) {
+				e.EncodedBytes, e.Finished = int64(event.EncodedDataLength), true
+				s.activity.setInflight(event.RequestID, "", false)
+			})
+			onChange(ActivityNetwork)
+		}
+		return true
+	})
+	s.OnEvent("Network.loadingFailed", func(_ string, params json.RawMessage) bool {
+		var event struct {
+			RequestID string `json:"requestId"`
+			ErrorText string `json:"errorText"`
+		}
+		if json.Unmarshal(params, &event) == nil {
+			s.activity.updateRequest(event.RequestID, func(e *NetworkEntry) {
+				e.Error, e.Finished = event.ErrorText, true
+				s.activity.setInflight(event.RequestID, "", false)
+			})
+			onChange(ActivityNetwork)
+		}
+		return true
+	})
+
+	// Downloads are reported on the page session or the browser session
+	// depending on how the browser was told to handle them.
+	for _, method := range []string{"Page.downloadProgress", "Browser.downloadProgress"} {
+		s.OnEvent(method, func(_ string, params json.RawMessage) bool {
+			var event struct {
+				State string `json:"state"`
+			}
+			if json.Unmarshal(params, &event) == nil && event.State == "completed" {
+				onChange(ActivityDownload)
+			}
+			return true
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package browser_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser"
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser/browsertest"
+)
+
+// eventually fails the test unless cond holds within two seconds.
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func requestSent(srv *browsertest.Server, id, loaderID string) {
+	srv.Emit("Network.requestWillBeSent", map[string]any{
+		"requestId": id,
+		"loaderId":  loaderID,
+		"type":      "XHR",
+		"request":   map[string]any{"method": "GET", "url": "https://example.com/" + id},
+	})
+}
+
+func busy(session *browser.Session) func() bool {
+	return func() bool { return session.NetworkIdleFor() == 0 }
+}
+
+func idle(session *browser.Session) func() bool {
+	return func() bool { return session.NetworkIdleFor() > 0 }
+}
+
+func TestNetworkIdleAfterMainFrameNavigation(t *testing.T) {
+	srv := browsertest.NewServer()
+	defer srv.Close()
+	session := connect(t, srv)
+	session.TrackActivity(func(browser.ActivityKind) {})
+
+	requestSent(srv, "old-doc-xhr", "loader-old")
+	eventually(t, "the request to be in flight", busy(session))
+
+	// A subframe navigation keeps it.
+	srv.Emit("Page.frameNavigated", map[string]any{"frame": map[string]any{"id": "child", "parentId": browsertest.MainFrameID, "loaderId": "loader-child"}})
+	time.Sleep(50 * time.Millisecond)
+	if !busy(session)() {
+		t.Fatal("a subframe navigation dropped the main document's request")
+	}
+
+	srv.Emit("Page.frameNavigated", map[string]any{"frame": map[string]any{"id": browsertest.MainFrameID, "loaderId": "loader-new"}})
+	eventually(t, "the previous document's request to be dropped", idle(session))
+}
+
+func TestNetworkIdleAfterRingEviction(t *testing.T) {
+	srv := browsertest.NewServer()
+	defer srv.Close()
+	session := connect(t, srv)
+	session.TrackActivity(func(browser.ActivityKind) {})
+
+	requestSent(srv, "never-finishes", "loader-1")
+	eventually(t, "the request to be in flight", busy(session))
+	for i := range 500 {
+		id := fmt.Sprintf("r%d", i)
+		requestSent(srv, id, "loader-1")
+		srv.Emit("Network.loadingFinished", map[string]any{"requestId": id})
+	}
+	eventually(t, "the evicted request to stop counting", idle(session))
+}
+
+func TestNetworkIdleAfterReconnect(t *testing.T) {
+	srv := browsertest.NewServer()
+	defer srv.Close()
+	session := connectWithSetup(t, srv, nil)
+	session.TrackActivity(func(browser.ActivityKind) {})
+
+	requestSent(srv, "lost-with-the-socket", "loader-1")
+	eventually(t, "the request to be in flight", busy(session))
+	srv.DropConnections()
+	eventually(t, "the reconnect to drop the request", idle(session))
+}
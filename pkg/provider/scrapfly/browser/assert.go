@@ -0,0 +1,376 @@
+package browser
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Assertions.
+//
+// Assert evaluates a list of QA checks against the live page and its
+// console and network history, and reports pass/fail with the evidence for
+// each, so a smoke test ends in a structured verdict rather than free text.
+
+// Assertion check types.
+const (
+	AssertElementExists   = "element_exists"
+	AssertTextPresent     = "text_present"
+	AssertInputValue      = "input_value"
+	AssertURLMatches      = "url_matches"
+	AssertNoConsoleErrors = "no_console_errors"
+	AssertRequestOK       = "request_ok"
+)
+
+// maxAssertEvidence bounds the entries quoted in a check's evidence.
+const maxAssertEvidence = 5
+
+// AssertCheck is one check. Which fields apply depends on Type:
+//
+//   - element_exists: Role and/or Label (the accessible name, matched
+//     case-insensitively).
+//   - text_present: Value, matched case-insensitively in the page text.
+//   - input_value: UID or Label of the field, and the expected Value.
+//   - url_matches: Value, a regular expression.
+//   - no_console_errors: Since, an optional activity mark.
+//   - request_ok: Value, a regular expression over request URLs, and an
+//     optional Since mark.
+//
+// Name labels the check in reports; it defaults to a description of it.
+type AssertCheck struct {
+	Name  string `json:"name,omitempty"`
+	Type  string `json:"type"`
+	Role  string `json:"role,omitempty"`
+	Label string `json:"label,omitempty"`
+	UID   string `json:"uid,omitempty"`
+	Value string `json:"value,omitempty"`
+	Since string `json:"since,omitempty"`
+}
+
+func (c AssertCheck) String() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	parts := []string{c.Type}
+	if c.Role != "" {
+		parts = append(parts, c.Role)
+	}
+	if c.UID != "" {
+		parts = append(parts, "uid="+c.UID)
+	}
+	if c.Label != "" {
+		parts = append(parts, fmt.Sprintf("%q", c.Label))
+	}
+	if c.Value != "" {
+		parts = append(parts, fmt.Sprintf("%q", c.Value))
+	}
+	if c.Since != "" {
+		parts = append(parts, "since "+c.Since)
+	}
+	return strings.Join(parts, " ")
+}
+
+// AssertResult is the verdict on one check.
+type AssertResult struct {
+	Check    AssertCheck   `json:"check"`
+	Passed   bool          `json:"passed"`
+	Evidence string        `json:"evidence"`
+	Duration time.Duration `json:"-"`
+}
+
+// AssertReport is the outcome of an Assert call.
+type AssertReport struct {
+	URL     string         `json:"url"`
+	Title   string         `json:"title"`
+	Time    time.Time      `json:"time"`
+	Passed  int            `json:"passed"`
+	Failed  int            `json:"failed"`
+	Results []AssertResult `json:"results"`
+}
+
+// OK reports whether every check passed.
+func (r *AssertReport) OK() bool {
+	return r.Failed == 0
+}
+
+// Assert refreshes the page state and evaluates every check in order. A
+// check that can't be evaluated (bad regexp, unknown mark, page error)
+// fails with the reason as its evidence.
+func (s *Session) Assert(ctx context.Context, checks []AssertCheck) (*AssertReport, error) {
+	if len(checks) == 0 {
+		return nil, fmt.Errorf("no checks to evaluate")
+	}
+	s.Page.Refresh(ctx, s)
+	report := &AssertReport{Time: time.Now(), Results: make([]AssertResult, 0, len(checks))}
+	report.URL, _ = s.evalString(ctx, "location.href")
+	report.Title, _ = s.evalString(ctx, "document.title")
+	for _, c := range checks {
+		start := time.Now()
+		passed, evidence, err := s.assertCheck(ctx, c)
+		if err != nil {
+			passed, evidence = false, err.Error()
+		}
+		report.Results = append(report.Results, AssertResult{
+			Check: c, Passed: passed, Evidence: evidence, Duration: time.Since(start),
+		})
+		if passed {
+			report.Passed++
+		} else {
+			report.Failed++
+		}
+	}
+	return report, nil
+}
+
+// assertCheck evaluates one check and describes what it saw.
+func (s *Session) assertCheck(ctx context.Context, c AssertCheck) (bool, string, error) {
+	switch c.Type {
+	case AssertElementExists:
+		if c.Role == "" && c.Label == "" {
+			return false, "", fmt.Errorf("role or label is required")
+		}
+		var found, sameRole []InteractiveNode
+		for _, n := range s.Page.Nodes() {
+			if c.Role != "" && !strings.EqualFold(n.Role, c.Role) {
+				continue
+			}
+			sameRole = append(sameRole, n)
+			if c.Label == "" || strings.EqualFold(strings.TrimSpace(n.Name), strings.TrimSpace(c.Label)) {
+				found = append(found, n)
+			}
+		}
+		if len(found) > 0 {
+			n := found[0]
+			evidence := fmt.Sprintf("found id=%s %s %q", n.UID, n.Role, n.Name)
+			if len(found) > 1 {
+				evidence += fmt.Sprintf(" (%d matches)", len(found))
+			}
+			return true, evidence, nil
+		}
+		if len(sameRole) == 0 || c.Role == "" {
+			return false, "no matching element in the snapshot", nil
+		}
+		names := make([]string, 0, maxAssertEvidence)
+		for _, n := range sameRole[:min(len(sameRole), maxAssertEvidence)] {
+			names = append(names, fmt.Sprintf("%q", n.Name))
+		}
+		return false, fmt.Sprintf("no %s named %q; %d %s element(s) found: %s",
+			c.Role, c.Label, len(sameRole), c.Role, strings.Join(names, ", ")), nil
+
+	case AssertTextPresent:
+		if c.Value == "" {
+			return false, "", fmt.Errorf("value (the text) is required")
+		}
+		var snippet *string
+		expr := fmt.Sprintf(`(() => {
+  const t = document.body ? document.body.innerText : '', n = %s;
+  const i = t.toLowerCase().indexOf(n);
+  return i < 0 ? null : t.slice(Math.max(0, i - 40), i + n.length + 40).replace(/\s+/g, ' ').trim();
+})()`, jsString(strings.ToLower(c.Value)))
+		if err := s.evalValue(ctx, expr, &snippet); err != nil {
+			return false, "", err
+		}
+		if snippet == nil {
+			return false, "text not found in the page", nil
+		}
+		return true, fmt.Sprintf("found in %q", *snippet), nil
+
+	case AssertInputValue:
+		backendID, desc, err := s.assertField(c)
+		if err != nil {
+			return false, "", err
+		}
+		var got string
+		if err := s.callOnNode(ctx, backendID, inputValueJSFn, &got); err != nil {
+			return false, "", err
+		}
+		if got != c.Value {
+			return false, fmt.Sprintf("%s has value %q, want %q", desc, got, c.Value), nil
+		}
+		return true, fmt.Sprintf("%s has value %q", desc, got), nil
+
+	case AssertURLMatches:
+		re, err := regexp.Compile(c.Value)
+		if err != nil {
+			return false, "", fmt.Errorf("value must be a regular expression: %w", err)
+		}
+		url, err := s.evalString(ctx, "location.href")
+		if err != nil {
+			return false, "", err
+		}
+		return re.MatchString(url), "url is " + url, nil
+
+	case AssertNoConsoleErrors:
+		since, err := s.assertSince(c.Since)
+		if err != nil {
+			return false, "", err
+		}
+		var seen int
+		var errs []string
+		for _, e := range s.ConsoleLog() {
+			if e.Time.Before(since) {
+				continue
+			}
+			seen++
+			if e.Level == "error" || e.Level == "assert" {
+				errs = append(errs, fmt.Sprintf("[%s] %s", e.Source, e.Text))
+			}
+		}
+		if len(errs) == 0 {
+			return true, fmt.Sprintf("no errors among %d console entries%s", seen, sinceText(c.Since)), nil
+		}
+		return false, fmt.Sprintf("%d console error(s)%s: %s", len(errs), sinceText(c.Since),
+			strings.Join(errs[:min(len(errs), maxAssertEvidence)], "; ")), nil
+
+	case AssertRequestOK:
+		re, err := regexp.Compile(c.Value)
+		if err != nil {
+			return false, "", fmt.Errorf("value must be a regular expression: %w", err)
+		}
+		since, err := s.assertSince(c.Since)
+		if err != nil {
+			return false, "", err
+		}
+		var matched []NetworkEntry
+		for _, e := range s.NetworkLog() {
+			if !e.Time.Before(since) && re.MatchString(e.URL) {
+				matched = append(matched, e)
+			}
+		}
+		if len(matched) == 0 {
+			return false, "no request matched" + sinceText(c.Since), nil
+		}
+		// The latest successful request wins; otherwise report the latest.
+		for i := len(matched) - 1; i >= 0; i-- {
+			if e := matched[i]; e.Status >= 200 && e.Status < 300 {
+				return true, describeRequest(e), nil
+			}
+		}
+		return false, describeRequest(matched[len(matched)-1]), nil
+	}
+	return false, "", fmt.Errorf("unknown check type (use %s)", strings.Join([]string{
+		AssertElementExists, AssertTextPresent, AssertInputValue,
+		AssertURLMatches, AssertNoConsoleErrors, AssertRequestOK,
+	}, ", "))
+}
+
+// assertField resolves an input_value check's element to its DOM node.
+func (s *Session) assertField(c AssertCheck) (int64, string, error) {
+	if c.UID != "" {
+		id, ok := s.Page.BackendNodeID(c.UID)
+		if !ok {
+			return 0, "", fmt.Errorf("no element with uid %s in the snapshot", c.UID)
+		}
+		return id, "uid=" + c.UID, nil
+	}
+	n, err := findFormNode(s.Page.InteractiveNodes(), FormField{Label: c.Label})
+	if err != nil {
+		return 0, "", err
+	}
+	return n.BackendNodeID, fmt.Sprintf("id=%s %s %q", n.UID, n.Role, n.Name), nil
+}
+
+// assertSince returns the time of the named mark, or the zero time when
+// no mark is given.
+func (s *Session) assertSince(mark string) (time.Time, error) {
+	if mark == "" {
+		return time.Time{}, nil
+	}
+	t, ok := s.ActivityMark(mark)
+	if !ok {
+		return time.Time{}, fmt.Errorf("unknown mark %q: set it first", mark)
+	}
+	return t, nil
+}
+
+func sinceText(mark string) string {
+	if mark == "" {
+		return ""
+	}
+	return " since mark " + mark
+}
+
+func describeRequest(e NetworkEntry) string {
+	switch {
+	case e.Error != "":
+		return fmt.Sprintf("%s %s failed: %s", e.Method, e.URL, e.Error)
+	case e.Status == 0:
+		return fmt.Sprintf("%s %s has no response yet", e.Method, e.URL)
+	}
+	return fmt.Sprintf("%s %s returned %d", e.Method, e.URL, e.Status)
+}
+
+// inputValueJSFn reads a form control's current value: checked state for
+// checkboxes and radios, '|'-joined values for multi-selects, text content
+// for contenteditable elements.
+const inputValueJSFn = `function() {
+  if (this.type === 'checkbox' || this.type === 'radio') return String(this.checked);
+  if (this.multiple && this.selectedOptions) return Array.from(this.selectedOptions, o => o.value).join('|');
+  if ('value' in this) return String(this.value);
+  return (this.textContent || '').trim();
+}`
+
+// ── JUnit ────────────────────────────────────────────────────────────────
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// JUnitXML renders the report as a JUnit XML document with one test case
+// per check, for CI dashboards. suite names the test suite.
+func (r *AssertReport) JUnitXML(suite string) ([]byte, error) {
+	ts := junitTestSuite{
+		Name:      suite,
+		Tests:     len(r.Results),
+		Failures:  r.Failed,
+		Timestamp: r.Time.UTC().Format("2006-01-02T15:04:05"),
+	}
+	var total time.Duration
+	for _, res := range r.Results {
+		total += res.Duration
+		tc := junitTestCase{
+			ClassName: suite,
+			Name:      res.Check.String(),
+			Time:      fmt.Sprintf("%.3f", res.Duration.Seconds()),
+		}
+		if res.Passed {
+			tc.SystemOut = res.Evidence
+		} else {
+			tc.Failure = &junitFailure{Message: res.Evidence, Type: res.Check.Type, Text: res.Evidence}
+		}
+		ts.Cases = append(ts.Cases, tc)
+	}
+	ts.Time = fmt.Sprintf("%.3f", total.Seconds())
+	out, err := xml.MarshalIndent(junitTestSuites{Suites: []junitTestSuite{ts}}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
@@ -0,0 +1,64 @@
+package browser_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser"
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser/browsertest"
+)
+
+func TestAssert(t *testing.T) {
+	srv := browsertest.NewServer()
+	defer srv.Close()
+	srv.SetPage("https://shop.example/checkout/done", "Order placed")
+	srv.SetAXTree(
+		browsertest.AXNode{ID: "home", Role: "link", Name: "Back to shop", BackendNodeID: 10},
+		browsertest.AXNode{ID: "email", Role: "textbox", Name: "Email", BackendNodeID: 11},
+	)
+	srv.Eval("'value' in this", "jo@example.com")
+	srv.Eval("order #", "Thanks! Your order #1234 is confirmed")
+	session := connect(t, srv)
+	session.TrackActivity(func(browser.ActivityKind) {})
+	srv.Emit("Runtime.exceptionThrown", map[string]any{"exceptionDetails": map[string]any{"text": "Uncaught TypeError: x is undefined"}})
+	srv.Emit("Network.requestWillBeSent", map[string]any{"requestId": "r1", "request": map[string]any{"method": "POST", "url": "https://shop.example/api/order"}})
+	srv.Emit("Network.responseReceived", map[string]any{"requestId": "r1", "response": map[string]any{"status": 201}})
+	eventually(t, "the console and network events", func() bool {
+		return len(session.ConsoleLog()) == 1 && len(session.NetworkLog()) == 1 && session.NetworkLog()[0].Status == 201
+	})
+
+	checks := []struct {
+		check browser.AssertCheck
+		pass  bool
+	}{
+		{browser.AssertCheck{Type: browser.AssertElementExists, Role: "link", Label: "back to shop"}, true},
+		{browser.AssertCheck{Type: browser.AssertElementExists, Role: "button", Label: "Pay"}, false},
+		{browser.AssertCheck{Type: browser.AssertTextPresent, Value: "Order #"}, true},
+		{browser.AssertCheck{Type: browser.AssertInputValue, Label: "Email", Value: "jo@example.com"}, true},
+		{browser.AssertCheck{Type: browser.AssertInputValue, UID: "email", Value: "someone@else.com"}, false},
+		{browser.AssertCheck{Type: browser.AssertURLMatches, Value: `/checkout/done$`}, true},
+		{browser.AssertCheck{Type: browser.AssertNoConsoleErrors}, false},
+		{browser.AssertCheck{Type: browser.AssertRequestOK, Value: `/api/order`}, true},
+		{browser.AssertCheck{Type: browser.AssertRequestOK, Value: `/api/pay`}, false},
+		{browser.AssertCheck{Type: browser.AssertURLMatches, Value: `(`}, false},
+	}
+	in := make([]browser.AssertCheck, len(checks))
+	for i, c := range checks {
+		in[i] = c.check
+	}
+	report, err := session.Assert(context.Background(), in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.URL != "https://shop.example/checkout/done" || report.Title != "Order placed" {
+		t.Errorf("report on %q %q", report.URL, report.Title)
+	}
+	for i, r := range report.Results {
+		if r.Passed != checks[i].pass {
+			t.Errorf("%s: passed = %t, want %t (evidence: %s)", r.Check, r.Passed, checks[i].pass, r.Evidence)
+		}
+	}
+	if report.Passed != 5 || report.Failed != 5 || report.OK() {
+		t.Errorf("passed %d, failed %d", report.Passed, report.Failed)
+	}
+}
@@ -0,0 +1,202 @@
+package browser
+
+import (
+	"bufio"
+	"encoding/binary"
+	"os"
+)
+
+// aviWriter writes Motion-JPEG frames into an AVI (RIFF) file, which every
+// common player opens without codecs beyond JPEG. Frames are appended to
+// the 'movi' list as '00dc' chunks; Close writes the 'idx1' index and
+// patches the headers with the final frame count and size.
+//
+// A repeated frame is written as an empty chunk: players show the previous
+// frame again, so idle stretches of a recording cost 8 bytes per frame.
+type aviWriter struct {
+	f       *os.File
+	w       *bufio.Writer
+	fps     int
+	width   int
+	height  int
+	frames  int
+	maxSize uint32
+	offset  uint32 // bytes written after the 'movi' fourcc
+	index   []aviIndexEntry
+}
+
+type aviIndexEntry struct {
+	offset, size uint32
+	keyframe     bool
+}
+
+// Fixed header layout: RIFF/AVI, LIST hdrl (avih, LIST strl (strh, strf)),
+// then LIST movi.
+const (
+	aviHeaderSize = 224 // up to and including the 'movi' fourcc
+	aviMoviList   = 212 // offset of the movi 'LIST'
+	aviMoviFourCC = 220 // idx1 offsets are relative to this
+)
+
+func newAVIWriter(path string, fps int) (*aviWriter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	a := &aviWriter{f: f, w: bufio.NewWriter(f), fps: fps, offset: 4}
+	// Placeholder header, rewritten by Close.
+	if _, err := a.w.Write(a.header(0)); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return a, nil
+}
+
+// WriteFrame appends a JPEG frame. A nil frame repeats the previous one.
+func (a *aviWriter) WriteFrame(jpeg []byte, width, height int) error {
+	if a.width == 0 && width > 0 {
+		a.width, a.height = width, height
+	}
+	size := uint32(len(jpeg))
+	if err := a.chunk("00dc", jpeg); err != nil {
+		return err
+	}
+	a.index = append(a.index, aviIndexEntry{offset: a.offset, size: size, keyframe: size > 0})
+	a.offset += 8 + size + size%2
+	a.maxSize = max(a.maxSize, size)
+	a.frames++
+	return nil
+}
+
+// Frames returns the number of frames written so far.
+func (a *aviWriter) Frames() int {
+	return a.frames
+}
+
+// Close writes the index, finalizes the headers and closes the file.
+func (a *aviWriter) Close() error {
+	defer a.f.Close()
+	idx := make([]byte, 0, 16*len(a.index))
+	for _, e := range a.index {
+		var flags uint32
+		if e.keyframe {
+			flags = 0x10 // AVIIF_KEYFRAME
+		}
+		idx = append(idx, "00dc"...)
+		idx = binary.LittleEndian.AppendUint32(idx, flags)
+		idx = binary.LittleEndian.AppendUint32(idx, e.offset)
+		idx = binary.LittleEndian.AppendUint32(idx, e.size)
+	}
+	if err := a.chunk("idx1", idx); err != nil {
+		return err
+	}
+	if err := a.w.Flush(); err != nil {
+		return err
+	}
+	moviSize := a.offset
+	riffSize := aviHeaderSize - 12 + moviSize + 8 + uint32(len(idx))
+	if _, err := a.f.WriteAt(a.header(riffSize), 0); err != nil {
+		return err
+	}
+	buf := binary.LittleEndian.AppendUint32(nil, moviSize)
+	if _, err := a.f.WriteAt(buf, aviMoviList+4); err != nil {
+		return err
+	}
+	return a.f.Sync()
+}
+
+// chunk writes a RIFF chunk, padded to an even length.
+func (a *aviWriter) chunk(fourcc string, data []byte) error {
+	hdr := binary.LittleEndian.AppendUint32([]byte(fourcc), uint32(len(data)))
+	if _, err := a.w.Write(hdr); err != nil {
+		return err
+	}
+	if _, err := a.w.Write(data); err != nil {
+		return err
+	}
+	if len(data)%2 == 1 {
+		return a.w.WriteByte(0)
+	}
+	return nil
+}
+
+// header builds everything up to the 'movi' fourcc. The movi LIST size is
+// left as 0 and patched by Close.
+func (a *aviWriter) header(riffSize uint32) []byte {
+	le := binary.LittleEndian
+	b := make([]byte, 0, aviHeaderSize)
+	u32 := func(v uint32) { b = le.AppendUint32(b, v) }
+	u16 := func(v uint16) { b = le.AppendUint16(b, v) }
+	s := func(v string) { b = append(b, v...) }
+
+	s("RIFF")
+	u32(riffSize)
+	s("AVI ")
+
+	s("LIST")
+	u32(192)
+	s("hdrl")
+
+	// MainAVIHeader
+	s("avih")
+	u32(56)
+	u32(uint32(1_000_000 / a.fps)) // microseconds per frame
+	u32(a.maxSize * uint32(a.fps)) // max bytes per second
+	u32(0)                         // padding granularity
+	u32(0x10)                      // AVIF_HASINDEX
+	u32(uint32(a.frames))
+	u32(0) // initial frames
+	u32(1) // streams
+	u32(a.maxSize)
+	u32(uint32(a.width))
+	u32(uint32(a.height))
+	u32(0)
+	u32(0)
+	u32(0)
+	u32(0)
+
+	s("LIST")
+	u32(116)
+	s("strl")
+
+	// AVIStreamHeader
+	s("strh")
+	u32(56)
+	s("vids")
+	s("MJPG")
+	u32(0) // flags
+	u16(0) // priority
+	u16(0) // language
+	u32(0) // initial frames
+	u32(1) // scale
+	u32(uint32(a.fps))
+	u32(0) // start
+	u32(uint32(a.frames))
+	u32(a.maxSize)
+	u32(0xFFFFFFFF) // quality: default
+	u32(0)          // sample size
+	u16(0)
+	u16(0)
+	u16(uint16(a.width))
+	u16(uint16(a.height))
+
+	// BITMAPINFOHEADER
+	s("strf")
+	u32(40)
+	u32(40)
+	u32(uint32(a.width))
+	u32(uint32(a.height))
+	u16(1)  // planes
+	u16(24) // bit count
+	s("MJPG")
+	u32(uint32(a.width * a.height * 3))
+	u32(0)
+	u32(0)
+	u32(0)
+	u32(0)
+
+	s("LIST")
+	u32(0)
+	s("movi")
+	return b
+}
@@ -0,0 +1,127 @@
+package browser
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// riffChunk is a chunk read back from an AVI file.
+type riffChunk struct {
+	fourcc string
+	offset int // of the chunk header
+	data   []byte
+}
+
+func readChunk(t *testing.T, b []byte, at int) riffChunk {
+	t.Helper()
+	if at+8 > len(b) {
+		t.Fatalf("chunk header at %d runs past the end (%d bytes)", at, len(b))
+	}
+	size := int(binary.LittleEndian.Uint32(b[at+4:]))
+	if at+8+size > len(b) {
+		t.Fatalf("%q chunk at %d (%d bytes) runs past the end (%d bytes)", b[at:at+4], at, size, len(b))
+	}
+	return riffChunk{fourcc: string(b[at : at+4]), offset: at, data: b[at+8 : at+8+size]}
+}
+
+func TestAVILayout(t *testing.T) {
+	le := binary.LittleEndian
+	for _, tt := range []struct {
+		name   string
+		frames [][]byte
+	}{
+		{"no frames", nil},
+		{"one frame", [][]byte{[]byte("jpeg")}},
+		{"odd sizes are padded", [][]byte{[]byte("abc"), []byte("de"), []byte("f")}},
+		{"repeated frames", [][]byte{[]byte("first"), nil, nil, []byte("second!")}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "rec.avi")
+			a, err := newAVIWriter(path, 5)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, frame := range tt.frames {
+				if err := a.WriteFrame(frame, 640, 480); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := a.Close(); err != nil {
+				t.Fatal(err)
+			}
+			b, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			riff := readChunk(t, b, 0)
+			if riff.fourcc != "RIFF" || string(riff.data[:4]) != "AVI " || 8+len(riff.data) != len(b) {
+				t.Fatalf("RIFF header %q size %d, file is %d bytes", riff.fourcc, len(riff.data), len(b))
+			}
+			hdrl := readChunk(t, b, 12)
+			if hdrl.fourcc != "LIST" || string(hdrl.data[:4]) != "hdrl" {
+				t.Fatalf("hdrl list = %q %q", hdrl.fourcc, hdrl.data[:4])
+			}
+			avih := readChunk(t, b, 24)
+			if avih.fourcc != "avih" || len(avih.data) != 56 {
+				t.Fatalf("avih = %q, %d bytes", avih.fourcc, len(avih.data))
+			}
+			if got := le.Uint32(avih.data[16:]); got != uint32(len(tt.frames)) {
+				t.Errorf("avih total frames = %d, want %d", got, len(tt.frames))
+			}
+			if len(tt.frames) > 0 && (le.Uint32(avih.data[32:]) != 640 || le.Uint32(avih.data[36:]) != 480) {
+				t.Errorf("avih size = %dx%d, want 640x480", le.Uint32(avih.data[32:]), le.Uint32(avih.data[36:]))
+			}
+			strl := readChunk(t, b, 88)
+			if strl.fourcc != "LIST" || string(strl.data[:4]) != "strl" || strl.offset+8+len(strl.data) != 12+8+len(hdrl.data) {
+				t.Fatalf("strl list = %q %q, %d bytes", strl.fourcc, strl.data[:4], len(strl.data))
+			}
+			strh := readChunk(t, b, 100)
+			if strh.fourcc != "strh" || string(strh.data[:8]) != "vidsMJPG" || le.Uint32(strh.data[32:]) != uint32(len(tt.frames)) {
+				t.Errorf("strh = %q %q, length %d", strh.fourcc, strh.data[:8], le.Uint32(strh.data[32:]))
+			}
+			if strf := readChunk(t, b, 164); strf.fourcc != "strf" || len(strf.data) != 40 {
+				t.Errorf("strf = %q, %d bytes", strf.fourcc, len(strf.data))
+			}
+
+			movi := readChunk(t, b, aviMoviList)
+			if movi.fourcc != "LIST" || string(movi.data[:4]) != "movi" {
+				t.Fatalf("movi list = %q %q", movi.fourcc, movi.data[:4])
+			}
+			end := aviMoviList + 8 + len(movi.data)
+			idx1 := readChunk(t, b, end)
+			if idx1.fourcc != "idx1" || end+8+len(idx1.data) != len(b) {
+				t.Fatalf("idx1 = %q at %d, %d bytes, file is %d bytes", idx1.fourcc, end, len(idx1.data), len(b))
+			}
+			if len(idx1.data) != 16*len(tt.frames) {
+				t.Fatalf("idx1 has %d bytes, want 16 per frame", len(idx1.data))
+			}
+
+			// Each index entry points, from the movi fourcc, at its frame's chunk.
+			at := aviMoviFourCC + 4
+			for i, frame := range tt.frames {
+				e := idx1.data[16*i:]
+				chunk := readChunk(t, b, at)
+				if chunk.fourcc != "00dc" || !bytes.Equal(chunk.data, frame) {
+					t.Errorf("frame %d = %q %q, want 00dc %q", i, chunk.fourcc, chunk.data, frame)
+				}
+				if off := int(le.Uint32(e[8:])); aviMoviFourCC+off != at {
+					t.Errorf("frame %d index offset %d points at %d, chunk is at %d", i, off, aviMoviFourCC+off, at)
+				}
+				if size := le.Uint32(e[12:]); size != uint32(len(frame)) {
+					t.Errorf("frame %d index size = %d, want %d", i, size, len(frame))
+				}
+				if key := le.Uint32(e[4:])&0x10 != 0; key != (len(frame) > 0) {
+					t.Errorf("frame %d keyframe = %v, want %v", i, key, len(frame) > 0)
+				}
+				at += 8 + len(frame) + len(frame)%2
+			}
+			if at != end {
+				t.Errorf("frames end at %d, movi list ends at %d", at, end)
+			}
+		})
+	}
+}
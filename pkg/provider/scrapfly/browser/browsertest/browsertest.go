@@ -0,0 +1,326 @@
+// Package browsertest runs an in-process fake Cloud Browser: a CDP
+// WebSocket server that answers the commands the browser package sends
+// from fixtures, and emits scripted events. Code built on the browser
+// package — this provider's tools, or an agent flow embedding it — can be
+// tested deterministically, without a Scrapfly cluster or a real Chrome.
+//
+//	srv := browsertest.NewServer()
+//	defer srv.Close()
+//	srv.SetPage("https://shop.example/", "Shop")
+//	srv.SetAXTree(
+//		browsertest.AXNode{ID: "1", Role: "RootWebArea", Name: "Shop"},
+//		browsertest.AXNode{ID: "2", Role: "button", Name: "Add to cart", BackendNodeID: 12},
+//	)
+//	session, err := srv.Connect(ctx)
+//	...
+//	session.Click(ctx, browser.Selector{Type: browser.AntibotSelectorTypeAXNodeID, Query: "2"})
+//	calls := srv.Calls(browser.CommandAntibotClickOn)
+//
+// Target, Page, Accessibility, DOM, Runtime, Input and the Antibot, WebMCP
+// and ScrapiumBrowser domains have default answers (see fixtures.go);
+// Handle and Respond override any command, Eval scripts Runtime.evaluate,
+// and WithoutDomains makes the server behave like a plain Chrome.
+package browsertest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser"
+)
+
+// Fixed identifiers of the fake browser's single page.
+const (
+	PageTargetID  = "page-1"
+	PageSessionID = "session-1"
+	MainFrameID   = "frame-1"
+)
+
+// Call is one command received by the server.
+type Call struct {
+	Method    string
+	Params    json.RawMessage
+	SessionID string // page session ID, or "" for browser-level commands
+
+	after []event
+}
+
+// Emit queues an event to send once the command has been answered, as a
+// browser reports the effects of a command after replying to it.
+func (c *Call) Emit(method string, params any) {
+	c.after = append(c.after, event{method: method, params: params})
+}
+
+// Decode unmarshals the command's parameters into v.
+func (c *Call) Decode(v any) error {
+	if len(c.Params) == 0 {
+		return nil
+	}
+	return json.Unmarshal(c.Params, v)
+}
+
+// Handler answers a command. A non-nil error is sent as a CDP error
+// response: an *Error keeps its code, anything else is sent as -32000.
+type Handler func(c *Call) (result any, err error)
+
+// Error is a CDP error response.
+type Error struct {
+	Code    int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("CDP error %d: %s", e.Code, e.Message)
+}
+
+// MethodNotFound is the error a browser returns for a command it doesn't
+// have.
+func MethodNotFound(method string) *Error {
+	return &Error{Code: -32601, Message: fmt.Sprintf("'%s' wasn't found", method)}
+}
+
+type event struct {
+	method string
+	params any
+}
+
+// Server is a fake CDP endpoint. Its methods are safe for concurrent use.
+type Server struct {
+	// URL is the browser WebSocket endpoint, for browser.Connect.
+	URL string
+	// HTTPURL serves /json/version like a Chrome debugging port, for
+	// browser.ResolveCDPEndpoint and -cdp-url.
+	HTTPURL string
+
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	handlers map[string]Handler
+	missing  map[string]bool
+	calls    []Call
+	conns    map[*conn]bool
+	state    pageState
+}
+
+// conn is one client connection.
+type conn struct {
+	ws *websocket.Conn
+	mu sync.Mutex // serializes writes
+}
+
+func (c *conn) write(v any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ws.WriteJSON(v)
+}
+
+// NewServer starts a fake browser on a loopback port. Close it when done.
+func NewServer() *Server {
+	s := &Server{
+		handlers: map[string]Handler{},
+		missing:  map[string]bool{},
+		conns:    map[*conn]bool{},
+		state:    newPageState(),
+	}
+	s.srv = httptest.NewServer(s)
+	s.HTTPURL = s.srv.URL
+	s.URL = "ws" + strings.TrimPrefix(s.srv.URL, "http") + "/devtools/browser/browsertest"
+	return s
+}
+
+// Close drops every connection and stops the server.
+func (s *Server) Close() {
+	s.DropConnections()
+	s.srv.Close()
+}
+
+// Connect opens a browser.Session on the server, attached to its page,
+// with the page state refreshed from the fixtures. The session expires in
+// an hour and reconnects to the server when its socket drops, as a Cloud
+// Browser session does (see DropConnections).
+func (s *Server) Connect(ctx context.Context) (*browser.Session, error) {
+	session := &browser.Session{SessionID: "browsertest", WSURL: s.URL}
+	session.SetExpiry(time.Now().Add(time.Hour))
+	if err := browser.Connect(ctx, session, browser.ConnectOptions{URL: s.URL, ReconnectURL: s.URL, DialAttempts: 1}); err != nil {
+		return nil, err
+	}
+	session.Page.Refresh(ctx, session)
+	return session, nil
+}
+
+// Handle answers method with h, replacing the default answer or an earlier
+// handler.
+func (s *Server) Handle(method string, h Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[method] = h
+}
+
+// Respond answers method with a fixed result.
+func (s *Server) Respond(method string, result any) {
+	s.Handle(method, func(*Call) (any, error) { return result, nil })
+}
+
+// Fail answers method with a CDP error.
+func (s *Server) Fail(method string, code int, message string) {
+	s.Handle(method, func(*Call) (any, error) { return nil, &Error{Code: code, Message: message} })
+}
+
+// WithoutDomains makes every command of the given domains fail with
+// "method not found", as on a plain Chrome: WithoutDomains("Antibot",
+// "WebMCP", "ScrapiumBrowser").
+func (s *Server) WithoutDomains(domains ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, d := range domains {
+		s.missing[d] = true
+	}
+}
+
+// Calls returns the commands received for method, in order; every command
+// when method is empty.
+func (s *Server) Calls(method string) []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Call
+	for _, c := range s.calls {
+		if method == "" || c.Method == method {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// ResetCalls forgets the commands received so far.
+func (s *Server) ResetCalls() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = nil
+}
+
+// Emit sends an event on the page session of every connection.
+func (s *Server) Emit(method string, params any) {
+	s.mu.Lock()
+	conns := make([]*conn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	s.mu.Unlock()
+	for _, c := range conns {
+		c.write(eventMessage(event{method: method, params: params}))
+	}
+}
+
+// DropConnections closes every live connection, as a network failure
+// would, to exercise reconnects.
+func (s *Server) DropConnections() {
+	s.mu.Lock()
+	conns := s.conns
+	s.conns = map[*conn]bool{}
+	s.mu.Unlock()
+	for c := range conns {
+		c.ws.Close()
+	}
+}
+
+// ServeHTTP serves /json/version and the CDP WebSocket on any other path,
+// so the fake browser can also be mounted on another server — a mock
+// Cloud Browser host, say.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/json/version" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"Browser":              "browsertest/1.0",
+			"Protocol-Version":     "1.3",
+			"webSocketDebuggerUrl": s.URL,
+		})
+		return
+	}
+	ws, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	c := &conn{ws: ws}
+	s.mu.Lock()
+	s.conns[c] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+		ws.Close()
+	}()
+
+	for {
+		var msg struct {
+			ID        int64           `json:"id"`
+			Method    string          `json:"method"`
+			Params    json.RawMessage `json:"params"`
+			SessionID string          `json:"sessionId"`
+		}
+		if err := ws.ReadJSON(&msg); err != nil {
+			return
+		}
+		call := &Call{Method: msg.Method, Params: msg.Params, SessionID: msg.SessionID}
+		result, err := s.dispatch(call)
+
+		resp := map[string]any{"id": msg.ID}
+		if msg.SessionID != "" {
+			resp["sessionId"] = msg.SessionID
+		}
+		if err != nil {
+			e, ok := err.(*Error)
+			if !ok {
+				e = &Error{Code: -32000, Message: err.Error()}
+			}
+			resp["error"] = map[string]any{"code": e.Code, "message": e.Message}
+		} else {
+			if result == nil {
+				result = map[string]any{}
+			}
+			resp["result"] = result
+		}
+		if c.write(resp) != nil {
+			return
+		}
+		for _, e := range call.after {
+			c.write(eventMessage(e))
+		}
+	}
+}
+
+// dispatch records call and answers it: a registered handler, else
+// "method not found" for a removed domain, else the default fixture.
+func (s *Server) dispatch(call *Call) (any, error) {
+	s.mu.Lock()
+	s.calls = append(s.calls, Call{Method: call.Method, Params: call.Params, SessionID: call.SessionID})
+	h := s.handlers[call.Method]
+	domain, _, _ := strings.Cut(call.Method, ".")
+	missing := s.missing[domain]
+	s.mu.Unlock()
+
+	switch {
+	case h != nil:
+		return h(call)
+	case missing:
+		return nil, MethodNotFound(call.Method)
+	}
+	return s.defaultAnswer(call)
+}
+
+func eventMessage(e event) map[string]any {
+	msg := map[string]any{"method": e.method, "sessionId": PageSessionID}
+	if e.params != nil {
+		msg["params"] = e.params
+	} else {
+		msg["params"] = map[string]any{}
+	}
+	return msg
+}
@@ -0,0 +1,511 @@
+package browsertest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"strings"
+	"time"
+
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser"
+)
+
+// Viewport is the size Page.getLayoutMetrics reports.
+const (
+	ViewportWidth  = 1280
+	ViewportHeight = 720
+)
+
+// AXNode is one node of the fake page's accessibility tree.
+type AXNode struct {
+	ID            string // AX node ID, the uid of take_snapshot
+	Role          string
+	Name          string
+	Value         string
+	BackendNodeID int64
+	Ignored       bool
+	// Box is the element's viewport rectangle, for DOM.getBoxModel and
+	// Antibot element lookups. Nil places it at the viewport origin.
+	Box *browser.Rect
+	// Selector is the query (CSS, XPath, role…) Antibot commands find the
+	// node by, besides its AX node ID.
+	Selector string
+}
+
+// WebMCPFunc runs a page WebMCP tool: input is the invocation's JSON
+// arguments, output the JSON the tool responds with.
+type WebMCPFunc func(input json.RawMessage) (output string, err error)
+
+type webMCPTool struct {
+	tool browser.WebMCPTool
+	fn   WebMCPFunc
+}
+
+type evalFixture struct {
+	match string
+	value any
+}
+
+// pageState is the fake page the default answers read and update.
+type pageState struct {
+	url, title  string
+	nodes       []AXNode
+	evals       []evalFixture
+	tools       []webMCPTool
+	webMCP      bool
+	downloads   map[string][]byte
+	mouseX      float64
+	mouseY      float64
+	invocations int
+}
+
+func newPageState() pageState {
+	return pageState{url: "about:blank", downloads: map[string][]byte{}}
+}
+
+// ── Fixtures ────────────────────────────────────────────────────────────
+
+// SetPage sets the page's URL and title.
+func (s *Server) SetPage(url, title string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.url, s.state.title = url, title
+}
+
+// SetAXTree replaces the page's accessibility tree.
+func (s *Server) SetAXTree(nodes ...AXNode) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.nodes = append([]AXNode(nil), nodes...)
+}
+
+// Node returns the current state of the AX node with the given ID —
+// Antibot.fill and typeText update its Value.
+func (s *Server) Node(id string) (AXNode, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i := s.state.find(&browser.Selector{Type: browser.AntibotSelectorTypeAXNodeID, Query: id}); i >= 0 {
+		return s.state.nodes[i], true
+	}
+	return AXNode{}, false
+}
+
+// Eval answers Runtime.evaluate expressions and Runtime.callFunctionOn
+// function declarations containing match with value, returned by value.
+// The latest matching registration wins.
+func (s *Server) Eval(match string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.evals = append(s.state.evals, evalFixture{match: match, value: value})
+}
+
+// AddWebMCPTool registers a page WebMCP tool, announced with
+// WebMCP.toolsAdded once the client enables the domain. WebMCP.invokeTool
+// runs fn and reports its result with toolInvoked / toolResponded events.
+func (s *Server) AddWebMCPTool(tool browser.WebMCPTool, fn WebMCPFunc) {
+	if tool.FrameID == "" {
+		tool.FrameID = MainFrameID
+	}
+	s.mu.Lock()
+	s.state.tools = append(s.state.tools, webMCPTool{tool: tool, fn: fn})
+	enabled := s.state.webMCP
+	s.mu.Unlock()
+	if enabled {
+		s.Emit(browser.EventNameWebMCPToolsAdded, map[string]any{"tools": []browser.WebMCPTool{tool}})
+	}
+}
+
+// RemoveWebMCPTool unregisters a page WebMCP tool, announced with
+// WebMCP.toolsRemoved.
+func (s *Server) RemoveWebMCPTool(name string) {
+	s.mu.Lock()
+	var removed []browser.WebMCPTool
+	kept := s.state.tools[:0]
+	for _, t := range s.state.tools {
+		if t.tool.Name == name {
+			removed = append(removed, t.tool)
+			continue
+		}
+		kept = append(kept, t)
+	}
+	s.state.tools = kept
+	enabled := s.state.webMCP
+	s.mu.Unlock()
+	if enabled && len(removed) > 0 {
+		s.Emit(browser.EventNameWebMCPToolsRemoved, map[string]any{"tools": removed})
+	}
+}
+
+// AddDownload adds a file to the browser's downloads.
+func (s *Server) AddDownload(name string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.downloads[name] = data
+}
+
+// ── Default answers ─────────────────────────────────────────────────────
+
+// defaultAnswer answers the commands the browser package sends from the
+// page state; anything else gets an empty result, as most CDP commands
+// (enable, setDeviceMetricsOverride, Input.*) have.
+func (s *Server) defaultAnswer(c *Call) (any, error) {
+	var p struct {
+		URL              string                     `json:"url"`
+		Expression       string                     `json:"expression"`
+		Function         string                     `json:"functionDeclaration"`
+		BackendNodeID    int64                      `json:"backendNodeId"`
+		Selector         *browser.Selector          `json:"selector"`
+		Target           *browser.Selector          `json:"target"`
+		RelativePosition *browser.AntibotCoordinate `json:"relativePosition"`
+		Text             string                     `json:"text"`
+		Clear            *bool                      `json:"clear"`
+		ToolName         string                     `json:"toolName"`
+		Input            json.RawMessage            `json:"input"`
+		Filename         string                     `json:"filename"`
+		Delete           *bool                      `json:"delete"`
+	}
+	if err := c.Decode(&p); err != nil {
+		return nil, &Error{Code: -32602, Message: "Invalid parameters: " + err.Error()}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := &s.state
+
+	switch c.Method {
+	// Target
+	case "Target.getTargets":
+		return map[string]any{"targetInfos": []map[string]any{{
+			"targetId": PageTargetID, "type": "page", "title": st.title, "url": st.url, "attached": true,
+		}}}, nil
+	case "Target.createTarget":
+		return map[string]any{"targetId": PageTargetID}, nil
+	case "Target.attachToTarget":
+		return map[string]any{"sessionId": PageSessionID}, nil
+
+	// Page
+	case "Page.navigate":
+		st.url = p.URL
+		c.Emit("Page.frameNavigated", map[string]any{"frame": map[string]any{
+			"id": MainFrameID, "loaderId": "loader-1", "url": p.URL, "securityOrigin": p.URL, "mimeType": "text/html",
+		}})
+		c.Emit("Page.loadEventFired", map[string]any{"timestamp": float64(time.Now().UnixMilli()) / 1000})
+		return map[string]any{"frameId": MainFrameID, "loaderId": "loader-1"}, nil
+	case "Page.reload":
+		c.Emit("Page.loadEventFired", map[string]any{"timestamp": float64(time.Now().UnixMilli()) / 1000})
+		return nil, nil
+	case "Page.getFrameTree":
+		return map[string]any{"frameTree": map[string]any{"frame": map[string]any{
+			"id": MainFrameID, "loaderId": "loader-1", "url": st.url, "securityOrigin": st.url, "mimeType": "text/html",
+		}}}, nil
+	case "Page.getLayoutMetrics":
+		viewport := map[string]any{"pageX": 0, "pageY": 0, "clientWidth": ViewportWidth, "clientHeight": ViewportHeight}
+		return map[string]any{
+			"layoutViewport":    viewport,
+			"cssLayoutViewport": viewport,
+			"cssVisualViewport": map[string]any{"offsetX": 0, "offsetY": 0, "pageX": 0, "pageY": 0, "clientWidth": ViewportWidth, "clientHeight": ViewportHeight, "scale": 1, "zoom": 1},
+			"cssContentSize":    map[string]any{"x": 0, "y": 0, "width": ViewportWidth, "height": ViewportHeight},
+		}, nil
+	case "Page.captureScreenshot":
+		return map[string]any{"data": screenshotPNG}, nil
+
+	// Accessibility
+	case "Accessibility.getFullAXTree":
+		nodes := make([]map[string]any, 0, len(st.nodes))
+		for _, n := range st.nodes {
+			nodes = append(nodes, axJSON(n))
+		}
+		return map[string]any{"nodes": nodes}, nil
+
+	// Runtime
+	case "Runtime.evaluate":
+		if v, ok := st.eval(p.Expression); ok {
+			return remoteObject(v), nil
+		}
+		switch {
+		case strings.Contains(p.Expression, "JSON.stringify({title: document.title, url: location.href})"):
+			meta, _ := json.Marshal(map[string]string{"title": st.title, "url": st.url})
+			return remoteObject(string(meta)), nil
+		case strings.TrimSpace(p.Expression) == "location.href":
+			return remoteObject(st.url), nil
+		case strings.TrimSpace(p.Expression) == "document.title":
+			return remoteObject(st.title), nil
+		}
+		return remoteObject(nil), nil
+	case "Runtime.callFunctionOn":
+		if v, ok := st.eval(p.Function); ok {
+			return remoteObject(v), nil
+		}
+		return remoteObject(nil), nil
+
+	// DOM
+	case "DOM.getBoxModel":
+		n := st.byBackendID(p.BackendNodeID)
+		if n == nil {
+			return nil, &Error{Code: -32000, Message: "Could not compute box model."}
+		}
+		r := box(n)
+		quad := []float64{r.X, r.Y, r.X + r.Width, r.Y, r.X + r.Width, r.Y + r.Height, r.X, r.Y + r.Height}
+		return map[string]any{"model": map[string]any{
+			"content": quad, "padding": quad, "border": quad, "margin": quad,
+			"width": r.Width, "height": r.Height,
+		}}, nil
+	case "DOM.resolveNode":
+		if st.byBackendID(p.BackendNodeID) == nil {
+			return nil, &Error{Code: -32000, Message: "No node with given id found"}
+		}
+		return map[string]any{"object": map[string]any{
+			"type": "object", "subtype": "node", "objectId": fmt.Sprintf("node-%d", p.BackendNodeID),
+		}}, nil
+	case "DOM.describeNode":
+		return map[string]any{"node": map[string]any{
+			"nodeId": 0, "backendNodeId": p.BackendNodeID, "nodeType": 1, "nodeName": "DIV", "localName": "div",
+		}}, nil
+
+	// Antibot
+	case browser.CommandAntibotMoveTo, browser.CommandAntibotClickOn, browser.CommandAntibotHover,
+		browser.CommandAntibotClickAndHold, browser.CommandAntibotClickAndSlide:
+		if p.Selector == nil {
+			return antibotOK(), nil
+		}
+		i := st.find(p.Selector)
+		if i < 0 {
+			return antibotNotFound(p.Selector), nil
+		}
+		st.mouseX, st.mouseY = point(&st.nodes[i], p.RelativePosition)
+		return antibotOK(), nil
+	case browser.CommandAntibotDragAndDrop:
+		for _, sel := range []*browser.Selector{p.Selector, p.Target} {
+			if sel == nil {
+				continue
+			}
+			i := st.find(sel)
+			if i < 0 {
+				return antibotNotFound(sel), nil
+			}
+			st.mouseX, st.mouseY = point(&st.nodes[i], nil)
+		}
+		return antibotOK(), nil
+	case browser.CommandAntibotFill, browser.CommandAntibotTypeText:
+		if p.Selector == nil {
+			return antibotOK(), nil
+		}
+		i := st.find(p.Selector)
+		if i < 0 {
+			return antibotNotFound(p.Selector), nil
+		}
+		if c.Method == browser.CommandAntibotFill || (p.Clear != nil && *p.Clear) {
+			st.nodes[i].Value = p.Text
+		} else {
+			st.nodes[i].Value += p.Text
+		}
+		return antibotOK(), nil
+	case browser.CommandAntibotSelectOption:
+		if p.Selector != nil && st.find(p.Selector) < 0 {
+			return antibotNotFound(p.Selector), nil
+		}
+		return antibotOK(), nil
+	case browser.CommandAntibotScroll, browser.CommandAntibotPressKey, browser.CommandAntibotPerformAction,
+		browser.CommandAntibotFindAndPerformAction, browser.CommandAntibotShowCursor:
+		return antibotOK(), nil
+	case browser.CommandAntibotLocateElement, browser.CommandAntibotLocateElementAcrossFrames, browser.CommandAntibotWaitForElement:
+		i := -1
+		if p.Selector != nil {
+			i = st.find(p.Selector)
+		}
+		if i < 0 {
+			return antibotNotFound(p.Selector), nil
+		}
+		r := box(&st.nodes[i])
+		x, y := point(&st.nodes[i], p.RelativePosition)
+		return map[string]any{
+			"success": true, "frameId": MainFrameID,
+			"pointX": x, "pointY": y,
+			"boundsX": r.X, "boundsY": r.Y, "boundsWidth": r.Width, "boundsHeight": r.Height,
+		}, nil
+	case browser.CommandAntibotIsElementVisible:
+		if p.Selector == nil || st.find(p.Selector) < 0 {
+			return map[string]any{"visible": false, "exists": false, "reason": "element not found"}, nil
+		}
+		return map[string]any{"visible": true, "exists": true}, nil
+	case browser.CommandAntibotGetMousePosition:
+		return map[string]any{"x": st.mouseX, "y": st.mouseY}, nil
+	case browser.CommandAntibotGetFrames:
+		return map[string]any{"frames": []map[string]any{{"frameId": MainFrameID, "url": st.url}}}, nil
+	case browser.CommandAntibotGetSolvedCaptchas:
+		return map[string]any{"captchas": []any{}}, nil
+	case browser.CommandAntibotSolveCaptcha:
+		return map[string]any{"success": false, "errorMessage": "no captcha on the page"}, nil
+
+	// WebMCP
+	case browser.CommandWebMCPEnable:
+		st.webMCP = true
+		if len(st.tools) > 0 {
+			tools := make([]browser.WebMCPTool, 0, len(st.tools))
+			for _, t := range st.tools {
+				tools = append(tools, t.tool)
+			}
+			c.Emit(browser.EventNameWebMCPToolsAdded, map[string]any{"tools": tools})
+		}
+		return nil, nil
+	case browser.CommandWebMCPDisable:
+		st.webMCP = false
+		return nil, nil
+	case browser.CommandWebMCPInvokeTool:
+		var fn WebMCPFunc
+		for _, t := range st.tools {
+			if t.tool.Name == p.ToolName {
+				fn = t.fn
+			}
+		}
+		if fn == nil {
+			return nil, &Error{Code: -32000, Message: fmt.Sprintf("Tool not found: %s", p.ToolName)}
+		}
+		st.invocations++
+		id := fmt.Sprintf("invocation-%d", st.invocations)
+		c.Emit(browser.EventNameWebMCPToolInvoked, map[string]any{"invocationId": id, "toolName": p.ToolName})
+		output, err := fn(p.Input)
+		if err != nil {
+			c.Emit(browser.EventNameWebMCPToolResponded, map[string]any{"invocationId": id, "status": "error", "errorText": err.Error()})
+		} else {
+			c.Emit(browser.EventNameWebMCPToolResponded, map[string]any{"invocationId": id, "status": "completed", "output": output})
+		}
+		return map[string]any{"invocationId": id}, nil
+
+	// ScrapiumBrowser
+	case browser.CommandScrapiumBrowserHasDownloads:
+		return map[string]any{"result": len(st.downloads) > 0}, nil
+	case browser.CommandScrapiumBrowserGetDownloadsMetadatas:
+		meta := map[string]int64{}
+		for name, data := range st.downloads {
+			meta[name] = int64(len(data))
+		}
+		return map[string]any{"metadata": meta}, nil
+	case browser.CommandScrapiumBrowserGetDownload:
+		data, ok := st.downloads[p.Filename]
+		if !ok {
+			return nil, &Error{Code: -32000, Message: fmt.Sprintf("download %s not found", p.Filename)}
+		}
+		return map[string]any{"data": base64.StdEncoding.EncodeToString(data)}, nil
+	case browser.CommandScrapiumBrowserGetDownloads:
+		files := map[string]string{}
+		for name, data := range st.downloads {
+			files[name] = base64.StdEncoding.EncodeToString(data)
+		}
+		if p.Delete != nil && *p.Delete {
+			st.downloads = map[string][]byte{}
+		}
+		return map[string]any{"files": files}, nil
+	}
+	return nil, nil
+}
+
+// eval returns the value of the latest Eval fixture matching code.
+func (st *pageState) eval(code string) (any, bool) {
+	for i := len(st.evals) - 1; i >= 0; i-- {
+		if code != "" && strings.Contains(code, st.evals[i].match) {
+			return st.evals[i].value, true
+		}
+	}
+	return nil, false
+}
+
+// find returns the index of the node sel designates, or -1.
+func (st *pageState) find(sel *browser.Selector) int {
+	for i, n := range st.nodes {
+		if sel.Type == browser.AntibotSelectorTypeAXNodeID && n.ID == sel.Query ||
+			sel.Type != browser.AntibotSelectorTypeAXNodeID && n.Selector != "" && n.Selector == sel.Query {
+			return i
+		}
+	}
+	return -1
+}
+
+func (st *pageState) byBackendID(id int64) *AXNode {
+	for i := range st.nodes {
+		if id != 0 && st.nodes[i].BackendNodeID == id {
+			return &st.nodes[i]
+		}
+	}
+	return nil
+}
+
+func box(n *AXNode) browser.Rect {
+	if n.Box == nil {
+		return browser.Rect{}
+	}
+	return *n.Box
+}
+
+// point is the viewport point of n at rel (fractions of its box), its
+// center by default.
+func point(n *AXNode, rel *browser.AntibotCoordinate) (float64, float64) {
+	r := box(n)
+	fx, fy := 0.5, 0.5
+	if rel != nil {
+		fx, fy = rel.X, rel.Y
+	}
+	return r.X + r.Width*fx, r.Y + r.Height*fy
+}
+
+func antibotOK() map[string]any {
+	return map[string]any{"success": true}
+}
+
+func antibotNotFound(sel *browser.Selector) map[string]any {
+	msg := "element not found"
+	if sel != nil {
+		msg = fmt.Sprintf("element not found: %s=%s", sel.Type, sel.Query)
+	}
+	return map[string]any{"success": false, "errorMessage": msg}
+}
+
+// axJSON renders n as an Accessibility.AXNode.
+func axJSON(n AXNode) map[string]any {
+	node := map[string]any{
+		"nodeId":  n.ID,
+		"ignored": n.Ignored,
+		"role":    map[string]any{"type": "role", "value": n.Role},
+		"name":    map[string]any{"type": "computedString", "value": n.Name},
+	}
+	if n.Value != "" {
+		node["value"] = map[string]any{"type": "string", "value": n.Value}
+	}
+	if n.BackendNodeID != 0 {
+		node["backendDOMNodeId"] = n.BackendNodeID
+	}
+	return node
+}
+
+// remoteObject wraps v as a by-value Runtime.RemoteObject result.
+func remoteObject(v any) map[string]any {
+	var obj map[string]any
+	switch v := v.(type) {
+	case nil:
+		obj = map[string]any{"type": "undefined"}
+	case string:
+		obj = map[string]any{"type": "string", "value": v}
+	case bool:
+		obj = map[string]any{"type": "boolean", "value": v}
+	case int, int64, float64:
+		obj = map[string]any{"type": "number", "value": v}
+	default:
+		obj = map[string]any{"type": "object", "value": v}
+	}
+	return map[string]any{"result": obj}
+}
+
+// screenshotPNG is the base64 PNG Page.captureScreenshot returns: a blank
+// viewport-sized image.
+var screenshotPNG = func() string {
+	img := image.NewGray(image.Rect(0, 0, ViewportWidth, ViewportHeight))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}()
@@ -1,6 +1,7 @@
 package browser
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -23,6 +24,16 @@ type cdpResponse struct {
 	Params json.RawMessage `json:"params"`
 }
 
+// cdpError is an error response to a CDP command.
+type cdpError struct {
+	Code    int
+	Message string
+}
+
+func (e *cdpError) Error() string {
+	return fmt.Sprintf("CDP error %d: %s", e.Code, e.Message)
+}
+
 // pendingRequest tracks a CDP command waiting for its response.
 type pendingRequest struct {
 	ch chan cdpResponse
@@ -31,14 +42,39 @@ type pendingRequest struct {
 // EventHandler is called for each CDP event. Return true to keep listening.
 type EventHandler func(method string, params json.RawMessage) bool
 
-// StartReader starts the background CDP reader goroutine.
-// Must be called once after the WebSocket connection is established.
+// eventSub is one registered handler. Handlers are tracked by pointer so
+// removal doesn't depend on slice positions, which shift under concurrent
+// registration.
+type eventSub struct {
+	fn EventHandler
+}
+
+// DefaultCDPTimeout bounds a CDP command whose ctx carries no deadline, so
+// a wedged browser fails the tool call instead of hanging it forever. Long
+// enough for Antibot actions that wait on the page (waitForElement, solves).
+var DefaultCDPTimeout = 2 * time.Minute
+
+// StartReader starts the background CDP reader goroutine for s.CdpConn.
+// Connect calls it (again after every reconnect); event handlers and the
+// pending map are kept across calls.
 // The reader dispatches responses to waiting SendCDP callers and events
 // to registered handlers. This eliminates read races between concurrent callers.
 func (s *Session) StartReader() {
-	s.pending = make(map[int64]*pendingRequest)
-	s.eventHandlers = make(map[string][]EventHandler)
-	s.readerDone = make(chan struct{})
+	done := make(chan struct{})
+	s.CdpMu.Lock()
+	conn := s.CdpConn
+	s.readerDone = done
+	s.CdpMu.Unlock()
+	s.pendingMu.Lock()
+	if s.pending == nil {
+		s.pending = make(map[int64]*pendingRequest)
+	}
+	s.pendingMu.Unlock()
+	s.handlersMu.Lock()
+	if s.eventHandlers == nil {
+		s.eventHandlers = make(map[string][]*eventSub)
+	}
+	s.handlersMu.Unlock()
 
 	// WebSocket keepalive — send ping every 5s to prevent proxy idle disconnect
 	go func() {
@@ -46,11 +82,11 @@ func (s *Session) StartReader() {
 		defer ticker.Stop()
 		for {
 			select {
-			case <-s.readerDone:
+			case <-done:
 				return
 			case <-ticker.C:
 				s.CdpMu.Lock()
-				err := s.CdpConn.WriteMessage(websocket.PingMessage, nil)
+				err := conn.WriteMessage(websocket.PingMessage, nil)
 				s.CdpMu.Unlock()
 				if err != nil {
 					return
@@ -60,9 +96,8 @@ func (s *Session) StartReader() {
 	}()
 
 	go func() {
-		defer close(s.readerDone)
 		for {
-			_, raw, err := s.CdpConn.ReadMessage()
+			_, raw, err := conn.ReadMessage()
 			if err != nil {
 				log.Printf("[CDP] Reader stopped: %v", err)
 				// Unblock all pending requests
@@ -75,6 +110,8 @@ func (s *Session) StartReader() {
 					delete(s.pending, id)
 				}
 				s.pendingMu.Unlock()
+				close(done)
+				s.disconnected(err)
 				return
 			}
 
@@ -113,84 +150,80 @@ func (s *Session) StartReader() {
 
 			// Event — dispatch to handlers.
 			// Handlers run in goroutines to avoid blocking the reader (some call SendCDP).
-			// Handlers returning false are flagged for removal after all goroutines complete.
+			// A handler returning false is removed by identity as soon as it returns.
 			if resp.Method != "" {
 				log.Printf("[CDP EVENT] %s (params=%d bytes)", resp.Method, len(resp.Params))
 				method := resp.Method
 				params := resp.Params
 
 				s.handlersMu.RLock()
-				handlers := make([]EventHandler, len(s.eventHandlers[method]))
-				copy(handlers, s.eventHandlers[method])
-				wildcards := make([]EventHandler, len(s.eventHandlers["*"]))
+				subs := make([]*eventSub, len(s.eventHandlers[method]))
+				copy(subs, s.eventHandlers[method])
+				wildcards := make([]*eventSub, len(s.eventHandlers["*"]))
 				copy(wildcards, s.eventHandlers["*"])
 				s.handlersMu.RUnlock()
 
-				// Track which handlers to remove
-				type removal struct {
-					idx int
-				}
-				removals := make(chan removal, len(handlers))
-				var wg sync.WaitGroup
-
-				for i, h := range handlers {
-					wg.Add(1)
-					go func(idx int, handler EventHandler) {
-						defer wg.Done()
-						if !handler(method, params) {
-							removals <- removal{idx}
+				for _, sub := range subs {
+					go func(sub *eventSub) {
+						if !sub.fn(method, params) {
+							s.removeHandler(method, sub)
 						}
-					}(i, h)
+					}(sub)
 				}
-				for _, h := range wildcards {
-					go h(method, params)
+				for _, sub := range wildcards {
+					go sub.fn(method, params)
 				}
-
-				// Wait for all handlers, then remove dead ones
-				go func() {
-					wg.Wait()
-					close(removals)
-					var toRemove []int
-					for r := range removals {
-						toRemove = append(toRemove, r.idx)
-					}
-					if len(toRemove) > 0 {
-						s.handlersMu.Lock()
-						current := s.eventHandlers[method]
-						var kept []EventHandler
-						for i, h := range current {
-							remove := false
-							for _, ri := range toRemove {
-								if ri == i {
-									remove = true
-									break
-								}
-							}
-							if !remove {
-								kept = append(kept, h)
-							}
-						}
-						s.eventHandlers[method] = kept
-						s.handlersMu.Unlock()
-					}
-				}()
 			}
 		}
 	}()
 }
 
 // OnEvent registers an event handler for a specific CDP event method.
-// Use "*" to receive all events. Return false from the handler to unregister.
-func (s *Session) OnEvent(method string, handler EventHandler) {
+// Use "*" to receive all events. Return false from the handler to unregister,
+// or call the returned function — safe to call more than once, and the only
+// way out for a handler waiting on an event that never arrives.
+func (s *Session) OnEvent(method string, handler EventHandler) (unsubscribe func()) {
+	sub := &eventSub{fn: handler}
 	s.handlersMu.Lock()
-	defer s.handlersMu.Unlock()
-	s.eventHandlers[method] = append(s.eventHandlers[method], handler)
+	if s.eventHandlers == nil {
+		s.eventHandlers = make(map[string][]*eventSub)
+	}
+	s.eventHandlers[method] = append(s.eventHandlers[method], sub)
+	s.handlersMu.Unlock()
+	return func() { s.removeHandler(method, sub) }
 }
 
+// removeHandler drops sub from method's handler list, if still present.
+func (s *Session) removeHandler(method string, sub *eventSub) {
+	s.handlersMu.Lock()
+	defer s.handlersMu.Unlock()
+	current := s.eventHandlers[method]
+	for i, h := range current {
+		if h == sub {
+			kept := make([]*eventSub, 0, len(current)-1)
+			kept = append(kept, current[:i]...)
+			s.eventHandlers[method] = append(kept, current[i+1:]...)
+			return
+		}
+	}
+}
 
 // SendCDPFireAndForget sends a CDP command without waiting for a response.
 // Used for acks and other fire-and-forget messages that don't return results.
+// Dropped while the session is reconnecting.
 func (s *Session) SendCDPFireAndForget(method string, params any) {
+	s.CdpMu.Lock()
+	defer s.CdpMu.Unlock()
+	if s.CdpConn == nil {
+		return
+	}
+	if s.ready != nil {
+		select {
+		case <-s.ready:
+		default:
+			return
+		}
+	}
 	id := s.CdpID.Add(1)
 	msg := map[string]any{"id": id, "method": method}
 	if params != nil {
@@ -199,13 +232,36 @@ func (s *Session) SendCDPFireAndForget(method string, params any) {
 	if s.CdpPageSessionID != "" {
 		msg["sessionId"] = s.CdpPageSessionID
 	}
-	s.CdpMu.Lock()
 	s.CdpConn.WriteJSON(msg)
-	s.CdpMu.Unlock()
 }
 
-// sendAndWait sends a CDP message and waits for the matching response.
-func (s *Session) sendAndWait(msg map[string]any, id int64) (json.RawMessage, error) {
+// sendAndWait sends method (scoped to the page session when page is set) and
+// waits for the matching response, the reader dying, ctx being done, or
+// DefaultCDPTimeout if ctx has no deadline. A command issued while the
+// session is reconnecting waits for the reconnect first.
+func (s *Session) sendAndWait(ctx context.Context, method string, params any, page bool) (json.RawMessage, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultCDPTimeout)
+		defer cancel()
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("CDP %s: %w", method, err)
+	}
+	conn, readerDone, pageSessionID, err := s.liveConn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("CDP %s: %w", method, err)
+	}
+
+	id := s.CdpID.Add(1)
+	msg := map[string]any{"id": id, "method": method}
+	if params != nil {
+		msg["params"] = params
+	}
+	if page && pageSessionID != "" {
+		msg["sessionId"] = pageSessionID
+	}
+
 	req := &pendingRequest{ch: make(chan cdpResponse, 1)}
 
 	s.pendingMu.Lock()
@@ -216,7 +272,7 @@ func (s *Session) sendAndWait(msg map[string]any, id int64) (json.RawMessage, er
 	log.Printf("[CDP SEND] %s", string(msgJSON))
 
 	s.CdpMu.Lock()
-	err := s.CdpConn.WriteJSON(msg)
+	err = conn.WriteJSON(msg)
 	s.CdpMu.Unlock()
 	if err != nil {
 		s.pendingMu.Lock()
@@ -234,92 +290,82 @@ func (s *Session) sendAndWait(msg map[string]any, id int64) (json.RawMessage, er
 	case resp := <-req.ch:
 		if resp.Error != nil {
 			log.Printf("[CDP RESP] id=%d ERROR %d: %s", id, resp.Error.Code, resp.Error.Message)
-			return nil, fmt.Errorf("CDP error %d: %s", resp.Error.Code, resp.Error.Message)
+			return nil, &cdpError{Code: resp.Error.Code, Message: resp.Error.Message}
 		}
 		log.Printf("[CDP RESP] id=%d OK len=%d", id, len(resp.Result))
 		return resp.Result, nil
-	case <-s.readerDone:
+	case <-readerDone:
 		s.pendingMu.Lock()
 		delete(s.pending, id)
 		s.pendingMu.Unlock()
 		return nil, fmt.Errorf("CDP reader exited before response for id=%d", id)
+	case <-ctx.Done():
+		// The browser may still answer; the reader logs it as an orphan.
+		s.pendingMu.Lock()
+		delete(s.pending, id)
+		s.pendingMu.Unlock()
+		log.Printf("[CDP RESP] id=%d abandoned: %v", id, ctx.Err())
+		return nil, fmt.Errorf("CDP %s (id=%d): %w", method, id, ctx.Err())
 	}
 }
 
 // SendCDP sends a CDP command scoped to the page session and waits for the response.
+// It is bounded only by DefaultCDPTimeout; tool handlers should use SendCDPCtx.
 func (s *Session) SendCDP(method string, params any) (json.RawMessage, error) {
-	id := s.CdpID.Add(1)
-	msg := map[string]any{"id": id, "method": method}
-	if params != nil {
-		msg["params"] = params
-	}
-	if s.CdpPageSessionID != "" {
-		msg["sessionId"] = s.CdpPageSessionID
+	return s.SendCDPCtx(context.Background(), method, params)
+}
+
+// SendCDPCtx is SendCDP bounded by ctx, so an MCP request cancellation stops
+// the wait. Antibot, WebMCP and ScrapiumBrowser commands fall back to
+// standard CDP on a browser without them (fallback.go).
+func (s *Session) SendCDPCtx(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	if isCustomDomain(method) {
+		return s.sendCustom(ctx, method, params)
 	}
-	return s.sendAndWait(msg, id)
+	return s.sendAndWait(ctx, method, params, true)
 }
 
 // SendCDPBrowser sends a CDP command to the browser process (no sessionId).
 func (s *Session) SendCDPBrowser(method string, params any) (json.RawMessage, error) {
-	id := s.CdpID.Add(1)
-	msg := map[string]any{"id": id, "method": method}
-	if params != nil {
-		msg["params"] = params
-	}
-	return s.sendAndWait(msg, id)
+	return s.SendCDPBrowserCtx(context.Background(), method, params)
+}
+
+// SendCDPBrowserCtx is SendCDPBrowser bounded by ctx.
+func (s *Session) SendCDPBrowserCtx(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	return s.sendAndWait(ctx, method, params, false)
 }
 
 // SendCDPCollectEvents sends a CDP command and collects matching events
 // that arrive before the response.
 func (s *Session) SendCDPCollectEvents(method string, params any, eventName string) (json.RawMessage, []json.RawMessage, error) {
-	id := s.CdpID.Add(1)
-	msg := map[string]any{"id": id, "method": method}
-	if params != nil {
-		msg["params"] = params
-	}
-	if s.CdpPageSessionID != "" {
-		msg["sessionId"] = s.CdpPageSessionID
-	}
+	return s.SendCDPCollectEventsCtx(context.Background(), method, params, eventName)
+}
 
-	// Collect events before the response arrives
+// SendCDPCollectEventsCtx is SendCDPCollectEvents bounded by ctx.
+func (s *Session) SendCDPCollectEventsCtx(ctx context.Context, method string, params any, eventName string) (json.RawMessage, []json.RawMessage, error) {
+	// Collect events until the response arrives. The collector is
+	// unsubscribed as soon as the RPC returns; `stopped` covers copies the
+	// dispatcher already snapshotted before the unsubscribe.
 	var events []json.RawMessage
 	var eventsMu sync.Mutex
-	done := make(chan struct{})
-
-	// Register a self-unregistering handler. The previous impl captured
-	// a slice index and tried to splice it out after the RPC returned,
-	// which was racy: any concurrent unregister that shrank the slice
-	// between registration and removal would cause us to delete the
-	// wrong handler. Now we rely on the dispatcher's documented contract
-	// — a handler returning false is removed — and flip `stopped` when
-	// the RPC is done. Stale copies self-destruct on the next event.
 	var stopped atomic.Bool
-	s.handlersMu.Lock()
-	s.eventHandlers[eventName] = append(s.eventHandlers[eventName], func(m string, p json.RawMessage) bool {
+	unsubscribe := s.OnEvent(eventName, func(m string, p json.RawMessage) bool {
 		if stopped.Load() {
 			return false
 		}
-		select {
-		case <-done:
-			stopped.Store(true)
-			return false
-		default:
-			eventsMu.Lock()
-			events = append(events, p)
-			log.Printf("[CDP] Collected event: %s (count=%d)", eventName, len(events))
-			eventsMu.Unlock()
-			return true
-		}
+		eventsMu.Lock()
+		events = append(events, p)
+		log.Printf("[CDP] Collected event: %s (count=%d)", eventName, len(events))
+		eventsMu.Unlock()
+		return true
 	})
-	s.handlersMu.Unlock()
 
-	// Send the command
-	result, err := s.sendAndWait(msg, id)
+	result, err := s.SendCDPCtx(ctx, method, params)
 
-	// Signal the collector to stop. It unregisters itself on the next
-	// dispatched event via the `return false` contract.
-	close(done)
 	stopped.Store(true)
+	unsubscribe()
 
+	eventsMu.Lock()
+	defer eventsMu.Unlock()
 	return result, events, err
 }
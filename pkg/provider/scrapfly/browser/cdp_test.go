@@ -0,0 +1,46 @@
+package browser_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser"
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser/browsertest"
+)
+
+// connect opens a session on srv and closes it with the test.
+func connect(t *testing.T, srv *browsertest.Server) *browser.Session {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	session, err := srv.Connect(ctx)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(session.Close)
+	return session
+}
+
+func TestSendCDPCollectEventsRoutesCustomDomains(t *testing.T) {
+	srv := browsertest.NewServer()
+	defer srv.Close()
+	srv.WithoutDomains("Antibot", "WebMCP")
+	session := connect(t, srv)
+	ctx := context.Background()
+
+	for range 2 {
+		if _, _, err := session.SendCDPCollectEventsCtx(ctx, browser.CommandAntibotGetMousePosition, nil, "Antibot.none"); err != nil {
+			t.Fatalf("Antibot.getMousePosition without the domain: %v, want the standard CDP fallback", err)
+		}
+	}
+	if n := len(srv.Calls(browser.CommandAntibotGetMousePosition)); n != 1 {
+		t.Errorf("browser got Antibot.getMousePosition %d times, want 1 (later calls go to the fallback)", n)
+	}
+
+	_, _, err := session.SendCDPCollectEventsCtx(ctx, browser.CommandWebMCPEnable, nil, "WebMCP.toolsAdded")
+	if !errors.Is(err, browser.ErrUnsupported) {
+		t.Errorf("WebMCP.enable without the domain: err = %v, want ErrUnsupported", err)
+	}
+}
@@ -0,0 +1,400 @@
+package browser
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Dial defaults. The handshake covers the full server-side allocation chain
+// (traefik → cloud-browser → scrape-engine /browser/allocate → autoscaler →
+// cold-start of a browser pod): sub-second on a warm pool, 30s+ on a cold
+// cluster. Retries cover pods that are still coming up.
+var (
+	DefaultHandshakeTimeout = 60 * time.Second
+	DefaultDialAttempts     = 3
+	DefaultDialBackoff      = 2 * time.Second
+
+	// DefaultReconnectAttempts bounds how long a dropped session keeps
+	// trying to resume before it is given up on.
+	DefaultReconnectAttempts = 5
+)
+
+// ErrSessionClosed is returned for CDP commands on a session that was
+// closed or whose connection could not be restored.
+var ErrSessionClosed = errors.New("browser session closed")
+
+// ConnectOptions configures Connect.
+type ConnectOptions struct {
+	// URL is the CDP WebSocket URL of the first dial.
+	URL string
+	// ReconnectURL resumes the same remote browser when the socket drops —
+	// for the Cloud Browser, CloudBrowser(&CloudBrowserConfig{Session: id}).
+	// Empty disables reconnection.
+	ReconnectURL string
+
+	HandshakeTimeout time.Duration // per attempt (default DefaultHandshakeTimeout)
+	DialAttempts     int           // default DefaultDialAttempts
+
+	// Setup runs once the page target is attached, on connect and again
+	// after every reconnect, to enable the CDP domains the session's event
+	// handlers rely on. Handlers registered with OnEvent live on the Session
+	// and carry over a reconnect; domain enablement does not. An error
+	// fails the connect, or the reconnect attempt.
+	Setup func(ctx context.Context, s *Session, reconnected bool) error
+	// OnLost is called once when a dropped connection can't be restored.
+	OnLost func(s *Session, err error)
+}
+
+// reconnectingKey marks the context of the reconnect goroutine, whose CDP
+// commands must not wait for the reconnect they are part of.
+type reconnectingKey struct{}
+
+// Connect dials opts.URL (retrying cold starts), starts the CDP reader on s
+// and attaches to the first page target. Event handlers may be registered
+// on s before calling Connect.
+func Connect(ctx context.Context, s *Session, opts ConnectOptions) error {
+	conn, err := dial(ctx, opts.URL, opts)
+	if err != nil {
+		return fmt.Errorf("browser connection failed: %w", err)
+	}
+	log.Printf("[CDP] connected (local=%s remote=%s)", conn.LocalAddr(), conn.RemoteAddr())
+
+	ready := make(chan struct{})
+	close(ready)
+	s.CdpMu.Lock()
+	s.CdpConn = conn
+	s.ready = ready
+	s.closed = make(chan struct{})
+	s.connect = opts
+	s.CdpMu.Unlock()
+	s.Touch()
+	s.StartReader()
+
+	if err := s.attachPage(ctx, ""); err != nil {
+		s.Close()
+		return err
+	}
+	if opts.Setup != nil {
+		if err := opts.Setup(ctx, s, false); err != nil {
+			s.Close()
+			return fmt.Errorf("browser setup failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// ResolveCDPEndpoint returns the WebSocket URL of a CDP endpoint: a
+// ws:// or wss:// URL is used as is, an http(s):// URL or bare host:port (a
+// Chrome started with --remote-debugging-port) is looked up through its
+// /json/version document.
+func ResolveCDPEndpoint(ctx context.Context, endpoint string) (string, error) {
+	if strings.HasPrefix(endpoint, "ws://") || strings.HasPrefix(endpoint, "wss://") {
+		return endpoint, nil
+	}
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "http://" + endpoint
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(endpoint, "/")+"/json/version", nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("CDP endpoint %s: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("CDP endpoint %s: /json/version returned HTTP %d", endpoint, resp.StatusCode)
+	}
+	var version struct {
+		WebSocketDebuggerURL string `json:"webSocketDebuggerUrl"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&version); err != nil || version.WebSocketDebuggerURL == "" {
+		return "", fmt.Errorf("CDP endpoint %s: no webSocketDebuggerUrl in /json/version", endpoint)
+	}
+	return version.WebSocketDebuggerURL, nil
+}
+
+// dial opens the CDP WebSocket, retrying with exponential backoff. Client
+// errors (bad key, unknown session) are not retried.
+func dial(ctx context.Context, url string, opts ConnectOptions) (*websocket.Conn, error) {
+	dialer := websocket.Dialer{
+		TLSClientConfig:  &tls.Config{InsecureSkipVerify: true},
+		HandshakeTimeout: opts.HandshakeTimeout,
+	}
+	if dialer.HandshakeTimeout == 0 {
+		dialer.HandshakeTimeout = DefaultHandshakeTimeout
+	}
+	attempts := opts.DialAttempts
+	if attempts <= 0 {
+		attempts = DefaultDialAttempts
+	}
+
+	backoff := DefaultDialBackoff
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		conn, resp, err := dialer.DialContext(ctx, url, nil)
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+		if resp != nil && resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+			resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+			return nil, fmt.Errorf("handshake rejected (HTTP %d): %w", resp.StatusCode, err)
+		}
+		if attempt == attempts {
+			break
+		}
+		log.Printf("[CDP] dial attempt %d/%d failed: %v (retrying in %s)", attempt, attempts, err, backoff)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		backoff *= 2
+	}
+	return nil, fmt.Errorf("dial failed after %d attempt(s): %w", attempts, lastErr)
+}
+
+// attachPage attaches to the page target — preferTarget if it still
+// exists, else the first page — and points page-level commands at it.
+func (s *Session) attachPage(ctx context.Context, preferTarget string) error {
+	raw, err := s.SendCDPBrowserCtx(ctx, "Target.getTargets", nil)
+	if err != nil {
+		return fmt.Errorf("get targets failed: %w", err)
+	}
+	var targets struct {
+		TargetInfos []struct {
+			TargetID string `json:"targetId"`
+			Type     string `json:"type"`
+		} `json:"targetInfos"`
+	}
+	json.Unmarshal(raw, &targets)
+	var targetID string
+	for _, t := range targets.TargetInfos {
+		if t.Type != "page" {
+			continue
+		}
+		if targetID == "" || t.TargetID == preferTarget {
+			targetID = t.TargetID
+		}
+	}
+	if targetID == "" {
+		// A browser with no tab open, as a headless Chrome may start.
+		raw, err := s.SendCDPBrowserCtx(ctx, "Target.createTarget", map[string]any{"url": "about:blank"})
+		if err != nil {
+			return fmt.Errorf("no page target found and none could be created: %w", err)
+		}
+		var created struct {
+			TargetID string `json:"targetId"`
+		}
+		json.Unmarshal(raw, &created)
+		if targetID = created.TargetID; targetID == "" {
+			return fmt.Errorf("no page target found")
+		}
+	}
+
+	raw, err = s.SendCDPBrowserCtx(ctx, "Target.attachToTarget", map[string]any{
+		"targetId": targetID,
+		"flatten":  true,
+	})
+	if err != nil {
+		return fmt.Errorf("attach failed: %w", err)
+	}
+	var attach struct {
+		SessionID string `json:"sessionId"`
+	}
+	json.Unmarshal(raw, &attach)
+
+	s.CdpMu.Lock()
+	s.PageTargetID = targetID
+	s.CdpPageSessionID = attach.SessionID
+	s.CdpMu.Unlock()
+	log.Printf("[CDP] attached to page target %s, sessionId=%s", targetID, attach.SessionID)
+	return nil
+}
+
+// disconnected is called by the reader when its connection dies. Unless the
+// session was closed (or is already reconnecting), it gates new commands
+// behind a fresh ready channel and starts resuming the remote browser.
+func (s *Session) disconnected(cause error) {
+	if s.isClosed() {
+		return
+	}
+	if s.connect.ReconnectURL == "" {
+		s.lost(cause)
+		return
+	}
+	if !s.reconnecting.CompareAndSwap(false, true) {
+		return // a reconnect attempt's own connection dropped; it retries
+	}
+	s.CdpMu.Lock()
+	s.ready = make(chan struct{})
+	s.CdpMu.Unlock()
+	go s.reconnect(cause)
+}
+
+// reconnect re-dials ReconnectURL, re-attaches the page target the session
+// was on and re-runs Setup. Commands issued meanwhile wait for it.
+func (s *Session) reconnect(cause error) {
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), reconnectingKey{}, true))
+	defer cancel()
+	go func() {
+		select {
+		case <-s.closed:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	backoff := DefaultDialBackoff
+	err := cause
+	for attempt := 1; attempt <= DefaultReconnectAttempts; attempt++ {
+		log.Printf("[CDP] connection lost (%v); reconnect attempt %d/%d", err, attempt, DefaultReconnectAttempts)
+		if err = s.resume(ctx); err == nil {
+			s.reconnecting.Store(false)
+			s.CdpMu.Lock()
+			close(s.ready)
+			s.CdpMu.Unlock()
+			log.Printf("[CDP] reconnected to page target %s", s.PageTargetID)
+			// A drop between resume's liveness check and clearing
+			// reconnecting was ignored by the reader; pick it up here.
+			if s.connDropped() {
+				s.disconnected(errors.New("connection dropped while reconnecting"))
+			}
+			return
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+		}
+		backoff *= 2
+	}
+	s.lost(fmt.Errorf("reconnect failed: %w", err))
+}
+
+// resume is one reconnect attempt.
+func (s *Session) resume(ctx context.Context) error {
+	conn, err := dial(ctx, s.connect.ReconnectURL, ConnectOptions{
+		HandshakeTimeout: s.connect.HandshakeTimeout,
+		DialAttempts:     1,
+	})
+	if err != nil {
+		return err
+	}
+	s.CdpMu.Lock()
+	s.CdpConn = conn
+	s.CdpMu.Unlock()
+	s.StartReader()
+
+	if err := s.attachPage(ctx, s.PageTargetID); err != nil {
+		conn.Close()
+		return err
+	}
+	// Requests in flight on the old connection won't report back on this one.
+	s.activity.dropInflight("")
+	if s.connect.Setup != nil {
+		if err := s.connect.Setup(ctx, s, true); err != nil {
+			conn.Close()
+			return fmt.Errorf("setup: %w", err)
+		}
+	}
+	s.resumeScreencast(ctx)
+	// The reader ignores drops while reconnecting, so a socket that died
+	// during setup would otherwise be declared ready.
+	if s.connDropped() {
+		return errors.New("connection dropped during setup")
+	}
+	return nil
+}
+
+// connDropped reports whether the current connection's reader has stopped.
+func (s *Session) connDropped() bool {
+	s.CdpMu.Lock()
+	done := s.readerDone
+	s.CdpMu.Unlock()
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
+
+// lost marks the session dead after its connection couldn't be restored.
+func (s *Session) lost(err error) {
+	first := false
+	s.closeOnce.Do(func() {
+		close(s.closed)
+		first = true
+	})
+	if !first {
+		return
+	}
+	log.Printf("[CDP] session %s lost: %v", s.SessionID, err)
+	if s.connect.OnLost != nil {
+		s.connect.OnLost(s, err)
+	}
+}
+
+// isClosed reports whether Close or lost has run.
+func (s *Session) isClosed() bool {
+	if s.closed == nil {
+		return false
+	}
+	select {
+	case <-s.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Alive reports whether the session can still serve commands: connected,
+// or reconnecting after a transient drop.
+func (s *Session) Alive() bool {
+	s.CdpMu.Lock()
+	conn := s.CdpConn
+	s.CdpMu.Unlock()
+	return conn != nil && !s.isClosed()
+}
+
+// liveConn waits out an in-flight reconnect and returns the current
+// connection, its reader's done channel and the page session ID.
+func (s *Session) liveConn(ctx context.Context) (*websocket.Conn, chan struct{}, string, error) {
+	s.CdpMu.Lock()
+	ready, closed := s.ready, s.closed
+	s.CdpMu.Unlock()
+	if ready != nil && ctx.Value(reconnectingKey{}) == nil {
+		select {
+		case <-ready:
+		case <-closed:
+			return nil, nil, "", ErrSessionClosed
+		case <-ctx.Done():
+			return nil, nil, "", ctx.Err()
+		}
+	}
+	if s.isClosed() {
+		return nil, nil, "", ErrSessionClosed
+	}
+	s.CdpMu.Lock()
+	defer s.CdpMu.Unlock()
+	if s.CdpConn == nil {
+		return nil, nil, "", ErrSessionClosed
+	}
+	return s.CdpConn, s.readerDone, s.CdpPageSessionID, nil
+}
@@ -0,0 +1,130 @@
+package browser_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser"
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser/browsertest"
+)
+
+func init() {
+	browser.DefaultDialBackoff = 10 * time.Millisecond
+}
+
+// connectWithSetup connects to srv with reconnection and the given Setup.
+func connectWithSetup(t *testing.T, srv *browsertest.Server, setup func(context.Context, *browser.Session, bool) error) *browser.Session {
+	t.Helper()
+	session := &browser.Session{SessionID: "reconnect-test", WSURL: srv.URL}
+	session.SetExpiry(time.Now().Add(time.Hour))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := browser.Connect(ctx, session, browser.ConnectOptions{
+		URL:          srv.URL,
+		ReconnectURL: srv.URL,
+		DialAttempts: 1,
+		Setup:        setup,
+	})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(session.Close)
+	return session
+}
+
+// dropDuringSetup returns a Setup that drops the connection during the
+// first reconnect's setup, then calls after.
+func dropDuringSetup(srv *browsertest.Server, after func(context.Context, *browser.Session) error) func(context.Context, *browser.Session, bool) error {
+	var dropped atomic.Bool
+	return func(ctx context.Context, s *browser.Session, reconnected bool) error {
+		if !reconnected || dropped.Swap(true) {
+			return nil
+		}
+		srv.DropConnections()
+		return after(ctx, s)
+	}
+}
+
+func assertResumes(t *testing.T, srv *browsertest.Server, session *browser.Session) {
+	t.Helper()
+	srv.DropConnections()
+	// Commands racing the drop fail; once the reconnect is through they
+	// must work again.
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		_, err := session.SendCDPCtx(ctx, "Page.getFrameTree", nil)
+		cancel()
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("session did not resume: %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if !session.Alive() {
+		t.Fatal("session not alive after reconnect")
+	}
+}
+
+func TestReconnectRetriesWhenSetupFails(t *testing.T) {
+	srv := browsertest.NewServer()
+	defer srv.Close()
+	session := connectWithSetup(t, srv, dropDuringSetup(srv, func(ctx context.Context, s *browser.Session) error {
+		_, err := s.SendCDPCtx(ctx, "Page.enable", nil)
+		return err
+	}))
+	assertResumes(t, srv, session)
+}
+
+func TestReconnectRetriesWhenConnectionDropsDuringSetup(t *testing.T) {
+	srv := browsertest.NewServer()
+	defer srv.Close()
+	// Setup itself succeeds, but the socket is gone by the time it returns.
+	session := connectWithSetup(t, srv, dropDuringSetup(srv, func(context.Context, *browser.Session) error {
+		time.Sleep(100 * time.Millisecond)
+		return nil
+	}))
+	assertResumes(t, srv, session)
+}
+
+func TestConnectFailsWhenSetupFails(t *testing.T) {
+	srv := browsertest.NewServer()
+	defer srv.Close()
+	srv.Fail("Page.enable", -32000, "boom")
+	session := &browser.Session{SessionID: "setup-test"}
+	err := browser.Connect(context.Background(), session, browser.ConnectOptions{
+		URL:          srv.URL,
+		DialAttempts: 1,
+		Setup: func(ctx context.Context, s *browser.Session, _ bool) error {
+			_, err := s.SendCDPCtx(ctx, "Page.enable", nil)
+			return err
+		},
+	})
+	if err == nil {
+		t.Fatal("Connect succeeded, want the Setup error")
+	}
+}
+
+func TestFindSessionForLeavesDeadSessions(t *testing.T) {
+	session := &browser.Session{SessionID: "dead-session", Owner: "owner"}
+	browser.Store.Store(session.SessionID, session)
+	defer browser.Store.Delete(session.SessionID)
+
+	if _, err := browser.FindSessionFor("owner", session.SessionID); err == nil {
+		t.Fatal("FindSessionFor found a session with no connection")
+	}
+	if _, ok := browser.Store.Load(session.SessionID); !ok {
+		t.Error("FindSessionFor removed the dead session; removal is up to OnLost")
+	}
+}
+
+func TestServerConnectResumesDroppedSession(t *testing.T) {
+	srv := browsertest.NewServer()
+	defer srv.Close()
+	session := connect(t, srv)
+	assertResumes(t, srv, session)
+}
@@ -0,0 +1,154 @@
+package browser
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// downloadDir is where SaveDownload writes files; see SetDownloadDir.
+var (
+	downloadDir   string
+	downloadDirMu sync.RWMutex
+)
+
+// SetDownloadDir configures the local directory downloads can be saved to
+// (wired to -download-dir), creating it if needed. Empty disables saving.
+func SetDownloadDir(dir string) error {
+	if dir != "" {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(abs, 0o755); err != nil {
+			return err
+		}
+		dir = abs
+	}
+	downloadDirMu.Lock()
+	downloadDir = dir
+	downloadDirMu.Unlock()
+	return nil
+}
+
+// DownloadDir returns the configured download directory, or "" if saving
+// is disabled.
+func DownloadDir() string {
+	downloadDirMu.RLock()
+	defer downloadDirMu.RUnlock()
+	return downloadDir
+}
+
+// DownloadDir returns the session's share of the download directory: a
+// subdirectory per owner, so API keys sharing a server neither see nor
+// upload each other's files. Sessions without an owner use the directory
+// itself.
+func (s *Session) DownloadDir() (string, error) {
+	dir := DownloadDir()
+	if dir == "" {
+		return "", fmt.Errorf("no download directory configured (set -download-dir or SCRAPFLY_DOWNLOAD_DIR)")
+	}
+	if s.Owner == "" {
+		return dir, nil
+	}
+	dir = filepath.Join(dir, s.Owner)
+	return dir, os.MkdirAll(dir, 0o755)
+}
+
+// Download is a downloaded file, decoded.
+type Download struct {
+	Filename string `json:"filename"`
+	MIMEType string `json:"mime_type"`
+	Size     int    `json:"size"`
+	SHA256   string `json:"sha256"`
+	Data     []byte `json:"-"`
+}
+
+// FetchDownload retrieves and decodes a downloaded file.
+func (s *Session) FetchDownload(ctx context.Context, filename string) (*Download, error) {
+	encoded, err := s.GetDownload(ctx, filename)
+	if err != nil {
+		return nil, err
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", filename, err)
+	}
+	sum := sha256.Sum256(data)
+	return &Download{
+		Filename: filename,
+		MIMEType: DetectMIMEType(filename, data),
+		Size:     len(data),
+		SHA256:   hex.EncodeToString(sum[:]),
+		Data:     data,
+	}, nil
+}
+
+// DetectMIMEType sniffs data's MIME type, falling back to the filename's
+// extension when the content alone is inconclusive (or nil).
+func DetectMIMEType(filename string, data []byte) string {
+	sniffed := "application/octet-stream"
+	if len(data) > 0 {
+		sniffed = http.DetectContentType(data)
+		if sniffed != "application/octet-stream" && !strings.HasPrefix(sniffed, "text/plain") {
+			return sniffed
+		}
+	}
+	if byExt := mime.TypeByExtension(filepath.Ext(filename)); byExt != "" {
+		return byExt
+	}
+	return sniffed
+}
+
+// SaveDownload writes d to the session's download directory and returns
+// its path. A different file already saved under the same name is kept; d
+// then gets its checksum prefix (or, failing that, the whole checksum)
+// appended to the name. Files are created exclusively, so concurrent saves
+// never write into the same file.
+func (s *Session) SaveDownload(d *Download) (string, error) {
+	dir, err := s.DownloadDir()
+	if err != nil {
+		return "", err
+	}
+	name := filepath.Base(filepath.Clean("/" + d.Filename))
+	if name == "/" || name == "." {
+		return "", fmt.Errorf("invalid filename %q", d.Filename)
+	}
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for _, candidate := range []string{name, base + "-" + d.SHA256[:8] + ext, base + "-" + d.SHA256 + ext} {
+		path := filepath.Join(dir, candidate)
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if errors.Is(err, fs.ErrExist) {
+			if existing, err := os.ReadFile(path); err == nil {
+				if sum := sha256.Sum256(existing); hex.EncodeToString(sum[:]) == d.SHA256 {
+					return path, nil
+				}
+			}
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		_, err = f.Write(d.Data)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(path)
+			return "", err
+		}
+		return path, nil
+	}
+	return "", fmt.Errorf("%s: other files are already saved under its names", name)
+}
@@ -0,0 +1,115 @@
+package browser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// withDownloadDir points the download directory at a fresh temp dir for
+// the test.
+func withDownloadDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	prev := DownloadDir()
+	SetDownloadDir(dir)
+	t.Cleanup(func() { SetDownloadDir(prev) })
+	return dir
+}
+
+func download(name, data string) *Download {
+	sum := sha256.Sum256([]byte(data))
+	return &Download{Filename: name, Data: []byte(data), SHA256: hex.EncodeToString(sum[:])}
+}
+
+func TestSaveDownloadConcurrentSameContent(t *testing.T) {
+	withDownloadDir(t)
+	s := &Session{Owner: "owner-a"}
+	paths := make([]string, 8)
+	var wg sync.WaitGroup
+	for i := range paths {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			path, err := s.SaveDownload(download("report.csv", "a,b\n1,2\n"))
+			if err != nil {
+				t.Error(err)
+			}
+			paths[i] = path
+		}()
+	}
+	wg.Wait()
+	for _, path := range paths[1:] {
+		if path != paths[0] {
+			t.Fatalf("same content saved to %s and %s", paths[0], path)
+		}
+	}
+	if data, _ := os.ReadFile(paths[0]); string(data) != "a,b\n1,2\n" {
+		t.Errorf("saved %q", data)
+	}
+}
+
+func TestSaveDownloadKeepsDifferentContent(t *testing.T) {
+	withDownloadDir(t)
+	s := &Session{Owner: "owner-a"}
+	first, err := s.SaveDownload(download("report.csv", "first"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := download("report.csv", "second")
+	second, err := s.SaveDownload(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "report-" + d.SHA256[:8] + ".csv"; filepath.Base(second) != want {
+		t.Errorf("second file saved as %s, want %s", filepath.Base(second), want)
+	}
+	if data, _ := os.ReadFile(first); string(data) != "first" {
+		t.Errorf("first file overwritten with %q", data)
+	}
+}
+
+func TestDownloadDirPerOwner(t *testing.T) {
+	root := withDownloadDir(t)
+	a, err := (&Session{Owner: "owner-a"}).SaveDownload(download("f.txt", "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := (&Session{Owner: "owner-b"}).SaveDownload(download("f.txt", "b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(a) != filepath.Join(root, "owner-a") || filepath.Dir(b) != filepath.Join(root, "owner-b") {
+		t.Errorf("saved to %s and %s, want a directory per owner under %s", a, b, root)
+	}
+	if dir, _ := (&Session{}).DownloadDir(); dir != root {
+		t.Errorf("unowned session's DownloadDir = %s, want %s", dir, root)
+	}
+}
+
+func TestUploadFilesFromOwnDirOnly(t *testing.T) {
+	withDownloadDir(t)
+	mine, theirs := &Session{Owner: "owner-a"}, &Session{Owner: "owner-b"}
+	if _, err := theirs.SaveDownload(download("secret.txt", "theirs")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mine.SaveDownload(download("cv.txt", "mine")); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := mine.uploadFiles("cv.txt")
+	if err != nil || len(files) != 1 || files[0].Name != "cv.txt" {
+		t.Fatalf("uploadFiles(own file) = %+v, %v", files, err)
+	}
+	for _, value := range []string{"secret.txt", "../owner-b/secret.txt"} {
+		if _, err := mine.uploadFiles(value); err == nil {
+			t.Errorf("uploadFiles(%q) read another owner's file", value)
+		} else if value != "secret.txt" && !strings.Contains(err.Error(), "outside the download directory") {
+			t.Errorf("uploadFiles(%q) = %v, want an outside-the-directory error", value, err)
+		}
+	}
+}
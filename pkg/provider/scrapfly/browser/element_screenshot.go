@@ -0,0 +1,110 @@
+package browser
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// DefaultElementPadding is the margin, in CSS pixels, ElementScreenshot
+// keeps around the element so its edges and focus rings aren't cut off.
+const DefaultElementPadding = 8
+
+// ElementScreenshot captures the element with the given snapshot uid as a
+// PNG and returns it with the element's viewport box. The element is
+// scrolled into view first; one taller or wider than the viewport is
+// captured beyond it. Boxes are in root-frame coordinates, so elements of
+// same-process iframes work as is; elements the last snapshot doesn't know
+// (out-of-process iframes) are located through Antibot.
+func (s *Session) ElementScreenshot(ctx context.Context, uid string, padding float64) ([]byte, Rect, error) {
+	padding = max(padding, 0)
+	box, err := s.elementBox(ctx, uid)
+	if err != nil {
+		return nil, Rect{}, err
+	}
+
+	raw, err := s.SendCDPCtx(ctx, "Page.getLayoutMetrics", nil)
+	if err != nil {
+		return nil, Rect{}, err
+	}
+	var metrics struct {
+		CSSVisualViewport struct {
+			PageX        float64 `json:"pageX"`
+			PageY        float64 `json:"pageY"`
+			ClientWidth  float64 `json:"clientWidth"`
+			ClientHeight float64 `json:"clientHeight"`
+		} `json:"cssVisualViewport"`
+		CSSContentSize struct {
+			Width  float64 `json:"width"`
+			Height float64 `json:"height"`
+		} `json:"cssContentSize"`
+	}
+	json.Unmarshal(raw, &metrics)
+	vp, content := metrics.CSSVisualViewport, metrics.CSSContentSize
+
+	// The clip is in document coordinates: add the scroll offset.
+	x0 := max(box.X+vp.PageX-padding, 0)
+	y0 := max(box.Y+vp.PageY-padding, 0)
+	x1 := box.X + vp.PageX + box.Width + padding
+	y1 := box.Y + vp.PageY + box.Height + padding
+	if content.Width > 0 {
+		x1 = min(x1, content.Width)
+	}
+	if content.Height > 0 {
+		y1 = min(y1, content.Height)
+	}
+	if x1 <= x0 || y1 <= y0 {
+		return nil, Rect{}, fmt.Errorf("element %s has no visible area", uid)
+	}
+	params := map[string]any{
+		"format": "png",
+		"clip": map[string]any{
+			"x": x0, "y": y0, "width": x1 - x0, "height": y1 - y0, "scale": 1,
+		},
+	}
+	if box.Width > vp.ClientWidth || box.Height > vp.ClientHeight {
+		params["captureBeyondViewport"] = true
+	}
+	raw, err = s.SendCDPCtx(ctx, "Page.captureScreenshot", params)
+	if err != nil {
+		return nil, Rect{}, fmt.Errorf("Page.captureScreenshot: %w", err)
+	}
+	var shot struct {
+		Data string `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &shot); err != nil {
+		return nil, Rect{}, err
+	}
+	png, err := base64.StdEncoding.DecodeString(shot.Data)
+	if err != nil {
+		return nil, Rect{}, err
+	}
+	return png, box, nil
+}
+
+// elementBox scrolls the element into view and returns its viewport box.
+func (s *Session) elementBox(ctx context.Context, uid string) (Rect, error) {
+	if backendID, ok := s.Page.BackendNodeID(uid); ok {
+		s.SendCDPCtx(ctx, "DOM.scrollIntoViewIfNeeded", map[string]any{"backendNodeId": backendID})
+		if r, err := s.nodeRect(ctx, backendID); err == nil && r.Width > 0 && r.Height > 0 {
+			return r, nil
+		}
+	}
+	sel := &AntibotSelector{Type: AntibotSelectorTypeAXNodeID, Query: uid}
+	if _, err := s.Scroll(ctx, sel, 0, 0); err != nil {
+		return Rect{}, err
+	}
+	loc, err := s.AntibotLocateElementAcrossFrames(ctx, NewAntibotLocateElementAcrossFramesParams(sel))
+	if err != nil {
+		return Rect{}, err
+	}
+	if !loc.Success || loc.BoundsWidth <= 0 || loc.BoundsHeight <= 0 {
+		msg := loc.ErrorMessage
+		if msg == "" {
+			msg = "not found"
+		}
+		return Rect{}, fmt.Errorf("element %s: %s. Take a fresh snapshot", uid, msg)
+	}
+	return Rect{X: loc.BoundsX, Y: loc.BoundsY, Width: loc.BoundsWidth, Height: loc.BoundsHeight}, nil
+}
@@ -0,0 +1,7 @@
+package browser
+
+// Internals exposed to the browser_test package.
+var (
+	FindFormNode  = findFormNode
+	ParseFormBool = parseFormBool
+)
@@ -0,0 +1,833 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Standard-CDP fallbacks for the custom domains.
+//
+// The Scrapfly Cloud Browser adds three CDP domains to Chromium: Antibot
+// (human-like input), WebMCP (page-registered tools) and ScrapiumBrowser
+// (downloads). A local Chrome started with --remote-debugging-port, or any
+// other generic CDP endpoint, has none of them. The first command the
+// browser doesn't know fails with "method not found"; the session remembers
+// it and from then on serves it here instead: Antibot commands through
+// Input.dispatchMouseEvent / Input.dispatchKeyEvent plus DOM and Runtime
+// lookups — without the human-like timing, and in the main frame only.
+// Commands with no standard equivalent (captchas, downloads, WebMCP) fail
+// with ErrUnsupported.
+
+// ErrUnsupported is returned for commands the connected browser can't serve.
+var ErrUnsupported = errors.New("not supported by this browser")
+
+// customDomains are the CDP domains only the Scrapfly Cloud Browser has.
+var customDomains = []string{"Antibot", "WebMCP", "ScrapiumBrowser"}
+
+// fallbackState records the custom commands a session's browser lacks and
+// the mouse position the fallbacks track (Antibot tracks it in the browser).
+type fallbackState struct {
+	missing sync.Map // method or domain name → struct{}
+
+	mu     sync.Mutex
+	mouseX float64
+	mouseY float64
+}
+
+func isCustomDomain(method string) bool {
+	domain, _, _ := strings.Cut(method, ".")
+	return slices.Contains(customDomains, domain)
+}
+
+func isMethodNotFound(err error) bool {
+	var e *cdpError
+	return errors.As(err, &e) && e.Code == -32601
+}
+
+// lacks reports whether the browser is known not to have method.
+func (s *Session) lacks(method string) bool {
+	domain, _, _ := strings.Cut(method, ".")
+	_, m := s.fallback.missing.Load(method)
+	_, d := s.fallback.missing.Load(domain)
+	return m || d
+}
+
+// sendCustom sends a custom-domain command, falling back to standard CDP
+// once the browser turns out not to have it.
+func (s *Session) sendCustom(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	if !s.lacks(method) {
+		raw, err := s.sendAndWait(ctx, method, params, true)
+		if !isMethodNotFound(err) {
+			return raw, err
+		}
+		log.Printf("[CDP] %s not available in this browser; using the standard CDP fallback", method)
+		s.fallback.missing.Store(method, struct{}{})
+	}
+	return s.emulate(ctx, method, params)
+}
+
+// MissingDomains probes the custom domains and returns the ones the browser
+// doesn't have. Their commands go to the fallbacks from then on.
+func (s *Session) MissingDomains(ctx context.Context) []string {
+	probes := map[string]string{
+		"Antibot":         CommandAntibotGetMousePosition,
+		"WebMCP":          CommandWebMCPEnable,
+		"ScrapiumBrowser": CommandScrapiumBrowserHasDownloads,
+	}
+	var missing []string
+	for _, domain := range customDomains {
+		if _, known := s.fallback.missing.Load(domain); !known {
+			if _, err := s.sendAndWait(ctx, probes[domain], nil, true); !isMethodNotFound(err) {
+				continue
+			}
+			s.fallback.missing.Store(domain, struct{}{})
+		}
+		missing = append(missing, domain)
+	}
+	return missing
+}
+
+// fallbackFunc serves one command from its JSON parameters.
+type fallbackFunc func(s *Session, ctx context.Context, params json.RawMessage) (any, error)
+
+// fallback adapts a typed handler to a fallbackFunc.
+func fallback[P any](fn func(*Session, context.Context, *P) any) fallbackFunc {
+	return func(s *Session, ctx context.Context, raw json.RawMessage) (any, error) {
+		var p P
+		if len(raw) > 0 && string(raw) != "null" {
+			if err := json.Unmarshal(raw, &p); err != nil {
+				return nil, fmt.Errorf("invalid parameters: %w", err)
+			}
+		}
+		return fn(s, ctx, &p), nil
+	}
+}
+
+// fallbackFor returns the stand-in for method, or nil if it has none.
+func fallbackFor(method string) fallbackFunc {
+	switch method {
+	case CommandAntibotMoveTo:
+		return fallback((*Session).fallbackMoveTo)
+	case CommandAntibotClickOn:
+		return fallback((*Session).fallbackClickOn)
+	case CommandAntibotDragAndDrop:
+		return fallback((*Session).fallbackDragAndDrop)
+	case CommandAntibotClickAndHold:
+		return fallback((*Session).fallbackClickAndHold)
+	case CommandAntibotScroll:
+		return fallback((*Session).fallbackScroll)
+	case CommandAntibotTypeText:
+		return fallback((*Session).fallbackTypeText)
+	case CommandAntibotFill:
+		return fallback((*Session).fallbackFill)
+	case CommandAntibotLocateElement:
+		return fallback((*Session).fallbackLocateElement)
+	case CommandAntibotLocateElementAcrossFrames:
+		return fallback((*Session).fallbackLocateElementAcrossFrames)
+	case CommandAntibotWaitForElement:
+		return fallback((*Session).fallbackWaitForElement)
+	case CommandAntibotGetMousePosition:
+		return fallback((*Session).fallbackGetMousePosition)
+	case CommandAntibotIsElementVisible:
+		return fallback((*Session).fallbackIsElementVisible)
+	case CommandAntibotPressKey:
+		return fallback((*Session).fallbackPressKey)
+	case CommandAntibotHover:
+		return fallback((*Session).fallbackHover)
+	case CommandAntibotSelectOption:
+		return fallback((*Session).fallbackSelectOption)
+	case CommandAntibotDisable, CommandAntibotCaptchaDisable:
+		// Nothing was enabled.
+		return func(*Session, context.Context, json.RawMessage) (any, error) { return struct{}{}, nil }
+	}
+	return nil
+}
+
+// emulate serves method with standard CDP. params are the generated
+// *Params structs or, from CallTool, a plain map.
+func (s *Session) emulate(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	fn := fallbackFor(method)
+	if fn == nil {
+		return nil, fmt.Errorf("%w: %s needs the Scrapfly Cloud Browser, and this session runs on a standard CDP browser", ErrUnsupported, method)
+	}
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	out, err := fn(s, ctx, raw)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(out)
+}
+
+// fallbackResult is the common Antibot reply for err.
+func fallbackResult(err error) *AntibotResult {
+	if err != nil {
+		return antibotResult(false, err.Error())
+	}
+	return antibotResult(true, "")
+}
+
+// ── Element lookup ──────────────────────────────────────────────────────────
+
+// fallbackNode resolves sel to a backend DOM node of the main frame.
+func (s *Session) fallbackNode(ctx context.Context, sel *AntibotSelector) (int64, error) {
+	if sel == nil {
+		return 0, fmt.Errorf("no selector given")
+	}
+	switch sel.Type {
+	case AntibotSelectorTypeAXNodeID:
+		if id, ok := s.Page.BackendNodeID(sel.Query); ok {
+			return id, nil
+		}
+		return 0, fmt.Errorf("no element with id %s. Take a fresh snapshot", sel.Query)
+	case AntibotSelectorTypeRole:
+		for _, n := range s.Page.Nodes() {
+			if strings.EqualFold(n.Role, sel.Query) && n.BackendNodeID != 0 {
+				return n.BackendNodeID, nil
+			}
+		}
+		return 0, fmt.Errorf("no element with role %s", sel.Query)
+	case AntibotSelectorTypeCSS:
+		return s.nodeFromExpr(ctx, "document.querySelector("+jsString(sel.Query)+")", sel)
+	case AntibotSelectorTypeXPath:
+		return s.nodeFromExpr(ctx, "document.evaluate("+jsString(sel.Query)+
+			", document, null, XPathResult.FIRST_ORDERED_NODE_TYPE, null).singleNodeValue", sel)
+	}
+	return 0, fmt.Errorf("selector type %q is not supported here", sel.Type)
+}
+
+// nodeFromExpr evaluates expr, which yields an element or null, to the
+// element's backend node.
+func (s *Session) nodeFromExpr(ctx context.Context, expr string, sel *AntibotSelector) (int64, error) {
+	raw, err := s.SendCDPCtx(ctx, "Runtime.evaluate", map[string]any{"expression": expr, "silent": true})
+	if err != nil {
+		return 0, err
+	}
+	var res struct {
+		Result struct {
+			ObjectID string `json:"objectId"`
+		} `json:"result"`
+		ExceptionDetails *struct {
+			Text string `json:"text"`
+		} `json:"exceptionDetails"`
+	}
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return 0, err
+	}
+	if res.ExceptionDetails != nil {
+		return 0, fmt.Errorf("invalid %s selector %q", sel.Type, sel.Query)
+	}
+	if res.Result.ObjectID == "" {
+		return 0, fmt.Errorf("no element matches %s %q", sel.Type, sel.Query)
+	}
+	defer s.SendCDPCtx(ctx, "Runtime.releaseObject", map[string]any{"objectId": res.Result.ObjectID})
+
+	raw, err = s.SendCDPCtx(ctx, "DOM.describeNode", map[string]any{"objectId": res.Result.ObjectID})
+	if err != nil {
+		return 0, err
+	}
+	var desc struct {
+		Node struct {
+			BackendNodeID int64 `json:"backendNodeId"`
+		} `json:"node"`
+	}
+	if err := json.Unmarshal(raw, &desc); err != nil || desc.Node.BackendNodeID == 0 {
+		return 0, fmt.Errorf("%s %q does not match an element", sel.Type, sel.Query)
+	}
+	return desc.Node.BackendNodeID, nil
+}
+
+// fallbackPoint resolves sel to a point in viewport CSS pixels: the
+// coordinates of a coord selector, else the point of the element at rel
+// (ratios of its box, default the center), after scrolling it into view
+// when scroll is set. The element's box is returned too.
+func (s *Session) fallbackPoint(ctx context.Context, sel *AntibotSelector, rel *AntibotCoordinate, scroll bool) (AntibotCoordinate, Rect, error) {
+	if sel != nil && sel.Type == AntibotSelectorTypeCoord {
+		x, y, ok := strings.Cut(sel.Query, ",")
+		px, errX := strconv.ParseFloat(strings.TrimSpace(x), 64)
+		py, errY := strconv.ParseFloat(strings.TrimSpace(y), 64)
+		if !ok || errX != nil || errY != nil {
+			return AntibotCoordinate{}, Rect{}, fmt.Errorf("invalid coord %q (want \"x,y\")", sel.Query)
+		}
+		return AntibotCoordinate{X: px, Y: py}, Rect{X: px, Y: py}, nil
+	}
+	id, err := s.fallbackNode(ctx, sel)
+	if err != nil {
+		return AntibotCoordinate{}, Rect{}, err
+	}
+	if scroll {
+		s.SendCDPCtx(ctx, "DOM.scrollIntoViewIfNeeded", map[string]any{"backendNodeId": id})
+	}
+	r, err := s.nodeRect(ctx, id)
+	if err != nil {
+		return AntibotCoordinate{}, Rect{}, fmt.Errorf("element is not rendered: %w", err)
+	}
+	rx, ry := 0.5, 0.5
+	if rel != nil {
+		rx, ry = rel.X, rel.Y
+	}
+	return AntibotCoordinate{X: r.X + r.Width*rx, Y: r.Y + r.Height*ry}, r, nil
+}
+
+// ── Mouse ───────────────────────────────────────────────────────────────────
+
+// mouseButtons maps a button name to its bit in the buttons mask.
+var mouseButtons = map[string]int{"left": 1, "right": 2, "middle": 4}
+
+// mouseEvent dispatches one mouse event at pt and records the position.
+// button is empty for plain moves.
+func (s *Session) mouseEvent(ctx context.Context, typ string, pt AntibotCoordinate, button string, buttons, clickCount int) error {
+	params := map[string]any{"type": typ, "x": pt.X, "y": pt.Y, "buttons": buttons}
+	if button != "" {
+		params["button"] = button
+		params["clickCount"] = clickCount
+	}
+	if _, err := s.SendCDPCtx(ctx, "Input.dispatchMouseEvent", params); err != nil {
+		return err
+	}
+	s.fallback.mu.Lock()
+	s.fallback.mouseX, s.fallback.mouseY = pt.X, pt.Y
+	s.fallback.mu.Unlock()
+	return nil
+}
+
+// mousePosition is the last position a fallback moved the mouse to.
+func (s *Session) mousePosition() AntibotCoordinate {
+	s.fallback.mu.Lock()
+	defer s.fallback.mu.Unlock()
+	return AntibotCoordinate{X: s.fallback.mouseX, Y: s.fallback.mouseY}
+}
+
+// clickAt moves to pt and clicks count times, holding each press for hold.
+func (s *Session) clickAt(ctx context.Context, pt AntibotCoordinate, button string, count int, hold time.Duration) error {
+	if button == "" {
+		button = "left"
+	}
+	mask, ok := mouseButtons[button]
+	if !ok {
+		return fmt.Errorf("unknown mouse button %q", button)
+	}
+	if err := s.mouseEvent(ctx, "mouseMoved", pt, "", 0, 0); err != nil {
+		return err
+	}
+	for i := 1; i <= max(count, 1); i++ {
+		if err := s.mouseEvent(ctx, "mousePressed", pt, button, mask, i); err != nil {
+			return err
+		}
+		if hold > 0 {
+			if err := sleepCtx(ctx, hold); err != nil {
+				return err
+			}
+		}
+		if err := s.mouseEvent(ctx, "mouseReleased", pt, button, 0, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Session) fallbackMoveTo(ctx context.Context, p *AntibotMoveToParams) any {
+	pt, _, err := s.fallbackPoint(ctx, p.Selector, p.RelativePosition, true)
+	if err != nil {
+		return fallbackResult(err)
+	}
+	if p.AbsoluteOffset != nil && *p.AbsoluteOffset && p.Selector.Type == AntibotSelectorTypeCoord {
+		cur := s.mousePosition()
+		pt.X, pt.Y = cur.X+pt.X, cur.Y+pt.Y
+	}
+	return fallbackResult(s.mouseEvent(ctx, "mouseMoved", pt, "", 0, 0))
+}
+
+func (s *Session) fallbackClickOn(ctx context.Context, p *AntibotClickOnParams) any {
+	pt, _, err := s.fallbackPoint(ctx, p.Selector, p.RelativePosition, true)
+	if err != nil {
+		return fallbackResult(err)
+	}
+	count, hold := 1, time.Duration(0)
+	if p.ClickCount != nil {
+		count = int(*p.ClickCount)
+	}
+	if p.ClickDuration != nil {
+		hold = time.Duration(*p.ClickDuration * float64(time.Second))
+	}
+	return fallbackResult(s.clickAt(ctx, pt, p.Button, count, hold))
+}
+
+func (s *Session) fallbackHover(ctx context.Context, p *AntibotHoverParams) any {
+	pt, _, err := s.fallbackPoint(ctx, p.Selector, p.RelativePosition, true)
+	if err != nil {
+		return fallbackResult(err)
+	}
+	return fallbackResult(s.mouseEvent(ctx, "mouseMoved", pt, "", 0, 0))
+}
+
+func (s *Session) fallbackClickAndHold(ctx context.Context, p *AntibotClickAndHoldParams) any {
+	if p.FrameURL != "" {
+		return fallbackResult(fmt.Errorf("frameUrl: only the main frame can be searched in this browser"))
+	}
+	pt, _, err := s.fallbackPoint(ctx, p.Selector, p.RelativePosition, true)
+	if err != nil {
+		return fallbackResult(err)
+	}
+	hold := 2 * time.Second
+	if p.HoldTime != nil {
+		hold = time.Duration(*p.HoldTime * float64(time.Second))
+	}
+	return fallbackResult(s.clickAt(ctx, pt, p.Button, 1, hold))
+}
+
+// dragSteps is how many moves a fallback drag takes between its ends.
+const dragSteps = 10
+
+func (s *Session) fallbackDragAndDrop(ctx context.Context, p *AntibotDragAndDropParams) any {
+	from, _, err := s.fallbackPoint(ctx, p.From, p.FromRelativePosition, true)
+	if err != nil {
+		return fallbackResult(fmt.Errorf("from: %w", err))
+	}
+	to, _, err := s.fallbackPoint(ctx, p.To, p.ToRelativePosition, false)
+	if err != nil {
+		return fallbackResult(fmt.Errorf("to: %w", err))
+	}
+	if err := s.mouseEvent(ctx, "mouseMoved", from, "", 0, 0); err != nil {
+		return fallbackResult(err)
+	}
+	if err := s.mouseEvent(ctx, "mousePressed", from, "left", 1, 1); err != nil {
+		return fallbackResult(err)
+	}
+	for i := 1; i <= dragSteps; i++ {
+		f := float64(i) / dragSteps
+		pt := AntibotCoordinate{X: from.X + (to.X-from.X)*f, Y: from.Y + (to.Y-from.Y)*f}
+		if err := s.mouseEvent(ctx, "mouseMoved", pt, "left", 1, 0); err != nil {
+			return fallbackResult(err)
+		}
+	}
+	return fallbackResult(s.mouseEvent(ctx, "mouseReleased", to, "left", 0, 1))
+}
+
+func (s *Session) fallbackScroll(ctx context.Context, p *AntibotScrollParams) any {
+	switch {
+	case p.Selector != nil && p.Selector.Type == AntibotSelectorTypeBottom:
+		var atBottom bool
+		err := s.evalValue(ctx, scrollBottomJS, &atBottom)
+		r := fallbackResult(err)
+		return &AntibotScrollReturns{Success: r.Success, ErrorMessage: r.ErrorMessage, AtBottom: atBottom}
+	case p.Selector != nil:
+		_, _, err := s.fallbackPoint(ctx, p.Selector, nil, true)
+		return fallbackResult(err)
+	case p.Delta != nil:
+		pt := s.mousePosition()
+		if pt.X == 0 && pt.Y == 0 {
+			w, h, err := s.viewportSize(ctx)
+			if err != nil {
+				return fallbackResult(err)
+			}
+			pt = AntibotCoordinate{X: w / 2, Y: h / 2}
+		}
+		_, err := s.SendCDPCtx(ctx, "Input.dispatchMouseEvent", map[string]any{
+			"type": "mouseWheel", "x": pt.X, "y": pt.Y, "deltaX": p.Delta.X, "deltaY": p.Delta.Y,
+		})
+		return fallbackResult(err)
+	}
+	return fallbackResult(fmt.Errorf("scroll needs a selector or a delta"))
+}
+
+// scrollBottomJS scrolls the document to its end and reports whether it is
+// there (within 5px, as Antibot.scroll does).
+const scrollBottomJS = `(() => {
+  const e = document.scrollingElement || document.documentElement;
+  e.scrollTo(0, e.scrollHeight);
+  return Math.ceil(e.scrollTop + innerHeight) >= e.scrollHeight - 5;
+})()`
+
+// ── Keyboard ────────────────────────────────────────────────────────────────
+
+// keyDef describes a key to Input.dispatchKeyEvent.
+type keyDef struct {
+	key     string
+	code    string
+	keyCode int
+	text    string
+	shift   bool // needs Shift held
+}
+
+// Modifier bits of Input.dispatchKeyEvent.
+const (
+	modAlt   = 1
+	modCtrl  = 2
+	modMeta  = 4
+	modShift = 8
+)
+
+var keyModifiers = map[string]int{
+	"alt": modAlt, "ctrl": modCtrl, "control": modCtrl,
+	"meta": modMeta, "cmd": modMeta, "command": modMeta, "shift": modShift,
+}
+
+// namedKeys are the special keys Antibot.pressKey accepts, lower-cased.
+var namedKeys = map[string]keyDef{
+	"enter":      {key: "Enter", code: "Enter", keyCode: 13, text: "\r"},
+	"tab":        {key: "Tab", code: "Tab", keyCode: 9},
+	"escape":     {key: "Escape", code: "Escape", keyCode: 27},
+	"esc":        {key: "Escape", code: "Escape", keyCode: 27},
+	"backspace":  {key: "Backspace", code: "Backspace", keyCode: 8},
+	"delete":     {key: "Delete", code: "Delete", keyCode: 46},
+	"space":      {key: " ", code: "Space", keyCode: 32, text: " "},
+	"home":       {key: "Home", code: "Home", keyCode: 36},
+	"end":        {key: "End", code: "End", keyCode: 35},
+	"pageup":     {key: "PageUp", code: "PageUp", keyCode: 33},
+	"pagedown":   {key: "PageDown", code: "PageDown", keyCode: 34},
+	"arrowup":    {key: "ArrowUp", code: "ArrowUp", keyCode: 38},
+	"up":         {key: "ArrowUp", code: "ArrowUp", keyCode: 38},
+	"arrowdown":  {key: "ArrowDown", code: "ArrowDown", keyCode: 40},
+	"down":       {key: "ArrowDown", code: "ArrowDown", keyCode: 40},
+	"arrowleft":  {key: "ArrowLeft", code: "ArrowLeft", keyCode: 37},
+	"left":       {key: "ArrowLeft", code: "ArrowLeft", keyCode: 37},
+	"arrowright": {key: "ArrowRight", code: "ArrowRight", keyCode: 39},
+	"right":      {key: "ArrowRight", code: "ArrowRight", keyCode: 39},
+}
+
+// charKey is the key typing r.
+func charKey(r rune) keyDef {
+	c := string(r)
+	switch {
+	case r == '\n' || r == '\r':
+		return namedKeys["enter"]
+	case r == '\t':
+		return namedKeys["tab"]
+	case r == ' ':
+		return namedKeys["space"]
+	case r >= 'a' && r <= 'z':
+		return keyDef{key: c, code: "Key" + strings.ToUpper(c), keyCode: int(r - 'a' + 'A'), text: c}
+	case r >= 'A' && r <= 'Z':
+		return keyDef{key: c, code: "Key" + c, keyCode: int(r), text: c, shift: true}
+	case r >= '0' && r <= '9':
+		return keyDef{key: c, code: "Digit" + c, keyCode: int(r), text: c}
+	}
+	return keyDef{key: c, text: c}
+}
+
+// parseKey reads an Antibot.pressKey key: a named key, F1-F12 or a single
+// character, optionally behind one "Ctrl+"-style modifier.
+func parseKey(spec string) (keyDef, int, error) {
+	mods := 0
+	if prefix, rest, ok := strings.Cut(spec, "+"); ok && rest != "" {
+		if m, known := keyModifiers[strings.ToLower(prefix)]; known {
+			mods, spec = m, rest
+		}
+	}
+	lower := strings.ToLower(spec)
+	if k, ok := namedKeys[lower]; ok {
+		return k, mods, nil
+	}
+	if n, err := strconv.Atoi(strings.TrimPrefix(lower, "f")); err == nil && strings.HasPrefix(lower, "f") && n >= 1 && n <= 12 {
+		name := "F" + strconv.Itoa(n)
+		return keyDef{key: name, code: name, keyCode: 111 + n}, mods, nil
+	}
+	if r := []rune(spec); len(r) == 1 {
+		k := charKey(r[0])
+		if k.shift {
+			mods |= modShift
+		}
+		return k, mods, nil
+	}
+	return keyDef{}, 0, fmt.Errorf("unknown key %q", spec)
+}
+
+// pressKey sends keyDown and keyUp for k. The key produces text only
+// without Ctrl, Alt or Meta held.
+func (s *Session) pressKey(ctx context.Context, k keyDef, mods int) error {
+	params := map[string]any{
+		"type":                  "rawKeyDown",
+		"key":                   k.key,
+		"code":                  k.code,
+		"windowsVirtualKeyCode": k.keyCode,
+		"modifiers":             mods,
+	}
+	if k.text != "" && mods&^modShift == 0 {
+		params["type"] = "keyDown"
+		params["text"] = k.text
+		params["unmodifiedText"] = k.text
+	}
+	if _, err := s.SendCDPCtx(ctx, "Input.dispatchKeyEvent", params); err != nil {
+		return err
+	}
+	delete(params, "text")
+	delete(params, "unmodifiedText")
+	params["type"] = "keyUp"
+	_, err := s.SendCDPCtx(ctx, "Input.dispatchKeyEvent", params)
+	return err
+}
+
+// typeText types text one key at a time.
+func (s *Session) typeText(ctx context.Context, text string) error {
+	for _, r := range text {
+		k := charKey(r)
+		mods := 0
+		if k.shift {
+			mods = modShift
+		}
+		if err := s.pressKey(ctx, k, mods); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Session) fallbackPressKey(ctx context.Context, p *AntibotPressKeyParams) any {
+	k, mods, err := parseKey(p.Key)
+	if err != nil {
+		return fallbackResult(err)
+	}
+	return fallbackResult(s.pressKey(ctx, k, mods))
+}
+
+func (s *Session) fallbackTypeText(ctx context.Context, p *AntibotTypeTextParams) any {
+	return fallbackResult(s.typeText(ctx, p.Text))
+}
+
+func (s *Session) fallbackFill(ctx context.Context, p *AntibotFillParams) any {
+	pt, _, err := s.fallbackPoint(ctx, p.Selector, p.RelativePosition, true)
+	if err != nil {
+		return fallbackResult(err)
+	}
+	if err := s.clickAt(ctx, pt, "left", 1, 0); err != nil {
+		return fallbackResult(err)
+	}
+	if p.Clear != nil && *p.Clear {
+		var ok bool
+		if err := s.evalValue(ctx, selectFocusedJS, &ok); err != nil {
+			return fallbackResult(err)
+		}
+		if err := s.pressKey(ctx, namedKeys["backspace"], 0); err != nil {
+			return fallbackResult(err)
+		}
+	}
+	if p.Paste != nil && *p.Paste {
+		_, err := s.SendCDPCtx(ctx, "Input.insertText", map[string]any{"text": p.Text})
+		return fallbackResult(err)
+	}
+	return fallbackResult(s.typeText(ctx, p.Text))
+}
+
+// selectFocusedJS selects the content of the focused field, so the next
+// key replaces it.
+const selectFocusedJS = `(() => {
+  const el = document.activeElement;
+  if (!el) return false;
+  if (typeof el.select === 'function') el.select();
+  else document.execCommand('selectAll');
+  return true;
+})()`
+
+// ── Queries ─────────────────────────────────────────────────────────────────
+
+func (s *Session) fallbackLocateElementAcrossFrames(ctx context.Context, p *AntibotLocateElementAcrossFramesParams) any {
+	pt, r, err := s.fallbackPoint(ctx, p.Selector, p.RelativePosition, false)
+	if err != nil {
+		return &AntibotLocateElementAcrossFramesReturns{ErrorMessage: err.Error()}
+	}
+	return &AntibotLocateElementAcrossFramesReturns{
+		Success: true,
+		PointX:  pt.X, PointY: pt.Y,
+		BoundsX: r.X, BoundsY: r.Y, BoundsWidth: r.Width, BoundsHeight: r.Height,
+	}
+}
+
+// fallbackLocateElement searches the main frame whatever frame is asked for.
+func (s *Session) fallbackLocateElement(ctx context.Context, p *AntibotLocateElementParams) any {
+	return s.fallbackLocateElementAcrossFrames(ctx, &AntibotLocateElementAcrossFramesParams{
+		Selector: p.Selector, RelativePosition: p.RelativePosition,
+	})
+}
+
+func (s *Session) fallbackGetMousePosition(ctx context.Context, _ *struct{}) any {
+	pt := s.mousePosition()
+	return &AntibotGetMousePositionReturns{X: pt.X, Y: pt.Y}
+}
+
+func (s *Session) fallbackWaitForElement(ctx context.Context, p *AntibotWaitForElementParams) any {
+	timeout := 10 * time.Second
+	if p.Timeout != nil && *p.Timeout > 0 {
+		timeout = time.Duration(*p.Timeout) * time.Millisecond
+	}
+	visible := p.Visible != nil && *p.Visible
+	if p.Selector != nil && p.Selector.Type == AntibotSelectorTypeCoord {
+		return &AntibotWaitForElementReturns{Success: true}
+	}
+	found := s.pollUntil(ctx, timeout, func() bool {
+		if p.Selector != nil && p.Selector.Type == AntibotSelectorTypeRole {
+			s.Page.Refresh(ctx, s)
+		}
+		id, err := s.fallbackNode(ctx, p.Selector)
+		if err != nil {
+			return false
+		}
+		if !visible {
+			return true
+		}
+		r, err := s.nodeRect(ctx, id)
+		return err == nil && r.Width > 0 && r.Height > 0
+	})
+	if !found {
+		what := "element"
+		if p.Selector != nil {
+			what = fmt.Sprintf("%s %q", p.Selector.Type, p.Selector.Query)
+		}
+		return &AntibotWaitForElementReturns{ErrorMessage: fmt.Sprintf("timed out after %s waiting for %s", timeout, what)}
+	}
+	return &AntibotWaitForElementReturns{Success: true}
+}
+
+func (s *Session) fallbackIsElementVisible(ctx context.Context, p *AntibotIsElementVisibleParams) any {
+	id, err := s.fallbackNode(ctx, p.Selector)
+	if err != nil {
+		return &AntibotIsElementVisibleReturns{Reason: err.Error()}
+	}
+	var res struct {
+		Visible bool   `json:"visible"`
+		Reason  string `json:"reason"`
+	}
+	if err := s.callOnNode(ctx, id, visibleJSFn, &res); err != nil {
+		return &AntibotIsElementVisibleReturns{Reason: err.Error()}
+	}
+	return &AntibotIsElementVisibleReturns{Visible: res.Visible, Exists: !res.Visible, Reason: res.Reason}
+}
+
+// visibleJSFn checks an element the way Antibot.isElementVisible does:
+// rendered, not transparent, with a size, and in the viewport.
+const visibleJSFn = `function(){
+  const st = getComputedStyle(this);
+  if (st.display === 'none') return {visible:false, reason:'display none'};
+  if (st.visibility === 'hidden' || st.visibility === 'collapse') return {visible:false, reason:'visibility hidden'};
+  if (parseFloat(st.opacity) === 0) return {visible:false, reason:'opacity 0'};
+  if (this.checkVisibility && !this.checkVisibility({opacityProperty:true, visibilityProperty:true}))
+    return {visible:false, reason:'hidden by an ancestor'};
+  const r = this.getBoundingClientRect();
+  if (r.width === 0 || r.height === 0) return {visible:false, reason:'zero size'};
+  if (r.bottom <= 0 || r.right <= 0 || r.top >= innerHeight || r.left >= innerWidth)
+    return {visible:false, reason:'off viewport'};
+  return {visible:true};
+}`
+
+// ── Select ──────────────────────────────────────────────────────────────────
+
+// fallbackOptionAttr marks the custom-dropdown option to click.
+const fallbackOptionAttr = "data-scrapfly-option"
+
+// optionWait bounds how long a custom dropdown takes to show its options.
+const optionWait = 5 * time.Second
+
+func (s *Session) fallbackSelectOption(ctx context.Context, p *AntibotSelectOptionParams) any {
+	index := int64(-1)
+	if p.Index != nil {
+		index = *p.Index
+	}
+	if p.OptionSelector != "" {
+		return s.fallbackSelectCustom(ctx, p, index)
+	}
+	id, err := s.fallbackNode(ctx, p.Selector)
+	if err != nil {
+		return &AntibotSelectOptionReturns{ErrorMessage: err.Error()}
+	}
+	var res struct {
+		Error string `json:"error"`
+		Value string `json:"value"`
+		Text  string `json:"text"`
+	}
+	if err := s.callOnNode(ctx, id, nativeSelectJSFn, &res, p.Value, p.Text, index); err != nil {
+		return &AntibotSelectOptionReturns{ErrorMessage: err.Error()}
+	}
+	if res.Error != "" {
+		return &AntibotSelectOptionReturns{ErrorMessage: res.Error}
+	}
+	return &AntibotSelectOptionReturns{Success: true, SelectedValue: res.Value, SelectedText: res.Text}
+}
+
+// fallbackSelectCustom opens a custom dropdown by clicking it, waits for
+// its options and clicks the matching one.
+func (s *Session) fallbackSelectCustom(ctx context.Context, p *AntibotSelectOptionParams, index int64) any {
+	trigger, _, err := s.fallbackPoint(ctx, p.Selector, nil, true)
+	if err != nil {
+		return &AntibotSelectOptionReturns{ErrorMessage: err.Error()}
+	}
+	if err := s.clickAt(ctx, trigger, "left", 1, 0); err != nil {
+		return &AntibotSelectOptionReturns{ErrorMessage: err.Error()}
+	}
+	expr := fmt.Sprintf("(%s)(%s, %s, %d, %s)", markOptionJSFn,
+		jsString(p.OptionSelector), jsString(p.Text), index, jsString(fallbackOptionAttr))
+	var text string
+	found := s.pollUntil(ctx, optionWait, func() bool {
+		var mark struct {
+			Found bool   `json:"found"`
+			Text  string `json:"text"`
+		}
+		if s.evalValue(ctx, expr, &mark) != nil || !mark.Found {
+			return false
+		}
+		text = mark.Text
+		return true
+	})
+	if !found {
+		return &AntibotSelectOptionReturns{ErrorMessage: fmt.Sprintf("no option matching %q appeared", p.OptionSelector)}
+	}
+	opt := &AntibotSelector{Type: AntibotSelectorTypeCSS, Query: "[" + fallbackOptionAttr + "]"}
+	pt, _, err := s.fallbackPoint(ctx, opt, nil, true)
+	if err == nil {
+		err = s.clickAt(ctx, pt, "left", 1, 0)
+	}
+	s.evalValue(ctx, fmt.Sprintf("document.querySelectorAll('[%s]').forEach(e => e.removeAttribute('%[1]s'))", fallbackOptionAttr), nil)
+	if err != nil {
+		return &AntibotSelectOptionReturns{ErrorMessage: err.Error()}
+	}
+	return &AntibotSelectOptionReturns{Success: true, SelectedText: text}
+}
+
+// nativeSelectJSFn picks a <select> option by value, text or index and
+// fires the events a user's choice would.
+const nativeSelectJSFn = `function(value, text, index){
+  if (this.tagName !== 'SELECT') return {error:'not a <select>: pass optionSelector for a custom dropdown'};
+  const opts = Array.from(this.options);
+  let o;
+  if (index >= 0) o = opts[index];
+  else if (value !== '') o = opts.find(o => o.value === value);
+  else o = opts.find(o => o.text.trim() === text.trim());
+  if (!o) return {error:'no matching option'};
+  this.focus();
+  this.value = o.value;
+  this.dispatchEvent(new Event('input', {bubbles:true}));
+  this.dispatchEvent(new Event('change', {bubbles:true}));
+  return {value:o.value, text:o.text.trim()};
+}`
+
+// markOptionJSFn marks the option of a custom dropdown matching text or
+// index (else the first one) with attr.
+const markOptionJSFn = `(sel, text, index, attr) => {
+  const opts = Array.from(document.querySelectorAll(sel));
+  let o;
+  if (index >= 0) o = opts[index];
+  else if (text !== '') o = opts.find(o => o.textContent.trim() === text.trim());
+  else o = opts[0];
+  if (!o) return {found:false};
+  document.querySelectorAll('[' + attr + ']').forEach(e => e.removeAttribute(attr));
+  o.setAttribute(attr, '');
+  return {found:true, text:o.textContent.trim()};
+}`
@@ -0,0 +1,99 @@
+package browser_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser"
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser/browsertest"
+)
+
+// withoutAntibot connects to a browser lacking the Antibot domain, showing
+// a button and a text field.
+func withoutAntibot(t *testing.T) (*browsertest.Server, *browser.Session) {
+	t.Helper()
+	srv := browsertest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.WithoutDomains("Antibot")
+	srv.SetAXTree(
+		browsertest.AXNode{ID: "buy", Role: "button", Name: "Buy", BackendNodeID: 10, Box: &browser.Rect{X: 100, Y: 200, Width: 50, Height: 20}},
+		browsertest.AXNode{ID: "email", Role: "textbox", Name: "Email", BackendNodeID: 11, Box: &browser.Rect{X: 0, Y: 0, Width: 200, Height: 30}},
+	)
+	return srv, connect(t, srv)
+}
+
+type inputEvent struct {
+	Type   string  `json:"type"`
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Button string  `json:"button"`
+	Text   string  `json:"text"`
+}
+
+func inputEvents(t *testing.T, srv *browsertest.Server, method string) []inputEvent {
+	t.Helper()
+	var out []inputEvent
+	for _, c := range srv.Calls(method) {
+		var e inputEvent
+		if err := json.Unmarshal(c.Params, &e); err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+func TestClickFallback(t *testing.T) {
+	srv, session := withoutAntibot(t)
+	r, err := session.Click(context.Background(), browser.Selector{Type: browser.AntibotSelectorTypeAXNodeID, Query: "buy"})
+	if err != nil || !r.Success {
+		t.Fatalf("Click = %+v, %v", r, err)
+	}
+	var pressed, released int
+	for _, e := range inputEvents(t, srv, "Input.dispatchMouseEvent") {
+		if e.X != 125 || e.Y != 210 {
+			t.Errorf("%s at (%g, %g), want the button's center (125, 210)", e.Type, e.X, e.Y)
+		}
+		switch e.Type {
+		case "mousePressed":
+			pressed++
+		case "mouseReleased":
+			released++
+		}
+	}
+	if pressed != 1 || released != 1 {
+		t.Errorf("%d presses and %d releases, want one click", pressed, released)
+	}
+
+	r, err = session.Click(context.Background(), browser.Selector{Type: browser.AntibotSelectorTypeAXNodeID, Query: "gone"})
+	if err != nil || r.Success || !strings.Contains(r.ErrorMessage, "gone") {
+		t.Errorf("Click on a missing element = %+v, %v; want a failure naming it", r, err)
+	}
+}
+
+func TestFillFallback(t *testing.T) {
+	srv, session := withoutAntibot(t)
+	r, err := session.Fill(context.Background(), browser.Selector{Type: browser.AntibotSelectorTypeAXNodeID, Query: "email"}, "Jo@x", true)
+	if err != nil || !r.Success {
+		t.Fatalf("Fill = %+v, %v", r, err)
+	}
+	if n := len(srv.Calls("Input.dispatchMouseEvent")); n == 0 {
+		t.Error("the field was not clicked before typing")
+	}
+	var typed strings.Builder
+	backspace := false
+	for _, e := range inputEvents(t, srv, "Input.dispatchKeyEvent") {
+		if e.Type == "rawKeyDown" && typed.Len() == 0 {
+			backspace = true // clearing the selected content comes first
+		}
+		typed.WriteString(e.Text)
+	}
+	if !backspace {
+		t.Error("clear=true did not delete the field's content first")
+	}
+	if typed.String() != "Jo@x" {
+		t.Errorf("typed %q, want %q", typed.String(), "Jo@x")
+	}
+}
@@ -0,0 +1,495 @@
+package browser
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Declarative form filling.
+//
+// FillForm fills a list of fields in one call, picking the action per field
+// from the element's role and compound metadata (see collectCompoundMeta):
+// typing for text inputs, option selection for <select>, toggling for
+// checkboxes and radios, injected File objects for file inputs. It then
+// reports each field's validity and the page's alert messages, so a model
+// learns what went wrong without re-snapshotting after every field.
+
+// FormField is one field to fill, addressed by snapshot uid or by its
+// accessible name (label).
+type FormField struct {
+	UID   string `json:"uid,omitempty"`
+	Label string `json:"label,omitempty"`
+	Value string `json:"value"`
+}
+
+// FormFieldResult is the outcome of filling one field.
+type FormFieldResult struct {
+	UID        string `json:"uid,omitempty"`
+	Label      string `json:"label,omitempty"`
+	Role       string `json:"role,omitempty"`
+	Action     string `json:"action,omitempty"` // fill, set, select, check, uncheck, upload
+	OK         bool   `json:"ok"`
+	Error      string `json:"error,omitempty"`
+	Invalid    bool   `json:"invalid,omitempty"`
+	Validation string `json:"validation,omitempty"` // the browser's or the page's message
+}
+
+// FormResult is the outcome of FillForm.
+type FormResult struct {
+	Fields      []FormFieldResult `json:"fields"`
+	Submitted   bool              `json:"submitted,omitempty"`
+	SubmitError string            `json:"submit_error,omitempty"`
+	Messages    []string          `json:"messages,omitempty"` // role=alert / aria-live text on the page
+}
+
+// FormSubmit says how FillForm submits once every field is filled.
+type FormSubmit struct {
+	Enabled bool
+	UID     string // button to click; empty submits the last field's form
+}
+
+// valueSetTypes are input types typing can't fill reliably (native pickers);
+// their value is set directly, with input/change events.
+var valueSetTypes = map[string]bool{
+	"date": true, "datetime-local": true, "month": true, "week": true,
+	"time": true, "color": true, "range": true,
+}
+
+// FillForm fills fields in order and optionally submits. A field that fails
+// doesn't stop the others.
+func (s *Session) FillForm(ctx context.Context, fields []FormField, submit FormSubmit) (*FormResult, error) {
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no fields to fill")
+	}
+	s.Page.Refresh(ctx, s)
+	nodes := s.Page.InteractiveNodes()
+
+	res := &FormResult{Fields: make([]FormFieldResult, len(fields))}
+	var lastBackendID int64
+	for i, f := range fields {
+		r := &res.Fields[i]
+		r.UID, r.Label = f.UID, f.Label
+		node, err := findFormNode(nodes, f)
+		if err != nil {
+			r.Error = err.Error()
+			continue
+		}
+		r.UID, r.Role = node.UID, node.Role
+		r.Action, r.Error = s.fillFormField(ctx, node, f.Value)
+		r.OK = r.Error == ""
+		lastBackendID = node.BackendNodeID
+	}
+
+	for i := range res.Fields {
+		r := &res.Fields[i]
+		if backendID, ok := s.Page.BackendNodeID(r.UID); ok && r.Role != "" {
+			var v struct {
+				Valid   bool   `json:"valid"`
+				Message string `json:"message"`
+			}
+			if err := s.callOnNode(ctx, backendID, fieldValidityJSFn, &v); err == nil {
+				r.Invalid, r.Validation = !v.Valid, v.Message
+			}
+		}
+	}
+
+	if submit.Enabled {
+		if err := s.submitForm(ctx, submit.UID, lastBackendID); err != nil {
+			res.SubmitError = err.Error()
+		} else {
+			res.Submitted = true
+			// Give client-side validation and XHR-driven error banners a
+			// moment to render.
+			select {
+			case <-time.After(time.Second):
+			case <-ctx.Done():
+			}
+		}
+	}
+	res.Messages = s.pageAlerts(ctx)
+	return res, nil
+}
+
+// findFormNode resolves a field by uid, else by accessible name: an exact
+// (case-insensitive) match first, then the only name containing it. A label
+// several names contain is an error rather than a guess, so "Name" can't
+// fill "Last name" in place of "First name".
+func findFormNode(nodes []InteractiveNode, f FormField) (InteractiveNode, error) {
+	if f.UID != "" {
+		for _, n := range nodes {
+			if n.UID == f.UID {
+				return n, nil
+			}
+		}
+		return InteractiveNode{}, fmt.Errorf("no element with uid %s in the snapshot", f.UID)
+	}
+	if f.Label == "" {
+		return InteractiveNode{}, fmt.Errorf("uid or label is required")
+	}
+	label := strings.ToLower(strings.TrimSpace(f.Label))
+	for _, n := range nodes {
+		if strings.ToLower(strings.TrimSpace(n.Name)) == label {
+			return n, nil
+		}
+	}
+	var matches []InteractiveNode
+	for _, n := range nodes {
+		if strings.Contains(strings.ToLower(n.Name), label) {
+			matches = append(matches, n)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return InteractiveNode{}, fmt.Errorf("no field labelled %q in the snapshot", f.Label)
+	case 1:
+		return matches[0], nil
+	}
+	names := make([]string, len(matches))
+	for i, n := range matches {
+		names[i] = fmt.Sprintf("id=%s %q", n.UID, n.Name)
+	}
+	return InteractiveNode{}, fmt.Errorf("label %q matches several fields (%s); give the full label or a uid", f.Label, strings.Join(names, ", "))
+}
+
+// fillFormField applies value to one element. Returns the action taken and
+// an error message, empty on success.
+func (s *Session) fillFormField(ctx context.Context, node InteractiveNode, value string) (string, string) {
+	meta := s.Page.collectCompoundMeta(ctx, s, []int64{node.BackendNodeID})[node.BackendNodeID]
+	sel := Selector{Type: AntibotSelectorTypeAXNodeID, Query: node.UID}
+
+	switch {
+	case meta.Type == "file":
+		files, err := s.uploadFiles(value)
+		if err != nil {
+			return "upload", err.Error()
+		}
+		var ok bool
+		if err := s.callOnNode(ctx, node.BackendNodeID, setFilesJSFn, &ok, files); err != nil {
+			return "upload", err.Error()
+		}
+		if !ok {
+			return "upload", "the element doesn't accept files"
+		}
+		return "upload", ""
+
+	case meta.Type == "select" || node.Role == "listbox":
+		var out struct {
+			OK    bool   `json:"ok"`
+			Error string `json:"error"`
+		}
+		if err := s.callOnNode(ctx, node.BackendNodeID, selectOptionJSFn, &out, value); err != nil {
+			return "select", err.Error()
+		}
+		return "select", out.Error
+
+	case node.Role == "checkbox" || node.Role == "radio" || node.Role == "switch" ||
+		meta.Type == "checkbox" || meta.Type == "radio":
+		want, err := parseFormBool(value)
+		if err != nil {
+			return "check", err.Error()
+		}
+		action := "check"
+		if !want {
+			action = "uncheck"
+		}
+		var checked bool
+		if err := s.callOnNode(ctx, node.BackendNodeID, checkedJSFn, &checked); err != nil {
+			return action, err.Error()
+		}
+		if checked == want {
+			return action, ""
+		}
+		if node.Role == "radio" && !want {
+			return action, "a radio button can't be unchecked; check another option instead"
+		}
+		r, err := s.Click(ctx, sel)
+		if err != nil {
+			return action, err.Error()
+		}
+		if !r.Success {
+			return action, r.ErrorMessage
+		}
+		return action, ""
+
+	case valueSetTypes[meta.Type]:
+		var ok bool
+		if err := s.callOnNode(ctx, node.BackendNodeID, setValueJSFn, &ok, value); err != nil {
+			return "set", err.Error()
+		}
+		if !ok {
+			return "set", fmt.Sprintf("the %s input rejected %q", meta.Type, value)
+		}
+		return "set", ""
+	}
+
+	r, err := s.Fill(ctx, sel, value, true)
+	if err != nil {
+		return "fill", err.Error()
+	}
+	if !r.Success {
+		return "fill", r.ErrorMessage
+	}
+	return "fill", ""
+}
+
+// maxUploadBytes bounds the files one field uploads; they travel through
+// the CDP socket as base64.
+const maxUploadBytes = 10 << 20
+
+// uploadFile is a file handed to setFilesJSFn.
+type uploadFile struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Data string `json:"data"` // base64
+}
+
+// uploadFiles reads the "|"-separated files of value from the session's
+// download directory. The browser runs remotely, so files are sent with
+// the command rather than referenced by path, and only that directory is
+// readable: a page must not be able to get arbitrary server files, nor
+// another API key's.
+func (s *Session) uploadFiles(value string) ([]uploadFile, error) {
+	if DownloadDir() == "" {
+		return nil, fmt.Errorf("file uploads need a download directory (set -download-dir or SCRAPFLY_DOWNLOAD_DIR)")
+	}
+	dir, err := s.DownloadDir()
+	if err != nil {
+		return nil, err
+	}
+	var files []uploadFile
+	total := 0
+	for _, name := range strings.Split(value, "|") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		path := name
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		rel, err := filepath.Rel(dir, filepath.Clean(path))
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("%s is outside the download directory", name)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if total += len(data); total > maxUploadBytes {
+			return nil, fmt.Errorf("files exceed %d MiB", maxUploadBytes>>20)
+		}
+		files = append(files, uploadFile{
+			Name: filepath.Base(path),
+			Type: DetectMIMEType(path, data),
+			Data: base64.StdEncoding.EncodeToString(data),
+		})
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no file given")
+	}
+	return files, nil
+}
+
+// submitForm clicks submitUID, or submits the form of the element
+// lastBackendID (requestSubmit, so the page's submit handlers and
+// constraint validation run).
+func (s *Session) submitForm(ctx context.Context, submitUID string, lastBackendID int64) error {
+	if submitUID != "" {
+		r, err := s.Click(ctx, Selector{Type: AntibotSelectorTypeAXNodeID, Query: submitUID})
+		if err != nil {
+			return err
+		}
+		if !r.Success {
+			return fmt.Errorf("%s", r.ErrorMessage)
+		}
+		return nil
+	}
+	if lastBackendID == 0 {
+		return fmt.Errorf("no field was found to submit the form of")
+	}
+	var submitted bool
+	if err := s.callOnNode(ctx, lastBackendID, requestSubmitJSFn, &submitted); err != nil {
+		return err
+	}
+	if !submitted {
+		return fmt.Errorf("the fields aren't inside a <form>; pass the submit button's uid")
+	}
+	return nil
+}
+
+// pageAlerts returns the text of the page's alert and live regions.
+func (s *Session) pageAlerts(ctx context.Context) []string {
+	raw, err := s.SendCDPCtx(ctx, "Runtime.evaluate", map[string]any{
+		"expression":    pageAlertsJS,
+		"returnByValue": true,
+	})
+	if err != nil {
+		return nil
+	}
+	var res struct {
+		Result struct {
+			Value []string `json:"value"`
+		} `json:"result"`
+	}
+	json.Unmarshal(raw, &res)
+	return res.Result.Value
+}
+
+// callOnNode runs fn with this = the node and decodes its return value
+// into out.
+func (s *Session) callOnNode(ctx context.Context, backendNodeID int64, fn string, out any, args ...any) error {
+	raw, err := s.SendCDPCtx(ctx, "DOM.resolveNode", map[string]any{"backendNodeId": backendNodeID})
+	if err != nil {
+		return err
+	}
+	var resolved struct {
+		Object struct {
+			ObjectID string `json:"objectId"`
+		} `json:"object"`
+	}
+	if err := json.Unmarshal(raw, &resolved); err != nil || resolved.Object.ObjectID == "" {
+		return fmt.Errorf("element is no longer in the page")
+	}
+	callArgs := make([]map[string]any, len(args))
+	for i, a := range args {
+		callArgs[i] = map[string]any{"value": a}
+	}
+	raw, err = s.SendCDPCtx(ctx, "Runtime.callFunctionOn", map[string]any{
+		"objectId":            resolved.Object.ObjectID,
+		"functionDeclaration": fn,
+		"arguments":           callArgs,
+		"returnByValue":       true,
+		"silent":              true,
+	})
+	if err != nil {
+		return err
+	}
+	var res struct {
+		Result struct {
+			Value json.RawMessage `json:"value"`
+		} `json:"result"`
+		ExceptionDetails *struct {
+			Text string `json:"text"`
+		} `json:"exceptionDetails"`
+	}
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return err
+	}
+	if res.ExceptionDetails != nil {
+		return fmt.Errorf("%s", res.ExceptionDetails.Text)
+	}
+	if len(res.Result.Value) == 0 {
+		return nil
+	}
+	return json.Unmarshal(res.Result.Value, out)
+}
+
+// parseFormBool reads a checkbox value. Anything else is an error rather
+// than a check, so a value meant for another field doesn't tick a box.
+func parseFormBool(v string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "true", "1", "yes", "on", "checked", "check":
+		return true, nil
+	case "false", "0", "no", "off", "unchecked", "uncheck":
+		return false, nil
+	}
+	return false, fmt.Errorf("%q is not a checkbox value (use true or false)", v)
+}
+
+// selectOptionJSFn selects options by value, then by visible text. A
+// multiple select takes several values separated by "|".
+const selectOptionJSFn = `function(v){
+  const opts = Array.from(this.options || this.querySelectorAll('[role=option]'));
+  const find = (want) => {
+    const w = want.trim(), lw = w.toLowerCase();
+    return opts.find(o => (o.value ?? o.getAttribute('data-value')) === w) ||
+      opts.find(o => (o.text ?? o.textContent).trim() === w) ||
+      opts.find(o => (o.text ?? o.textContent).trim().toLowerCase() === lw);
+  };
+  const wants = this.multiple ? v.split('|') : [v];
+  const picked = [];
+  for (const w of wants) {
+    const o = find(w);
+    if (!o) return {ok:false, error:'no option matches ' + JSON.stringify(w)};
+    picked.push(o);
+  }
+  if (this.tagName === 'SELECT') {
+    for (const o of opts) o.selected = picked.includes(o);
+    this.dispatchEvent(new Event('input', {bubbles:true}));
+    this.dispatchEvent(new Event('change', {bubbles:true}));
+  } else {
+    for (const o of picked) o.click();
+  }
+  return {ok:true};
+}`
+
+// setFilesJSFn puts files on a file input through a DataTransfer, as a drop
+// would, and fires the events pages listen for.
+const setFilesJSFn = `function(files){
+  if (!(this instanceof HTMLInputElement) || this.type !== 'file') return false;
+  const dt = new DataTransfer();
+  for (const f of files) {
+    const bin = atob(f.data), bytes = new Uint8Array(bin.length);
+    for (let i = 0; i < bin.length; i++) bytes[i] = bin.charCodeAt(i);
+    dt.items.add(new File([bytes], f.name, {type: f.type}));
+  }
+  this.files = dt.files;
+  this.dispatchEvent(new Event('input', {bubbles:true}));
+  this.dispatchEvent(new Event('change', {bubbles:true}));
+  return true;
+}`
+
+const checkedJSFn = `function(){
+  if (typeof this.checked === 'boolean') return this.checked;
+  return this.getAttribute('aria-checked') === 'true';
+}`
+
+// setValueJSFn sets a value through the native setter, so frameworks that
+// track the input's value (React) see the change.
+const setValueJSFn = `function(v){
+  const proto = Object.getPrototypeOf(this);
+  const desc = Object.getOwnPropertyDescriptor(proto, 'value');
+  if (desc && desc.set) desc.set.call(this, v); else this.value = v;
+  this.dispatchEvent(new Event('input', {bubbles:true}));
+  this.dispatchEvent(new Event('change', {bubbles:true}));
+  return this.value === v || (this.type === 'range' && String(this.value) !== '');
+}`
+
+const requestSubmitJSFn = `function(){
+  const form = this.form || this.closest('form');
+  if (!form) return false;
+  if (form.requestSubmit) form.requestSubmit(); else form.submit();
+  return true;
+}`
+
+// fieldValidityJSFn reports constraint validation plus ARIA error wiring
+// (aria-invalid, aria-errormessage, aria-describedby).
+const fieldValidityJSFn = `function(){
+  const out = {valid:true, message:''};
+  if (this.validity) { out.valid = this.validity.valid; out.message = this.validationMessage || ''; }
+  if (this.getAttribute('aria-invalid') === 'true') out.valid = false;
+  if (!out.valid) {
+    const ids = ((this.getAttribute('aria-errormessage') || '') + ' ' + (this.getAttribute('aria-describedby') || '')).trim().split(/\s+/).filter(Boolean);
+    for (const id of ids) {
+      const el = document.getElementById(id);
+      const t = el && (el.innerText || '').trim();
+      if (t) { out.message = t; break; }
+    }
+  }
+  return out;
+}`
+
+const pageAlertsJS = `(() => {
+  const seen = new Set(), out = [];
+  for (const el of document.querySelectorAll('[role=alert],[aria-live=assertive],[aria-live=polite]')) {
+    const t = (el.innerText || '').trim().replace(/\s+/g, ' ').slice(0, 200);
+    if (t && !seen.has(t)) { seen.add(t); out.push(t); }
+    if (out.length >= 10) break;
+  }
+  return out;
+})()`
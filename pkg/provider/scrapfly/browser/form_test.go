@@ -0,0 +1,109 @@
+package browser_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser"
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser/browsertest"
+)
+
+func TestFindFormNode(t *testing.T) {
+	nodes := []browser.InteractiveNode{
+		{UID: "1_1", Role: "textbox", Name: "First name"},
+		{UID: "1_2", Role: "textbox", Name: "Last name"},
+		{UID: "1_3", Role: "textbox", Name: "Email"},
+		{UID: "1_4", Role: "textbox", Name: "Email address confirmation"},
+	}
+	for _, tt := range []struct {
+		field   browser.FormField
+		wantUID string
+		wantErr string
+	}{
+		{field: browser.FormField{UID: "1_2"}, wantUID: "1_2"},
+		{field: browser.FormField{UID: "9_9"}, wantErr: "no element with uid"},
+		{field: browser.FormField{Label: " email "}, wantUID: "1_3"}, // exact beats containing
+		{field: browser.FormField{Label: "last"}, wantUID: "1_2"},    // the only name containing it
+		{field: browser.FormField{Label: "name"}, wantErr: "matches several fields"},
+		{field: browser.FormField{Label: "phone"}, wantErr: "no field labelled"},
+		{field: browser.FormField{}, wantErr: "uid or label is required"},
+	} {
+		n, err := browser.FindFormNode(nodes, tt.field)
+		switch {
+		case tt.wantErr != "":
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("findFormNode(%+v) = %s, %v; want error %q", tt.field, n.UID, err, tt.wantErr)
+			}
+		case err != nil || n.UID != tt.wantUID:
+			t.Errorf("findFormNode(%+v) = %s, %v; want %s", tt.field, n.UID, err, tt.wantUID)
+		}
+	}
+}
+
+func TestParseFormBool(t *testing.T) {
+	for v, want := range map[string]bool{
+		"true": true, " Yes ": true, "1": true, "on": true, "checked": true,
+		"false": false, "NO": false, "0": false, "off": false, "uncheck": false,
+	} {
+		if got, err := browser.ParseFormBool(v); err != nil || got != want {
+			t.Errorf("parseFormBool(%q) = %t, %v; want %t", v, got, err, want)
+		}
+	}
+	for _, v := range []string{"", "maybe", "jane@example.com"} {
+		if _, err := browser.ParseFormBool(v); err == nil {
+			t.Errorf("parseFormBool(%q) succeeded, want an error", v)
+		}
+	}
+}
+
+func TestFillForm(t *testing.T) {
+	srv := browsertest.NewServer()
+	defer srv.Close()
+	srv.SetAXTree(
+		browsertest.AXNode{ID: "email", Role: "textbox", Name: "Email", BackendNodeID: 10},
+		browsertest.AXNode{ID: "first", Role: "textbox", Name: "First name", BackendNodeID: 11},
+		browsertest.AXNode{ID: "last", Role: "textbox", Name: "Last name", BackendNodeID: 12},
+		browsertest.AXNode{ID: "news", Role: "checkbox", Name: "Subscribe to news", BackendNodeID: 13},
+	)
+	srv.Eval("typeof this.checked === 'boolean'", false)
+	srv.Eval("const out = {valid:true", map[string]any{"valid": true, "message": ""})
+	session := connect(t, srv)
+
+	res, err := session.FillForm(context.Background(), []browser.FormField{
+		{Label: "email", Value: "jo@example.com"},
+		{Label: "subscribe", Value: "yes"},
+		{Label: "name", Value: "Jo"},
+		{UID: "news", Value: "maybe"},
+	}, browser.FormSubmit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		uid, action string
+		ok          bool
+		err         string
+	}{
+		{"email", "fill", true, ""},
+		{"news", "check", true, ""},
+		{"", "", false, "matches several fields"},
+		{"news", "check", false, "not a checkbox value"},
+	}
+	for i, w := range want {
+		got := res.Fields[i]
+		if got.UID != w.uid || got.Action != w.action || got.OK != w.ok || !strings.Contains(got.Error, w.err) {
+			t.Errorf("field %d = %+v, want uid %q action %q ok %t error %q", i, got, w.uid, w.action, w.ok, w.err)
+		}
+	}
+	if n, _ := srv.Node("email"); n.Value != "jo@example.com" {
+		t.Errorf("email field holds %q", n.Value)
+	}
+	if n := len(srv.Calls(browser.CommandAntibotClickOn)); n != 1 {
+		t.Errorf("clicked %d times, want once, to check the box", n)
+	}
+	for _, n := range []string{"first", "last"} {
+		if node, _ := srv.Node(n); node.Value != "" {
+			t.Errorf("an ambiguous label filled %s", n)
+		}
+	}
+}
@@ -0,0 +1,461 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Harvesting.
+//
+// Harvest collects the items of a listing across pages without a tool call
+// per scroll or click: it reads every element matching a selector, then
+// either scrolls to the bottom (infinite scroll) or clicks a "next"
+// control, and repeats until it runs out of pages, items or time.
+
+// Harvest strategies.
+const (
+	HarvestScroll = "scroll"
+	HarvestNext   = "next"
+)
+
+// Why a harvest stopped.
+const (
+	HarvestStopMaxItems   = "max_items"
+	HarvestStopMaxBytes   = "max_bytes"
+	HarvestStopMaxPages   = "max_pages"
+	HarvestStopTimeBudget = "time_budget"
+	HarvestStopEndOfList  = "end_of_list" // scrolling loaded nothing new
+	HarvestStopNoNext     = "no_next"     // the next control is gone or disabled
+	HarvestStopNoChange   = "next_no_change"
+)
+
+// Harvest timing: how long to wait for a page to change after a scroll or
+// click, and for the network to settle afterwards.
+const (
+	harvestScrollWait = 5 * time.Second
+	harvestNextWait   = 10 * time.Second
+	harvestSettle     = 3 * time.Second
+	harvestIdle       = 500 * time.Millisecond
+	// Consecutive scrolls that load nothing before giving up.
+	harvestMaxStalls = 2
+	// Elements read per page, so a runaway selector can't return the DOM.
+	harvestMaxRead = 2000
+)
+
+// HarvestNextControl locates the "next page" control: a snapshot uid, its
+// text, or a CSS selector. A uid only identifies the control on the first
+// page; it is then found again by its text.
+type HarvestNextControl struct {
+	UID      string
+	Text     string
+	Selector string
+}
+
+// HarvestOptions configures Harvest.
+type HarvestOptions struct {
+	ItemSelector string // CSS selector of one item
+	Strategy     string // HarvestScroll or HarvestNext
+	Next         HarvestNextControl
+	// KeyAttr is the attribute, on the item or inside it, that identifies
+	// an item across pages. Without it items are keyed by their first
+	// link, then by their text.
+	KeyAttr string
+	// Attributes to collect from each item. Empty collects all of them.
+	Attributes []string
+	MaxItems   int
+	MaxPages   int
+	// MaxBytes bounds the JSON size of the collected items.
+	MaxBytes int
+	Budget   time.Duration
+	// OnPage is called after each page is read.
+	OnPage func(HarvestPage)
+}
+
+// HarvestItem is one collected item.
+type HarvestItem struct {
+	Key   string            `json:"key"`
+	Text  string            `json:"text"`
+	Link  string            `json:"link,omitempty"`
+	Attrs map[string]string `json:"attrs,omitempty"`
+	Page  int               `json:"page"`
+}
+
+// HarvestPage reports the progress of a harvest.
+type HarvestPage struct {
+	Page     int
+	NewItems int
+	Total    int
+}
+
+// HarvestResult is what Harvest collected, and why it stopped.
+type HarvestResult struct {
+	URL        string        `json:"url"`
+	Items      []HarvestItem `json:"items"`
+	Pages      int           `json:"pages"`
+	StopReason string        `json:"stop_reason"`
+	Seconds    float64       `json:"elapsed_seconds"`
+}
+
+// Harvest collects items page by page until a limit is reached or the
+// listing ends. Items are deduplicated across pages.
+func (s *Session) Harvest(ctx context.Context, opts HarvestOptions) (*HarvestResult, error) {
+	if opts.ItemSelector == "" {
+		return nil, fmt.Errorf("item selector is required")
+	}
+	switch opts.Strategy {
+	case HarvestScroll:
+	case HarvestNext:
+		if opts.Next == (HarvestNextControl{}) {
+			return nil, fmt.Errorf("the next strategy needs a next control: uid, text or selector")
+		}
+	default:
+		return nil, fmt.Errorf("unknown strategy %q (use %s or %s)", opts.Strategy, HarvestScroll, HarvestNext)
+	}
+	start := time.Now()
+	if opts.Budget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Budget)
+		defer cancel()
+	}
+
+	next := opts.Next
+	if next.UID != "" && next.Text == "" {
+		// Snapshot uids don't survive navigation: remember the text.
+		text, err := s.uidText(ctx, next.UID)
+		if err != nil {
+			return nil, fmt.Errorf("next control: %w", err)
+		}
+		next.Text = text
+	}
+
+	res := &HarvestResult{Items: []HarvestItem{}}
+	seen := map[string]bool{}
+	size := 0
+	full := func() bool {
+		return (opts.MaxItems > 0 && len(res.Items) >= opts.MaxItems) || (opts.MaxBytes > 0 && size >= opts.MaxBytes)
+	}
+	take := func(items []HarvestItem, page int) int {
+		added := 0
+		for _, it := range items {
+			key := it.Key
+			if key == "" {
+				key = it.Link
+			}
+			if key == "" {
+				key = it.Text
+			}
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			it.Key, it.Page = key, page
+			if opts.MaxBytes > 0 {
+				b, _ := json.Marshal(it)
+				if size += len(b); size > opts.MaxBytes {
+					break
+				}
+			}
+			res.Items = append(res.Items, it)
+			added++
+			if full() {
+				break
+			}
+		}
+		return added
+	}
+
+	stalls := 0
+	// Items of the page read so far. A scroll keeps the items above, so
+	// the next read starts after them rather than at the top.
+	offset := 0
+	for page := 1; ; page++ {
+		first, read, added, err := s.readNewHarvestItems(ctx, opts, &offset, func(items []HarvestItem) int { return take(items, page) }, full)
+		if err != nil {
+			if ctx.Err() != nil {
+				res.StopReason = HarvestStopTimeBudget
+				break
+			}
+			return nil, err
+		}
+		res.Pages = page
+		if opts.OnPage != nil {
+			opts.OnPage(HarvestPage{Page: page, NewItems: added, Total: len(res.Items)})
+		}
+
+		switch {
+		case opts.MaxItems > 0 && len(res.Items) >= opts.MaxItems:
+			res.StopReason = HarvestStopMaxItems
+		case opts.MaxBytes > 0 && size >= opts.MaxBytes:
+			res.StopReason = HarvestStopMaxBytes
+		case opts.MaxPages > 0 && page >= opts.MaxPages:
+			res.StopReason = HarvestStopMaxPages
+		case ctx.Err() != nil:
+			res.StopReason = HarvestStopTimeBudget
+		}
+		if res.StopReason != "" {
+			break
+		}
+
+		if opts.Strategy == HarvestScroll {
+			grew, err := s.harvestScroll(ctx, opts.ItemSelector, read.Count)
+			if err != nil {
+				return nil, err
+			}
+			if grew || added > 0 {
+				stalls = 0
+			} else if stalls++; stalls >= harvestMaxStalls {
+				res.StopReason = HarvestStopEndOfList
+				break
+			}
+		} else {
+			reason, err := s.harvestNext(ctx, opts.ItemSelector, next, first)
+			if err != nil {
+				return nil, err
+			}
+			if reason != "" {
+				res.StopReason = reason
+				break
+			}
+			offset = 0
+		}
+		if ctx.Err() != nil {
+			res.StopReason = HarvestStopTimeBudget
+			break
+		}
+	}
+	if ctx.Err() != nil && res.StopReason == "" {
+		res.StopReason = HarvestStopTimeBudget
+	}
+	// The budget may be spent: read the URL on the caller's clock.
+	res.URL, _ = s.evalString(context.WithoutCancel(ctx), "location.href")
+	res.Seconds = time.Since(start).Seconds()
+	return res, nil
+}
+
+// harvestRead is one read of the items in the page.
+type harvestRead struct {
+	Count int           `json:"count"`
+	Items []HarvestItem `json:"items"`
+}
+
+// signature identifies what the page shows, to tell when a click changed
+// it. It ignores keys and attributes, which the polling reads skip.
+func (r *harvestRead) signature() string {
+	if len(r.Items) == 0 {
+		return "0"
+	}
+	first := r.Items[0]
+	return fmt.Sprintf("%d|%s|%s", r.Count, first.Link, first.Text)
+}
+
+// readNewHarvestItems reads the page's items from *offset on, harvestMaxRead
+// at a time, handing each batch to take until the page is read or full
+// reports true. It returns the page's first batch (for harvestNext), the
+// last one (for its count) and the number of items taken.
+func (s *Session) readNewHarvestItems(ctx context.Context, opts HarvestOptions, offset *int, take func([]HarvestItem) int, full func() bool) (first, last *harvestRead, added int, err error) {
+	rewound := false
+	for {
+		read, err := s.readHarvestItems(ctx, opts, *offset)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		if *offset > 0 && len(read.Items) == 0 {
+			// Nothing past what was read: the list shrank or was replaced
+			// in place (virtualized lists). Read its top again, once.
+			*offset, rewound = 0, true
+			continue
+		}
+		if first == nil {
+			first = read
+		}
+		last = read
+		added += take(read.Items)
+		*offset += len(read.Items)
+		if rewound || *offset >= read.Count || full() {
+			return first, last, added, nil
+		}
+	}
+}
+
+func (s *Session) readHarvestItems(ctx context.Context, opts HarvestOptions, offset int) (*harvestRead, error) {
+	attrs := opts.Attributes
+	if attrs == nil {
+		attrs = []string{}
+	}
+	expr := fmt.Sprintf("(%s)(%s, %s, %s, %d, %d)", harvestItemsJSFn,
+		jsString(opts.ItemSelector), jsString(opts.KeyAttr), jsonArg(attrs), offset, harvestMaxRead)
+	var read harvestRead
+	if err := s.evalValue(ctx, expr, &read); err != nil {
+		return nil, fmt.Errorf("reading items: %w", err)
+	}
+	return &read, nil
+}
+
+// harvestScroll scrolls to the bottom and waits for more items. Reports
+// whether the item count grew.
+func (s *Session) harvestScroll(ctx context.Context, itemSelector string, before int) (bool, error) {
+	if _, err := s.Scroll(ctx, &Selector{Type: AntibotSelectorTypeBottom}, 0, 0); err != nil {
+		if ctx.Err() != nil {
+			return false, nil
+		}
+		return false, fmt.Errorf("scroll: %w", err)
+	}
+	countExpr := fmt.Sprintf("document.querySelectorAll(%s).length", jsString(itemSelector))
+	grew := s.pollUntil(ctx, harvestScrollWait, func() bool {
+		var n int
+		return s.evalValue(ctx, countExpr, &n) == nil && n > before
+	})
+	if grew {
+		s.settle(ctx)
+	}
+	return grew, nil
+}
+
+// harvestNext clicks the next control and waits for the page to change.
+// Returns a stop reason when there is no next page.
+func (s *Session) harvestNext(ctx context.Context, itemSelector string, next HarvestNextControl, current *harvestRead) (string, error) {
+	var found struct {
+		Found    bool `json:"found"`
+		Disabled bool `json:"disabled"`
+	}
+	expr := fmt.Sprintf("(%s)(%s, %s)", markNextJSFn, jsString(next.Selector), jsString(next.Text))
+	if err := s.evalValue(ctx, expr, &found); err != nil {
+		return "", fmt.Errorf("next control: %w", err)
+	}
+	if !found.Found || found.Disabled {
+		return HarvestStopNoNext, nil
+	}
+	beforeURL, _ := s.evalString(ctx, "location.href")
+	r, err := s.Click(ctx, Selector{Type: AntibotSelectorTypeCSS, Query: "[" + harvestNextAttr + "]"})
+	if err != nil {
+		if ctx.Err() != nil {
+			return HarvestStopTimeBudget, nil
+		}
+		return "", fmt.Errorf("clicking next: %w", err)
+	}
+	if !r.Success {
+		return "", fmt.Errorf("clicking next: %s", r.ErrorMessage)
+	}
+	before := current.signature()
+	changed := s.pollUntil(ctx, harvestNextWait, func() bool {
+		if url, err := s.evalString(ctx, "location.href"); err == nil && url != beforeURL {
+			return true
+		}
+		read, err := s.readHarvestItems(ctx, HarvestOptions{ItemSelector: itemSelector, Attributes: []string{"-"}}, 0)
+		return err == nil && read.signature() != before
+	})
+	if !changed {
+		if ctx.Err() != nil {
+			return HarvestStopTimeBudget, nil
+		}
+		return HarvestStopNoChange, nil
+	}
+	s.settle(ctx)
+	return "", nil
+}
+
+// pollUntil calls cond every waitPollInterval until it holds, ctx is done
+// or timeout passes.
+func (s *Session) pollUntil(ctx context.Context, timeout time.Duration, cond func() bool) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		if cond() {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(waitPollInterval):
+		}
+	}
+}
+
+// settle waits briefly for the network to go idle, so a page's items have
+// all arrived before they are read.
+func (s *Session) settle(ctx context.Context) {
+	s.pollUntil(ctx, harvestSettle, func() bool { return s.NetworkIdleFor() >= harvestIdle })
+}
+
+// uidText returns the text of the element with the given snapshot uid.
+func (s *Session) uidText(ctx context.Context, uid string) (string, error) {
+	backendID, ok := s.Page.BackendNodeID(uid)
+	if !ok {
+		s.Page.Refresh(ctx, s)
+		if backendID, ok = s.Page.BackendNodeID(uid); !ok {
+			return "", fmt.Errorf("no element with uid %s. Take a fresh snapshot", uid)
+		}
+	}
+	var text string
+	if err := s.callOnNode(ctx, backendID, `function() { return (this.innerText || this.value || this.getAttribute('aria-label') || '').trim(); }`, &text); err != nil {
+		return "", err
+	}
+	if text == "" {
+		return "", fmt.Errorf("element %s has no text to find it by on later pages; give a selector", uid)
+	}
+	return text, nil
+}
+
+func jsonArg(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// harvestItemsJSFn reads up to limit items matching sel, from offset on:
+// their text, first link, attributes (all of them unless attrs lists some;
+// ["-"] reads none) and key attribute, found on the item or inside it.
+const harvestItemsJSFn = `(sel, keyAttr, attrs, offset, limit) => {
+  const all = document.querySelectorAll(sel);
+  const items = [];
+  for (const el of Array.from(all).slice(offset, offset + limit)) {
+    const a = {};
+    const names = attrs.length ? attrs : Array.from(el.attributes, x => x.name)
+      .filter(n => n !== 'style' && !n.startsWith('on')).slice(0, 20);
+    for (const n of names) {
+      const v = n === '-' ? null : el.getAttribute(n);
+      if (v !== null) a[n] = v.slice(0, 500);
+    }
+    const link = el.matches('a[href]') ? el : el.querySelector('a[href]');
+    let key = '';
+    if (keyAttr) {
+      const holder = el.hasAttribute(keyAttr) ? el : el.querySelector('[' + CSS.escape(keyAttr) + ']');
+      key = holder ? holder.getAttribute(keyAttr) || '' : '';
+    }
+    items.push({
+      key,
+      text: (el.innerText || el.textContent || '').replace(/\s+/g, ' ').trim().slice(0, 1000),
+      link: link ? link.href : '',
+      attrs: a,
+    });
+  }
+  return {count: all.length, items};
+}`
+
+// harvestNextAttr marks the next control for the CSS click selector.
+const harvestNextAttr = "data-scrapfly-harvest-next"
+
+// markNextJSFn finds the next control by selector or text (exact, then
+// contained, among links and buttons), marks it with harvestNextAttr and
+// reports whether it is disabled.
+const markNextJSFn = `(sel, text) => {
+  document.querySelectorAll('[` + harvestNextAttr + `]').forEach(e => e.removeAttribute('` + harvestNextAttr + `'));
+  const visible = e => { const r = e.getBoundingClientRect(); return r.width > 0 && r.height > 0; };
+  let el = null;
+  if (sel) {
+    el = Array.from(document.querySelectorAll(sel)).find(visible) || null;
+  } else {
+    const want = text.replace(/\s+/g, ' ').trim().toLowerCase();
+    const cands = Array.from(document.querySelectorAll('a, button, [role=button], [role=link], input[type=submit], input[type=button]')).filter(visible);
+    const label = e => (e.innerText || e.value || e.getAttribute('aria-label') || e.title || '').replace(/\s+/g, ' ').trim().toLowerCase();
+    el = cands.find(e => label(e) === want) || cands.find(e => label(e).includes(want)) || null;
+  }
+  if (!el) return {found: false, disabled: false};
+  el.setAttribute('` + harvestNextAttr + `', '');
+  const disabled = el.disabled === true || el.getAttribute('aria-disabled') === 'true' ||
+    /(^|\s)disabled(\s|$)/.test(typeof el.className === 'string' ? el.className : '');
+  return {found: true, disabled};
+}`
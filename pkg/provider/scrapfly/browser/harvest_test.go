@@ -0,0 +1,103 @@
+package browser_test
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser"
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser/browsertest"
+)
+
+var harvestWindow = regexp.MustCompile(`, (\d+), (\d+)\)$`)
+
+// infiniteList serves a listing of total items, shown loaded at first and
+// perScroll more on each scroll. It returns the offsets items were read
+// from.
+func infiniteList(srv *browsertest.Server, total, shown, perScroll int) func() []int {
+	var mu sync.Mutex
+	var offsets []int
+	srv.Handle(browser.CommandAntibotScroll, func(*browsertest.Call) (any, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		shown = min(shown+perScroll, total)
+		return map[string]any{"success": true}, nil
+	})
+	srv.Handle("Runtime.evaluate", func(c *browsertest.Call) (any, error) {
+		var p struct {
+			Expression string `json:"expression"`
+		}
+		c.Decode(&p)
+		mu.Lock()
+		defer mu.Unlock()
+		var value any = "https://shop.example/list"
+		switch m := harvestWindow.FindStringSubmatch(p.Expression); {
+		case m != nil:
+			offset, _ := strconv.Atoi(m[1])
+			limit, _ := strconv.Atoi(m[2])
+			offsets = append(offsets, offset)
+			items := []map[string]any{}
+			for i := offset; i < min(offset+limit, shown); i++ {
+				items = append(items, map[string]any{"text": fmt.Sprintf("Item %d", i), "link": fmt.Sprintf("https://shop.example/p/%d", i)})
+			}
+			value = map[string]any{"count": shown, "items": items}
+		case strings.HasSuffix(p.Expression, ".length"):
+			value = shown
+		}
+		return map[string]any{"result": map[string]any{"type": "object", "value": value}}, nil
+	})
+	return func() []int {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]int(nil), offsets...)
+	}
+}
+
+func TestHarvestScrollReadsPastFirstWindow(t *testing.T) {
+	srv := browsertest.NewServer()
+	defer srv.Close()
+	offsets := infiniteList(srv, 5000, 2500, 1000)
+	session := connect(t, srv)
+
+	res, err := session.Harvest(context.Background(), browser.HarvestOptions{
+		ItemSelector: ".item", Strategy: browser.HarvestScroll, MaxItems: 5000, MaxPages: 10, Budget: 10 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Items) != 5000 {
+		t.Errorf("harvested %d items (stopped: %s), want 5000", len(res.Items), res.StopReason)
+	}
+	// After a scroll, reading resumes where the last read ended.
+	fromTop := 0
+	for _, o := range offsets() {
+		if o == 0 {
+			fromTop++
+		}
+	}
+	if fromTop != 1 {
+		t.Errorf("read the list from the top %d times (offsets %v), want once", fromTop, offsets())
+	}
+}
+
+func TestHarvestStopsAtMaxBytes(t *testing.T) {
+	srv := browsertest.NewServer()
+	defer srv.Close()
+	infiniteList(srv, 100, 100, 0)
+	session := connect(t, srv)
+
+	res, err := session.Harvest(context.Background(), browser.HarvestOptions{
+		ItemSelector: ".item", Strategy: browser.HarvestScroll, MaxItems: 100, MaxBytes: 1000, Budget: 10 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StopReason != browser.HarvestStopMaxBytes || len(res.Items) == 0 || len(res.Items) >= 100 {
+		t.Errorf("harvested %d items, stopped: %s; want a few, stopped: %s", len(res.Items), res.StopReason, browser.HarvestStopMaxBytes)
+	}
+}
@@ -0,0 +1,46 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// OuterHTML returns the serialized HTML of the current document, or of the
+// element with the given snapshot uid when uid is set. It reflects the live
+// DOM, scripts' changes included, not the HTML the server sent.
+func (s *Session) OuterHTML(ctx context.Context, uid string) (string, error) {
+	params := map[string]any{}
+	if uid != "" {
+		backendID, ok := s.Page.BackendNodeID(uid)
+		if !ok {
+			return "", fmt.Errorf("no element with uid %s in the last snapshot. Take a fresh snapshot", uid)
+		}
+		params["backendNodeId"] = backendID
+	} else {
+		raw, err := s.SendCDPCtx(ctx, "DOM.getDocument", map[string]any{"depth": 0})
+		if err != nil {
+			return "", fmt.Errorf("DOM.getDocument: %w", err)
+		}
+		var doc struct {
+			Root struct {
+				NodeID int64 `json:"nodeId"`
+			} `json:"root"`
+		}
+		if err := json.Unmarshal(raw, &doc); err != nil {
+			return "", err
+		}
+		params["nodeId"] = doc.Root.NodeID
+	}
+	raw, err := s.SendCDPCtx(ctx, "DOM.getOuterHTML", params)
+	if err != nil {
+		return "", fmt.Errorf("DOM.getOuterHTML: %w", err)
+	}
+	var res struct {
+		OuterHTML string `json:"outerHTML"`
+	}
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return "", err
+	}
+	return res.OuterHTML, nil
+}
@@ -0,0 +1,219 @@
+package browser
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+)
+
+// Set-of-marks screenshots.
+//
+// Vision models locate elements more reliably on a screenshot with numbered
+// boxes than from a bare PNG next to the AX text. AnnotatedScreenshot boxes
+// every interactive element of the last snapshot in the viewport and labels
+// it with its uid, so "click 183" means the same thing in the image, the
+// snapshot and the click tools.
+
+// maxMarks bounds the DOM.getBoxModel round trips per screenshot.
+const maxMarks = 200
+
+// Mark is one labelled box: an interactive element and its viewport rect.
+type Mark struct {
+	UID  string `json:"uid"`
+	Role string `json:"role"`
+	Name string `json:"name,omitempty"`
+	Rect
+}
+
+// Center returns the middle of the mark, in CSS viewport pixels.
+func (m Mark) Center() (float64, float64) {
+	return m.X + m.Width/2, m.Y + m.Height/2
+}
+
+// markPalette cycles through colours distinct enough to tell neighbouring
+// boxes apart.
+var markPalette = []color.RGBA{
+	{0xe5, 0x1c, 0x23, 0xff},
+	{0x1e, 0x88, 0xe5, 0xff},
+	{0x43, 0xa0, 0x47, 0xff},
+	{0x8e, 0x24, 0xaa, 0xff},
+	{0xef, 0x6c, 0x00, 0xff},
+	{0x00, 0x83, 0x8f, 0xff},
+}
+
+// ResolveMarks returns the boxes of the last snapshot's interactive
+// elements that are visible in the viewport, in snapshot order.
+func (s *Session) ResolveMarks(ctx context.Context) ([]Mark, error) {
+	vw, vh, err := s.viewportSize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var marks []Mark
+	for _, n := range s.Page.InteractiveNodes() {
+		if len(marks) == maxMarks {
+			break
+		}
+		r, err := s.nodeRect(ctx, n.BackendNodeID)
+		if err != nil || r.Width < 1 || r.Height < 1 {
+			continue
+		}
+		if r.X+r.Width <= 0 || r.Y+r.Height <= 0 || r.X >= vw || r.Y >= vh {
+			continue
+		}
+		marks = append(marks, Mark{UID: n.UID, Role: n.Role, Name: n.Name, Rect: r})
+	}
+	return marks, nil
+}
+
+// MarkFor returns the current box of the element with the given snapshot uid.
+func (s *Session) MarkFor(ctx context.Context, uid string) (Mark, error) {
+	for _, n := range s.Page.InteractiveNodes() {
+		if n.UID != uid {
+			continue
+		}
+		r, err := s.nodeRect(ctx, n.BackendNodeID)
+		if err != nil {
+			return Mark{}, fmt.Errorf("element %s: %w", uid, err)
+		}
+		return Mark{UID: n.UID, Role: n.Role, Name: n.Name, Rect: r}, nil
+	}
+	return Mark{}, fmt.Errorf("no interactive element with uid %s in the last snapshot", uid)
+}
+
+// nodeRect is the border box of a node, in CSS viewport pixels.
+func (s *Session) nodeRect(ctx context.Context, backendNodeID int64) (Rect, error) {
+	raw, err := s.SendCDPCtx(ctx, "DOM.getBoxModel", map[string]any{"backendNodeId": backendNodeID})
+	if err != nil {
+		return Rect{}, err
+	}
+	var res struct {
+		Model struct {
+			Border []float64 `json:"border"`
+		} `json:"model"`
+	}
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return Rect{}, err
+	}
+	q := res.Model.Border
+	if len(q) != 8 {
+		return Rect{}, fmt.Errorf("node has no box")
+	}
+	minX, minY, maxX, maxY := q[0], q[1], q[0], q[1]
+	for i := 2; i < 8; i += 2 {
+		minX, maxX = min(minX, q[i]), max(maxX, q[i])
+		minY, maxY = min(minY, q[i+1]), max(maxY, q[i+1])
+	}
+	return Rect{X: minX, Y: minY, Width: maxX - minX, Height: maxY - minY}, nil
+}
+
+// viewportSize returns the layout viewport in CSS pixels.
+func (s *Session) viewportSize(ctx context.Context) (float64, float64, error) {
+	raw, err := s.SendCDPCtx(ctx, "Page.getLayoutMetrics", nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	var metrics struct {
+		CSSLayoutViewport struct {
+			ClientWidth  float64 `json:"clientWidth"`
+			ClientHeight float64 `json:"clientHeight"`
+		} `json:"cssLayoutViewport"`
+	}
+	if err := json.Unmarshal(raw, &metrics); err != nil {
+		return 0, 0, err
+	}
+	vp := metrics.CSSLayoutViewport
+	if vp.ClientWidth <= 0 || vp.ClientHeight <= 0 {
+		return 0, 0, fmt.Errorf("page has no layout viewport")
+	}
+	return vp.ClientWidth, vp.ClientHeight, nil
+}
+
+// AnnotatedScreenshot captures the viewport and draws a labelled box over
+// each mark. Refresh the page state first: marks come from the last
+// snapshot. Returns the PNG and the marks drawn.
+func (s *Session) AnnotatedScreenshot(ctx context.Context) ([]byte, []Mark, error) {
+	marks, err := s.ResolveMarks(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	vw, _, err := s.viewportSize(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	raw, err := s.SendCDPCtx(ctx, "Page.captureScreenshot", map[string]any{"format": "png"})
+	if err != nil {
+		return nil, nil, fmt.Errorf("Page.captureScreenshot: %w", err)
+	}
+	var shot struct {
+		Data string `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &shot); err != nil {
+		return nil, nil, err
+	}
+	pngBytes, err := base64.StdEncoding.DecodeString(shot.Data)
+	if err != nil {
+		return nil, nil, err
+	}
+	img, err := png.Decode(bytes.NewReader(pngBytes))
+	if err != nil {
+		return nil, nil, err
+	}
+	canvas := image.NewRGBA(img.Bounds())
+	draw.Draw(canvas, canvas.Bounds(), img, img.Bounds().Min, draw.Src)
+	// Screenshot pixels = CSS px × DPR.
+	drawMarks(canvas, marks, float64(canvas.Bounds().Dx())/vw)
+	out, err := encodePNG(canvas)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, marks, nil
+}
+
+// drawMarks outlines each mark on img and tags it with its uid. scale
+// converts CSS pixels to image pixels.
+func drawMarks(img *image.RGBA, marks []Mark, scale float64) {
+	const pad = 2
+	labelH := 5*glyphScale + 2*pad
+	for i, m := range marks {
+		c := markPalette[i%len(markPalette)]
+		box := image.Rect(
+			int(m.X*scale), int(m.Y*scale),
+			int((m.X+m.Width)*scale), int((m.Y+m.Height)*scale),
+		).Intersect(img.Bounds())
+		if box.Empty() {
+			continue
+		}
+		strokeRect(img, box, 2, c)
+
+		labelW := len(m.UID)*4*glyphScale - glyphScale + 2*pad
+		// Above the box when there's room, otherwise just inside it.
+		y := box.Min.Y - labelH
+		if y < 0 {
+			y = box.Min.Y
+		}
+		label := image.Rect(box.Min.X, y, box.Min.X+labelW, y+labelH).Intersect(img.Bounds())
+		draw.Draw(img, label, image.NewUniform(c), image.Point{}, draw.Src)
+		drawText(img, label.Min.X+pad, label.Min.Y+pad, m.UID, color.White)
+	}
+}
+
+// MarksLegend lists marks one per line, for the text half of a set-of-marks
+// result.
+func MarksLegend(marks []Mark) string {
+	var sb strings.Builder
+	for _, m := range marks {
+		fmt.Fprintf(&sb, "[%s] %s", m.UID, m.Role)
+		if m.Name != "" {
+			fmt.Fprintf(&sb, " %q", m.Name)
+		}
+		fmt.Fprintf(&sb, " at (%.0f,%.0f %.0fx%.0f)\n", m.X, m.Y, m.Width, m.Height)
+	}
+	return sb.String()
+}
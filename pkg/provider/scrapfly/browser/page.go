@@ -1,6 +1,7 @@
 package browser
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -12,10 +13,17 @@ import (
 
 // WebMCPToolInfo describes a page-registered WebMCP tool discovered via toolsAdded events.
 type WebMCPToolInfo struct {
-	Name        string          `json:"name"`
-	Description string          `json:"description"`
-	InputSchema json.RawMessage `json:"inputSchema,omitempty"`
-	FrameID     string          `json:"frameId"`
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	InputSchema json.RawMessage    `json:"inputSchema,omitempty"`
+	Annotations *WebMCPAnnotations `json:"annotations,omitempty"`
+	FrameID     string             `json:"frameId"`
+}
+
+// WebMCPAnnotations mirrors WebMCP.Annotation.
+type WebMCPAnnotations struct {
+	ReadOnly   bool `json:"readOnly,omitempty"`
+	Autosubmit bool `json:"autosubmit,omitempty"`
 }
 
 // PageState tracks the current browser page state, refreshed after navigations and interactions.
@@ -25,7 +33,28 @@ type PageState struct {
 	Title       string
 	AXTree      string // compact AX tree text for LLM consumption
 	FrameID     string
-	WebMCPTools []WebMCPToolInfo // page-registered tools from WebMCP.toolsAdded
+	WebMCPTools []WebMCPToolInfo  // page-registered tools from WebMCP.toolsAdded
+	Interactive []InteractiveNode // actionable elements of the AX tree, in snapshot order
+	nodes       []InteractiveNode // every element of the snapshot, in order
+	backendIDs  map[string]int64  // snapshot uid -> backendDOMNodeId
+}
+
+// InteractiveNode is an element of the snapshot an agent can act on. The
+// uid is the snapshot's id=…; BackendNodeID addresses the element in the
+// DOM domain (box models for set-of-marks screenshots).
+type InteractiveNode struct {
+	UID           string
+	Role          string
+	Name          string
+	BackendNodeID int64
+}
+
+// interactiveRoles are the AX roles set-of-marks screenshots label.
+var interactiveRoles = map[string]bool{
+	"button": true, "link": true, "textbox": true, "searchbox": true,
+	"checkbox": true, "radio": true, "combobox": true, "listbox": true,
+	"option": true, "menuitem": true, "menuitemcheckbox": true, "menuitemradio": true,
+	"tab": true, "switch": true, "slider": true, "spinbutton": true, "treeitem": true,
 }
 
 // axValueString extracts a string from an accessibility.Value.
@@ -112,13 +141,13 @@ func (p *PageState) ClearWebMCPTools() {
 }
 
 // Refresh re-fetches page metadata and AX tree from the browser.
-func (p *PageState) Refresh(session *Session) {
+func (p *PageState) Refresh(ctx context.Context, session *Session) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 	log.Printf("[Page] Refreshing page state...")
 
 	// Get metadata
-	metaResult, _ := session.SendCDP("Runtime.evaluate", map[string]any{
+	metaResult, _ := session.SendCDPCtx(ctx, "Runtime.evaluate", map[string]any{
 		"expression":    `JSON.stringify({title: document.title, url: location.href})`,
 		"returnByValue": true,
 	})
@@ -135,7 +164,7 @@ func (p *PageState) Refresh(session *Session) {
 	p.Title = pageMeta.Title
 
 	// Get frame ID
-	frameResult, _ := session.SendCDP("Page.getFrameTree", nil)
+	frameResult, _ := session.SendCDPCtx(ctx, "Page.getFrameTree", nil)
 	if frameResult != nil {
 		var ft struct {
 			FrameTree struct {
@@ -151,10 +180,10 @@ func (p *PageState) Refresh(session *Session) {
 	if p.FrameID != "" {
 		axParams["frameId"] = p.FrameID
 	}
-	axResult, err := session.SendCDP("Accessibility.getFullAXTree", axParams)
+	axResult, err := session.SendCDPCtx(ctx, "Accessibility.getFullAXTree", axParams)
 	if err != nil {
 		// Fallback to text
-		textResult, _ := session.SendCDP("Runtime.evaluate", map[string]any{
+		textResult, _ := session.SendCDPCtx(ctx, "Runtime.evaluate", map[string]any{
 			"expression":    `document.body?.innerText?.substring(0, 50000) || ''`,
 			"returnByValue": true,
 		})
@@ -193,9 +222,12 @@ func (p *PageState) Refresh(session *Session) {
 			}
 		}
 	}
-	compoundByID := p.collectCompoundMeta(session, candidateBackendIDs)
+	compoundByID := p.collectCompoundMeta(ctx, session, candidateBackendIDs)
 
 	var sb strings.Builder
+	p.Interactive = p.Interactive[:0]
+	p.nodes = p.nodes[:0]
+	p.backendIDs = make(map[string]int64)
 	for _, node := range axTree.Nodes {
 		if node.Ignored {
 			continue
@@ -209,6 +241,19 @@ func (p *PageState) Refresh(session *Session) {
 		if name == "" && value == "" && role != "textbox" && role != "button" && role != "link" && role != "checkbox" && role != "radio" && role != "combobox" {
 			continue
 		}
+		if node.BackendDOMNodeID > 0 {
+			p.backendIDs[string(node.NodeID)] = int64(node.BackendDOMNodeID)
+		}
+		snapNode := InteractiveNode{
+			UID:           string(node.NodeID),
+			Role:          role,
+			Name:          name,
+			BackendNodeID: int64(node.BackendDOMNodeID),
+		}
+		p.nodes = append(p.nodes, snapNode)
+		if interactiveRoles[role] && node.BackendDOMNodeID > 0 {
+			p.Interactive = append(p.Interactive, snapNode)
+		}
 		line := fmt.Sprintf("id=%s %s", node.NodeID, role)
 		if name != "" {
 			line += fmt.Sprintf(` "%s"`, name)
@@ -298,7 +343,7 @@ type compoundMeta struct {
 // (a temp helper), and returns a JSON-friendly structure. Falls back
 // to per-element queries if the batched call fails — never errors;
 // a missing entry just means the model loses one enrichment field.
-func (p *PageState) collectCompoundMeta(session *Session, backendIDs []int64) map[int64]compoundMeta {
+func (p *PageState) collectCompoundMeta(ctx context.Context, session *Session, backendIDs []int64) map[int64]compoundMeta {
 	out := make(map[int64]compoundMeta)
 	if len(backendIDs) == 0 {
 		return out
@@ -309,7 +354,7 @@ func (p *PageState) collectCompoundMeta(session *Session, backendIDs []int64) ma
 	// against shadow roots. Per-call is cheap (each is <2ms) and there
 	// are typically <20 form controls per page.
 	for _, bid := range backendIDs {
-		resolveResult, err := session.SendCDP("DOM.resolveNode", map[string]any{
+		resolveResult, err := session.SendCDPCtx(ctx, "DOM.resolveNode", map[string]any{
 			"backendNodeId": bid,
 		})
 		if err != nil {
@@ -323,7 +368,7 @@ func (p *PageState) collectCompoundMeta(session *Session, backendIDs []int64) ma
 		if err := json.Unmarshal(resolveResult, &resolved); err != nil || resolved.Object.ObjectID == "" {
 			continue
 		}
-		callResult, err := session.SendCDP("Runtime.callFunctionOn", map[string]any{
+		callResult, err := session.SendCDPCtx(ctx, "Runtime.callFunctionOn", map[string]any{
 			"objectId":      resolved.Object.ObjectID,
 			"functionDeclaration": _compoundMetaJSFn,
 			"returnByValue": true,
@@ -398,6 +443,32 @@ type DownloadMeta struct {
 	Size     int64  `json:"size"`
 }
 
+// InteractiveNodes returns the actionable elements of the last Refresh.
+func (p *PageState) InteractiveNodes() []InteractiveNode {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]InteractiveNode, len(p.Interactive))
+	copy(out, p.Interactive)
+	return out
+}
+
+// Nodes returns every element of the last Refresh's snapshot.
+func (p *PageState) Nodes() []InteractiveNode {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]InteractiveNode, len(p.nodes))
+	copy(out, p.nodes)
+	return out
+}
+
+// BackendNodeID returns the DOM backend node of a uid from the last Refresh.
+func (p *PageState) BackendNodeID(uid string) (int64, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	id, ok := p.backendIDs[uid]
+	return id, ok
+}
+
 // Snapshot returns the full snapshot text for LLM consumption.
 func (p *PageState) Snapshot() string {
 	p.mu.Lock()
@@ -410,14 +481,11 @@ func (p *PageState) Snapshot() string {
 }
 
 // ── Antibot CDP actions ─────────────────────────────────────────────────────
-// These methods call Antibot.* CDP commands directly on the browser session.
+// Convenience wrappers over the generated Antibot bindings (antibot_gen.go).
 // All return (success bool, errorMessage string).
 
 // Selector is the Antibot element selector.
-type Selector struct {
-	Type  string `json:"type"`  // css, xpath, axNodeId, coord, role, bottom
-	Query string `json:"query"`
-}
+type Selector = AntibotSelector
 
 // AntibotResult is the common response from Antibot commands.
 type AntibotResult struct {
@@ -425,107 +493,103 @@ type AntibotResult struct {
 	ErrorMessage string `json:"errorMessage,omitempty"`
 }
 
-func (s *Session) antibotCall(method string, params map[string]any) (*AntibotResult, error) {
-	result, err := s.SendCDP(method, params)
-	if err != nil {
-		return nil, fmt.Errorf("%s: %w", method, err)
-	}
-	var r AntibotResult
-	json.Unmarshal(result, &r)
-	return &r, nil
-}
-
-func selectorParam(sel Selector) map[string]any {
-	return map[string]any{"type": sel.Type, "query": sel.Query}
+func antibotResult(success bool, errorMessage string) *AntibotResult {
+	return &AntibotResult{Success: success, ErrorMessage: errorMessage}
 }
 
 // Click clicks on an element with human-like mouse movement.
-func (s *Session) Click(sel Selector) (*AntibotResult, error) {
-	return s.antibotCall("Antibot.clickOn", map[string]any{
-		"selector": selectorParam(sel),
-	})
+func (s *Session) Click(ctx context.Context, sel Selector) (*AntibotResult, error) {
+	r, err := s.AntibotClickOn(ctx, NewAntibotClickOnParams(&sel))
+	if err != nil {
+		return nil, err
+	}
+	return antibotResult(r.Success, r.ErrorMessage), nil
 }
 
 // Fill clicks on an element, optionally clears it, then types text.
-func (s *Session) Fill(sel Selector, text string, clear bool) (*AntibotResult, error) {
-	return s.antibotCall("Antibot.fill", map[string]any{
-		"selector": selectorParam(sel),
-		"text":     text,
-		"clear":    clear,
-	})
+func (s *Session) Fill(ctx context.Context, sel Selector, text string, clear bool) (*AntibotResult, error) {
+	r, err := s.AntibotFill(ctx, NewAntibotFillParams(&sel, text).WithClear(clear))
+	if err != nil {
+		return nil, err
+	}
+	return antibotResult(r.Success, r.ErrorMessage), nil
 }
 
 // Type types text at the current cursor position with human-like timing.
-func (s *Session) Type(text string) (*AntibotResult, error) {
-	return s.antibotCall("Antibot.typeText", map[string]any{
-		"text": text,
-	})
+func (s *Session) Type(ctx context.Context, text string) (*AntibotResult, error) {
+	r, err := s.AntibotTypeText(ctx, NewAntibotTypeTextParams(text))
+	if err != nil {
+		return nil, err
+	}
+	return antibotResult(r.Success, r.ErrorMessage), nil
 }
 
 // PressKey sends a single key press (Enter, Tab, Escape, Ctrl+a, etc.).
-func (s *Session) PressKey(key string) (*AntibotResult, error) {
-	return s.antibotCall("Antibot.pressKey", map[string]any{
-		"key": key,
-	})
+func (s *Session) PressKey(ctx context.Context, key string) (*AntibotResult, error) {
+	r, err := s.AntibotPressKey(ctx, NewAntibotPressKeyParams(key))
+	if err != nil {
+		return nil, err
+	}
+	return antibotResult(r.Success, r.ErrorMessage), nil
 }
 
 // Scroll scrolls an element into view, scrolls to bottom, or scrolls by delta.
-func (s *Session) Scroll(sel *Selector, deltaX, deltaY float64) (*AntibotResult, error) {
-	params := map[string]any{}
+func (s *Session) Scroll(ctx context.Context, sel *Selector, deltaX, deltaY float64) (*AntibotResult, error) {
+	params := NewAntibotScrollParams()
 	if sel != nil {
-		params["selector"] = selectorParam(*sel)
+		params.WithSelector(sel)
 	}
 	if deltaX != 0 || deltaY != 0 {
-		params["delta"] = map[string]any{"x": deltaX, "y": deltaY}
+		params.WithDelta(&AntibotCoordinate{X: deltaX, Y: deltaY})
 	}
-	return s.antibotCall("Antibot.scroll", params)
+	r, err := s.AntibotScroll(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return antibotResult(r.Success, r.ErrorMessage), nil
 }
 
 // Hover hovers over an element with human-like mouse movement.
-func (s *Session) Hover(sel Selector) (*AntibotResult, error) {
-	return s.antibotCall("Antibot.hover", map[string]any{
-		"selector": selectorParam(sel),
-	})
+func (s *Session) Hover(ctx context.Context, sel Selector) (*AntibotResult, error) {
+	r, err := s.AntibotHover(ctx, NewAntibotHoverParams(&sel))
+	if err != nil {
+		return nil, err
+	}
+	return antibotResult(r.Success, r.ErrorMessage), nil
 }
 
 // SelectOption selects an option in a <select> or custom dropdown.
-func (s *Session) SelectOption(sel Selector, value, text string, index int) (*AntibotResult, error) {
-	params := map[string]any{"selector": selectorParam(sel)}
-	if value != "" {
-		params["value"] = value
-	}
-	if text != "" {
-		params["text"] = text
-	}
+func (s *Session) SelectOption(ctx context.Context, sel Selector, value, text string, index int) (*AntibotResult, error) {
+	params := NewAntibotSelectOptionParams(&sel).WithValue(value).WithText(text)
 	if index >= 0 {
-		params["index"] = index
+		params.WithIndex(int64(index))
+	}
+	r, err := s.AntibotSelectOption(ctx, params)
+	if err != nil {
+		return nil, err
 	}
-	return s.antibotCall("Antibot.selectOption", params)
+	return antibotResult(r.Success, r.ErrorMessage), nil
 }
 
 // WaitForElement waits for an element to appear in any frame.
-func (s *Session) WaitForElement(sel Selector, timeoutMs int) (*AntibotResult, error) {
-	params := map[string]any{"selector": selectorParam(sel)}
+func (s *Session) WaitForElement(ctx context.Context, sel Selector, timeoutMs int) (*AntibotResult, error) {
+	params := NewAntibotWaitForElementParams(&sel)
 	if timeoutMs > 0 {
-		params["timeout"] = timeoutMs
+		params.WithTimeout(int64(timeoutMs))
+	}
+	r, err := s.AntibotWaitForElement(ctx, params)
+	if err != nil {
+		return nil, err
 	}
-	return s.antibotCall("Antibot.waitForElement", params)
+	return antibotResult(r.Success, r.ErrorMessage), nil
 }
 
 // IsVisible checks whether an element is visible in the viewport.
-func (s *Session) IsVisible(sel Selector) (visible bool, err error) {
-	result, err := s.SendCDP("Antibot.isElementVisible", map[string]any{
-		"selector": selectorParam(sel),
-	})
+func (s *Session) IsVisible(ctx context.Context, sel Selector) (visible bool, err error) {
+	r, err := s.AntibotIsElementVisible(ctx, NewAntibotIsElementVisibleParams(&sel))
 	if err != nil {
 		return false, err
 	}
-	var r struct {
-		Visible bool   `json:"visible"`
-		Exists  bool   `json:"exists"`
-		Reason  string `json:"reason"`
-	}
-	json.Unmarshal(result, &r)
 	return r.Visible, nil
 }
 
@@ -570,63 +634,156 @@ type ScreencastFrame struct {
 	SessionID int64             `json:"sessionId"`
 }
 
+// ScreencastOptions configures Page.startScreencast.
+type ScreencastOptions struct {
+	Format    string // "jpeg" (default) or "png"
+	Quality   int    // 0-100, default 50
+	MaxWidth  int    // default 1920
+	MaxHeight int    // default 1080
+}
+
+// screencastState multiplexes one CDP screencast to several subscribers
+// (SSE viewers, recorders).
+type screencastState struct {
+	mu          sync.Mutex
+	subs        map[int64]func(ScreencastFrame)
+	nextID      int64
+	params      map[string]any
+	unsubscribe func()
+}
+
 // StartScreencast begins streaming page screenshots via CDP Page.startScreencast.
-// The callback is called for each frame. Call StopScreencast to stop.
-func (s *Session) StartScreencast(format string, quality int, onFrame func(ScreencastFrame)) {
-	if format == "" {
-		format = "jpeg"
-	}
-	if quality == 0 {
-		quality = 50
-	}
-
-	// Register handler for screencastFrame events
-	s.OnEvent("Page.screencastFrame", func(method string, params json.RawMessage) bool {
-		var frame ScreencastFrame
-		json.Unmarshal(params, &frame)
-		onFrame(frame)
-		// Acknowledge the frame (fire-and-forget — acks don't return results)
-		s.SendCDPFireAndForget("Page.screencastFrameAck", map[string]any{
-			"sessionId": frame.SessionID,
+// The callback is called for each frame until the returned stop function is
+// called.
+func (s *Session) StartScreencast(format string, quality int, onFrame func(ScreencastFrame)) (stop func()) {
+	return s.StartScreencastWith(ScreencastOptions{Format: format, Quality: quality}, onFrame)
+}
+
+// StartScreencastWith is StartScreencast with size limits. The screencast
+// is shared: the first subscriber's options apply until the last one stops.
+func (s *Session) StartScreencastWith(opts ScreencastOptions, onFrame func(ScreencastFrame)) (stop func()) {
+	if opts.Format == "" {
+		opts.Format = "jpeg"
+	}
+	if opts.Quality == 0 {
+		opts.Quality = 50
+	}
+	if opts.MaxWidth == 0 {
+		opts.MaxWidth = 1920
+	}
+	if opts.MaxHeight == 0 {
+		opts.MaxHeight = 1080
+	}
+
+	sc := &s.screencast
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if sc.subs == nil {
+		sc.subs = map[int64]func(ScreencastFrame){}
+	}
+	sc.nextID++
+	id := sc.nextID
+	sc.subs[id] = onFrame
+	if len(sc.subs) == 1 {
+		// Register handler for screencastFrame events
+		sc.unsubscribe = s.OnEvent("Page.screencastFrame", func(method string, params json.RawMessage) bool {
+			var frame ScreencastFrame
+			json.Unmarshal(params, &frame)
+			w, _ := frame.Metadata["deviceWidth"].(float64)
+			h, _ := frame.Metadata["deviceHeight"].(float64)
+			if w > 0 && h > 0 {
+				s.screencastViewport.Store([2]float64{w, h})
+			}
+			sc.mu.Lock()
+			subs := make([]func(ScreencastFrame), 0, len(sc.subs))
+			for _, fn := range sc.subs {
+				subs = append(subs, fn)
+			}
+			sc.mu.Unlock()
+			for _, fn := range subs {
+				fn(frame)
+			}
+			// Acknowledge the frame (fire-and-forget — acks don't return results)
+			s.SendCDPFireAndForget("Page.screencastFrameAck", map[string]any{
+				"sessionId": frame.SessionID,
+			})
+			return true
 		})
-		return true
-	})
+		sc.params = map[string]any{
+			"format":        opts.Format,
+			"quality":       opts.Quality,
+			"maxWidth":      opts.MaxWidth,
+			"maxHeight":     opts.MaxHeight,
+			"everyNthFrame": 1,
+		}
+		s.SendCDP("Page.startScreencast", sc.params)
+	}
 
-	s.SendCDP("Page.startScreencast", map[string]any{
-		"format":        format,
-		"quality":       quality,
-		"maxWidth":      1920,
-		"maxHeight":     1080,
-		"everyNthFrame": 1,
-	})
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			sc.mu.Lock()
+			defer sc.mu.Unlock()
+			if _, ok := sc.subs[id]; !ok {
+				return
+			}
+			delete(sc.subs, id)
+			if len(sc.subs) == 0 {
+				s.stopScreencastLocked()
+			}
+		})
+	}
 }
 
-// StopScreencast stops the page screencast.
+// StopScreencast stops the page screencast for every subscriber.
 func (s *Session) StopScreencast() {
+	sc := &s.screencast
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	clear(sc.subs)
+	s.stopScreencastLocked()
+}
+
+// stopScreencastLocked stops the CDP screencast. Callers hold screencast.mu.
+func (s *Session) stopScreencastLocked() {
+	sc := &s.screencast
+	if sc.unsubscribe != nil {
+		sc.unsubscribe()
+		sc.unsubscribe = nil
+	}
+	sc.params = nil
 	s.SendCDP("Page.stopScreencast", nil)
 }
 
+// resumeScreencast restarts a screencast that was running when the
+// connection dropped.
+func (s *Session) resumeScreencast(ctx context.Context) {
+	sc := &s.screencast
+	sc.mu.Lock()
+	params := sc.params
+	sc.mu.Unlock()
+	if params != nil {
+		s.SendCDPCtx(ctx, "Page.startScreencast", params)
+	}
+}
+
 // ── ScrapiumBrowser downloads ────────────────────────────────────────────────
 
 // HasDownloads returns whether any files have been downloaded in this session.
-func (s *Session) HasDownloads() (bool, error) {
-	result, err := s.SendCDP("ScrapiumBrowser.hasDownloads", nil)
+func (s *Session) HasDownloads(ctx context.Context) (bool, error) {
+	r, err := s.ScrapiumBrowserHasDownloads(ctx)
 	if err != nil {
 		return false, err
 	}
-	var r struct{ Result bool `json:"result"` }
-	json.Unmarshal(result, &r)
 	return r.Result, nil
 }
 
 // ListDownloads returns metadata for all downloaded files.
-func (s *Session) ListDownloads() ([]DownloadMeta, error) {
-	result, err := s.SendCDP("ScrapiumBrowser.getDownloadsMetadatas", nil)
+func (s *Session) ListDownloads(ctx context.Context) ([]DownloadMeta, error) {
+	r, err := s.ScrapiumBrowserGetDownloadsMetadatas(ctx)
 	if err != nil {
 		return nil, err
 	}
-	var r struct{ Metadata map[string]int64 `json:"metadata"` }
-	json.Unmarshal(result, &r)
 	var downloads []DownloadMeta
 	for name, size := range r.Metadata {
 		downloads = append(downloads, DownloadMeta{Filename: name, Size: size})
@@ -635,31 +792,21 @@ func (s *Session) ListDownloads() ([]DownloadMeta, error) {
 }
 
 // GetDownload retrieves a single downloaded file as base64-encoded data.
-func (s *Session) GetDownload(filename string) (string, error) {
-	result, err := s.SendCDP("ScrapiumBrowser.getDownload", map[string]any{
-		"filename": filename,
-	})
+func (s *Session) GetDownload(ctx context.Context, filename string) (string, error) {
+	r, err := s.ScrapiumBrowserGetDownload(ctx, NewScrapiumBrowserGetDownloadParams(filename))
 	if err != nil {
 		return "", err
 	}
-	var r struct {
-		Data string `json:"data"`
-	}
-	json.Unmarshal(result, &r)
 	return r.Data, nil
 }
 
 // GetAllDownloads retrieves all downloaded files as a map of filename to base64 data.
 // If deleteAfter is true, files are removed from disk after reading.
-func (s *Session) GetAllDownloads(deleteAfter bool) (map[string]string, error) {
-	result, err := s.SendCDP("ScrapiumBrowser.getDownloads", map[string]any{
-		"delete": deleteAfter,
-	})
+func (s *Session) GetAllDownloads(ctx context.Context, deleteAfter bool) (map[string]string, error) {
+	r, err := s.ScrapiumBrowserGetDownloads(ctx, NewScrapiumBrowserGetDownloadsParams().WithDelete(deleteAfter))
 	if err != nil {
 		return nil, err
 	}
-	var r struct{ Files map[string]string `json:"files"` }
-	json.Unmarshal(result, &r)
 	return r.Files, nil
 }
 
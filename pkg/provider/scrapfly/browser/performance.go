@@ -1,6 +1,7 @@
 package browser
 
 import (
+	"context"
 	"bytes"
 	"encoding/base64"
 	"encoding/json"
@@ -93,19 +94,26 @@ var presets = map[Preset]presetConfig{
 type PSIOptions struct {
 	Preset    Preset
 	TimeoutMs int // total budget for the run (default 10000, capped at 30000)
+	// Visuals attaches a filmstrip PNG and an LCP-outlined screenshot to
+	// report.Visuals. FilmstripIntervalMs is the frame spacing (default 1000).
+	Visuals             bool
+	FilmstripIntervalMs int
 }
 
 type PSIReport struct {
-	Preset       Preset            `json:"preset"`
-	URL          string            `json:"url,omitempty"`
-	FetchTimeMs  int               `json:"fetch_time_ms"`
-	Metrics      LabMetrics        `json:"metrics"`
-	Ratings      map[string]string `json:"ratings"`
-	Score        int               `json:"performance_score"`
-	Diagnostics  Diagnostics       `json:"diagnostics"`
-	Resources    ResourceReport    `json:"resources"`
-	Field        *string           `json:"field_data"`   // always nil — CrUX requires API; see warnings
-	Warnings     []string          `json:"warnings,omitempty"`
+	Preset        Preset            `json:"preset"`
+	URL           string            `json:"url,omitempty"`
+	FetchTimeMs   int               `json:"fetch_time_ms"`
+	Metrics       LabMetrics        `json:"metrics"`
+	Ratings       map[string]string `json:"ratings"`
+	Score         int               `json:"performance_score"`
+	Diagnostics   Diagnostics       `json:"diagnostics"`
+	Resources     ResourceReport    `json:"resources"`
+	Coverage      *CoverageReport   `json:"coverage,omitempty"`
+	Opportunities []Opportunity     `json:"opportunities,omitempty"`
+	Visuals       *Visuals          `json:"visuals,omitempty"`
+	Field         *string           `json:"field_data"`   // always nil — CrUX requires API; see warnings
+	Warnings      []string          `json:"warnings,omitempty"`
 }
 
 // LabMetrics: all times in ms, all scores in ms, CLS unitless.
@@ -131,6 +139,8 @@ type Diagnostics struct {
 	DOMNodes          int     `json:"dom_nodes"`
 	RenderBlockingKB  float64 `json:"render_blocking_kb"`
 	TotalByteWeightKB float64 `json:"total_byte_weight_kb"`
+	UnusedJSKB        float64 `json:"unused_js_kb"`
+	UnusedCSSKB       float64 `json:"unused_css_kb"`
 }
 
 type ResourceReport struct {
@@ -141,6 +151,7 @@ type ResourceReport struct {
 	Slowest         []ResourceEntry      `json:"slowest,omitempty"`
 	LargestTransfer []ResourceEntry      `json:"largest_transfer,omitempty"`
 	RenderBlocking  []ResourceEntry      `json:"render_blocking,omitempty"`
+	ByEntity        []EntityStats        `json:"by_entity,omitempty"`
 }
 
 type TypeStats struct {
@@ -173,6 +184,7 @@ type netReq struct {
 	resType       string
 	startMs       float64 // Network.EventRequestWillBeSent.timestamp is a monotonic seconds.fractional value
 	endMs         float64 // set on loadingFinished / loadingFailed
+	wallMs        float64 // requestWillBeSent.wallTime: the browser's clock, which screencast frames share
 	ttfbMs        float64
 	transferBytes int64 // exact byte count; KB derived at output time
 	fromCache     bool
@@ -189,8 +201,10 @@ type screenFrame struct {
 
 // CollectPSI runs a PSI-style lab measurement against the given session and
 // returns the structured report. On hard CDP errors it returns (partial, err);
-// on soft errors it appends to report.Warnings.
-func CollectPSI(s *Session, opts PSIOptions) (*PSIReport, error) {
+// on soft errors it appends to report.Warnings. Canceling ctx cuts the
+// measurement short; the session's emulation and instrumentation are still
+// torn down.
+func CollectPSI(ctx context.Context, s *Session, opts PSIOptions) (*PSIReport, error) {
 	if opts.Preset == "" {
 		opts.Preset = PresetMobile
 	}
@@ -213,12 +227,12 @@ func CollectPSI(s *Session, opts PSIOptions) (*PSIReport, error) {
 
 	// 1. Apply preset emulation. Best-effort — if any of these fail we warn
 	//    and proceed; the numbers just won't match PSI exactly.
-	applyEmulation(s, cfg, report)
+	applyEmulation(ctx, s, cfg, report)
 	defer clearEmulation(s) // always restore so subsequent tool calls aren't throttled
 
 	// 2. Enable CDP domains we need.
 	for _, d := range []string{"Performance", "Page", "Network"} {
-		if _, err := s.SendCDP(d+".enable", nil); err != nil {
+		if _, err := s.SendCDPCtx(ctx, d+".enable", nil); err != nil {
 			report.Warnings = append(report.Warnings, d+".enable: "+err.Error())
 		}
 	}
@@ -233,14 +247,14 @@ func CollectPSI(s *Session, opts PSIOptions) (*PSIReport, error) {
 		"layout-shift",
 		"first-input",
 	}
-	if _, err := s.SendCDP("PerformanceTimeline.enable", map[string]any{
+	if _, err := s.SendCDPCtx(ctx, "PerformanceTimeline.enable", map[string]any{
 		"eventTypes": ptlTypes,
 	}); err != nil {
 		// Retry per type so a future-added unsupported type doesn't kill the
 		// good ones.
 		accepted := []string{}
 		for _, t := range ptlTypes {
-			if _, pe := s.SendCDP("PerformanceTimeline.enable", map[string]any{
+			if _, pe := s.SendCDPCtx(ctx, "PerformanceTimeline.enable", map[string]any{
 				"eventTypes": []string{t},
 			}); pe == nil {
 				accepted = append(accepted, t)
@@ -291,7 +305,7 @@ func CollectPSI(s *Session, opts PSIOptions) (*PSIReport, error) {
 	//    on a fast page already shows a painted layout — (1 - progress) is ~0
 	//    everywhere and Speed Index comes out absurdly low (~20ms).
 	targetURL := ""
-	if raw, err := s.SendCDP("Runtime.evaluate", map[string]any{
+	if raw, err := s.SendCDPCtx(ctx, "Runtime.evaluate", map[string]any{
 		"expression":    `location.href`,
 		"returnByValue": true,
 	}); err == nil {
@@ -308,8 +322,8 @@ func CollectPSI(s *Session, opts PSIOptions) (*PSIReport, error) {
 		return report, fmt.Errorf("could not resolve current page URL for cold-cache reload")
 	}
 	// Blank viewport, clear caches for a true cold-cache run.
-	_, _ = s.SendCDP("Network.clearBrowserCache", nil)
-	if _, err := s.SendCDP("Page.navigate", map[string]any{"url": "about:blank"}); err != nil {
+	_, _ = s.SendCDPCtx(ctx, "Network.clearBrowserCache", nil)
+	if _, err := s.SendCDPCtx(ctx, "Page.navigate", map[string]any{"url": "about:blank"}); err != nil {
 		report.Warnings = append(report.Warnings, "Page.navigate about:blank: "+err.Error())
 	}
 	// Small pause so the blank page paints before we start the screencast.
@@ -317,7 +331,7 @@ func CollectPSI(s *Session, opts PSIOptions) (*PSIReport, error) {
 
 	// 5. Start screencast with a known-blank baseline. Chrome streams JPEG
 	//    frames (~60fps potential; we cap via everyNthFrame=1 = every frame).
-	if _, err := s.SendCDP("Page.startScreencast", map[string]any{
+	if _, err := s.SendCDPCtx(ctx, "Page.startScreencast", map[string]any{
 		"format":        "jpeg",
 		"quality":       30,
 		"maxWidth":      cfg.width,
@@ -327,21 +341,30 @@ func CollectPSI(s *Session, opts PSIOptions) (*PSIReport, error) {
 		report.Warnings = append(report.Warnings, "Page.startScreencast (Speed Index will be unavailable): "+err.Error())
 	}
 
+	// Arm JS/CSS coverage after the about:blank hop so only the target
+	// page's code is counted.
+	coverage := startCoverage(ctx, s, report)
+
 	// 6. Navigate to the real URL — this starts the measurement.
-	if _, err := s.SendCDP("Page.navigate", map[string]any{"url": targetURL}); err != nil {
+	if _, err := s.SendCDPCtx(ctx, "Page.navigate", map[string]any{"url": targetURL}); err != nil {
 		return report, fmt.Errorf("Page.navigate to target: %w", err)
 	}
 
 	// 6. Wait for load + LCP-settle window. LCP can update up to the first
 	//    user interaction; PSI waits a quiet window after loadEventFired, and
 	//    so do we.
-	waitForQuietLoad(loadFired, domLoaded, &mu, &timelineEvents, netByID, opts.TimeoutMs, report)
+	waitForQuietLoad(ctx, loadFired, domLoaded, &mu, &timelineEvents, netByID, opts.TimeoutMs, report)
 
-	// 7. Stop screencast, final metrics snapshot.
+	// 7. Stop screencast, final metrics snapshot. Teardown commands don't
+	//    take ctx: they must run even when the caller has given up.
 	if _, err := s.SendCDP("Page.stopScreencast", nil); err != nil {
 		report.Warnings = append(report.Warnings, "Page.stopScreencast: "+err.Error())
 	}
-	if raw, err := s.SendCDP("Performance.getMetrics", nil); err == nil {
+	if err := ctx.Err(); err != nil {
+		takeCoverage(ctx, s, coverage, report)
+		return report, fmt.Errorf("measurement canceled: %w", err)
+	}
+	if raw, err := s.SendCDPCtx(ctx, "Performance.getMetrics", nil); err == nil {
 		var env struct {
 			Metrics []performance.Metric `json:"metrics"`
 		}
@@ -351,22 +374,60 @@ func CollectPSI(s *Session, opts PSIOptions) (*PSIReport, error) {
 			mu.Unlock()
 		}
 	}
+	scriptCoverage, ruleUsage := takeCoverage(ctx, s, coverage, report)
 
 	// 8. In-page fallback: captures LCP/FCP/CLS/longtask/INP/DOM size via
 	//    PerformanceObserver({buffered:true}). Authoritative when CDP
 	//    PerformanceTimeline subscription dropped or rejected types.
-	fallback := fetchPageFallback(s, report)
+	fallback := fetchPageFallback(ctx, s, report)
 	var domNodes int
 	if fallback != nil {
 		domNodes = fallback.DOMNodes
 		report.URL = fallback.URL
 	}
+	if opts.Visuals {
+		report.Visuals = &Visuals{}
+		shot, rect, err := captureLCPScreenshot(ctx, s)
+		if err != nil {
+			report.Warnings = append(report.Warnings, "LCP screenshot: "+err.Error())
+		} else {
+			report.Visuals.LCPScreenshotPNG = shot
+			report.Visuals.LCPRect = rect
+		}
+	}
 
 	// 9. Compute everything from the collected events.
 	mu.Lock()
 	defer mu.Unlock()
 	computeMetrics(report, cfg, timelineEvents, finalMetrics, netByID, screencastFrames, domNodes, int(time.Since(start).Milliseconds()))
 	applyFallback(report, fallback)
+	var scriptTime []fallbackScript
+	pageURL := targetURL
+	if fallback != nil {
+		scriptTime = fallback.ScriptTime
+		if fallback.URL != "" {
+			pageURL = fallback.URL
+		}
+	}
+	report.Resources.ByEntity = buildEntityReport(netByID, pageURL, scriptTime)
+	if report.Visuals != nil {
+		if navStartMs := navStartWallMs(netByID); navStartMs == 0 {
+			report.Warnings = append(report.Warnings, "filmstrip: no navigation request seen")
+		} else if strip, atMs, size, err := buildFilmstrip(screencastFrames, navStartMs, opts.FilmstripIntervalMs); err != nil {
+			report.Warnings = append(report.Warnings, "filmstrip: "+err.Error())
+		} else if strip != nil {
+			report.Visuals.FilmstripPNG = strip
+			report.Visuals.FilmstripFramesMs = atMs
+			report.Visuals.FilmstripWidth, report.Visuals.FilmstripHeight = size.X, size.Y
+		}
+	}
+	coverage.mu.Lock()
+	report.Coverage, report.Opportunities = buildCoverage(scriptCoverage, ruleUsage, coverage.sheets, netByID, cfg.downloadKbps)
+	coverage.mu.Unlock()
+	if report.Coverage != nil {
+		report.Diagnostics.UnusedJSKB = report.Coverage.UnusedJSKB
+		report.Diagnostics.UnusedCSSKB = report.Coverage.UnusedCSSKB
+	}
 
 	report.FetchTimeMs = int(time.Since(start).Milliseconds())
 	report.Score, report.Ratings = scoreAndRate(report.Metrics, report.Preset)
@@ -383,23 +444,23 @@ func CollectPSI(s *Session, opts PSIOptions) (*PSIReport, error) {
 
 // ── Emulation ──────────────────────────────────────────────────────────────
 
-func applyEmulation(s *Session, cfg presetConfig, report *PSIReport) {
+func applyEmulation(ctx context.Context, s *Session, cfg presetConfig, report *PSIReport) {
 	deviceParams := map[string]any{
 		"width":             cfg.width,
 		"height":            cfg.height,
 		"deviceScaleFactor": cfg.dpr,
 		"mobile":            cfg.mobile,
 	}
-	if _, err := s.SendCDP("Emulation.setDeviceMetricsOverride", deviceParams); err != nil {
+	if _, err := s.SendCDPCtx(ctx, "Emulation.setDeviceMetricsOverride", deviceParams); err != nil {
 		report.Warnings = append(report.Warnings, "Emulation.setDeviceMetricsOverride: "+err.Error())
 	}
 	if cfg.ua != "" {
-		if _, err := s.SendCDP("Emulation.setUserAgentOverride", map[string]any{"userAgent": cfg.ua}); err != nil {
+		if _, err := s.SendCDPCtx(ctx, "Emulation.setUserAgentOverride", map[string]any{"userAgent": cfg.ua}); err != nil {
 			report.Warnings = append(report.Warnings, "Emulation.setUserAgentOverride: "+err.Error())
 		}
 	}
 	// Network throttling — convert kilobits/s → bytes/s as CDP expects.
-	if _, err := s.SendCDP("Network.emulateNetworkConditions", map[string]any{
+	if _, err := s.SendCDPCtx(ctx, "Network.emulateNetworkConditions", map[string]any{
 		"offline":            false,
 		"latency":            cfg.latencyMs,
 		"downloadThroughput": cfg.downloadKbps * 1024 / 8,
@@ -408,7 +469,7 @@ func applyEmulation(s *Session, cfg presetConfig, report *PSIReport) {
 		report.Warnings = append(report.Warnings, "Network.emulateNetworkConditions: "+err.Error())
 	}
 	if cfg.cpuSlowdown > 1 {
-		if _, err := s.SendCDP("Emulation.setCPUThrottlingRate", map[string]any{"rate": cfg.cpuSlowdown}); err != nil {
+		if _, err := s.SendCDPCtx(ctx, "Emulation.setCPUThrottlingRate", map[string]any{"rate": cfg.cpuSlowdown}); err != nil {
 			report.Warnings = append(report.Warnings, "Emulation.setCPUThrottlingRate: "+err.Error())
 		}
 	}
@@ -426,11 +487,10 @@ func clearEmulation(s *Session) {
 // ── Event collectors ───────────────────────────────────────────────────────
 
 // registerCollectors wires the PSI event collectors onto s and returns a
-// cleanup func. The cleanup flips a shared atomic flag that makes every
-// registered handler return false on its next invocation, which the CDP
-// dispatcher treats as a deregistration signal (see Session.dispatch in
-// cdp.go). Callers MUST defer the returned cleanup; otherwise handlers
-// persist for the session lifetime and accumulate across repeated
+// cleanup func. The cleanup unsubscribes every handler, and flips a shared
+// atomic flag so copies the dispatcher already snapshotted for an in-flight
+// event are no-ops. Callers MUST defer the returned cleanup; otherwise
+// handlers persist for the session lifetime and accumulate across repeated
 // CollectPSI calls on a reused session.
 func registerCollectors(
 	s *Session,
@@ -443,6 +503,10 @@ func registerCollectors(
 	domLoaded chan<- struct{},
 ) (cleanup func()) {
 	var stopped atomic.Bool
+	var unsubscribers []func()
+	on := func(method string, h EventHandler) {
+		unsubscribers = append(unsubscribers, s.OnEvent(method, h))
+	}
 
 	// keep wraps a long-lived handler: once stopped flips true, the next
 	// event drops the handler via the `return false` contract.
@@ -456,7 +520,7 @@ func registerCollectors(
 		}
 	}
 
-	s.OnEvent("PerformanceTimeline.timelineEventAdded", keep(func(_ string, params json.RawMessage) {
+	on("PerformanceTimeline.timelineEventAdded", keep(func(_ string, params json.RawMessage) {
 		var evt performancetimeline.EventTimelineEventAdded
 		if err := json.Unmarshal(params, &evt); err != nil || evt.Event == nil {
 			return
@@ -466,7 +530,7 @@ func registerCollectors(
 		mu.Unlock()
 	}))
 
-	s.OnEvent("Performance.metrics", keep(func(_ string, params json.RawMessage) {
+	on("Performance.metrics", keep(func(_ string, params json.RawMessage) {
 		var evt performance.EventMetrics
 		if err := json.Unmarshal(params, &evt); err != nil {
 			return
@@ -481,7 +545,7 @@ func registerCollectors(
 		mu.Unlock()
 	}))
 
-	s.OnEvent("Network.requestWillBeSent", keep(func(_ string, params json.RawMessage) {
+	on("Network.requestWillBeSent", keep(func(_ string, params json.RawMessage) {
 		var evt network.EventRequestWillBeSent
 		if err := json.Unmarshal(params, &evt); err != nil {
 			return
@@ -492,12 +556,13 @@ func registerCollectors(
 			url:       evt.Request.URL,
 			resType:   strings.ToLower(string(evt.Type)),
 			startMs:   cdpMonoMs(evt.Timestamp),
+			wallMs:    cdpEpochMs(evt.WallTime),
 			priority:  string(evt.Request.InitialPriority),
 		}
 		mu.Unlock()
 	}))
 
-	s.OnEvent("Network.responseReceived", keep(func(_ string, params json.RawMessage) {
+	on("Network.responseReceived", keep(func(_ string, params json.RawMessage) {
 		var evt network.EventResponseReceived
 		if err := json.Unmarshal(params, &evt); err != nil {
 			return
@@ -520,7 +585,7 @@ func registerCollectors(
 		}
 	}))
 
-	s.OnEvent("Network.loadingFinished", keep(func(_ string, params json.RawMessage) {
+	on("Network.loadingFinished", keep(func(_ string, params json.RawMessage) {
 		var evt network.EventLoadingFinished
 		if err := json.Unmarshal(params, &evt); err != nil {
 			return
@@ -535,7 +600,7 @@ func registerCollectors(
 		r.transferBytes = int64(evt.EncodedDataLength)
 	}))
 
-	s.OnEvent("Network.loadingFailed", keep(func(_ string, params json.RawMessage) {
+	on("Network.loadingFailed", keep(func(_ string, params json.RawMessage) {
 		var evt network.EventLoadingFailed
 		if err := json.Unmarshal(params, &evt); err != nil {
 			return
@@ -547,7 +612,7 @@ func registerCollectors(
 		}
 	}))
 
-	s.OnEvent("Page.loadEventFired", func(_ string, params json.RawMessage) bool {
+	on("Page.loadEventFired", func(_ string, params json.RawMessage) bool {
 		var evt page.EventLoadEventFired
 		_ = json.Unmarshal(params, &evt) // we only need the signal
 		select {
@@ -559,7 +624,7 @@ func registerCollectors(
 	// domContentLoadedEventFired is a useful fallback signal when load takes
 	// >30s but DOM was ready much earlier. The wait logic uses this plus a
 	// network-idle window as an alternate exit condition.
-	s.OnEvent("Page.domContentEventFired", func(_ string, params json.RawMessage) bool {
+	on("Page.domContentEventFired", func(_ string, params json.RawMessage) bool {
 		select {
 		case domLoaded <- struct{}{}:
 		default:
@@ -567,7 +632,7 @@ func registerCollectors(
 		return false
 	})
 
-	s.OnEvent("Page.screencastFrame", keep(func(_ string, params json.RawMessage) {
+	on("Page.screencastFrame", keep(func(_ string, params json.RawMessage) {
 		// Typed: page.EventScreencastFrame.
 		var evt page.EventScreencastFrame
 		if err := json.Unmarshal(params, &evt); err != nil {
@@ -592,7 +657,12 @@ func registerCollectors(
 		mu.Unlock()
 	}))
 
-	return func() { stopped.Store(true) }
+	return func() {
+		stopped.Store(true)
+		for _, unsubscribe := range unsubscribers {
+			unsubscribe()
+		}
+	}
 }
 
 // waitForQuietLoad blocks until the page is ready for metric computation.
@@ -601,11 +671,12 @@ func registerCollectors(
 //   1. loadEventFired + 2s of no new LCP events + no new in-flight requests.
 //   2. domContentLoadedEventFired + 3s of network idle (≤2 in-flight) — slow
 //      pages where some resources hang past loadEventFired still reach this.
-//   3. budgetMs elapsed.
+//   3. budgetMs elapsed, or ctx is done.
 //
 // Returns true if we exited via a completion signal (1 or 2), false on budget
 // timeout. A warning is appended for partial-data cases.
 func waitForQuietLoad(
+	ctx context.Context,
 	loadFired, domLoaded <-chan struct{},
 	mu *sync.Mutex,
 	timeline *[]performancetimeline.TimelineEvent,
@@ -650,6 +721,9 @@ func waitForQuietLoad(
 			lastLCPChange = time.Now()
 		case <-domLoaded:
 			domSeen = true
+		case <-ctx.Done():
+			report.Warnings = append(report.Warnings, "measurement canceled before the page settled")
+			return
 		case <-deadline:
 			// Give a clear, actionable warning describing why we gave up.
 			if !loadSeen && !domSeen {
@@ -786,17 +860,18 @@ func applyFallback(report *PSIReport, fb *pageFallback) {
 //     domain doesn't expose (notably longtask, paint). PerformanceObserver
 //     with buffered:true still captures these via the W3C API.
 type pageFallback struct {
-	URL       string         `json:"url"`
-	DOMNodes  int            `json:"nodes"`
-	NavStart  float64        `json:"nav_start_ms"` // performance.timeOrigin-relative 0
-	FCP       *float64       `json:"fcp"`
-	FP        *float64       `json:"fp"`
-	LCP       *fallbackLCP   `json:"lcp"`
-	LongTask  []fallbackTask `json:"longtasks"`
-	LayoutCLS float64        `json:"cls"`
-	INPMs     *float64       `json:"inp"`
-	MemUsedMB int            `json:"mem_used_mb"`
-	MemLimMB  int            `json:"mem_lim_mb"`
+	URL        string           `json:"url"`
+	DOMNodes   int              `json:"nodes"`
+	NavStart   float64          `json:"nav_start_ms"` // performance.timeOrigin-relative 0
+	FCP        *float64         `json:"fcp"`
+	FP         *float64         `json:"fp"`
+	LCP        *fallbackLCP     `json:"lcp"`
+	LongTask   []fallbackTask   `json:"longtasks"`
+	ScriptTime []fallbackScript `json:"script_time"`
+	LayoutCLS  float64          `json:"cls"`
+	INPMs      *float64         `json:"inp"`
+	MemUsedMB  int              `json:"mem_used_mb"`
+	MemLimMB   int              `json:"mem_lim_mb"`
 }
 type fallbackLCP struct {
 	TimeMs  float64 `json:"time_ms"`
@@ -808,14 +883,18 @@ type fallbackTask struct {
 	StartMs    float64 `json:"start_ms"`
 	DurationMs float64 `json:"duration_ms"`
 }
+type fallbackScript struct {
+	URL        string  `json:"url"`
+	DurationMs float64 `json:"duration_ms"`
+}
 
 // fetchPageFallback runs a single Runtime.evaluate that uses
 // PerformanceObserver({buffered:true}) to capture LCP, FCP, paint, longtask,
 // layout-shift, and first-input entries that the page observed directly. This
 // is the ground-truth fallback for when CDP's PerformanceTimeline domain
 // rejects some entry types.
-func fetchPageFallback(s *Session, report *PSIReport) *pageFallback {
-	raw, err := s.SendCDP("Runtime.evaluate", map[string]any{
+func fetchPageFallback(ctx context.Context, s *Session, report *PSIReport) *pageFallback {
+	raw, err := s.SendCDPCtx(ctx, "Runtime.evaluate", map[string]any{
 		"expression":    pageFallbackScript,
 		"returnByValue": true,
 		"awaitPromise":  true,
@@ -863,13 +942,32 @@ const pageFallbackScript = `(async () => {
     } catch (e) { resolve([]); }
   });
 
-  const [lcpEntries, clsEntries, longtaskEntries, firstInputEntries] = await Promise.all([
+  const [lcpEntries, clsEntries, longtaskEntries, firstInputEntries, loafEntries] = await Promise.all([
     observe(['largest-contentful-paint']),
     observe(['layout-shift']),
     observe(['longtask']),
     observe(['first-input']),
+    observe(['long-animation-frame']),
   ]);
 
+  // Long Animation Frames (Chrome 123+) attribute blocking time to script
+  // URLs; plain longtask entries only name the frame. Fall back to the
+  // longtask container src (iframes) when LoAF isn't available.
+  const scriptTime = {};
+  for (const f of loafEntries) {
+    for (const sc of (f.scripts || [])) {
+      const u = sc.sourceURL || '';
+      if (u) scriptTime[u] = (scriptTime[u] || 0) + sc.duration;
+    }
+  }
+  if (!loafEntries.length) {
+    for (const t of longtaskEntries) {
+      for (const a of (t.attribution || [])) {
+        if (a.containerSrc) scriptTime[a.containerSrc] = (scriptTime[a.containerSrc] || 0) + t.duration;
+      }
+    }
+  }
+
   const paintEntries = performance.getEntriesByType('paint');
   const fcp = paintEntries.find(p => p.name === 'first-contentful-paint');
   const fp  = paintEntries.find(p => p.name === 'first-paint');
@@ -909,6 +1007,7 @@ const pageFallbackScript = `(async () => {
     fp:  fp  ? fp.startTime  : null,
     lcp,
     longtasks: longtaskEntries.map(e => ({ start_ms: e.startTime, duration_ms: e.duration })),
+    script_time: Object.entries(scriptTime).map(([url, d]) => ({ url, duration_ms: Math.round(d) })),
     cls: Math.round(cls * 1000) / 1000,
     inp,
     mem_used_mb: mem.usedJSHeapSize ? Math.round(mem.usedJSHeapSize / 1048576) : 0,
@@ -1406,6 +1505,24 @@ func histDistance(a, b [48]uint32) uint64 {
 	return d
 }
 
+// navStartWallMs is the browser's wall-clock time (epoch ms) of the earliest
+// request, the navigation anchor computeMetrics takes as t0. Screencast
+// frames are stamped by the same clock, so the filmstrip is anchored here
+// rather than on the host's, which may be skewed. Returns 0 when no request
+// was seen.
+func navStartWallMs(netByID map[string]*netReq) float64 {
+	var first *netReq
+	for _, r := range netByID {
+		if first == nil || r.startMs < first.startMs {
+			first = r
+		}
+	}
+	if first == nil {
+		return 0
+	}
+	return first.wallMs
+}
+
 // ── Resource waterfall ─────────────────────────────────────────────────────
 
 func buildResourceReport(netByID map[string]*netReq, fcpMs int, fcpSet bool) ResourceReport {
@@ -1452,6 +1569,7 @@ func buildResourceReport(netByID map[string]*netReq, fcpMs int, fcpSet bool) Res
 			startRel := int(r.startMs - t0Ms)
 			if startRel < fcpMs && (r.resType == "stylesheet" ||
 				(r.resType == "script" && (r.priority == "High" || r.priority == "VeryHigh"))) {
+				r.isRenderBlocking = true
 				out.RenderBlocking = append(out.RenderBlocking, entry)
 			}
 		}
@@ -1544,5 +1662,8 @@ func SummarizeReport(r *PSIReport) string {
 	if r.Resources.Count > 0 {
 		parts = append(parts, fmt.Sprintf("resources=%d (%.1fKB)", r.Resources.Count, r.Resources.TotalKB))
 	}
+	if r.Coverage != nil {
+		parts = append(parts, fmt.Sprintf("unused_js=%.1fKB unused_css=%.1fKB", r.Coverage.UnusedJSKB, r.Coverage.UnusedCSSKB))
+	}
 	return strings.Join(parts, " ")
 }
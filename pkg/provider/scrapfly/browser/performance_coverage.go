@@ -0,0 +1,373 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/chromedp/cdproto/css"
+	"github.com/chromedp/cdproto/profiler"
+)
+
+// JS / CSS coverage for the "Reduce unused JavaScript" and "Reduce unused
+// CSS" opportunities Lighthouse reports.
+//
+// Coverage is armed BEFORE the measured navigation (Profiler precise
+// coverage + CSS rule-usage tracking) and harvested after the quiet-load
+// window, so it reflects what the page actually executed / matched during
+// load — not what a later interaction might need. Like Lighthouse, a byte of
+// JS counts as unused when it sits in a function or block whose execution
+// count is 0, and a byte of CSS counts as unused when it isn't covered by a
+// rule Chrome marked as used.
+
+// Lighthouse skips resources wasting less than these many bytes — tiny files
+// aren't worth a request to split. Same values as unused-javascript (20 KiB)
+// and unused-css-rules (10 KiB).
+const (
+	unusedJSThresholdBytes  = 20 * 1024
+	unusedCSSThresholdBytes = 10 * 1024
+	coverageTopN            = 10
+)
+
+// CoverageReport lists unused bytes per script and stylesheet, worst first.
+type CoverageReport struct {
+	UnusedJSKB  float64         `json:"unused_js_kb"`
+	TotalJSKB   float64         `json:"total_js_kb"`
+	UnusedCSSKB float64         `json:"unused_css_kb"`
+	TotalCSSKB  float64         `json:"total_css_kb"`
+	Scripts     []CoverageEntry `json:"scripts,omitempty"`
+	Stylesheets []CoverageEntry `json:"stylesheets,omitempty"`
+}
+
+// CoverageEntry sizes are in transferred KB when the resource was seen on the
+// network (unused share scaled to the compressed size, as Lighthouse does),
+// otherwise in decoded source KB (inline scripts/styles).
+type CoverageEntry struct {
+	URL       string  `json:"url"`
+	TotalKB   float64 `json:"total_kb"`
+	UnusedKB  float64 `json:"unused_kb"`
+	UnusedPct int     `json:"unused_pct"`
+}
+
+// Opportunity mirrors a Lighthouse "Opportunities" row: the top offenders
+// and what fixing them would save on the preset's throttled connection.
+type Opportunity struct {
+	ID        string          `json:"id"`
+	Title     string          `json:"title"`
+	SavingsKB float64         `json:"savings_kb"`
+	SavingsMs int             `json:"savings_ms"`
+	Items     []CoverageEntry `json:"items"`
+}
+
+// coverageState holds what the coverage collectors saw between arm and take.
+type coverageState struct {
+	jsArmed, cssArmed bool
+	mu                sync.Mutex
+	sheets            map[string]css.StyleSheetHeader // styleSheetId → header
+	stopped           atomic.Bool
+	unsubscribe       func()
+}
+
+// startCoverage enables the Profiler / CSS domains and starts precise
+// coverage + rule-usage tracking. Failures are soft: the report just won't
+// carry the corresponding opportunity.
+func startCoverage(ctx context.Context, s *Session, report *PSIReport) *coverageState {
+	st := &coverageState{sheets: map[string]css.StyleSheetHeader{}}
+
+	if _, err := s.SendCDPCtx(ctx, "Profiler.enable", nil); err != nil {
+		report.Warnings = append(report.Warnings, "Profiler.enable (unused JS unavailable): "+err.Error())
+	} else if _, err := s.SendCDPCtx(ctx, "Profiler.startPreciseCoverage", map[string]any{
+		"callCount": true,
+		"detailed":  true,
+	}); err != nil {
+		report.Warnings = append(report.Warnings, "Profiler.startPreciseCoverage (unused JS unavailable): "+err.Error())
+	} else {
+		st.jsArmed = true
+	}
+
+	// styleSheetAdded carries the sheet length + URL; rule usage only carries
+	// the sheet id. Registered before CSS.enable so the replayed headers for
+	// already-attached sheets are captured too.
+	st.unsubscribe = s.OnEvent("CSS.styleSheetAdded", func(_ string, params json.RawMessage) bool {
+		if st.stopped.Load() {
+			return false
+		}
+		var evt css.EventStyleSheetAdded
+		if err := json.Unmarshal(params, &evt); err != nil || evt.Header == nil {
+			return true
+		}
+		st.mu.Lock()
+		st.sheets[string(evt.Header.StyleSheetID)] = *evt.Header
+		st.mu.Unlock()
+		return true
+	})
+	// CSS.enable requires the DOM agent.
+	if _, err := s.SendCDPCtx(ctx, "DOM.enable", nil); err != nil {
+		report.Warnings = append(report.Warnings, "DOM.enable (unused CSS unavailable): "+err.Error())
+	} else if _, err := s.SendCDPCtx(ctx, "CSS.enable", nil); err != nil {
+		report.Warnings = append(report.Warnings, "CSS.enable (unused CSS unavailable): "+err.Error())
+	} else if _, err := s.SendCDPCtx(ctx, "CSS.startRuleUsageTracking", nil); err != nil {
+		report.Warnings = append(report.Warnings, "CSS.startRuleUsageTracking (unused CSS unavailable): "+err.Error())
+	} else {
+		st.cssArmed = true
+	}
+	return st
+}
+
+// takeCoverage stops both trackers and returns the raw data. Always call it
+// once startCoverage has run so the session isn't left instrumented; the
+// stop / disable commands ignore ctx for that reason.
+func takeCoverage(ctx context.Context, s *Session, st *coverageState, report *PSIReport) ([]*profiler.ScriptCoverage, []*css.RuleUsage) {
+	defer st.unsubscribe()
+	defer st.stopped.Store(true)
+
+	var scripts []*profiler.ScriptCoverage
+	if st.jsArmed {
+		if raw, err := s.SendCDPCtx(ctx, "Profiler.takePreciseCoverage", nil); err != nil {
+			report.Warnings = append(report.Warnings, "Profiler.takePreciseCoverage: "+err.Error())
+		} else {
+			var ret profiler.TakePreciseCoverageReturns
+			if err := json.Unmarshal(raw, &ret); err == nil {
+				scripts = ret.Result
+			}
+		}
+		_, _ = s.SendCDP("Profiler.stopPreciseCoverage", nil)
+		_, _ = s.SendCDP("Profiler.disable", nil)
+	}
+
+	var rules []*css.RuleUsage
+	if st.cssArmed {
+		if raw, err := s.SendCDP("CSS.stopRuleUsageTracking", nil); err != nil {
+			report.Warnings = append(report.Warnings, "CSS.stopRuleUsageTracking: "+err.Error())
+		} else {
+			var ret css.StopRuleUsageTrackingReturns
+			if err := json.Unmarshal(raw, &ret); err == nil {
+				rules = ret.RuleUsage
+			}
+		}
+		_, _ = s.SendCDP("CSS.disable", nil)
+	}
+	return scripts, rules
+}
+
+// buildCoverage turns raw coverage into the per-resource report and the two
+// Lighthouse opportunities. netByID supplies transfer sizes so unused bytes
+// are expressed in what actually went over the wire; downloadKbps converts
+// the savings into milliseconds on the preset's throttled link.
+func buildCoverage(
+	scripts []*profiler.ScriptCoverage,
+	rules []*css.RuleUsage,
+	sheets map[string]css.StyleSheetHeader,
+	netByID map[string]*netReq,
+	downloadKbps float64,
+) (*CoverageReport, []Opportunity) {
+	if len(scripts) == 0 && len(rules) == 0 {
+		return nil, nil
+	}
+	// Transfer sizes by URL, for one resource type. Inline scripts and
+	// styles carry the document's URL; they must not be scaled to the HTML
+	// response, so only script (stylesheet) responses count.
+	transferOf := func(resType string) map[string]int64 {
+		m := map[string]int64{}
+		for _, r := range netByID {
+			if r.resType == resType && r.transferBytes > m[r.url] {
+				m[r.url] = r.transferBytes
+			}
+		}
+		return m
+	}
+
+	// Several inline scripts can share the document URL — aggregate per URL.
+	type agg struct{ total, unused int64 }
+	jsByURL := map[string]*agg{}
+	for _, sc := range scripts {
+		if sc == nil || sc.URL == "" {
+			continue // eval'd / injected code has no URL and no request to blame
+		}
+		total, unused := scriptUnusedBytes(sc)
+		if total == 0 {
+			continue
+		}
+		a := jsByURL[sc.URL]
+		if a == nil {
+			a = &agg{}
+			jsByURL[sc.URL] = a
+		}
+		a.total += total
+		a.unused += unused
+	}
+
+	cssBySheet := map[string]*agg{}
+	usedRanges := map[string][][2]int64{}
+	for _, ru := range rules {
+		if ru == nil || !ru.Used {
+			continue
+		}
+		id := string(ru.StyleSheetID)
+		usedRanges[id] = append(usedRanges[id], [2]int64{int64(ru.StartOffset), int64(ru.EndOffset)})
+	}
+	for id, h := range sheets {
+		if h.Length <= 0 || h.Origin != css.StyleSheetOriginRegular {
+			continue
+		}
+		total := int64(h.Length)
+		used := mergedLength(usedRanges[id])
+		if used > total {
+			used = total
+		}
+		cssBySheet[id] = &agg{total: total, unused: total - used}
+	}
+	cssByURL := map[string]*agg{}
+	for id, a := range cssBySheet {
+		u := sheets[id].SourceURL
+		if u == "" {
+			u = "(constructed stylesheet)"
+		}
+		b := cssByURL[u]
+		if b == nil {
+			b = &agg{}
+			cssByURL[u] = b
+		}
+		b.total += a.total
+		b.unused += a.unused
+	}
+
+	// toEntries scales unused source bytes to the resource's transfer size when
+	// we have one; returns entries + (unused, total) byte sums in those units.
+	toEntries := func(m map[string]*agg, transferByURL map[string]int64) ([]CoverageEntry, []int64, int64, int64) {
+		entries := make([]CoverageEntry, 0, len(m))
+		wasted := make([]int64, 0, len(m))
+		var sumUnused, sumTotal int64
+		for u, a := range m {
+			ratio := float64(a.unused) / float64(a.total)
+			size := a.total
+			if tb := transferByURL[u]; tb > 0 {
+				size = tb
+			}
+			unused := int64(float64(size) * ratio)
+			sumUnused += unused
+			sumTotal += size
+			entries = append(entries, CoverageEntry{
+				URL:       truncateURL(u),
+				TotalKB:   roundKB(bytesToKB(size)),
+				UnusedKB:  roundKB(bytesToKB(unused)),
+				UnusedPct: int(ratio*100 + 0.5),
+			})
+			wasted = append(wasted, unused)
+		}
+		idx := make([]int, len(entries))
+		for i := range idx {
+			idx[i] = i
+		}
+		sort.SliceStable(idx, func(i, j int) bool { return wasted[idx[i]] > wasted[idx[j]] })
+		sortedE := make([]CoverageEntry, len(entries))
+		sortedW := make([]int64, len(entries))
+		for i, k := range idx {
+			sortedE[i] = entries[k]
+			sortedW[i] = wasted[k]
+		}
+		return sortedE, sortedW, sumUnused, sumTotal
+	}
+
+	jsEntries, jsWasted, jsUnused, jsTotal := toEntries(jsByURL, transferOf("script"))
+	cssEntries, cssWasted, cssUnused, cssTotal := toEntries(cssByURL, transferOf("stylesheet"))
+
+	cov := &CoverageReport{
+		UnusedJSKB:  roundKB(bytesToKB(jsUnused)),
+		TotalJSKB:   roundKB(bytesToKB(jsTotal)),
+		UnusedCSSKB: roundKB(bytesToKB(cssUnused)),
+		TotalCSSKB:  roundKB(bytesToKB(cssTotal)),
+		Scripts:     capEntries(jsEntries, 25),
+		Stylesheets: capEntries(cssEntries, 25),
+	}
+
+	var opps []Opportunity
+	if o, ok := opportunity("unused-javascript", "Reduce unused JavaScript", jsEntries, jsWasted, unusedJSThresholdBytes, downloadKbps); ok {
+		opps = append(opps, o)
+	}
+	if o, ok := opportunity("unused-css-rules", "Reduce unused CSS", cssEntries, cssWasted, unusedCSSThresholdBytes, downloadKbps); ok {
+		opps = append(opps, o)
+	}
+	return cov, opps
+}
+
+// opportunity keeps the entries above Lighthouse's per-resource threshold
+// (entries arrive sorted worst-first) and estimates the time saved as the
+// wasted bytes' download time on the preset link. That ignores request
+// parallelism, so treat it as an upper bound rather than an LCP delta.
+func opportunity(id, title string, entries []CoverageEntry, wasted []int64, threshold int64, downloadKbps float64) (Opportunity, bool) {
+	o := Opportunity{ID: id, Title: title}
+	var savedBytes int64
+	for i, e := range entries {
+		if wasted[i] < threshold {
+			break
+		}
+		savedBytes += wasted[i]
+		if len(o.Items) < coverageTopN {
+			o.Items = append(o.Items, e)
+		}
+	}
+	if savedBytes == 0 {
+		return o, false
+	}
+	o.SavingsKB = roundKB(bytesToKB(savedBytes))
+	if downloadKbps > 0 {
+		o.SavingsMs = int(float64(savedBytes) * 8 / (downloadKbps * 1024) * 1000)
+	}
+	return o, true
+}
+
+// scriptUnusedBytes returns the script length and the number of bytes inside
+// ranges that never executed. The script's top-level range spans the whole
+// source, so its end offset is the length; count-0 ranges nest, so they're
+// merged before summing to avoid double counting.
+func scriptUnusedBytes(sc *profiler.ScriptCoverage) (total, unused int64) {
+	var zero [][2]int64
+	for _, fn := range sc.Functions {
+		if fn == nil {
+			continue
+		}
+		for _, r := range fn.Ranges {
+			if r == nil {
+				continue
+			}
+			if r.EndOffset > total {
+				total = r.EndOffset
+			}
+			if r.Count == 0 {
+				zero = append(zero, [2]int64{r.StartOffset, r.EndOffset})
+			}
+		}
+	}
+	return total, mergedLength(zero)
+}
+
+// mergedLength returns the number of offsets covered by the union of ranges.
+func mergedLength(ranges [][2]int64) int64 {
+	if len(ranges) == 0 {
+		return 0
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] < ranges[j][0] })
+	var n int64
+	curStart, curEnd := ranges[0][0], ranges[0][1]
+	for _, r := range ranges[1:] {
+		if r[0] <= curEnd {
+			if r[1] > curEnd {
+				curEnd = r[1]
+			}
+			continue
+		}
+		n += curEnd - curStart
+		curStart, curEnd = r[0], r[1]
+	}
+	return n + curEnd - curStart
+}
+
+func capEntries(e []CoverageEntry, n int) []CoverageEntry {
+	if len(e) > n {
+		return e[:n]
+	}
+	return e
+}
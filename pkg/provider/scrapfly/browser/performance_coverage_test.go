@@ -0,0 +1,147 @@
+package browser
+
+import (
+	"testing"
+
+	"github.com/chromedp/cdproto/css"
+	"github.com/chromedp/cdproto/profiler"
+)
+
+func TestMergedLength(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		ranges [][2]int64
+		want   int64
+	}{
+		{"none", nil, 0},
+		{"one", [][2]int64{{10, 30}}, 20},
+		{"disjoint", [][2]int64{{0, 10}, {20, 25}}, 15},
+		{"overlapping", [][2]int64{{0, 10}, {5, 20}}, 20},
+		{"nested", [][2]int64{{0, 100}, {10, 20}, {30, 40}}, 100},
+		{"touching", [][2]int64{{0, 10}, {10, 20}}, 20},
+		{"unsorted", [][2]int64{{50, 60}, {0, 10}, {5, 15}}, 25},
+	} {
+		if got := mergedLength(tt.ranges); got != tt.want {
+			t.Errorf("%s: mergedLength(%v) = %d, want %d", tt.name, tt.ranges, got, tt.want)
+		}
+	}
+}
+
+// fn is a function's coverage with ranges of {start, end, count}.
+func fn(ranges ...[3]int64) *profiler.FunctionCoverage {
+	f := &profiler.FunctionCoverage{}
+	for _, r := range ranges {
+		f.Ranges = append(f.Ranges, &profiler.CoverageRange{StartOffset: r[0], EndOffset: r[1], Count: r[2]})
+	}
+	return f
+}
+
+func TestScriptUnusedBytes(t *testing.T) {
+	for _, tt := range []struct {
+		name          string
+		functions     []*profiler.FunctionCoverage
+		total, unused int64
+	}{
+		{"no coverage", nil, 0, 0},
+		{"all executed", []*profiler.FunctionCoverage{fn([3]int64{0, 100, 1})}, 100, 0},
+		{"never ran", []*profiler.FunctionCoverage{fn([3]int64{0, 100, 0})}, 100, 100},
+		{"nested blocks counted once", []*profiler.FunctionCoverage{
+			fn([3]int64{0, 100, 1}, [3]int64{10, 50, 0}, [3]int64{20, 30, 0}),
+		}, 100, 40},
+		{"overlaps across functions", []*profiler.FunctionCoverage{
+			fn([3]int64{0, 100, 1}),
+			fn([3]int64{10, 50, 0}),
+			fn([3]int64{40, 70, 0}),
+		}, 100, 60},
+		{"nil entries skipped", []*profiler.FunctionCoverage{
+			nil,
+			{Ranges: []*profiler.CoverageRange{nil, {StartOffset: 0, EndOffset: 80, Count: 1}}},
+		}, 80, 0},
+	} {
+		total, unused := scriptUnusedBytes(&profiler.ScriptCoverage{Functions: tt.functions})
+		if total != tt.total || unused != tt.unused {
+			t.Errorf("%s: scriptUnusedBytes = %d, %d; want %d, %d", tt.name, total, unused, tt.total, tt.unused)
+		}
+	}
+}
+
+func TestBuildCoverage(t *testing.T) {
+	if cov, opps := buildCoverage(nil, nil, nil, nil, 1600); cov != nil || opps != nil {
+		t.Errorf("no coverage = %+v, %+v; want nil", cov, opps)
+	}
+
+	const page = "https://example.com/"
+	scripts := []*profiler.ScriptCoverage{
+		// Half of app.js never ran: 50000 of 100000 source bytes.
+		{URL: "https://example.com/app.js", Functions: []*profiler.FunctionCoverage{
+			fn([3]int64{0, 100000, 1}, [3]int64{10000, 60000, 0}),
+		}},
+		// Two inline scripts, 24000 of their 30000 bytes unused.
+		{URL: page, Functions: []*profiler.FunctionCoverage{fn([3]int64{0, 20000, 1}, [3]int64{0, 16000, 0})}},
+		{URL: page, Functions: []*profiler.FunctionCoverage{fn([3]int64{0, 10000, 1}), fn([3]int64{0, 8000, 0})}},
+		// eval'd code has no URL.
+		{Functions: []*profiler.FunctionCoverage{fn([3]int64{0, 5000, 0})}},
+	}
+	sheets := map[string]css.StyleSheetHeader{
+		"site":   {SourceURL: "https://example.com/site.css", Length: 50000, Origin: css.StyleSheetOriginRegular},
+		"inline": {SourceURL: page, Length: 4000, Origin: css.StyleSheetOriginRegular},
+		"ua":     {SourceURL: "", Length: 90000, Origin: css.StyleSheetOriginUserAgent},
+	}
+	rules := []*css.RuleUsage{
+		{StyleSheetID: "site", StartOffset: 0, EndOffset: 10000, Used: true},
+		{StyleSheetID: "site", StartOffset: 5000, EndOffset: 20000, Used: true},
+		{StyleSheetID: "site", StartOffset: 30000, EndOffset: 40000, Used: false},
+		{StyleSheetID: "inline", StartOffset: 0, EndOffset: 1000, Used: true},
+	}
+	netByID := map[string]*netReq{
+		"doc": {url: page, resType: "document", transferBytes: 200000},
+		"app": {url: "https://example.com/app.js", resType: "script", transferBytes: 60000},
+		// A retried request: the larger transfer counts.
+		"app-retry": {url: "https://example.com/app.js", resType: "script", transferBytes: 1000},
+		"css":       {url: "https://example.com/site.css", resType: "stylesheet", transferBytes: 10000},
+	}
+
+	cov, opps := buildCoverage(scripts, rules, sheets, netByID, 1600)
+	if cov == nil {
+		t.Fatal("no coverage report")
+	}
+
+	for _, tt := range []struct {
+		entries []CoverageEntry
+		want    CoverageEntry
+	}{
+		// Scaled to the 60000 transferred bytes.
+		{cov.Scripts, CoverageEntry{URL: "https://example.com/app.js", TotalKB: 58.6, UnusedKB: 29.3, UnusedPct: 50}},
+		// Inline code stays in source bytes, not the HTML's transfer size.
+		{cov.Scripts, CoverageEntry{URL: page, TotalKB: 29.3, UnusedKB: 23.4, UnusedPct: 80}},
+		{cov.Stylesheets, CoverageEntry{URL: "https://example.com/site.css", TotalKB: 9.8, UnusedKB: 5.9, UnusedPct: 60}},
+		{cov.Stylesheets, CoverageEntry{URL: page, TotalKB: 3.9, UnusedKB: 2.9, UnusedPct: 75}},
+	} {
+		var got *CoverageEntry
+		for i := range tt.entries {
+			if tt.entries[i].URL == tt.want.URL {
+				got = &tt.entries[i]
+			}
+		}
+		if got == nil || *got != tt.want {
+			t.Errorf("entry %s = %+v, want %+v", tt.want.URL, got, tt.want)
+		}
+	}
+	if len(cov.Scripts) != 2 || cov.Scripts[0].URL != "https://example.com/app.js" {
+		t.Errorf("scripts = %+v, want app.js then the inline scripts", cov.Scripts)
+	}
+	if len(cov.Stylesheets) != 2 {
+		t.Errorf("stylesheets = %+v, want the user-agent sheet skipped", cov.Stylesheets)
+	}
+	if cov.TotalJSKB != 87.9 || cov.UnusedJSKB != 52.7 {
+		t.Errorf("JS totals = %v KB, %v KB unused; want 87.9, 52.7", cov.TotalJSKB, cov.UnusedJSKB)
+	}
+
+	// Both scripts waste more than 20 KiB; no stylesheet wastes 10 KiB.
+	if len(opps) != 1 || opps[0].ID != "unused-javascript" {
+		t.Fatalf("opportunities = %+v, want only unused-javascript", opps)
+	}
+	if o := opps[0]; o.SavingsKB != 52.7 || o.SavingsMs != 263 || len(o.Items) != 2 {
+		t.Errorf("unused-javascript = %v KB, %d ms, %d items; want 52.7 KB, 263 ms, 2 items", o.SavingsKB, o.SavingsMs, len(o.Items))
+	}
+}
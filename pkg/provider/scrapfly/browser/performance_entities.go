@@ -0,0 +1,249 @@
+package browser
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// Third-party breakdown for PSI reports.
+//
+// Requests are grouped by the entity that owns their host — the same idea
+// as Lighthouse's third-party-summary, which uses the third-party-web
+// dataset. We ship a small built-in map of the tags marketing teams add
+// most often; anything unmapped is grouped by its registrable domain
+// (eTLD+1), so an unknown vendor still shows up as one line instead of
+// being scattered across CDN sub-domains. The map is extensible at runtime
+// via RegisterEntity / LoadEntitiesFile (wired to -psi-entities).
+
+// FirstPartyEntity is the label for requests on the page's own site.
+const FirstPartyEntity = "first-party"
+
+// EntityStats is the cost one entity imposed on the measured load.
+type EntityStats struct {
+	Entity              string  `json:"entity"`
+	FirstParty          bool    `json:"first_party,omitempty"`
+	Count               int     `json:"count"`
+	TransferKB          float64 `json:"transfer_kb"`
+	MainThreadMs        int     `json:"main_thread_ms"`
+	RenderBlockingCount int     `json:"render_blocking_count,omitempty"`
+}
+
+var (
+	entitiesMu sync.RWMutex
+	// entityDomains maps a domain suffix to its entity name. A request host
+	// matches when it equals the suffix or ends with "."+suffix.
+	entityDomains = map[string]string{
+		"googletagmanager.com":       "Google Tag Manager",
+		"google-analytics.com":       "Google Analytics",
+		"analytics.google.com":       "Google Analytics",
+		"doubleclick.net":            "Google Ads",
+		"googleadservices.com":       "Google Ads",
+		"googlesyndication.com":      "Google Ads",
+		"googleapis.com":             "Google APIs",
+		"gstatic.com":                "Google CDN",
+		"fonts.googleapis.com":       "Google Fonts",
+		"fonts.gstatic.com":          "Google Fonts",
+		"youtube.com":                "YouTube",
+		"ytimg.com":                  "YouTube",
+		"facebook.net":               "Facebook",
+		"facebook.com":               "Facebook",
+		"connect.facebook.net":       "Facebook",
+		"hotjar.com":                 "Hotjar",
+		"hotjar.io":                  "Hotjar",
+		"clarity.ms":                 "Microsoft Clarity",
+		"bing.com":                   "Microsoft Advertising",
+		"linkedin.com":               "LinkedIn",
+		"licdn.com":                  "LinkedIn",
+		"twitter.com":                "Twitter/X",
+		"ads-twitter.com":            "Twitter/X",
+		"tiktok.com":                 "TikTok",
+		"analytics.tiktok.com":       "TikTok",
+		"hubspot.com":                "HubSpot",
+		"hs-scripts.com":             "HubSpot",
+		"hs-analytics.net":           "HubSpot",
+		"hsforms.net":                "HubSpot",
+		"intercom.io":                "Intercom",
+		"intercomcdn.com":            "Intercom",
+		"crisp.chat":                 "Crisp",
+		"segment.com":                "Segment",
+		"segment.io":                 "Segment",
+		"posthog.com":                "PostHog",
+		"mixpanel.com":               "Mixpanel",
+		"amplitude.com":              "Amplitude",
+		"sentry.io":                  "Sentry",
+		"sentry-cdn.com":             "Sentry",
+		"newrelic.com":               "New Relic",
+		"nr-data.net":                "New Relic",
+		"cookielaw.org":              "OneTrust",
+		"onetrust.com":               "OneTrust",
+		"cookiebot.com":              "Cookiebot",
+		"stripe.com":                 "Stripe",
+		"cloudflareinsights.com":     "Cloudflare Web Analytics",
+		"cdnjs.cloudflare.com":       "cdnjs",
+		"jsdelivr.net":               "jsDelivr",
+		"unpkg.com":                  "unpkg",
+		"typekit.net":                "Adobe Fonts",
+		"adobedtm.com":               "Adobe Tag Manager",
+		"omtrdc.net":                 "Adobe Analytics",
+		"demdex.net":                 "Adobe Audience Manager",
+		"criteo.com":                 "Criteo",
+		"criteo.net":                 "Criteo",
+		"taboola.com":                "Taboola",
+		"outbrain.com":               "Outbrain",
+		"pinterest.com":              "Pinterest",
+		"pinimg.com":                 "Pinterest",
+		"snapchat.com":               "Snapchat",
+		"sc-static.net":              "Snapchat",
+		"zendesk.com":                "Zendesk",
+		"zdassets.com":               "Zendesk",
+		"vimeo.com":                  "Vimeo",
+		"vimeocdn.com":               "Vimeo",
+		"optimizely.com":             "Optimizely",
+		"vwo.com":                    "VWO",
+		"visualwebsiteoptimizer.com": "VWO",
+	}
+)
+
+// RegisterEntity maps the given domains (suffix match) to entity, overriding
+// any built-in mapping for the same domain.
+func RegisterEntity(entity string, domains ...string) {
+	entitiesMu.Lock()
+	defer entitiesMu.Unlock()
+	for _, d := range domains {
+		d = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(d), "."))
+		if d != "" {
+			entityDomains[d] = entity
+		}
+	}
+}
+
+// LoadEntitiesFile merges a JSON file of the form
+//
+//	{"Acme Tags": ["acmetags.com", "cdn.acmetags.net"], ...}
+//
+// into the entity map.
+func LoadEntitiesFile(path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var m map[string][]string
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	for entity, domains := range m {
+		RegisterEntity(entity, domains...)
+	}
+	return nil
+}
+
+// entityForHost returns the entity owning host, falling back to the host's
+// registrable domain. firstPartySite is the eTLD+1 of the measured page.
+func entityForHost(host, firstPartySite string) (string, bool) {
+	host = strings.ToLower(host)
+	if host == "" {
+		return "other", false
+	}
+	site := registrableDomain(host)
+	if firstPartySite != "" && site == firstPartySite {
+		return FirstPartyEntity, true
+	}
+	entitiesMu.RLock()
+	defer entitiesMu.RUnlock()
+	// Longest matching suffix wins so fonts.googleapis.com beats googleapis.com.
+	best, bestLen := "", 0
+	for d, e := range entityDomains {
+		if (host == d || strings.HasSuffix(host, "."+d)) && len(d) > bestLen {
+			best, bestLen = e, len(d)
+		}
+	}
+	if best != "" {
+		return best, false
+	}
+	return site, false
+}
+
+func registrableDomain(host string) string {
+	if site, err := publicsuffix.EffectiveTLDPlusOne(host); err == nil {
+		return site
+	}
+	return host
+}
+
+func hostOf(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// buildEntityReport groups requests by entity. scriptTime is per-script-URL
+// main-thread time (from Long Animation Frame script attribution) and is
+// folded into the owning entity. Must run after buildResourceReport, which
+// sets netReq.isRenderBlocking.
+func buildEntityReport(netByID map[string]*netReq, pageURL string, scriptTime []fallbackScript) []EntityStats {
+	firstPartySite := ""
+	if h := hostOf(pageURL); h != "" {
+		firstPartySite = registrableDomain(h)
+	} else {
+		// No page URL (fallback failed) — the main document is still first party.
+		for _, r := range netByID {
+			if r.resType == "document" {
+				firstPartySite = registrableDomain(hostOf(r.url))
+				break
+			}
+		}
+	}
+
+	byEntity := map[string]*EntityStats{}
+	get := func(host string) *EntityStats {
+		name, fp := entityForHost(host, firstPartySite)
+		st := byEntity[name]
+		if st == nil {
+			st = &EntityStats{Entity: name, FirstParty: fp}
+			byEntity[name] = st
+		}
+		return st
+	}
+	for _, r := range netByID {
+		if strings.HasPrefix(r.url, "data:") || strings.HasPrefix(r.url, "blob:") {
+			continue
+		}
+		st := get(hostOf(r.url))
+		st.Count++
+		st.TransferKB += bytesToKB(r.transferBytes)
+		if r.isRenderBlocking {
+			st.RenderBlockingCount++
+		}
+	}
+	for _, sc := range scriptTime {
+		if sc.URL == "" || sc.DurationMs <= 0 {
+			continue
+		}
+		get(hostOf(sc.URL)).MainThreadMs += int(sc.DurationMs)
+	}
+
+	out := make([]EntityStats, 0, len(byEntity))
+	for _, st := range byEntity {
+		st.TransferKB = roundKB(st.TransferKB)
+		out = append(out, *st)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].TransferKB != out[j].TransferKB {
+			return out[i].TransferKB > out[j].TransferKB
+		}
+		return out[i].MainThreadMs > out[j].MainThreadMs
+	})
+	if len(out) > 20 {
+		out = out[:20]
+	}
+	return out
+}
@@ -0,0 +1,56 @@
+package browser
+
+import "testing"
+
+func TestEntityForHost(t *testing.T) {
+	RegisterEntity("Acme Tags", ".AcmeTags.com ", "cdn.acme.net")
+	t.Cleanup(func() {
+		entitiesMu.Lock()
+		defer entitiesMu.Unlock()
+		delete(entityDomains, "acmetags.com")
+		delete(entityDomains, "cdn.acme.net")
+	})
+
+	for _, tt := range []struct {
+		host, firstParty string
+		entity           string
+		isFirstParty     bool
+	}{
+		{"", "example.com", "other", false},
+		{"example.com", "example.com", FirstPartyEntity, true},
+		{"static.cdn.example.com", "example.com", FirstPartyEntity, true},
+		{"WWW.Example.COM", "example.com", FirstPartyEntity, true},
+		{"shop.example.co.uk", "example.co.uk", FirstPartyEntity, true},
+		// A site sharing only the public suffix is not first party.
+		{"other.co.uk", "example.co.uk", "other.co.uk", false},
+
+		{"googletagmanager.com", "example.com", "Google Tag Manager", false},
+		{"www.googletagmanager.com", "example.com", "Google Tag Manager", false},
+		// The longest mapped suffix wins.
+		{"fonts.googleapis.com", "example.com", "Google Fonts", false},
+		{"maps.googleapis.com", "example.com", "Google APIs", false},
+		{"connect.facebook.net", "example.com", "Facebook", false},
+		// A suffix only matches on a label boundary.
+		{"notgoogleapis.com", "example.com", "notgoogleapis.com", false},
+
+		// A mapped vendor measuring its own site is first party.
+		{"www.hotjar.com", "hotjar.com", FirstPartyEntity, true},
+		{"script.hotjar.com", "", "Hotjar", false},
+
+		// Unmapped hosts group under their registrable domain.
+		{"cdn1.vendor.io", "example.com", "vendor.io", false},
+		{"img.vendor.co.uk", "example.com", "vendor.co.uk", false},
+		{"localhost", "example.com", "localhost", false},
+
+		// Registered domains are normalised and suffix matched too.
+		{"acmetags.com", "example.com", "Acme Tags", false},
+		{"eu.AcmeTags.com", "example.com", "Acme Tags", false},
+		{"cdn.acme.net", "example.com", "Acme Tags", false},
+		{"www.acme.net", "example.com", "acme.net", false},
+	} {
+		entity, fp := entityForHost(tt.host, tt.firstParty)
+		if entity != tt.entity || fp != tt.isFirstParty {
+			t.Errorf("entityForHost(%q, %q) = %q, %v; want %q, %v", tt.host, tt.firstParty, entity, fp, tt.entity, tt.isFirstParty)
+		}
+	}
+}
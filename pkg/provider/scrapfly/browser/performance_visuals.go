@@ -0,0 +1,367 @@
+package browser
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+)
+
+// Visual output for PSI runs: a filmstrip of what the user saw while the
+// page loaded (the filmstrip row under PSI's lab data) and a screenshot with the
+// LCP element outlined. Both are PNGs the tool handler returns as MCP image
+// content; the JSON report only carries their metadata.
+
+const (
+	// maxVisualBytes caps each encoded PNG. MCP clients inline image content
+	// into the model context, so a 5 MB filmstrip is worse than none.
+	maxVisualBytes = 1 << 20
+	// maxFilmstripWidth caps the stitched image width in pixels.
+	maxFilmstripWidth          = 1600
+	maxFilmstripFrames         = 12
+	defaultFilmstripIntervalMs = 1000
+	// maxLCPShotWidth caps the LCP screenshot width; DPR-3 mobile captures
+	// are 1080px wide, which is more detail than an outline needs.
+	maxLCPShotWidth = 720
+)
+
+// Visuals describes the images attached to a PSI run. The PNG bytes are not
+// serialized into the JSON report; the tool handler emits them as images.
+type Visuals struct {
+	FilmstripFramesMs []int  `json:"filmstrip_frames_ms,omitempty"`
+	FilmstripWidth    int    `json:"filmstrip_width,omitempty"`
+	FilmstripHeight   int    `json:"filmstrip_height,omitempty"`
+	LCPRect           *Rect  `json:"lcp_rect,omitempty"`
+	FilmstripPNG      []byte `json:"-"`
+	LCPScreenshotPNG  []byte `json:"-"`
+}
+
+// Rect is a CSS-pixel, viewport-relative box.
+type Rect struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// ── Filmstrip ──────────────────────────────────────────────────────────────
+
+// buildFilmstrip samples frames every intervalMs from navStartMs (epoch ms)
+// until the last frame, downscales them and stitches them left-to-right
+// with a timestamp label under each. The interval widens when the load is
+// long enough to exceed maxFilmstripFrames. Returns nil PNG when there are
+// no frames after navigation.
+func buildFilmstrip(frames []screenFrame, navStartMs float64, intervalMs int) ([]byte, []int, image.Point, error) {
+	if intervalMs <= 0 {
+		intervalMs = defaultFilmstripIntervalMs
+	}
+	if len(frames) == 0 {
+		return nil, nil, image.Point{}, nil
+	}
+	lastMs := int(frames[len(frames)-1].timestampMs - navStartMs)
+	if lastMs <= 0 {
+		return nil, nil, image.Point{}, nil
+	}
+	for lastMs/intervalMs+1 > maxFilmstripFrames {
+		intervalMs *= 2
+	}
+
+	// For each tick pick the newest frame painted at or before it — that's
+	// what was on screen at that moment.
+	var picked []screenFrame
+	var at []int
+	for t := intervalMs; ; t += intervalMs {
+		if t > lastMs {
+			t = lastMs
+		}
+		var best *screenFrame
+		for i := range frames {
+			if frames[i].timestampMs-navStartMs <= float64(t) {
+				best = &frames[i]
+			}
+		}
+		if best != nil {
+			picked = append(picked, *best)
+			at = append(at, t)
+		}
+		if t == lastMs {
+			break
+		}
+	}
+	if len(picked) == 0 {
+		return nil, nil, image.Point{}, nil
+	}
+
+	decoded := make([]image.Image, 0, len(picked))
+	keptAt := make([]int, 0, len(picked))
+	for i, f := range picked {
+		img, err := jpeg.Decode(bytes.NewReader(f.data))
+		if err != nil {
+			continue
+		}
+		decoded = append(decoded, img)
+		keptAt = append(keptAt, at[i])
+	}
+	if len(decoded) == 0 {
+		return nil, nil, image.Point{}, fmt.Errorf("no decodable screencast frames")
+	}
+
+	const gap = 8
+	src := decoded[0].Bounds()
+	thumbW := (maxFilmstripWidth - gap*(len(decoded)+1)) / len(decoded)
+	if thumbW > 240 {
+		thumbW = 240
+	}
+	if thumbW > src.Dx() {
+		thumbW = src.Dx()
+	}
+	// Shrink until the PNG fits the byte budget.
+	for attempt := 0; attempt < 5 && thumbW >= 40; attempt++ {
+		thumbH := src.Dy() * thumbW / src.Dx()
+		out := stitchFilmstrip(decoded, keptAt, thumbW, thumbH, gap)
+		encoded, err := encodePNG(out)
+		if err != nil {
+			return nil, nil, image.Point{}, err
+		}
+		if len(encoded) <= maxVisualBytes {
+			return encoded, keptAt, out.Bounds().Size(), nil
+		}
+		thumbW = thumbW * 3 / 4
+	}
+	return nil, nil, image.Point{}, fmt.Errorf("filmstrip exceeds %d bytes even at minimum size", maxVisualBytes)
+}
+
+func stitchFilmstrip(frames []image.Image, atMs []int, thumbW, thumbH, gap int) *image.RGBA {
+	const labelH = 5*glyphScale + 8
+	w := gap + len(frames)*(thumbW+gap)
+	h := gap + thumbH + labelH
+	out := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(out, out.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	border := color.RGBA{0xcc, 0xcc, 0xcc, 0xff}
+	for i, f := range frames {
+		x := gap + i*(thumbW+gap)
+		thumb := downscale(f, thumbW, thumbH)
+		draw.Draw(out, image.Rect(x, gap, x+thumbW, gap+thumbH), thumb, image.Point{}, draw.Src)
+		strokeRect(out, image.Rect(x-1, gap-1, x+thumbW+1, gap+thumbH+1), 1, border)
+		label := fmt.Sprintf("%.1fs", float64(atMs[i])/1000)
+		lw := len(label)*4*glyphScale - glyphScale
+		drawText(out, x+(thumbW-lw)/2, gap+thumbH+4, label, color.Black)
+	}
+	return out
+}
+
+// ── LCP screenshot ─────────────────────────────────────────────────────────
+
+// captureLCPScreenshot captures the viewport and outlines the current LCP
+// element. Must be called while the preset emulation is still applied so
+// the capture matches the measured viewport, and NOT while holding the
+// collectors' mutex (SendCDP needs the reader goroutine, which may be
+// blocked dispatching a collector event).
+func captureLCPScreenshot(ctx context.Context, s *Session) ([]byte, *Rect, error) {
+	raw, err := s.SendCDPCtx(ctx, "Runtime.evaluate", map[string]any{
+		"expression":    lcpRectScript,
+		"returnByValue": true,
+		"awaitPromise":  true,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("locate LCP element: %w", err)
+	}
+	var env struct {
+		Result struct {
+			Value *struct {
+				Rect
+				ViewportWidth float64 `json:"vw"`
+			} `json:"value"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return nil, nil, err
+	}
+	lcp := env.Result.Value
+	if lcp == nil || lcp.Width <= 0 || lcp.Height <= 0 {
+		return nil, nil, fmt.Errorf("LCP element not found or no longer in the DOM")
+	}
+
+	shotRaw, err := s.SendCDPCtx(ctx, "Page.captureScreenshot", map[string]any{"format": "png"})
+	if err != nil {
+		return nil, nil, fmt.Errorf("Page.captureScreenshot: %w", err)
+	}
+	var shot struct {
+		Data string `json:"data"`
+	}
+	if err := json.Unmarshal(shotRaw, &shot); err != nil {
+		return nil, nil, err
+	}
+	pngBytes, err := base64.StdEncoding.DecodeString(shot.Data)
+	if err != nil {
+		return nil, nil, err
+	}
+	img, err := png.Decode(bytes.NewReader(pngBytes))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Screenshot pixels = CSS px × DPR; derive the factor from the capture
+	// itself rather than trusting the preset.
+	b := img.Bounds()
+	k := 1.0
+	if lcp.ViewportWidth > 0 {
+		k = float64(b.Dx()) / lcp.ViewportWidth
+	}
+	outW := b.Dx()
+	if outW > maxLCPShotWidth {
+		outW = maxLCPShotWidth
+	}
+	outH := b.Dy() * outW / b.Dx()
+	scale := k * float64(outW) / float64(b.Dx())
+
+	canvas := image.NewRGBA(image.Rect(0, 0, outW, outH))
+	draw.Draw(canvas, canvas.Bounds(), downscale(img, outW, outH), image.Point{}, draw.Src)
+	box := image.Rect(
+		int(lcp.X*scale), int(lcp.Y*scale),
+		int((lcp.X+lcp.Width)*scale), int((lcp.Y+lcp.Height)*scale),
+	).Intersect(canvas.Bounds())
+	if !box.Empty() {
+		strokeRect(canvas, box, 3, color.RGBA{0xe5, 0x1c, 0x23, 0xff})
+	}
+
+	for {
+		encoded, err := encodePNG(canvas)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(encoded) <= maxVisualBytes {
+			return encoded, &lcp.Rect, nil
+		}
+		w, h := canvas.Bounds().Dx()*3/4, canvas.Bounds().Dy()*3/4
+		if w < 120 {
+			return nil, nil, fmt.Errorf("LCP screenshot exceeds %d bytes", maxVisualBytes)
+		}
+		smaller := image.NewRGBA(image.Rect(0, 0, w, h))
+		draw.Draw(smaller, smaller.Bounds(), downscale(canvas, w, h), image.Point{}, draw.Src)
+		canvas = smaller
+	}
+}
+
+// lcpRectScript returns the viewport-relative box of the latest LCP entry's
+// element, or null when the element is gone (LCP nodes are often replaced
+// by hydration).
+const lcpRectScript = `(async () => {
+  const entries = await new Promise(resolve => {
+    const out = [];
+    try {
+      const obs = new PerformanceObserver(list => out.push(...list.getEntries()));
+      obs.observe({type: 'largest-contentful-paint', buffered: true});
+      setTimeout(() => { try { obs.disconnect(); } catch {} resolve(out); }, 80);
+    } catch (e) { resolve([]); }
+  });
+  const last = entries[entries.length - 1];
+  if (!last || !last.element || !last.element.isConnected) return null;
+  const r = last.element.getBoundingClientRect();
+  return { x: r.x, y: r.y, width: r.width, height: r.height, vw: window.innerWidth };
+})()`
+
+// ── Raster helpers ─────────────────────────────────────────────────────────
+
+// downscale box-filters src into a w×h RGBA. Box averaging keeps text and
+// thin lines legible where nearest-neighbour would alias them away.
+func downscale(src image.Image, w, h int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	sb := src.Bounds()
+	sw, sh := sb.Dx(), sb.Dy()
+	for y := 0; y < h; y++ {
+		y0 := sb.Min.Y + y*sh/h
+		y1 := sb.Min.Y + (y+1)*sh/h
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < w; x++ {
+			x0 := sb.Min.X + x*sw/w
+			x1 := sb.Min.X + (x+1)*sw/w
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+			var r, g, b, n uint32
+			for yy := y0; yy < y1; yy++ {
+				for xx := x0; xx < x1; xx++ {
+					cr, cg, cb, _ := src.At(xx, yy).RGBA()
+					r += cr >> 8
+					g += cg >> 8
+					b += cb >> 8
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{uint8(r / n), uint8(g / n), uint8(b / n), 0xff})
+		}
+	}
+	return dst
+}
+
+func strokeRect(img *image.RGBA, r image.Rectangle, thickness int, c color.Color) {
+	u := image.NewUniform(c)
+	for t := 0; t < thickness; t++ {
+		in := image.Rect(r.Min.X+t, r.Min.Y+t, r.Max.X-t, r.Max.Y-t)
+		if in.Empty() {
+			return
+		}
+		draw.Draw(img, image.Rect(in.Min.X, in.Min.Y, in.Max.X, in.Min.Y+1), u, image.Point{}, draw.Src)
+		draw.Draw(img, image.Rect(in.Min.X, in.Max.Y-1, in.Max.X, in.Max.Y), u, image.Point{}, draw.Src)
+		draw.Draw(img, image.Rect(in.Min.X, in.Min.Y, in.Min.X+1, in.Max.Y), u, image.Point{}, draw.Src)
+		draw.Draw(img, image.Rect(in.Max.X-1, in.Min.Y, in.Max.X, in.Max.Y), u, image.Point{}, draw.Src)
+	}
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := enc.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// glyphScale is the pixel size of one dot of the 3×5 label font.
+const glyphScale = 2
+
+// glyphs is a 3×5 bitmap font covering the characters timestamps need.
+// Each row is 3 bits, MSB = leftmost column.
+var glyphs = map[rune][5]uint8{
+	'0': {7, 5, 5, 5, 7},
+	'1': {2, 6, 2, 2, 7},
+	'2': {7, 1, 7, 4, 7},
+	'3': {7, 1, 7, 1, 7},
+	'4': {5, 5, 7, 1, 1},
+	'5': {7, 4, 7, 1, 7},
+	'6': {7, 4, 7, 5, 7},
+	'7': {7, 1, 1, 1, 1},
+	'8': {7, 5, 7, 5, 7},
+	'9': {7, 5, 7, 1, 7},
+	'.': {0, 0, 0, 0, 2},
+	's': {0, 3, 6, 1, 6},
+}
+
+func drawText(img *image.RGBA, x, y int, text string, c color.Color) {
+	u := image.NewUniform(c)
+	for _, ch := range text {
+		g, ok := glyphs[ch]
+		if ok {
+			for row := 0; row < 5; row++ {
+				for col := 0; col < 3; col++ {
+					if g[row]&(4>>col) == 0 {
+						continue
+					}
+					px := x + col*glyphScale
+					py := y + row*glyphScale
+					draw.Draw(img, image.Rect(px, py, px+glyphScale, py+glyphScale), u, image.Point{}, draw.Src)
+				}
+			}
+		}
+		x += 4 * glyphScale
+	}
+}
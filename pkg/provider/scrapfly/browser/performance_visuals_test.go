@@ -0,0 +1,130 @@
+package browser
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"slices"
+	"testing"
+	"time"
+)
+
+// jpegFrame encodes a 160x100 screencast frame.
+func jpegFrame(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 160, 100))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{0x20, 0x60, 0xa0, 0xff}), image.Point{}, draw.Src)
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestBuildFilmstrip(t *testing.T) {
+	const navStart = 1_000_000.0
+	good, bad := jpegFrame(t), []byte("not a jpeg")
+	// frames builds a capture with a frame at each offset (ms after
+	// navigation); the frames at the offsets in broken are undecodable.
+	frames := func(broken []float64, offsets ...float64) []screenFrame {
+		var out []screenFrame
+		for _, ms := range offsets {
+			data := good
+			if slices.Contains(broken, ms) {
+				data = bad
+			}
+			out = append(out, screenFrame{timestampMs: navStart + ms, data: data})
+		}
+		return out
+	}
+
+	for _, tt := range []struct {
+		name       string
+		frames     []screenFrame
+		intervalMs int
+		at         []int // nil: no filmstrip
+		wantErr    bool
+	}{
+		{"no frames", nil, 1000, nil, false},
+		{"nothing after navigation", frames(nil, -500, 0), 1000, nil, false},
+		{"a tick per interval, then the last frame", frames(nil, 0, 500, 2500), 1000, []int{1000, 2000, 2500}, false},
+		{"ticks before the first paint are skipped", frames(nil, 1500, 3000), 1000, []int{2000, 3000}, false},
+		{"the default interval", frames(nil, 0, 2000), 0, []int{1000, 2000}, false},
+		{"a custom interval", frames(nil, 0, 1000), 250, []int{250, 500, 750, 1000}, false},
+		{"a long load widens the interval", frames(nil, 0, 30000), 1000,
+			[]int{4000, 8000, 12000, 16000, 20000, 24000, 28000, 30000}, false},
+		{"undecodable frames are dropped", frames([]float64{0}, 0, 1500, 2000), 1000, []int{2000}, false},
+		{"no decodable frames", frames([]float64{0, 2000}, 0, 2000), 1000, nil, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			pngData, at, size, err := buildFilmstrip(tt.frames, navStart, tt.intervalMs)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("built a filmstrip, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(at, tt.at) {
+				t.Errorf("frames at %v ms, want %v", at, tt.at)
+			}
+			if tt.at == nil {
+				if pngData != nil {
+					t.Error("returned a PNG with no frames")
+				}
+				return
+			}
+			if len(at) > maxFilmstripFrames {
+				t.Errorf("%d frames, want at most %d", len(at), maxFilmstripFrames)
+			}
+
+			img, err := png.Decode(bytes.NewReader(pngData))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Thumbnails keep the frame's size, 8px apart, over their labels.
+			want := image.Point{X: 8 + len(at)*(160+8), Y: 8 + 100 + 5*glyphScale + 8}
+			if size != want || img.Bounds().Size() != want {
+				t.Errorf("size = %v, PNG is %v, want %v", size, img.Bounds().Size(), want)
+			}
+		})
+	}
+}
+
+func TestFilmstripAnchoredOnBrowserClock(t *testing.T) {
+	if got := navStartWallMs(nil); got != 0 {
+		t.Errorf("navStartWallMs(nil) = %v, want 0", got)
+	}
+
+	// The browser's wall clock runs an hour behind the host's; its
+	// monotonic clock has nothing to do with either.
+	browserNav := float64(time.Now().Add(-time.Hour).UnixMilli())
+	netByID := map[string]*netReq{
+		"img": {url: "https://example.com/hero.jpg", startMs: 5_300, wallMs: browserNav + 300},
+		"doc": {url: "https://example.com/", startMs: 5_000, wallMs: browserNav},
+		"app": {url: "https://example.com/app.js", startMs: 5_120, wallMs: browserNav + 120},
+	}
+	navStart := navStartWallMs(netByID)
+	if navStart != browserNav {
+		t.Fatalf("navStartWallMs = %v, want the document request's %v", navStart, browserNav)
+	}
+
+	good := jpegFrame(t)
+	frames := []screenFrame{
+		{timestampMs: browserNav - 200, data: good}, // the about:blank baseline
+		{timestampMs: browserNav + 900, data: good},
+		{timestampMs: browserNav + 1800, data: good},
+	}
+	_, at, _, err := buildFilmstrip(frames, navStart, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{1000, 1800}; !slices.Equal(at, want) {
+		t.Errorf("frames at %v ms, want %v", at, want)
+	}
+}
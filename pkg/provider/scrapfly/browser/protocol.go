@@ -0,0 +1,42 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Typed bindings for the custom CDP domains (Antibot, WebMCP,
+// ScrapiumBrowser) live in the *_gen.go files, generated by internal/cdpgen
+// from browser_protocol.json plus scrapium_protocol.json (ScrapiumBrowser
+// isn't in the upstream file). Standard domains come from chromedp/cdproto.
+
+//go:generate go run ../../../../internal/cdpgen -mode bindings -protocol ../../../../browser_protocol.json -protocol ../../../../scrapium_protocol.json -domains Antibot,WebMCP,ScrapiumBrowser -out .
+
+// call sends method with params on the page session and decodes the result
+// into ret (skipped when ret is nil). Used by the generated Session methods.
+func (s *Session) call(ctx context.Context, method string, params, ret any) error {
+	raw, err := s.SendCDPCtx(ctx, method, params)
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	if ret == nil || len(raw) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(raw, ret); err != nil {
+		return fmt.Errorf("%s: decode result: %w", method, err)
+	}
+	return nil
+}
+
+// onEvent is the typed counterpart of Session.OnEvent used by the generated
+// On<Domain><Event> methods. Events that fail to decode are skipped.
+func onEvent[T any](s *Session, method string, fn func(*T) bool) (unsubscribe func()) {
+	return s.OnEvent(method, func(_ string, params json.RawMessage) bool {
+		var evt T
+		if err := json.Unmarshal(params, &evt); err != nil {
+			return true
+		}
+		return fn(&evt)
+	})
+}
@@ -0,0 +1,257 @@
+package browser
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"image/jpeg"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Screencast recording.
+//
+// A Recording subscribes to the session's screencast and samples the
+// latest frame at a fixed rate into an MJPEG AVI. Chrome only sends a frame
+// when the page repaints, so while the page is idle (or repaints to the
+// same pixels) the sampled frame is a repeat and costs an empty chunk.
+
+// Recording limits.
+const (
+	DefaultRecordingFPS      = 5
+	MaxRecordingFPS          = 30
+	DefaultRecordingWidth    = 1280
+	DefaultRecordingHeight   = 720
+	DefaultRecordingDuration = 10 * time.Minute
+	MaxRecordingDuration     = 30 * time.Minute
+)
+
+// RecordingOptions configures StartRecording. Zero values take the defaults.
+type RecordingOptions struct {
+	Path        string // output file, required
+	FPS         int
+	MaxWidth    int
+	MaxHeight   int
+	Quality     int // JPEG quality, default 70
+	MaxDuration time.Duration
+}
+
+// RecordingInfo describes a finished (or running) recording.
+type RecordingInfo struct {
+	SessionID    string        `json:"session_id"`
+	Path         string        `json:"path"`
+	Started      time.Time     `json:"started"`
+	Duration     time.Duration `json:"-"`
+	Seconds      float64       `json:"duration_seconds"`
+	FPS          int           `json:"fps"`
+	Width        int           `json:"width"`
+	Height       int           `json:"height"`
+	Frames       int           `json:"frames"`
+	UniqueFrames int           `json:"unique_frames"`
+	Size         int64         `json:"size,omitempty"`
+	SHA256       string        `json:"sha256,omitempty"`
+	Error        string        `json:"error,omitempty"`
+}
+
+// Recording is a screencast being written to disk.
+type Recording struct {
+	session *Session
+	opts    RecordingOptions
+	started time.Time
+
+	mu      sync.Mutex
+	latest  []byte // latest decoded frame, nil once written
+	lastSum [32]byte
+	avi     *aviWriter
+	unique  int
+
+	stopScreencast func()
+	stop           chan struct{}
+	stopOnce       sync.Once
+	done           chan struct{}
+	info           RecordingInfo
+}
+
+// StartRecording starts recording the session's screencast to opts.Path.
+// The recording stops on Stop, after opts.MaxDuration, or when the session
+// closes, whichever comes first.
+func (s *Session) StartRecording(opts RecordingOptions) (*Recording, error) {
+	if opts.Path == "" {
+		return nil, fmt.Errorf("recording path is required")
+	}
+	if opts.FPS <= 0 {
+		opts.FPS = DefaultRecordingFPS
+	}
+	opts.FPS = min(opts.FPS, MaxRecordingFPS)
+	if opts.MaxWidth <= 0 {
+		opts.MaxWidth = DefaultRecordingWidth
+	}
+	if opts.MaxHeight <= 0 {
+		opts.MaxHeight = DefaultRecordingHeight
+	}
+	if opts.Quality <= 0 {
+		opts.Quality = 70
+	}
+	if opts.MaxDuration <= 0 {
+		opts.MaxDuration = DefaultRecordingDuration
+	}
+	opts.MaxDuration = min(opts.MaxDuration, MaxRecordingDuration)
+
+	avi, err := newAVIWriter(opts.Path, opts.FPS)
+	if err != nil {
+		return nil, err
+	}
+	r := &Recording{
+		session: s,
+		opts:    opts,
+		started: time.Now(),
+		avi:     avi,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	r.stopScreencast = s.StartScreencastWith(ScreencastOptions{
+		Format:    "jpeg",
+		Quality:   opts.Quality,
+		MaxWidth:  opts.MaxWidth,
+		MaxHeight: opts.MaxHeight,
+	}, r.onFrame)
+	go r.run()
+	return r, nil
+}
+
+// onFrame keeps the latest frame for the next tick.
+func (r *Recording) onFrame(frame ScreencastFrame) {
+	data, err := base64.StdEncoding.DecodeString(frame.Data)
+	if err != nil {
+		return
+	}
+	r.mu.Lock()
+	r.latest = data
+	r.mu.Unlock()
+}
+
+// run writes one frame per tick until the recording stops.
+func (r *Recording) run() {
+	defer close(r.done)
+	ticker := time.NewTicker(time.Second / time.Duration(r.opts.FPS))
+	defer ticker.Stop()
+	deadline := time.NewTimer(r.opts.MaxDuration)
+	defer deadline.Stop()
+	closed := r.session.closedChan()
+
+	var err error
+	for err == nil {
+		select {
+		case <-ticker.C:
+			err = r.tick()
+		case <-deadline.C:
+			log.Printf("[CDP] Recording of session %s reached its %s limit", r.session.SessionID, r.opts.MaxDuration)
+			r.finish(nil)
+			return
+		case <-closed:
+			r.finish(nil)
+			return
+		case <-r.stop:
+			r.finish(nil)
+			return
+		}
+	}
+	r.finish(err)
+}
+
+// tick writes the latest frame, or a repeat if nothing new arrived or it's
+// pixel-identical to the previous one. Nothing is written before the first
+// frame.
+func (r *Recording) tick() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.latest == nil {
+		if r.unique == 0 {
+			return nil
+		}
+		return r.avi.WriteFrame(nil, 0, 0)
+	}
+	data := r.latest
+	r.latest = nil
+	sum := sha256.Sum256(data)
+	if r.unique > 0 && sum == r.lastSum {
+		return r.avi.WriteFrame(nil, 0, 0)
+	}
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil // skip frames that aren't valid JPEG
+	}
+	r.lastSum = sum
+	r.unique++
+	return r.avi.WriteFrame(data, cfg.Width, cfg.Height)
+}
+
+// finish stops the screencast subscription and finalizes the file.
+func (r *Recording) finish(err error) {
+	r.stopScreencast()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cerr := r.avi.Close(); err == nil {
+		err = cerr
+	}
+	r.info = r.snapshot()
+	if err != nil {
+		r.info.Error = err.Error()
+		return
+	}
+	if f, err := os.Open(r.opts.Path); err == nil {
+		h := sha256.New()
+		r.info.Size, _ = io.Copy(h, f)
+		r.info.SHA256 = hex.EncodeToString(h.Sum(nil))
+		f.Close()
+	}
+}
+
+// snapshot describes the recording so far. Callers hold r.mu.
+func (r *Recording) snapshot() RecordingInfo {
+	frames := r.avi.Frames()
+	d := time.Duration(frames) * time.Second / time.Duration(r.opts.FPS)
+	return RecordingInfo{
+		SessionID:    r.session.SessionID,
+		Path:         r.opts.Path,
+		Started:      r.started,
+		Duration:     d,
+		Seconds:      d.Seconds(),
+		FPS:          r.opts.FPS,
+		Width:        r.avi.width,
+		Height:       r.avi.height,
+		Frames:       frames,
+		UniqueFrames: r.unique,
+	}
+}
+
+// Stop ends the recording and returns the finished file's details.
+// Stopping a recording that already ended just returns them.
+func (r *Recording) Stop() RecordingInfo {
+	r.stopOnce.Do(func() { close(r.stop) })
+	<-r.done
+	return r.info
+}
+
+// Done is closed once the recording has ended and its file is final.
+func (r *Recording) Done() <-chan struct{} {
+	return r.done
+}
+
+// Info describes the recording: progress so far while it runs, the final
+// file once Done.
+func (r *Recording) Info() RecordingInfo {
+	select {
+	case <-r.done:
+		return r.info
+	default:
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.snapshot()
+}
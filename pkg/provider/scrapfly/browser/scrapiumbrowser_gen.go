@@ -0,0 +1,106 @@
+// Code generated by cdpgen from browser_protocol.json, scrapium_protocol.json. DO NOT EDIT.
+
+package browser
+
+import (
+	"context"
+)
+
+// ScrapiumBrowser command and event method names.
+const (
+	CommandScrapiumBrowserHasDownloads          = "ScrapiumBrowser.hasDownloads"
+	CommandScrapiumBrowserGetDownloadsMetadatas = "ScrapiumBrowser.getDownloadsMetadatas"
+	CommandScrapiumBrowserGetDownload           = "ScrapiumBrowser.getDownload"
+	CommandScrapiumBrowserGetDownloads          = "ScrapiumBrowser.getDownloads"
+)
+
+// ── Types ───────────────────────────────────────────────────────────────────
+
+// ── Commands ────────────────────────────────────────────────────────────────
+
+// ScrapiumBrowserHasDownloadsReturns return values of ScrapiumBrowser.hasDownloads.
+type ScrapiumBrowserHasDownloadsReturns struct {
+	Result bool `json:"result"`
+}
+
+// ScrapiumBrowserHasDownloads reports whether any file has been downloaded in this session.
+func (s *Session) ScrapiumBrowserHasDownloads(ctx context.Context) (*ScrapiumBrowserHasDownloadsReturns, error) {
+	var ret ScrapiumBrowserHasDownloadsReturns
+	if err := s.call(ctx, CommandScrapiumBrowserHasDownloads, nil, &ret); err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// ScrapiumBrowserGetDownloadsMetadatasReturns return values of ScrapiumBrowser.getDownloadsMetadatas.
+type ScrapiumBrowserGetDownloadsMetadatasReturns struct {
+	// Metadata filename to size in bytes.
+	Metadata map[string]int64 `json:"metadata"`
+}
+
+// ScrapiumBrowserGetDownloadsMetadatas returns the name and size of every downloaded file.
+func (s *Session) ScrapiumBrowserGetDownloadsMetadatas(ctx context.Context) (*ScrapiumBrowserGetDownloadsMetadatasReturns, error) {
+	var ret ScrapiumBrowserGetDownloadsMetadatasReturns
+	if err := s.call(ctx, CommandScrapiumBrowserGetDownloadsMetadatas, nil, &ret); err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// ScrapiumBrowserGetDownloadParams parameters of ScrapiumBrowser.getDownload.
+type ScrapiumBrowserGetDownloadParams struct {
+	// Filename filename as listed by getDownloadsMetadatas.
+	Filename string `json:"filename"`
+}
+
+// NewScrapiumBrowserGetDownloadParams builds ScrapiumBrowser.getDownload parameters.
+func NewScrapiumBrowserGetDownloadParams(filename string) *ScrapiumBrowserGetDownloadParams {
+	return &ScrapiumBrowserGetDownloadParams{Filename: filename}
+}
+
+// ScrapiumBrowserGetDownloadReturns return values of ScrapiumBrowser.getDownload.
+type ScrapiumBrowserGetDownloadReturns struct {
+	// Data file content, base64-encoded.
+	Data string `json:"data"`
+}
+
+// ScrapiumBrowserGetDownload returns one downloaded file.
+func (s *Session) ScrapiumBrowserGetDownload(ctx context.Context, params *ScrapiumBrowserGetDownloadParams) (*ScrapiumBrowserGetDownloadReturns, error) {
+	var ret ScrapiumBrowserGetDownloadReturns
+	if err := s.call(ctx, CommandScrapiumBrowserGetDownload, params, &ret); err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// ScrapiumBrowserGetDownloadsParams parameters of ScrapiumBrowser.getDownloads.
+type ScrapiumBrowserGetDownloadsParams struct {
+	// Delete remove the files from the browser's disk once read.
+	Delete *bool `json:"delete,omitempty"`
+}
+
+// NewScrapiumBrowserGetDownloadsParams builds ScrapiumBrowser.getDownloads parameters.
+func NewScrapiumBrowserGetDownloadsParams() *ScrapiumBrowserGetDownloadsParams {
+	return &ScrapiumBrowserGetDownloadsParams{}
+}
+
+// WithDelete sets the optional delete parameter.
+func (p *ScrapiumBrowserGetDownloadsParams) WithDelete(delete bool) *ScrapiumBrowserGetDownloadsParams {
+	p.Delete = &delete
+	return p
+}
+
+// ScrapiumBrowserGetDownloadsReturns return values of ScrapiumBrowser.getDownloads.
+type ScrapiumBrowserGetDownloadsReturns struct {
+	// Files filename to file content, base64-encoded.
+	Files map[string]string `json:"files"`
+}
+
+// ScrapiumBrowserGetDownloads returns every downloaded file.
+func (s *Session) ScrapiumBrowserGetDownloads(ctx context.Context, params *ScrapiumBrowserGetDownloadsParams) (*ScrapiumBrowserGetDownloadsReturns, error) {
+	var ret ScrapiumBrowserGetDownloadsReturns
+	if err := s.call(ctx, CommandScrapiumBrowserGetDownloads, params, &ret); err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
@@ -3,6 +3,8 @@
 package browser
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"sync"
 	"sync/atomic"
@@ -17,82 +19,153 @@ type Session struct {
 	MCPEndpoint      string
 	WSURL            string
 	ToolNames        []string        // namespaced tool names registered on the MCP server
-	ExpiresAt        time.Time       // browser timeout
 	CdpConn          *websocket.Conn // live CDP WebSocket connection
 	CdpMu            sync.Mutex      // protects CdpConn writes
 	CdpID            atomic.Int64    // CDP message ID counter
 	CdpPageSessionID string          // flattened session ID for page-level CDP commands
+	PageTargetID     string          // page target the session is attached to; re-attached on reconnect
+	Owner            string          // OwnerKey of the API key that opened the session
 
 	// CDP multiplexer state (managed by StartReader)
 	pending       map[int64]*pendingRequest
 	pendingMu     sync.Mutex
-	eventHandlers map[string][]EventHandler
+	eventHandlers map[string][]*eventSub
 	handlersMu    sync.RWMutex
-	readerDone    chan struct{}
+	readerDone    chan struct{} // closed when the current connection's reader exits
 
-	// CancelCleanup cancels the auto-cleanup goroutine when the session is
-	// closed manually (before timeout expiry).
-	CancelCleanup func()
+	// Connection state (managed by Connect). ready is closed while CdpConn
+	// is usable and replaced by an open channel during a reconnect; closed
+	// is closed once by Close or when reconnecting gives up.
+	connect      ConnectOptions
+	ready        chan struct{}
+	closed       chan struct{}
+	closeOnce    sync.Once
+	reconnecting atomic.Bool
+
+	// Lifetime: see SetExpiry / Touch. The provider's lifecycle manager
+	// moves the expiry and reaps idle sessions.
+	expiresAt  atomic.Int64 // unix nanos
+	lastActive atomic.Int64 // unix nanos of the last FindSession hit
 
 	// Page state — maintained across tool calls.
 	Page PageState
+
+	// Console and network history, recorded by TrackActivity.
+	activity activityLog
+
+	// Human takeover lock and the last screencast frame's viewport size
+	// (takeover.go).
+	human              humanControl
+	screencastViewport atomic.Value // [2]float64{width, height} in CSS pixels
+
+	// Shared Page.startScreencast stream (page.go).
+	screencast screencastState
+
+	// Standard-CDP stand-ins for missing custom domains (fallback.go).
+	fallback fallbackState
+}
+
+// Expiry returns when the session is due to be released.
+func (s *Session) Expiry() time.Time {
+	return time.Unix(0, s.expiresAt.Load())
+}
+
+// SetExpiry moves the session's release deadline.
+func (s *Session) SetExpiry(t time.Time) {
+	s.expiresAt.Store(t.UnixNano())
+}
+
+// Expired reports whether the session is past its deadline.
+func (s *Session) Expired() bool {
+	return time.Now().After(s.Expiry())
+}
+
+// Touch records activity. Every tool call reaches its session through
+// FindSession, which touches it.
+func (s *Session) Touch() {
+	s.lastActive.Store(time.Now().UnixNano())
+}
+
+// IdleFor reports how long the session has gone without a Touch.
+func (s *Session) IdleFor() time.Duration {
+	return time.Since(time.Unix(0, s.lastActive.Load()))
 }
 
 // Store is a per-provider in-memory store of active browser sessions.
 // Thread-safe via sync.Map. Keyed by session_id.
 var Store sync.Map
 
+// OwnerKey derives a session owner from the API key that opened it, so the
+// key itself isn't kept on the session.
+func OwnerKey(apiKey string) string {
+	if apiKey == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:16])
+}
+
 // FindSession looks up a browser session by ID. If sessionID is empty,
 // returns the first active session found (non-deterministic if multiple exist).
 func FindSession(sessionID string) (*Session, error) {
+	return FindSessionFor("", sessionID)
+}
+
+// FindSessionFor is FindSession restricted to sessions opened by owner.
+// Sessions of other owners are reported as not found. An empty owner sees
+// every session.
+func FindSessionFor(owner, sessionID string) (*Session, error) {
 	if sessionID != "" {
 		val, ok := Store.Load(sessionID)
 		if !ok {
 			return nil, fmt.Errorf("session %s not found", sessionID)
 		}
-		return val.(*Session), nil
+		session := val.(*Session)
+		if owner != "" && session.Owner != owner {
+			return nil, fmt.Errorf("session %s not found", sessionID)
+		}
+		// A dead session is left in the store: removing it (and releasing
+		// the remote browser) is up to OnLost.
+		if !session.Alive() {
+			return nil, fmt.Errorf("session %s disconnected", sessionID)
+		}
+		session.Touch()
+		return session, nil
 	}
 	// Fallback: return the most recent active session with a live connection.
-	// Clean up dead sessions as we go.
 	var session *Session
-	var deadKeys []any
 	Store.Range(func(key, value any) bool {
 		s := value.(*Session)
-		if s.CdpConn == nil {
-			deadKeys = append(deadKeys, key)
+		// A session mid-reconnect counts as alive: its commands wait for
+		// the reconnect to finish.
+		if !s.Alive() {
 			return true // skip dead sessions
 		}
-		// Check if connection is alive by checking if the reader is still running
-		select {
-		case <-s.readerDone:
-			// Reader has stopped — connection is dead
-			deadKeys = append(deadKeys, key)
+		if owner != "" && s.Owner != owner {
 			return true
-		default:
-			// Reader still running — connection is alive
-			session = s
-			return false
 		}
+		session = s
+		return false
 	})
-	// Remove dead sessions
-	for _, k := range deadKeys {
-		Store.Delete(k)
-	}
 	if session == nil {
 		return nil, fmt.Errorf("no active browser session")
 	}
+	session.Touch()
 	return session, nil
 }
 
-// Close closes the CDP WebSocket, removes the session from the store,
-// and cancels the auto-cleanup goroutine.
+// Close closes the CDP WebSocket (without reconnecting) and removes the
+// session from the store. Releasing the remote browser is up to the caller.
 func (s *Session) Close() {
-	if s.CancelCleanup != nil {
-		s.CancelCleanup()
-	}
 	Store.Delete(s.SessionID)
-	if s.CdpConn != nil {
-		s.CdpConn.Close()
+	if s.closed != nil {
+		s.closeOnce.Do(func() { close(s.closed) })
+	}
+	s.CdpMu.Lock()
+	conn := s.CdpConn
+	s.CdpMu.Unlock()
+	if conn != nil {
+		conn.Close()
 	}
 }
 
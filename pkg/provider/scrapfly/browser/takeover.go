@@ -0,0 +1,244 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Human takeover.
+//
+// A human watching the screencast can take control of a session to get
+// past something the agent can't (2FA, a captcha). While they hold it the
+// agent's interaction tools fail with ErrHumanInControl, and their mouse
+// and keyboard input is replayed on the page through Input.dispatch*.
+
+// ErrHumanInControl is returned to the agent while a human holds a session.
+var ErrHumanInControl = errors.New("HUMAN_IN_CONTROL")
+
+// humanControl is a session's takeover lock.
+type humanControl struct {
+	mu       sync.Mutex
+	holder   string
+	since    time.Time
+	released chan struct{} // closed when the current holder lets go
+	onChange []func(holder string, taken bool)
+}
+
+// OnControlChange registers fn to run (on its own goroutine) whenever a
+// human takes or releases the session.
+func (s *Session) OnControlChange(fn func(holder string, taken bool)) {
+	h := &s.human
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.onChange = append(h.onChange, fn)
+}
+
+// controlChanged runs the OnControlChange hooks. Callers hold h.mu.
+func (h *humanControl) controlChanged(holder string, taken bool) {
+	for _, fn := range h.onChange {
+		go fn(holder, taken)
+	}
+}
+
+// TakeControl gives holder exclusive control of the session. Taking it
+// again as the same holder is a no-op.
+func (s *Session) TakeControl(holder string) error {
+	if holder == "" {
+		return fmt.Errorf("holder is required")
+	}
+	h := &s.human
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.holder == holder {
+		return nil
+	}
+	if h.holder != "" {
+		return fmt.Errorf("session %s is already controlled by %s", s.SessionID, h.holder)
+	}
+	h.holder, h.since = holder, time.Now()
+	h.released = make(chan struct{})
+	h.controlChanged(holder, true)
+	return nil
+}
+
+// ReleaseControl hands the session back to the agent if holder has it.
+// Reports whether it did.
+func (s *Session) ReleaseControl(holder string) bool {
+	h := &s.human
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.holder == "" || h.holder != holder {
+		return false
+	}
+	h.holder = ""
+	close(h.released)
+	h.controlChanged(holder, false)
+	return true
+}
+
+// HumanInControl reports who holds the session, and since when.
+func (s *Session) HumanInControl() (holder string, since time.Time, ok bool) {
+	h := &s.human
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.holder, h.since, h.holder != ""
+}
+
+// CheckAgentControl returns an error wrapping ErrHumanInControl while a
+// human holds the session.
+func (s *Session) CheckAgentControl() error {
+	holder, since, ok := s.HumanInControl()
+	if !ok {
+		return nil
+	}
+	return fmt.Errorf("%w: %s took control of browser session %s at %s. Call wait_for_human to wait until they hand it back",
+		ErrHumanInControl, holder, s.SessionID, since.Format(time.RFC3339))
+}
+
+// WaitForAgentControl blocks until no human holds the session. Reports
+// whether a human had it.
+func (s *Session) WaitForAgentControl(ctx context.Context) (bool, error) {
+	h := &s.human
+	h.mu.Lock()
+	held, released := h.holder != "", h.released
+	h.mu.Unlock()
+	if !held {
+		return false, nil
+	}
+	select {
+	case <-released:
+		return true, nil
+	case <-s.closedChan():
+		return true, ErrSessionClosed
+	case <-ctx.Done():
+		return true, ctx.Err()
+	}
+}
+
+// closedChan returns a channel closed once the session is closed.
+func (s *Session) closedChan() <-chan struct{} {
+	s.CdpMu.Lock()
+	defer s.CdpMu.Unlock()
+	return s.closed
+}
+
+// HumanInput is one input event from the takeover channel. Coordinates are
+// in screencast frame pixels; FrameWidth/FrameHeight are the frame's size,
+// used to scale them to the page's viewport. Without them, coordinates are
+// taken as CSS pixels.
+type HumanInput struct {
+	Type        string  `json:"type"`  // "mouse", "scroll" or "key"
+	Event       string  `json:"event"` // mouse: mousePressed/mouseReleased/mouseMoved; key: keyDown/keyUp/char
+	X           float64 `json:"x"`
+	Y           float64 `json:"y"`
+	FrameWidth  float64 `json:"frame_width"`
+	FrameHeight float64 `json:"frame_height"`
+	Button      string  `json:"button"` // left, middle, right
+	ClickCount  int     `json:"click_count"`
+	DeltaX      float64 `json:"delta_x"`
+	DeltaY      float64 `json:"delta_y"`
+	Key         string  `json:"key"`
+	Code        string  `json:"code"`
+	Text        string  `json:"text"`
+	KeyCode     int     `json:"key_code"`
+	Modifiers   int     `json:"modifiers"` // CDP bit field: Alt=1, Ctrl=2, Meta=4, Shift=8
+}
+
+// DispatchHumanInput replays ev on the page. holder must hold the session.
+// Human input counts as activity, like a tool call.
+func (s *Session) DispatchHumanInput(ctx context.Context, holder string, ev HumanInput) error {
+	if current, _, _ := s.HumanInControl(); current != holder {
+		return fmt.Errorf("take control of the session before sending input")
+	}
+	s.Touch()
+	switch ev.Type {
+	case "mouse", "scroll":
+		x, y := s.viewportPoint(ctx, ev)
+		params := map[string]any{
+			"x":         x,
+			"y":         y,
+			"modifiers": ev.Modifiers,
+		}
+		if ev.Type == "scroll" {
+			params["type"] = "mouseWheel"
+			params["deltaX"], params["deltaY"] = ev.DeltaX, ev.DeltaY
+		} else {
+			switch ev.Event {
+			case "mousePressed", "mouseReleased", "mouseMoved":
+			default:
+				return fmt.Errorf("unknown mouse event %q", ev.Event)
+			}
+			params["type"] = ev.Event
+			button := ev.Button
+			if button == "" {
+				button = "none"
+				if ev.Event != "mouseMoved" {
+					button = "left"
+				}
+			}
+			params["button"] = button
+			if ev.Event != "mouseMoved" {
+				params["clickCount"] = max(ev.ClickCount, 1)
+			}
+		}
+		_, err := s.SendCDPCtx(ctx, "Input.dispatchMouseEvent", params)
+		return err
+	case "key":
+		switch ev.Event {
+		case "keyDown", "keyUp", "rawKeyDown", "char":
+		default:
+			return fmt.Errorf("unknown key event %q", ev.Event)
+		}
+		params := map[string]any{
+			"type":      ev.Event,
+			"modifiers": ev.Modifiers,
+		}
+		if ev.Key != "" {
+			params["key"] = ev.Key
+		}
+		if ev.Code != "" {
+			params["code"] = ev.Code
+		}
+		if ev.Text != "" {
+			params["text"] = ev.Text
+		}
+		if ev.KeyCode != 0 {
+			params["windowsVirtualKeyCode"] = ev.KeyCode
+		}
+		_, err := s.SendCDPCtx(ctx, "Input.dispatchKeyEvent", params)
+		return err
+	}
+	return fmt.Errorf("unknown input type %q", ev.Type)
+}
+
+// viewportPoint scales ev's frame coordinates to CSS pixels, using the
+// viewport size of the latest screencast frame (or the page's layout
+// metrics before the first one).
+func (s *Session) viewportPoint(ctx context.Context, ev HumanInput) (float64, float64) {
+	if ev.FrameWidth <= 0 || ev.FrameHeight <= 0 {
+		return ev.X, ev.Y
+	}
+	vp, _ := s.screencastViewport.Load().([2]float64)
+	if vp[0] == 0 {
+		raw, err := s.SendCDPCtx(ctx, "Page.getLayoutMetrics", nil)
+		if err != nil {
+			return ev.X, ev.Y
+		}
+		var metrics struct {
+			CSSVisualViewport struct {
+				ClientWidth  float64 `json:"clientWidth"`
+				ClientHeight float64 `json:"clientHeight"`
+			} `json:"cssVisualViewport"`
+		}
+		json.Unmarshal(raw, &metrics)
+		vp = [2]float64{metrics.CSSVisualViewport.ClientWidth, metrics.CSSVisualViewport.ClientHeight}
+		if vp[0] == 0 || vp[1] == 0 {
+			return ev.X, ev.Y
+		}
+	}
+	return ev.X * vp[0] / ev.FrameWidth, ev.Y * vp[1] / ev.FrameHeight
+}
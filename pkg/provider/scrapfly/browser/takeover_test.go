@@ -0,0 +1,44 @@
+package browser_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser"
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser/browsertest"
+)
+
+func TestDispatchHumanInputTouchesSession(t *testing.T) {
+	srv := browsertest.NewServer()
+	defer srv.Close()
+	session := connect(t, srv)
+	if err := session.TakeControl("human"); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if idle := session.IdleFor(); idle < 50*time.Millisecond {
+		t.Fatalf("IdleFor = %s before any input, want at least 50ms", idle)
+	}
+	err := session.DispatchHumanInput(context.Background(), "human", browser.HumanInput{Type: "mouse", Event: "mouseMoved", X: 10, Y: 10})
+	if err != nil {
+		t.Fatalf("DispatchHumanInput: %v", err)
+	}
+	if idle := session.IdleFor(); idle >= 50*time.Millisecond {
+		t.Errorf("IdleFor = %s after human input, want it reset", idle)
+	}
+	if n := len(srv.Calls("Input.dispatchMouseEvent")); n != 1 {
+		t.Errorf("Input.dispatchMouseEvent sent %d times, want 1", n)
+	}
+}
+
+func TestDispatchHumanInputNeedsControl(t *testing.T) {
+	srv := browsertest.NewServer()
+	defer srv.Close()
+	session := connect(t, srv)
+	err := session.DispatchHumanInput(context.Background(), "human", browser.HumanInput{Type: "mouse", Event: "mouseMoved"})
+	if err == nil {
+		t.Fatal("input from a human without control was dispatched")
+	}
+}
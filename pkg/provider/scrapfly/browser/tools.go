@@ -36,7 +36,7 @@ func RegisterCDPTools(
 			return toolErrf("take_screenshot: session not found"), nil
 		}
 		s := val.(*Session)
-		result, err := s.SendCDP("Page.captureScreenshot", map[string]any{"format": "png"})
+		result, err := s.SendCDPCtx(ctx, "Page.captureScreenshot", map[string]any{"format": "png"})
 		if err != nil {
 			return toolErrf("take_screenshot: %v", err), nil
 		}
@@ -85,7 +85,7 @@ func RegisterCDPTools(
 		if args.Expression == "" {
 			return toolErrf("evaluate_script: expression is required"), nil
 		}
-		result, err := s.SendCDP("Runtime.evaluate", map[string]any{
+		result, err := s.SendCDPCtx(ctx, "Runtime.evaluate", map[string]any{
 			"expression":    args.Expression,
 			"returnByValue": true,
 		})
@@ -127,7 +127,7 @@ func RegisterCDPTools(
 			return toolErrf("take_snapshot: session not found"), nil
 		}
 		s := val.(*Session)
-		result, err := s.SendCDP("Runtime.evaluate", map[string]any{
+		result, err := s.SendCDPCtx(ctx, "Runtime.evaluate", map[string]any{
 			"expression":    `JSON.stringify({title: document.title, url: location.href, text: document.body?.innerText?.substring(0, 50000) || ''})`,
 			"returnByValue": true,
 		})
@@ -161,7 +161,7 @@ func RegisterCDPTools(
 			return toolErrf("get_page_url: session not found"), nil
 		}
 		s := val.(*Session)
-		result, err := s.SendCDP("Runtime.evaluate", map[string]any{
+		result, err := s.SendCDPCtx(ctx, "Runtime.evaluate", map[string]any{
 			"expression":    `JSON.stringify({url: location.href, title: document.title})`,
 			"returnByValue": true,
 		})
@@ -216,7 +216,7 @@ func RegisterCDPTools(
 		}
 		_ = json.Unmarshal(req.Params.Arguments, &args)
 
-		report, err := CollectPSI(s, PSIOptions{
+		report, err := CollectPSI(ctx, s, PSIOptions{
 			Preset:    Preset(args.Preset),
 			TimeoutMs: args.TimeoutMs,
 		})
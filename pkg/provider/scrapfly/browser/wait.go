@@ -0,0 +1,282 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Condition-based waiting.
+//
+// WaitFor polls a set of conditions until any (or all) of them hold, so an
+// agent can wait for "the confirmation text shows up" instead of sleeping
+// and hoping.
+
+// Wait condition types.
+const (
+	WaitTextAppears    = "text_appears"
+	WaitTextDisappears = "text_disappears"
+	WaitVisible        = "visible"
+	WaitHidden         = "hidden"
+	WaitURLMatches     = "url_matches"
+	WaitNetworkIdle    = "network_idle"
+	WaitTitleChanges   = "title_changes"
+	WaitJS             = "js"
+)
+
+// ErrWaitTimeout is returned by WaitFor when its conditions didn't hold in
+// time.
+var ErrWaitTimeout = errors.New("wait timed out")
+
+// waitPollInterval is how often WaitFor re-checks its conditions.
+const waitPollInterval = 200 * time.Millisecond
+
+// WaitCondition is one thing to wait for. Value is the text, the uid or CSS
+// selector, the URL regexp, the idle time in milliseconds (default 500) or
+// the JS predicate, depending on Type.
+type WaitCondition struct {
+	Type  string `json:"type"`
+	Value string `json:"value,omitempty"`
+}
+
+func (c WaitCondition) String() string {
+	if c.Value == "" {
+		return c.Type
+	}
+	return fmt.Sprintf("%s %q", c.Type, c.Value)
+}
+
+// WaitResult says which conditions held, and when.
+type WaitResult struct {
+	Met     []WaitCondition `json:"met"`
+	Elapsed time.Duration   `json:"-"`
+	Seconds float64         `json:"elapsed_seconds"`
+}
+
+// waitCheck reports whether a condition holds now.
+type waitCheck func(ctx context.Context) (bool, error)
+
+// WaitFor polls conds until any of them holds (all of them when all is
+// set), ctx is done, or timeout passes. On timeout the error wraps
+// ErrWaitTimeout and says which conditions were still unmet; when ctx is
+// done first, it is ctx's error.
+func (s *Session) WaitFor(ctx context.Context, conds []WaitCondition, all bool, timeout time.Duration) (*WaitResult, error) {
+	if len(conds) == 0 {
+		return nil, fmt.Errorf("no conditions to wait for")
+	}
+	start := time.Now()
+	checks := make([]waitCheck, len(conds))
+	for i, c := range conds {
+		check, err := s.waitCheck(ctx, c)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", c.Type, err)
+		}
+		checks[i] = check
+	}
+
+	parent := ctx
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ticker := time.NewTicker(waitPollInterval)
+	defer ticker.Stop()
+	var lastErr error
+	for {
+		var met []WaitCondition
+		for i, check := range checks {
+			ok, err := check(ctx)
+			if err != nil {
+				lastErr = fmt.Errorf("%s: %w", conds[i], err)
+			}
+			if ok {
+				met = append(met, conds[i])
+			}
+		}
+		if (all && len(met) == len(conds)) || (!all && len(met) > 0) {
+			elapsed := time.Since(start)
+			return &WaitResult{Met: met, Elapsed: elapsed, Seconds: elapsed.Seconds()}, nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			if err := parent.Err(); err != nil {
+				return nil, err
+			}
+			unmet := make([]string, 0, len(conds))
+			for _, c := range conds {
+				if !containsCondition(met, c) {
+					unmet = append(unmet, c.String())
+				}
+			}
+			err := fmt.Errorf("%w: still waiting for %s after %s", ErrWaitTimeout, strings.Join(unmet, ", "), time.Since(start).Round(100*time.Millisecond))
+			if lastErr != nil {
+				err = fmt.Errorf("%w (last error: %v)", err, lastErr)
+			}
+			return nil, err
+		}
+	}
+}
+
+func containsCondition(conds []WaitCondition, c WaitCondition) bool {
+	for _, m := range conds {
+		if m == c {
+			return true
+		}
+	}
+	return false
+}
+
+// waitCheck builds the check for c, capturing any starting state.
+func (s *Session) waitCheck(ctx context.Context, c WaitCondition) (waitCheck, error) {
+	switch c.Type {
+	case WaitTextAppears, WaitTextDisappears:
+		if c.Value == "" {
+			return nil, fmt.Errorf("value (the text) is required")
+		}
+		want := c.Type == WaitTextAppears
+		expr := fmt.Sprintf(`(document.body ? document.body.innerText : '').toLowerCase().includes(%s)`, jsString(strings.ToLower(c.Value)))
+		return func(ctx context.Context) (bool, error) {
+			present, err := s.evalBool(ctx, expr)
+			return err == nil && present == want, err
+		}, nil
+
+	case WaitVisible, WaitHidden:
+		if c.Value == "" {
+			return nil, fmt.Errorf("value (a uid or CSS selector) is required")
+		}
+		want := c.Type == WaitVisible
+		if isUID(c.Value) {
+			sel := Selector{Type: AntibotSelectorTypeAXNodeID, Query: c.Value}
+			return func(ctx context.Context) (bool, error) {
+				visible, err := s.IsVisible(ctx, sel)
+				if err != nil {
+					// A uid that no longer resolves is gone: hidden.
+					return !want, nil
+				}
+				return visible == want, nil
+			}, nil
+		}
+		expr := fmt.Sprintf(`(() => {
+  const el = document.querySelector(%s);
+  if (!el) return false;
+  const r = el.getBoundingClientRect(), st = getComputedStyle(el);
+  return r.width > 0 && r.height > 0 && st.visibility !== 'hidden' && st.display !== 'none' && st.opacity !== '0';
+})()`, jsString(c.Value))
+		return func(ctx context.Context) (bool, error) {
+			visible, err := s.evalBool(ctx, expr)
+			return err == nil && visible == want, err
+		}, nil
+
+	case WaitURLMatches:
+		re, err := regexp.Compile(c.Value)
+		if err != nil {
+			return nil, fmt.Errorf("value must be a regular expression: %w", err)
+		}
+		return func(ctx context.Context) (bool, error) {
+			url, err := s.evalString(ctx, "location.href")
+			return err == nil && re.MatchString(url), err
+		}, nil
+
+	case WaitNetworkIdle:
+		idle := 500 * time.Millisecond
+		if c.Value != "" {
+			var ms int
+			if _, err := fmt.Sscan(c.Value, &ms); err != nil || ms < 0 {
+				return nil, fmt.Errorf("value must be the idle time in milliseconds")
+			}
+			idle = time.Duration(ms) * time.Millisecond
+		}
+		return func(context.Context) (bool, error) {
+			return s.NetworkIdleFor() >= idle, nil
+		}, nil
+
+	case WaitTitleChanges:
+		initial, err := s.evalString(ctx, "document.title")
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context) (bool, error) {
+			title, err := s.evalString(ctx, "document.title")
+			return err == nil && title != initial, err
+		}, nil
+
+	case WaitJS:
+		if c.Value == "" {
+			return nil, fmt.Errorf("value (a JavaScript expression) is required")
+		}
+		expr := fmt.Sprintf("(async () => !!(%s))()", c.Value)
+		return func(ctx context.Context) (bool, error) {
+			return s.evalBool(ctx, expr)
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown condition type (use %s)", strings.Join([]string{
+		WaitTextAppears, WaitTextDisappears, WaitVisible, WaitHidden,
+		WaitURLMatches, WaitNetworkIdle, WaitTitleChanges, WaitJS,
+	}, ", "))
+}
+
+// isUID reports whether v looks like a snapshot uid rather than a selector.
+func isUID(v string) bool {
+	for _, r := range v {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return v != ""
+}
+
+func jsString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// evalValue evaluates expr in the page and decodes its value into out.
+func (s *Session) evalValue(ctx context.Context, expr string, out any) error {
+	raw, err := s.SendCDPCtx(ctx, "Runtime.evaluate", map[string]any{
+		"expression":    expr,
+		"returnByValue": true,
+		"awaitPromise":  true,
+	})
+	if err != nil {
+		return err
+	}
+	var res struct {
+		Result struct {
+			Value json.RawMessage `json:"value"`
+		} `json:"result"`
+		ExceptionDetails *struct {
+			Text      string `json:"text"`
+			Exception *struct {
+				Description string `json:"description"`
+			} `json:"exception"`
+		} `json:"exceptionDetails"`
+	}
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return err
+	}
+	if d := res.ExceptionDetails; d != nil {
+		if d.Exception != nil && d.Exception.Description != "" {
+			return fmt.Errorf("%s", d.Exception.Description)
+		}
+		return fmt.Errorf("%s", d.Text)
+	}
+	if len(res.Result.Value) == 0 {
+		return nil
+	}
+	return json.Unmarshal(res.Result.Value, out)
+}
+
+func (s *Session) evalBool(ctx context.Context, expr string) (bool, error) {
+	var b bool
+	err := s.evalValue(ctx, expr, &b)
+	return b, err
+}
+
+func (s *Session) evalString(ctx context.Context, expr string) (string, error) {
+	var str string
+	err := s.evalValue(ctx, expr, &str)
+	return str, err
+}
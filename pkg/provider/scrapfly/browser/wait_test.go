@@ -0,0 +1,54 @@
+package browser_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser"
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser/browsertest"
+)
+
+var textAppears = []browser.WaitCondition{{Type: browser.WaitTextAppears, Value: "Order confirmed"}}
+
+func TestWaitForMet(t *testing.T) {
+	srv := browsertest.NewServer()
+	defer srv.Close()
+	srv.Eval("order confirmed", true)
+	session := connect(t, srv)
+
+	res, err := session.WaitFor(context.Background(), textAppears, false, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Met) != 1 || res.Met[0] != textAppears[0] {
+		t.Errorf("met = %v, want %v", res.Met, textAppears)
+	}
+}
+
+func TestWaitForTimeout(t *testing.T) {
+	srv := browsertest.NewServer()
+	defer srv.Close()
+	srv.Eval("order confirmed", false)
+	session := connect(t, srv)
+
+	_, err := session.WaitFor(context.Background(), textAppears, false, 300*time.Millisecond)
+	if !errors.Is(err, browser.ErrWaitTimeout) {
+		t.Errorf("err = %v, want ErrWaitTimeout", err)
+	}
+}
+
+func TestWaitForCancelled(t *testing.T) {
+	srv := browsertest.NewServer()
+	defer srv.Close()
+	srv.Eval("order confirmed", false)
+	session := connect(t, srv)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(300*time.Millisecond, cancel)
+	_, err := session.WaitFor(ctx, textAppears, false, 10*time.Second)
+	if !errors.Is(err, context.Canceled) || errors.Is(err, browser.ErrWaitTimeout) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
@@ -2,13 +2,19 @@ package browser
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/google/jsonschema-go/jsonschema"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -92,16 +98,11 @@ func DiscoverTools(logger Logger, mcpEndpoint, sessionID string) []MCPToolInfo {
 		return nil
 	}
 
-	// Generate short session prefix (first 8 chars)
-	shortID := sessionID
-	if len(shortID) > 8 {
-		shortID = shortID[:8]
-	}
-	shortID = strings.ReplaceAll(shortID, "-", "")
+	shortID := shortSessionID(sessionID)
 
 	var tools []MCPToolInfo
 	for _, t := range rpcResp.Result.Tools {
-		namespacedName := fmt.Sprintf("webmcp_%s_%s", shortID, t.Name)
+		namespacedName := NamespacedToolName(sessionID, t.Name)
 		tools = append(tools, MCPToolInfo{
 			OriginalName:   t.Name,
 			NamespacedName: namespacedName,
@@ -114,12 +115,54 @@ func DiscoverTools(logger Logger, mcpEndpoint, sessionID string) []MCPToolInfo {
 	return tools
 }
 
+// shortSessionID is the session prefix used in namespaced tool names: the
+// first 8 chars, dashes stripped.
+func shortSessionID(sessionID string) string {
+	shortID := sessionID
+	if len(shortID) > 8 {
+		shortID = shortID[:8]
+	}
+	return strings.ReplaceAll(shortID, "-", "")
+}
+
+// maxToolNameLen is the MCP limit on tool name length.
+const maxToolNameLen = 128
+
+// NamespacedToolName returns the MCP tool name a page-registered WebMCP tool
+// is exposed under: webmcp_<short session>_<name>. Characters MCP doesn't
+// allow in tool names are replaced with '_' and the result is capped at the
+// 128-char limit, since page authors pick the raw name. A name changed
+// either way gets a hash of the raw name appended, so "a b" and "a/b", or
+// two long names sharing a prefix, don't end up under the same tool.
+func NamespacedToolName(sessionID, toolName string) string {
+	name := []rune(fmt.Sprintf("webmcp_%s_%s", shortSessionID(sessionID), toolName))
+	changed := len(name) > maxToolNameLen
+	for i, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' || r == '.') {
+			name[i] = '_'
+			changed = true
+		}
+	}
+	if !changed {
+		return string(name)
+	}
+	sum := sha256.Sum256([]byte(toolName))
+	suffix := "_" + hex.EncodeToString(sum[:4])
+	if len(name) > maxToolNameLen-len(suffix) {
+		name = name[:maxToolNameLen-len(suffix)]
+	}
+	return string(name) + suffix
+}
+
 // CallTool calls an Antibot CDP command directly (Antibot.fill, Antibot.clickOn, etc.).
 // For page-registered tools (navigator.modelContext), use InvokeTool instead.
-func CallTool(logger Logger, session *Session, toolName string, arguments json.RawMessage) (*mcp.CallToolResult, error) {
-	if time.Now().After(session.ExpiresAt) {
+func CallTool(ctx context.Context, logger Logger, session *Session, toolName string, arguments json.RawMessage) (*mcp.CallToolResult, error) {
+	if session.Expired() {
 		return toolErrf("browser tool %s: session has expired", toolName), nil
 	}
+	if err := session.CheckAgentControl(); err != nil {
+		return toolErrf("browser tool %s: %v", toolName, err), nil
+	}
 
 	// Parse and fix arguments — Claude may double-encode JSON objects as strings
 	var params map[string]any
@@ -149,14 +192,14 @@ func CallTool(logger Logger, session *Session, toolName string, arguments json.R
 	cdpMethod := "Antibot." + toolName
 	paramsJSON, _ := json.Marshal(params)
 	logger.Printf("[Antibot] %s params=%s", cdpMethod, string(paramsJSON))
-	result, err := session.SendCDP(cdpMethod, params)
+	result, err := session.SendCDPCtx(ctx, cdpMethod, params)
+	if errors.Is(err, ErrUnsupported) {
+		return toolErrf("browser tool %s: %v", toolName, err), nil
+	}
 	if err != nil {
+		// A dropped socket is resumed by the session itself; one that can't
+		// be is released by its OnLost hook, so nothing to clean up here.
 		logger.Printf("[Antibot] %s error: %v", cdpMethod, err)
-		// Remove dead session so FindSession("") won't return it again
-		if strings.Contains(err.Error(), "closed") || strings.Contains(err.Error(), "broken pipe") {
-			session.Close()
-			logger.Printf("[Antibot] Removed dead session %s", session.SessionID)
-		}
 		return toolErrf("browser tool %s: %v — session may have expired, use cloud_browser_open to start a new one", toolName, err), nil
 	}
 
@@ -177,7 +220,7 @@ func CallTool(logger Logger, session *Session, toolName string, arguments json.R
 	resultText := "success"
 	if toolName == "fill" || toolName == "clickOn" || toolName == "selectOption" || toolName == "pressKey" {
 		time.Sleep(500 * time.Millisecond)
-		session.Page.Refresh(session)
+		session.Page.Refresh(ctx, session)
 		resultText += "\n\n" + session.Page.Snapshot()
 	}
 
@@ -186,66 +229,84 @@ func CallTool(logger Logger, session *Session, toolName string, arguments json.R
 	}, nil
 }
 
+// DefaultInvokeTimeout bounds a WebMCP tool invocation when the caller
+// doesn't pass one. MaxInvokeTimeout caps caller-supplied values.
+const (
+	DefaultInvokeTimeout = 30 * time.Second
+	MaxInvokeTimeout     = 5 * time.Minute
+)
+
 // InvokeTool forwards a WebMCP page-registered tool call via CDP (async).
 // Flow: invokeTool → response(invocationId) → toolInvoked event → toolResponded event.
-func InvokeTool(logger Logger, session *Session, toolName string, arguments json.RawMessage) (*mcp.CallToolResult, error) {
-	if time.Now().After(session.ExpiresAt) {
+//
+// The wait is bounded by ctx and by timeout (0 = DefaultInvokeTimeout). If
+// either fires first the invocation is aborted in the page with
+// WebMCP.cancelInvocation, so the tool's AbortSignal runs instead of the
+// page finishing work nobody will read. Arguments are validated against the
+// tool's declared inputSchema before anything is sent.
+func InvokeTool(ctx context.Context, logger Logger, session *Session, toolName string, arguments json.RawMessage, timeout time.Duration) (*mcp.CallToolResult, error) {
+	if session.Expired() {
 		return toolErrf("browser tool %s: session has expired", toolName), nil
 	}
+	if err := session.CheckAgentControl(); err != nil {
+		return toolErrf("browser tool %s: %v", toolName, err), nil
+	}
+	if timeout <= 0 {
+		timeout = DefaultInvokeTimeout
+	}
+	if timeout > MaxInvokeTimeout {
+		timeout = MaxInvokeTimeout
+	}
 
-	// Get the frame ID
-	var frameId string
-	frameResult, _ := session.SendCDP("Page.getFrameTree", nil)
-	if frameResult != nil {
-		var ft struct {
-			FrameTree struct {
-				Frame struct{ Id string `json:"id"` } `json:"frame"`
-			} `json:"frameTree"`
+	// Look up the tool's registration: its frame and declared schema.
+	var tool *WebMCPToolInfo
+	for _, t := range session.Page.GetWebMCPTools() {
+		if t.Name == toolName {
+			tool = &t
+			break
 		}
-		json.Unmarshal(frameResult, &ft)
-		frameId = ft.FrameTree.Frame.Id
+	}
+	if tool == nil {
+		return toolErrf("browser tool %s: not registered on the current page (see list_webmcp_tools)", toolName), nil
 	}
 
-	// Build input as object — fix double-encoded JSON strings from Claude
-	var inputObj map[string]any
-	if len(arguments) > 0 {
+	inputObj := map[string]any{}
+	if len(arguments) > 0 && string(arguments) != "null" {
 		if err := json.Unmarshal(arguments, &inputObj); err != nil {
-			inputObj = map[string]any{}
+			return toolErrf("browser tool %s: input must be a JSON object: %v", toolName, err), nil
 		}
-		for k, v := range inputObj {
-			if str, ok := v.(string); ok {
-				if len(str) > 1 && str[0] == '{' {
-					var parsed any
-					if json.Unmarshal([]byte(str), &parsed) == nil {
-						inputObj[k] = parsed
-						continue
-					}
-				}
-				if str == "true" {
-					inputObj[k] = true
-				} else if str == "false" {
-					inputObj[k] = false
-				}
+	}
+	if err := validateWebMCPInput(tool.InputSchema, inputObj); err != nil {
+		return toolErrf("browser tool %s: invalid input: %v", toolName, err), nil
+	}
+
+	frameId := tool.FrameID
+	if frameId == "" {
+		frameResult, _ := session.SendCDPCtx(ctx, "Page.getFrameTree", nil)
+		if frameResult != nil {
+			var ft struct {
+				FrameTree struct {
+					Frame struct {
+						Id string `json:"id"`
+					} `json:"frame"`
+				} `json:"frameTree"`
 			}
+			json.Unmarshal(frameResult, &ft)
+			frameId = ft.FrameTree.Frame.Id
 		}
 	}
-	if inputObj == nil {
-		inputObj = map[string]any{}
-	}
 
 	// Refresh page state before action so the agent has current context
-	session.Page.Refresh(session)
+	session.Page.Refresh(ctx, session)
 
-	invokeParams := map[string]any{
-		"frameId":  frameId,
-		"toolName": toolName,
-		"input":    inputObj,
-	}
+	inputJSON, _ := json.Marshal(inputObj)
+	invokeParams := NewWebMCPInvokeToolParams(toolName, cdp.FrameID(frameId)).WithInput(inputJSON)
 	invokeJSON, _ := json.Marshal(invokeParams)
-	logger.Printf("WebMCP.invokeTool: tool=%s params=%s", toolName, string(invokeJSON))
+	logger.Printf("WebMCP.invokeTool: tool=%s timeout=%s params=%s", toolName, timeout, string(invokeJSON))
 
-	// toolRespondedEvent is a loose version of webmcp.EventToolResponded
-	// that accepts any status string (Chrome v149 sends "completed" instead of "Success").
+	// toolRespondedEvent is a loose version of EventWebMCPToolResponded:
+	// output arrives either as the declared JSON-encoded string or as a raw
+	// JSON value depending on the Chrome build, so it's kept undecoded.
 	type toolRespondedEvent struct {
 		InvocationID string          `json:"invocationId"`
 		Status       string          `json:"status"`
@@ -253,71 +314,119 @@ func InvokeTool(logger Logger, session *Session, toolName string, arguments json
 		ErrorText    string          `json:"errorText"`
 	}
 
-	// Step 1: Register event handler BEFORE sending the command
-	resultCh := make(chan *toolRespondedEvent, 1)
-	session.OnEvent("WebMCP.toolResponded", func(method string, params json.RawMessage) bool {
+	// Step 1: Register event handler BEFORE sending the command. Responses
+	// for other (concurrent) invocations are buffered too; we filter by
+	// invocationId once invokeTool tells us ours. The handler is dropped on
+	// every exit path, including the ones where no event ever arrives.
+	respondedCh := make(chan *toolRespondedEvent, 16)
+	unsubscribe := session.OnEvent(EventNameWebMCPToolResponded, func(method string, params json.RawMessage) bool {
 		var responded toolRespondedEvent
 		if err := json.Unmarshal(params, &responded); err != nil {
 			logger.Printf("[InvokeTool] toolResponded unmarshal error: %v", err)
 			return true
 		}
-		logger.Printf("[InvokeTool] toolResponded: invocationId=%s status=%s", responded.InvocationID, responded.Status)
-		resultCh <- &responded
-		return false // stop listening
+		select {
+		case respondedCh <- &responded:
+		default:
+		}
+		return true
 	})
+	defer unsubscribe()
 
 	// Step 2: Send invokeTool — get invocationId
-	result, err := session.SendCDP("WebMCP.invokeTool", invokeParams)
+	invoked, err := session.WebMCPInvokeTool(ctx, invokeParams)
 	if err != nil {
 		logger.Printf("WebMCP.invokeTool error: tool=%s err=%v", toolName, err)
 		return toolErrf("browser tool %s: %v", toolName, err), nil
 	}
+	invocationID := invoked.InvocationID
+	logger.Printf("WebMCP.invokeTool: invocationId=%s", invocationID)
 
-	var invokeResult struct {
-		InvocationId string `json:"invocationId"`
-	}
-	json.Unmarshal(result, &invokeResult)
-	logger.Printf("WebMCP.invokeTool: invocationId=%s", invokeResult.InvocationId)
-
-	// Step 3: Wait for toolResponded event via the multiplexer (30s timeout)
-	select {
-	case responded := <-resultCh:
-		status := strings.ToLower(responded.Status)
-		if status == "error" || status == "canceled" {
-			logger.Printf("WebMCP.invokeTool failed: tool=%s error=%s", toolName, responded.ErrorText)
-			return toolErrf("browser tool %s error: %s", toolName, responded.ErrorText), nil
+	// Fire-and-forget: by the time we cancel, ctx may already be done.
+	cancelInvocation := func(reason string) {
+		if invocationID == "" {
+			return
 		}
-		// The output may be a JSON string wrapping the actual result — unwrap it
-		output := responded.Output
-		var unwrapped string
-		if json.Unmarshal(output, &unwrapped) == nil {
-			// It was a JSON-encoded string — try to parse the inner value
-			var inner any
-			if json.Unmarshal([]byte(unwrapped), &inner) == nil {
-				pretty, _ := json.MarshalIndent(inner, "", "  ")
-				output = pretty
-			} else {
-				output = []byte(unwrapped)
+		logger.Printf("WebMCP.cancelInvocation: tool=%s invocationId=%s (%s)", toolName, invocationID, reason)
+		session.SendCDPFireAndForget(CommandWebMCPCancelInvocation, NewWebMCPCancelInvocationParams(invocationID))
+	}
+
+	// Step 3: Wait for our toolResponded event, the caller's ctx, or the timeout.
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case responded := <-respondedCh:
+			if invocationID != "" && responded.InvocationID != invocationID {
+				continue
 			}
-		} else {
-			// Already a raw JSON object/array
-			pretty, _ := json.MarshalIndent(json.RawMessage(output), "", "  ")
+			return webMCPResult(logger, toolName, responded.Status, responded.Output, responded.ErrorText), nil
+		case <-ctx.Done():
+			cancelInvocation("request canceled")
+			return toolErrf("browser tool %s: canceled: %v", toolName, ctx.Err()), nil
+		case <-timer.C:
+			cancelInvocation("timeout")
+			logger.Printf("WebMCP.invokeTool: timeout waiting for toolResponded: tool=%s", toolName)
+			return toolErrf("browser tool %s: no response within %s (invocation canceled)", toolName, timeout), nil
+		}
+	}
+}
+
+// webMCPResult converts a toolResponded payload into a tool result.
+func webMCPResult(logger Logger, toolName, status string, output json.RawMessage, errorText string) *mcp.CallToolResult {
+	status = strings.ToLower(status)
+	if status == "error" || status == "canceled" {
+		logger.Printf("WebMCP.invokeTool failed: tool=%s error=%s", toolName, errorText)
+		return toolErrf("browser tool %s error: %s", toolName, errorText)
+	}
+	// The output may be a JSON string wrapping the actual result — unwrap it
+	var unwrapped string
+	if json.Unmarshal(output, &unwrapped) == nil {
+		// It was a JSON-encoded string — try to parse the inner value
+		var inner any
+		if json.Unmarshal([]byte(unwrapped), &inner) == nil {
+			pretty, _ := json.MarshalIndent(inner, "", "  ")
 			output = pretty
+		} else {
+			output = []byte(unwrapped)
 		}
-		logger.Printf("WebMCP.invokeTool result: tool=%s output=%s", toolName, string(output))
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{&mcp.TextContent{Text: string(output)}},
-		}, nil
-	case <-time.After(30 * time.Second):
-		logger.Printf("WebMCP.invokeTool: timeout waiting for toolResponded: tool=%s", toolName)
-		return toolErrf("browser tool %s: timeout waiting for response", toolName), nil
+	} else {
+		// Already a raw JSON object/array
+		pretty, _ := json.MarshalIndent(json.RawMessage(output), "", "  ")
+		output = pretty
+	}
+	logger.Printf("WebMCP.invokeTool result: tool=%s output=%s", toolName, string(output))
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: string(output)}},
+	}
+}
+
+// validateWebMCPInput checks input against the tool's declared inputSchema.
+// Tools without a schema accept anything. A schema we can't compile (page
+// authors write these by hand) is not the caller's fault, so it's skipped
+// rather than blocking the call.
+func validateWebMCPInput(rawSchema json.RawMessage, input map[string]any) error {
+	if len(rawSchema) == 0 || string(rawSchema) == "null" || string(rawSchema) == "{}" {
+		return nil
+	}
+	var schema jsonschema.Schema
+	if err := json.Unmarshal(rawSchema, &schema); err != nil {
+		return nil
+	}
+	resolved, err := schema.Resolve(nil)
+	if err != nil {
+		return nil
 	}
+	// Validate against the generic JSON shape (map/slice/float64) so the
+	// validator sees exactly what the page will receive.
+	var instance any = input
+	return resolved.Validate(instance)
 }
 
 // ProxyHTTPToolCall forwards a tool call to Chrome's MCP endpoint (HTTP).
 // Used for the unblock path where there is no CDP WebSocket.
-func ProxyHTTPToolCall(logger Logger, session *Session, toolName string, arguments json.RawMessage) (*mcp.CallToolResult, error) {
-	if time.Now().After(session.ExpiresAt) {
+func ProxyHTTPToolCall(ctx context.Context, logger Logger, session *Session, toolName string, arguments json.RawMessage) (*mcp.CallToolResult, error) {
+	if session.Expired() {
 		Store.Delete(session.SessionID)
 		return toolErrf("webmcp proxy: session %s has expired", session.SessionID), nil
 	}
@@ -334,7 +443,7 @@ func ProxyHTTPToolCall(logger Logger, session *Session, toolName string, argumen
 	}
 	body, _ := json.Marshal(rpcReq)
 
-	httpReq, err := http.NewRequest(http.MethodPost, session.MCPEndpoint, bytes.NewReader(body))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, session.MCPEndpoint, bytes.NewReader(body))
 	if err != nil {
 		return toolErrf("webmcp proxy: failed to create request: %v", err), nil
 	}
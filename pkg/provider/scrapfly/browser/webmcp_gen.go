@@ -0,0 +1,185 @@
+// Code generated by cdpgen from browser_protocol.json, scrapium_protocol.json. DO NOT EDIT.
+
+package browser
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/runtime"
+)
+
+// WebMCP command and event method names.
+const (
+	CommandWebMCPEnable           = "WebMCP.enable"
+	CommandWebMCPDisable          = "WebMCP.disable"
+	CommandWebMCPCancelInvocation = "WebMCP.cancelInvocation"
+	CommandWebMCPInvokeTool       = "WebMCP.invokeTool"
+	EventNameWebMCPToolsAdded     = "WebMCP.toolsAdded"
+	EventNameWebMCPToolsRemoved   = "WebMCP.toolsRemoved"
+	EventNameWebMCPToolInvoked    = "WebMCP.toolInvoked"
+	EventNameWebMCPToolResponded  = "WebMCP.toolResponded"
+)
+
+// ── Types ───────────────────────────────────────────────────────────────────
+
+// WebMCPAnnotation tool annotations
+type WebMCPAnnotation struct {
+	// ReadOnly a hint indicating that the tool does not modify any state.
+	ReadOnly bool `json:"readOnly,omitempty"`
+	// Autosubmit if the declarative tool was declared with the autosubmit attribute.
+	Autosubmit bool `json:"autosubmit,omitempty"`
+}
+
+// WebMCPTool definition of a tool that can be invoked.
+type WebMCPTool struct {
+	// Name tool name.
+	Name string `json:"name"`
+	// Description tool description.
+	Description string `json:"description"`
+	// InputSchema schema for the tool's input parameters.
+	InputSchema json.RawMessage `json:"inputSchema,omitempty"`
+	// Annotations optional annotations for the tool.
+	Annotations *WebMCPAnnotation `json:"annotations,omitempty"`
+	// FrameID frame identifier associated with the tool registration.
+	FrameID cdp.FrameID `json:"frameId"`
+	// BackendNodeID optional node ID for declarative tools.
+	BackendNodeID cdp.BackendNodeID `json:"backendNodeId,omitempty"`
+	// StackTrace the stack trace at the time of the registration.
+	StackTrace *runtime.StackTrace `json:"stackTrace,omitempty"`
+}
+
+// WebMCPInvocationStatus status of a tool invocation.
+type WebMCPInvocationStatus string
+
+// WebMCPInvocationStatus values.
+const (
+	WebMCPInvocationStatusCompleted WebMCPInvocationStatus = "completed"
+	WebMCPInvocationStatusError     WebMCPInvocationStatus = "error"
+)
+
+// ── Commands ────────────────────────────────────────────────────────────────
+
+// WebMCPEnable enables the WebMCP domain, allowing events to be sent. Enabling the domain will trigger a toolsAdded event for
+// all currently registered tools.
+func (s *Session) WebMCPEnable(ctx context.Context) error {
+	return s.call(ctx, CommandWebMCPEnable, nil, nil)
+}
+
+// WebMCPDisable disables the WebMCP domain.
+func (s *Session) WebMCPDisable(ctx context.Context) error {
+	return s.call(ctx, CommandWebMCPDisable, nil, nil)
+}
+
+// WebMCPCancelInvocationParams parameters of WebMCP.cancelInvocation.
+type WebMCPCancelInvocationParams struct {
+	// InvocationID the invocation ID returned by invokeTool.
+	InvocationID string `json:"invocationId"`
+}
+
+// NewWebMCPCancelInvocationParams builds WebMCP.cancelInvocation parameters.
+func NewWebMCPCancelInvocationParams(invocationID string) *WebMCPCancelInvocationParams {
+	return &WebMCPCancelInvocationParams{InvocationID: invocationID}
+}
+
+// WebMCPCancelInvocation cancels a pending tool invocation. The tool's AbortSignal is fired, and a
+// toolResponded event with status "error" will be emitted for the invocationId.
+func (s *Session) WebMCPCancelInvocation(ctx context.Context, params *WebMCPCancelInvocationParams) error {
+	return s.call(ctx, CommandWebMCPCancelInvocation, params, nil)
+}
+
+// WebMCPInvokeToolParams parameters of WebMCP.invokeTool.
+type WebMCPInvokeToolParams struct {
+	// ToolName name of the tool to invoke.
+	ToolName string `json:"toolName"`
+	// FrameID frame identifier where the tool is registered.
+	FrameID cdp.FrameID `json:"frameId"`
+	// Input JSON-encoded input arguments for the tool (parsed and passed to the execute callback).
+	Input json.RawMessage `json:"input,omitempty"`
+}
+
+// NewWebMCPInvokeToolParams builds WebMCP.invokeTool parameters.
+func NewWebMCPInvokeToolParams(toolName string, frameID cdp.FrameID) *WebMCPInvokeToolParams {
+	return &WebMCPInvokeToolParams{ToolName: toolName, FrameID: frameID}
+}
+
+// WithInput sets the optional input parameter.
+func (p *WebMCPInvokeToolParams) WithInput(input json.RawMessage) *WebMCPInvokeToolParams {
+	p.Input = input
+	return p
+}
+
+// WebMCPInvokeToolReturns return values of WebMCP.invokeTool.
+type WebMCPInvokeToolReturns struct {
+	// InvocationID unique ID for this invocation, used to correlate with toolResponded.
+	InvocationID string `json:"invocationId"`
+}
+
+// WebMCPInvokeTool invokes a tool by name with JSON-encoded input arguments.
+// Returns an invocationId; the result arrives asynchronously via toolResponded event.
+func (s *Session) WebMCPInvokeTool(ctx context.Context, params *WebMCPInvokeToolParams) (*WebMCPInvokeToolReturns, error) {
+	var ret WebMCPInvokeToolReturns
+	if err := s.call(ctx, CommandWebMCPInvokeTool, params, &ret); err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// ── Events ──────────────────────────────────────────────────────────────────
+
+// EventWebMCPToolsAdded event fired when new tools are added.
+type EventWebMCPToolsAdded struct {
+	// Tools array of tools that were added.
+	Tools []*WebMCPTool `json:"tools"`
+}
+
+// OnWebMCPToolsAdded subscribes fn to WebMCP.toolsAdded events. Return false from fn, or call
+// the returned func, to unsubscribe.
+func (s *Session) OnWebMCPToolsAdded(fn func(*EventWebMCPToolsAdded) bool) (unsubscribe func()) {
+	return onEvent(s, EventNameWebMCPToolsAdded, fn)
+}
+
+// EventWebMCPToolsRemoved event fired when tools are removed.
+type EventWebMCPToolsRemoved struct {
+	// Tools array of tools that were removed.
+	Tools []*WebMCPTool `json:"tools"`
+}
+
+// OnWebMCPToolsRemoved subscribes fn to WebMCP.toolsRemoved events. Return false from fn, or call
+// the returned func, to unsubscribe.
+func (s *Session) OnWebMCPToolsRemoved(fn func(*EventWebMCPToolsRemoved) bool) (unsubscribe func()) {
+	return onEvent(s, EventNameWebMCPToolsRemoved, fn)
+}
+
+// EventWebMCPToolInvoked event fired when a tool invocation has been started (the execute callback is running).
+type EventWebMCPToolInvoked struct {
+	// InvocationID the invocation ID returned by invokeTool.
+	InvocationID string `json:"invocationId"`
+	// ToolName name of the tool being invoked.
+	ToolName string `json:"toolName"`
+}
+
+// OnWebMCPToolInvoked subscribes fn to WebMCP.toolInvoked events. Return false from fn, or call
+// the returned func, to unsubscribe.
+func (s *Session) OnWebMCPToolInvoked(fn func(*EventWebMCPToolInvoked) bool) (unsubscribe func()) {
+	return onEvent(s, EventNameWebMCPToolInvoked, fn)
+}
+
+// EventWebMCPToolResponded event fired when a tool invocation has completed (successfully or with error).
+type EventWebMCPToolResponded struct {
+	// InvocationID the invocation ID returned by invokeTool.
+	InvocationID string `json:"invocationId"`
+	// Status status of the invocation.
+	Status WebMCPInvocationStatus `json:"status"`
+	// Output the tool's return value (JSON-encoded), present when status is "completed".
+	Output string `json:"output,omitempty"`
+	// ErrorText error text, present when status is "error".
+	ErrorText string `json:"errorText,omitempty"`
+}
+
+// OnWebMCPToolResponded subscribes fn to WebMCP.toolResponded events. Return false from fn, or call
+// the returned func, to unsubscribe.
+func (s *Session) OnWebMCPToolResponded(fn func(*EventWebMCPToolResponded) bool) (unsubscribe func()) {
+	return onEvent(s, EventNameWebMCPToolResponded, fn)
+}
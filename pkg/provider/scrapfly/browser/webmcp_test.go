@@ -0,0 +1,39 @@
+package browser_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser"
+)
+
+var validToolName = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,128}$`)
+
+func TestNamespacedToolName(t *testing.T) {
+	const session = "0123abcd-ef45-6789"
+	if got := browser.NamespacedToolName(session, "search_products"); got != "webmcp_0123abcd_search_products" {
+		t.Errorf("plain name = %s, want it unchanged", got)
+	}
+
+	long := strings.Repeat("x", 200)
+	for _, pair := range [][2]string{
+		{"add to cart", "add/to/cart"},
+		{"add to cart", "add_to_cart"},
+		{long + "a", long + "b"},
+		{"café", "cafè"},
+	} {
+		a, b := browser.NamespacedToolName(session, pair[0]), browser.NamespacedToolName(session, pair[1])
+		if a == b {
+			t.Errorf("%q and %q both map to %s", pair[0], pair[1], a)
+		}
+		for _, name := range []string{a, b} {
+			if !validToolName.MatchString(name) {
+				t.Errorf("%s is not a valid MCP tool name", name)
+			}
+		}
+	}
+	if a, b := browser.NamespacedToolName(session, "add to cart"), browser.NamespacedToolName(session, "add to cart"); a != b {
+		t.Errorf("the same tool maps to %s and %s", a, b)
+	}
+}
@@ -0,0 +1,329 @@
+package scrapflyprovider
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	scrapfly "github.com/scrapfly/go-scrapfly"
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser"
+)
+
+// Cloud Browser session lifecycle.
+//
+// Every session opened by cloud_browser_open / browser_unblock is tracked
+// here from open to release. Release is the one exit path — expiry, idle
+// reaping, cloud_browser_close, a connection that can't be resumed,
+// replacement by the same API key's next open, and server shutdown all go
+// through it — and it always unmounts the session's tools, closes the CDP
+// socket and frees the remote pool slot.
+//
+// The requested timeout is enforced here. The remote browser is requested
+// for browserRemoteMargin more (see remoteBrowserTimeout), so
+// cloud_browser_extend can move the deadline within that margin without
+// touching the remote session, and a server that dies without releasing a
+// session doesn't hold its pool slot much past the deadline.
+
+const (
+	defaultBrowserTimeout = 900  // seconds
+	maxBrowserTimeout     = 1800 // seconds; Cloud Browser ceiling on a session's lifetime
+	browserRemoteMargin   = 300  // seconds the remote browser outlives the requested timeout
+)
+
+var (
+	// DefaultBrowserIdleTimeout releases sessions no tool has touched for
+	// this long. Zero, the default, disables idle reaping.
+	DefaultBrowserIdleTimeout time.Duration
+
+	// browserExpiryWarning is how long before expiry the client is told.
+	browserExpiryWarning = time.Minute
+)
+
+// trackedBrowser is one session under lifecycle management.
+type trackedBrowser struct {
+	session  *browser.Session
+	client   *scrapfly.Client   // client that opened it; releases the slot with the same key
+	notify   *mcp.ServerSession // MCP session that opened it; receives notices
+	openedAt time.Time
+	limit    time.Time // the remote browser's end; extensions stop here
+	expiry   *time.Timer
+	warn     *time.Timer
+	idle     *time.Timer
+}
+
+// browserLifecycle is the provider's registry of tracked sessions.
+type browserLifecycle struct {
+	mu          sync.Mutex
+	sessions    map[string]*trackedBrowser
+	idleTimeout time.Duration
+}
+
+// SetBrowserIdleTimeout overrides DefaultBrowserIdleTimeout for sessions
+// opened from now on. Zero disables idle reaping.
+func (p *ScrapflyToolProvider) SetBrowserIdleTimeout(d time.Duration) {
+	p.browsers.mu.Lock()
+	defer p.browsers.mu.Unlock()
+	p.browsers.idleTimeout = d
+}
+
+// trackBrowser puts session (stored under session.SessionID) under
+// lifecycle management: expiry warning and release, idle reaping. A
+// session with a client runs on a remote browser requested with
+// remoteBrowserTimeout; a CDP endpoint browser (nil client) has no remote
+// lifetime beyond the Cloud Browser ceiling.
+func (p *ScrapflyToolProvider) trackBrowser(req *mcp.CallToolRequest, client *scrapfly.Client, session *browser.Session) {
+	t := &trackedBrowser{
+		session:  session,
+		client:   client,
+		openedAt: time.Now(),
+	}
+	t.limit = t.openedAt.Add(maxBrowserTimeout * time.Second)
+	if client != nil {
+		// The expiry was set before connecting, so the remote browser
+		// (requested on connect for the expiry plus the margin) lives at
+		// least this long.
+		if remote := session.Expiry().Add(browserRemoteMargin * time.Second); remote.Before(t.limit) {
+			t.limit = remote
+		}
+	}
+	if req != nil {
+		t.notify = req.Session
+	}
+	id := session.SessionID
+
+	l := &p.browsers
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.sessions == nil {
+		l.sessions = map[string]*trackedBrowser{}
+	}
+	l.sessions[id] = t
+	p.armExpiry(id, t)
+
+	if idle := l.idleTimeout; idle > 0 {
+		var check func()
+		check = func() {
+			// A human holding the session may just be reading the page.
+			if _, _, held := session.HumanInControl(); held {
+				session.Touch()
+			}
+			if left := idle - session.IdleFor(); left > 0 {
+				l.mu.Lock()
+				if l.sessions[id] == t {
+					t.idle = time.AfterFunc(left, check)
+				}
+				l.mu.Unlock()
+				return
+			}
+			p.releaseBrowser(id, fmt.Sprintf("idle for %s", idle.Round(time.Second)))
+		}
+		t.idle = time.AfterFunc(idle, check)
+	}
+	p.browserResourceUpdated(browserSessionsURI)
+}
+
+// armExpiry (re)schedules t's expiry warning and release. Callers hold
+// p.browsers.mu.
+func (p *ScrapflyToolProvider) armExpiry(id string, t *trackedBrowser) {
+	if t.expiry != nil {
+		t.expiry.Stop()
+	}
+	if t.warn != nil {
+		t.warn.Stop()
+	}
+	left := time.Until(t.session.Expiry())
+	t.expiry = time.AfterFunc(left, func() {
+		p.releaseBrowser(id, "timeout reached")
+	})
+	if left > browserExpiryWarning {
+		t.warn = time.AfterFunc(left-browserExpiryWarning, func() {
+			p.notifyBrowser(t, "warning", fmt.Sprintf(
+				"Cloud Browser session %s expires in %s (at %s). Call cloud_browser_extend to keep it open.",
+				id, browserExpiryWarning, t.session.Expiry().Format(time.RFC3339)))
+		})
+	}
+}
+
+// extendBrowser moves session id's expiry out by d, capped at the end of
+// its remote browser. Returns the new expiry and whether the cap cut the
+// extension short.
+func (p *ScrapflyToolProvider) extendBrowser(id string, d time.Duration) (time.Time, bool, error) {
+	l := &p.browsers
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	t, ok := l.sessions[id]
+	if !ok {
+		return time.Time{}, false, fmt.Errorf("session %s not found", id)
+	}
+	limit := t.limit
+	current := t.session.Expiry()
+	if !current.Before(limit) {
+		return current, true, fmt.Errorf("session %s already runs until its remote browser ends (%s); open a new session to continue",
+			id, limit.Format(time.RFC3339))
+	}
+	next := current.Add(d)
+	capped := next.After(limit)
+	if capped {
+		next = limit
+	}
+	t.session.SetExpiry(next)
+	p.armExpiry(id, t)
+	p.logger.Printf("Extended browser session %s to %s", id, next.Format(time.RFC3339))
+	return next, capped, nil
+}
+
+// remoteBrowserTimeout is the lifetime, in seconds, to request for the
+// remote browser of a session with the given timeout: browserRemoteMargin
+// longer, within the Cloud Browser ceiling.
+func remoteBrowserTimeout(timeout int) int {
+	return min(timeout+browserRemoteMargin, maxBrowserTimeout)
+}
+
+// browserOwner returns the browser.OwnerKey of the caller's API key, the
+// owner of the sessions it opens. Sessions on a CDP endpoint have no owner
+// and are visible to anyone ("").
+func (p *ScrapflyToolProvider) browserOwner(ctx context.Context) (string, error) {
+	if p.cdpEndpoint != "" {
+		return "", nil
+	}
+	client, err := p.ClientGetter(p, ctx)
+	if err != nil {
+		return "", err
+	}
+	return browser.OwnerKey(client.APIKey()), nil
+}
+
+// findBrowserSession is browser.FindSessionFor scoped to the caller: only
+// sessions opened with the caller's API key are found.
+func (p *ScrapflyToolProvider) findBrowserSession(ctx context.Context, id string) (*browser.Session, error) {
+	owner, err := p.browserOwner(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return browser.FindSessionFor(owner, id)
+}
+
+// localBrowserOwner returns the owner of session id if this server stores
+// or tracks it, whether or not it is still connected.
+func (p *ScrapflyToolProvider) localBrowserOwner(id string) (string, bool) {
+	if val, ok := browser.Store.Load(id); ok {
+		return val.(*browser.Session).Owner, true
+	}
+	p.browsers.mu.Lock()
+	defer p.browsers.mu.Unlock()
+	if t, ok := p.browsers.sessions[id]; ok {
+		return t.session.Owner, true
+	}
+	return "", false
+}
+
+// releaseBrowser ends session id: stops its timers, unmounts its tools,
+// closes the CDP socket and frees the remote pool slot. A reason other than
+// "closed" is sent to the client that opened it. Safe to call more than
+// once; untracked sessions still found in browser.Store are released with
+// the provider's default client.
+func (p *ScrapflyToolProvider) releaseBrowser(id, reason string) {
+	l := &p.browsers
+	l.mu.Lock()
+	t, ok := l.sessions[id]
+	if ok {
+		delete(l.sessions, id)
+		for _, timer := range []*time.Timer{t.expiry, t.warn, t.idle} {
+			if timer != nil {
+				timer.Stop()
+			}
+		}
+	}
+	remaining := len(l.sessions)
+	l.mu.Unlock()
+
+	if !ok {
+		val, found := browser.Store.Load(id)
+		if !found {
+			return
+		}
+		t = &trackedBrowser{session: val.(*browser.Session)}
+		if p.cdpEndpoint == "" {
+			t.client = p.Client
+		}
+	}
+
+	p.unmountWebMCPTools(t.session)
+	t.session.Close()
+	if t.client != nil {
+		if err := t.client.CloudBrowserSessionStop(id); err != nil {
+			p.logger.Printf("Releasing browser session %s: stop API call failed (non-fatal): %v", id, err)
+		}
+	}
+	if remaining == 0 {
+		p.unmountInteractionTools()
+	}
+	p.browserResourceUpdated(browserSessionsURI)
+	p.logger.Printf("Released browser session %s (%s)", id, reason)
+	if reason != "closed" {
+		p.notifyBrowser(t, "notice", fmt.Sprintf("Cloud Browser session %s was closed: %s.", id, reason))
+	}
+}
+
+// releaseAllBrowsers releases every tracked or stored session.
+func (p *ScrapflyToolProvider) releaseAllBrowsers(reason string) {
+	p.releaseBrowsersWhere(reason, func(*browser.Session) bool { return true })
+}
+
+// releaseOwnerBrowsers releases the tracked or stored sessions opened with
+// the API key of owner (a browser.OwnerKey), leaving other keys' sessions
+// alone. Used when a new open replaces the caller's browser.
+func (p *ScrapflyToolProvider) releaseOwnerBrowsers(owner, reason string) {
+	p.releaseBrowsersWhere(reason, func(s *browser.Session) bool { return s.Owner == owner })
+}
+
+func (p *ScrapflyToolProvider) releaseBrowsersWhere(reason string, match func(*browser.Session) bool) {
+	var ids []string
+	browser.Store.Range(func(key, value any) bool {
+		if match(value.(*browser.Session)) {
+			ids = append(ids, key.(string))
+		}
+		return true
+	})
+	p.browsers.mu.Lock()
+	for id, t := range p.browsers.sessions {
+		if _, stored := browser.Store.Load(id); !stored && match(t.session) {
+			ids = append(ids, id)
+		}
+	}
+	p.browsers.mu.Unlock()
+	for _, id := range ids {
+		p.releaseBrowser(id, reason)
+	}
+}
+
+// Shutdown releases every open Cloud Browser session, whichever API key
+// opened it. Call on server exit so pool slots aren't held until their
+// remote timeout.
+func (p *ScrapflyToolProvider) Shutdown() {
+	p.releaseAllBrowsers("server shutting down")
+}
+
+// notifyBrowser sends a logging notification to the MCP session that opened
+// t. Clients that haven't set a log level don't receive it.
+func (p *ScrapflyToolProvider) notifyBrowser(t *trackedBrowser, level mcp.LoggingLevel, msg string) {
+	if t.notify == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := t.notify.Log(ctx, &mcp.LoggingMessageParams{
+		Level:  level,
+		Logger: "cloud_browser",
+		Data: map[string]any{
+			"session_id": t.session.SessionID,
+			"expires_at": t.session.Expiry().Format(time.RFC3339),
+			"message":    msg,
+		},
+	})
+	if err != nil {
+		p.logger.Printf("Browser notice for %s not delivered: %v", t.session.SessionID, err)
+	}
+}
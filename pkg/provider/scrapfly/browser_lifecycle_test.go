@@ -0,0 +1,242 @@
+package scrapflyprovider
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	scrapfly "github.com/scrapfly/go-scrapfly"
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser"
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser/browsertest"
+)
+
+func newTestProvider() *ScrapflyToolProvider {
+	return NewScrapflyToolProvider(nil, GetDefaultScrapflyClient, log.New(io.Discard, "", 0))
+}
+
+// track puts a fresh session under lifecycle management, released without
+// a stop call when the test ends.
+func track(t *testing.T, p *ScrapflyToolProvider, id string, client *scrapfly.Client, timeout time.Duration) *browser.Session {
+	t.Helper()
+	session := &browser.Session{SessionID: id}
+	session.SetExpiry(time.Now().Add(timeout))
+	p.trackBrowser(nil, client, session)
+	t.Cleanup(func() {
+		p.browsers.mu.Lock()
+		if tb := p.browsers.sessions[id]; tb != nil {
+			tb.client = nil
+		}
+		p.browsers.mu.Unlock()
+		p.releaseBrowser(id, "closed")
+	})
+	return session
+}
+
+func tracked(p *ScrapflyToolProvider, id string) bool {
+	p.browsers.mu.Lock()
+	defer p.browsers.mu.Unlock()
+	_, ok := p.browsers.sessions[id]
+	return ok
+}
+
+func TestRemoteBrowserTimeout(t *testing.T) {
+	for _, tc := range []struct{ timeout, want int }{
+		{60, 60 + browserRemoteMargin},
+		{defaultBrowserTimeout, defaultBrowserTimeout + browserRemoteMargin},
+		{maxBrowserTimeout - 10, maxBrowserTimeout},
+		{maxBrowserTimeout, maxBrowserTimeout},
+	} {
+		if got := remoteBrowserTimeout(tc.timeout); got != tc.want {
+			t.Errorf("remoteBrowserTimeout(%d) = %d, want %d", tc.timeout, got, tc.want)
+		}
+	}
+}
+
+func TestExtendBrowserStopsAtRemoteBrowser(t *testing.T) {
+	p := newTestProvider()
+	session := track(t, p, "extend-remote", &scrapfly.Client{}, 2*time.Minute)
+	opened := session.Expiry()
+
+	next, capped, err := p.extendBrowser("extend-remote", time.Minute)
+	if err != nil || capped || !next.Equal(opened.Add(time.Minute)) {
+		t.Fatalf("extend by 1m = %s, capped=%v, err=%v; want %s", next, capped, err, opened.Add(time.Minute))
+	}
+	next, capped, err = p.extendBrowser("extend-remote", time.Hour)
+	if limit := opened.Add(browserRemoteMargin * time.Second); err != nil || !capped || !next.Equal(limit) {
+		t.Fatalf("extend by 1h = %s, capped=%v, err=%v; want capped at %s", next, capped, err, limit)
+	}
+	if _, _, err := p.extendBrowser("extend-remote", time.Minute); err == nil {
+		t.Error("extending past the remote browser's end succeeded")
+	}
+}
+
+func TestExtendCDPBrowserUpToCeiling(t *testing.T) {
+	p := newTestProvider()
+	track(t, p, "extend-cdp", nil, 2*time.Minute)
+	// Well past browserRemoteMargin, within the ceiling.
+	next, capped, err := p.extendBrowser("extend-cdp", 20*time.Minute)
+	if err != nil || capped {
+		t.Fatalf("extend a CDP browser by 20m: capped=%v, err=%v", capped, err)
+	}
+	if left := time.Until(next); left < 21*time.Minute {
+		t.Errorf("CDP browser extended to %s from now, want over 21m", left)
+	}
+}
+
+func TestIdleReapingOffByDefault(t *testing.T) {
+	if p := newTestProvider(); p.browsers.idleTimeout != 0 {
+		t.Errorf("default idle timeout = %s, want reaping off", p.browsers.idleTimeout)
+	}
+}
+
+func TestIdleReapingSkipsHumanControl(t *testing.T) {
+	p := newTestProvider()
+	p.SetBrowserIdleTimeout(30 * time.Millisecond)
+	session := track(t, p, "idle-human", nil, time.Minute)
+	if err := session.TakeControl("human"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(150 * time.Millisecond)
+	if !tracked(p, "idle-human") {
+		t.Fatal("session reaped while a human was in control")
+	}
+
+	session.ReleaseControl("human")
+	deadline := time.Now().Add(2 * time.Second)
+	for tracked(p, "idle-human") {
+		if time.Now().After(deadline) {
+			t.Fatal("idle session not reaped after the human released it")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// ownedSessions stores a live session for each of the API keys, under the
+// key's name, and returns a provider acting for the first key.
+func ownedSessions(t *testing.T, keys ...string) *ScrapflyToolProvider {
+	t.Helper()
+	srv := browsertest.NewServer()
+	t.Cleanup(srv.Close)
+	for _, key := range keys {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		session, err := srv.Connect(ctx)
+		cancel()
+		if err != nil {
+			t.Fatal(err)
+		}
+		session.SessionID, session.Owner = key, browser.OwnerKey(key)
+		browser.Store.Store(key, session)
+		t.Cleanup(func() {
+			browser.Store.Delete(key)
+			session.Close()
+		})
+	}
+	client, err := scrapfly.New(keys[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := newTestProvider()
+	p.Client = client
+	return p
+}
+
+func TestSessionToolsScopedToOwner(t *testing.T) {
+	p := ownedSessions(t, "key-mine", "key-theirs")
+	ctx := context.Background()
+	const theirs = "key-theirs"
+	for name, call := range map[string]func() (*mcp.CallToolResult, any, error){
+		"wait_for": func() (*mcp.CallToolResult, any, error) {
+			return p.WaitFor(ctx, nil, WaitForInput{SessionID: theirs, Conditions: []WaitForCondition{{Type: "title_changes"}}})
+		},
+		"assert": func() (*mcp.CallToolResult, any, error) {
+			return p.Assert(ctx, nil, AssertInput{SessionID: theirs})
+		},
+		"harvest": func() (*mcp.CallToolResult, any, error) {
+			return p.Harvest(ctx, nil, HarvestInput{SessionID: theirs, ItemSelector: ".item", Strategy: "scroll"})
+		},
+		"fill_form": func() (*mcp.CallToolResult, any, error) {
+			return p.FillForm(ctx, nil, FillFormInput{SessionID: theirs})
+		},
+		"extract_tables": func() (*mcp.CallToolResult, any, error) {
+			return p.ExtractTables(ctx, nil, ExtractTablesInput{SessionID: theirs})
+		},
+		"cloud_browser_extract": func() (*mcp.CallToolResult, any, error) {
+			return p.CloudBrowserExtract(ctx, nil, CloudBrowserExtractInput{SessionID: theirs})
+		},
+		"cloud_browser_record": func() (*mcp.CallToolResult, any, error) {
+			return p.CloudBrowserRecord(ctx, nil, CloudBrowserRecordInput{SessionID: theirs})
+		},
+		"wait_for_human": func() (*mcp.CallToolResult, any, error) {
+			return p.WaitForHuman(ctx, nil, WaitForHumanInput{SessionID: theirs})
+		},
+		"cloud_browser_snapshot": func() (*mcp.CallToolResult, any, error) {
+			return p.CloudBrowserSnapshot(ctx, nil, CloudBrowserSnapshotInput{SessionID: theirs})
+		},
+	} {
+		res, _, err := call()
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if !res.IsError || !strings.Contains(res.Content[0].(*mcp.TextContent).Text, "not found") {
+			t.Errorf("%s reached another key's session: %+v", name, res.Content)
+		}
+	}
+}
+
+func TestHumanControlGuardScopedToOwner(t *testing.T) {
+	p := ownedSessions(t, "key-mine", "key-theirs")
+	val, _ := browser.Store.Load("key-theirs")
+	if err := val.(*browser.Session).TakeControl("human"); err != nil {
+		t.Fatal(err)
+	}
+	ts := interactionTools(p)
+	ht, ok := ts["cloud_browser_navigate"]
+	if !ok {
+		t.Fatal("cloud_browser_navigate not in the interaction tools")
+	}
+	args, _ := json.Marshal(map[string]string{"session_id": "key-theirs", "url": "https://example.com"})
+	res, err := ht.Handler(context.Background(), &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Name: "cloud_browser_navigate", Arguments: args}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The guard doesn't see another key's session, so it can't tell that
+	// a human holds it: the tool itself reports it not found.
+	if text := res.Content[0].(*mcp.TextContent).Text; !res.IsError || strings.Contains(text, "HUMAN_IN_CONTROL") {
+		t.Errorf("cloud_browser_navigate on another key's session = %s", text)
+	}
+}
+
+func TestReleaseOwnerBrowsersKeepsOtherKeys(t *testing.T) {
+	p := newTestProvider()
+	track(t, p, "owned-mine", nil, time.Minute).Owner = browser.OwnerKey("key-mine")
+	track(t, p, "owned-theirs", nil, time.Minute).Owner = browser.OwnerKey("key-theirs")
+
+	p.releaseOwnerBrowsers(browser.OwnerKey("key-mine"), "replaced by a new cloud_browser_open")
+	if tracked(p, "owned-mine") {
+		t.Error("the caller's session was not released")
+	}
+	if !tracked(p, "owned-theirs") {
+		t.Error("another key's session was released")
+	}
+}
+
+func TestCloudBrowserCloseScopedToOwner(t *testing.T) {
+	p := ownedSessions(t, "key-mine", "key-theirs")
+	res, _, err := p.CloudBrowserClose(context.Background(), nil, CloudBrowserCloseInput{SessionID: "key-theirs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.IsError || !strings.Contains(res.Content[0].(*mcp.TextContent).Text, "not found") {
+		t.Errorf("closing another key's session = %+v, want it not found", res.Content)
+	}
+	val, ok := browser.Store.Load("key-theirs")
+	if !ok || !val.(*browser.Session).Alive() {
+		t.Error("another key's session was closed")
+	}
+}
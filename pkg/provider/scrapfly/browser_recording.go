@@ -0,0 +1,179 @@
+package scrapflyprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser"
+)
+
+// Screencast recordings.
+//
+// cloud_browser_record start/stop writes a session's screencast to an MJPEG
+// AVI in the download directory (or a temp directory when none is set).
+// Finished files stay readable as scrapfly://recordings/{name} after the
+// session is gone; only files recorded by this process are served.
+
+const recordingResourcePrefix = "scrapfly://recordings/"
+
+var recordingResourceTemplate = &mcp.ResourceTemplate{
+	Name:        "cloud_browser_recording",
+	URITemplate: recordingResourcePrefix + "{name}",
+	MIMEType:    "video/x-msvideo",
+	Description: "A Cloud Browser screencast recorded with cloud_browser_record (MJPEG AVI)",
+}
+
+// browserRecordings tracks the running recording of each session and the
+// files recorded so far.
+type browserRecordings struct {
+	mu     sync.Mutex
+	active map[string]*browser.Recording // by session ID
+	files  map[string]string             // file name -> path
+}
+
+type CloudBrowserRecordInput struct {
+	SessionID          string `json:"session_id,omitempty" jsonschema:"Browser session ID. If omitted, uses the most recent session."`
+	Action             string `json:"action" jsonschema:"'start' to begin recording the session's screen, 'stop' to finish and get the video."`
+	FPS                int    `json:"fps,omitempty" jsonschema:"Frames per second (default 5, max 30). Only for start."`
+	MaxWidth           int    `json:"max_width,omitempty" jsonschema:"Maximum frame width in pixels (default 1280). Only for start."`
+	MaxHeight          int    `json:"max_height,omitempty" jsonschema:"Maximum frame height in pixels (default 720). Only for start."`
+	MaxDurationSeconds int    `json:"max_duration_seconds,omitempty" jsonschema:"Stop automatically after this many seconds (default 600, max 1800). Only for start."`
+}
+
+func (p *ScrapflyToolProvider) CloudBrowserRecord(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input CloudBrowserRecordInput,
+) (*mcp.CallToolResult, any, error) {
+	session, err := p.findBrowserSession(ctx, input.SessionID)
+	if err != nil {
+		return ToolErrf("cloud_browser_record: %v", err), nil, nil
+	}
+	id := session.SessionID
+	r := &p.recordings
+
+	switch input.Action {
+	case "start":
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if rec := r.active[id]; rec != nil {
+			select {
+			case <-rec.Done():
+			default:
+				return ToolErr("ALREADY_RECORDING", fmt.Sprintf("cloud_browser_record: session %s is already being recorded", id),
+					"Call cloud_browser_record with action='stop' first.", 0, ""), nil, nil
+			}
+		}
+		dir, err := recordingDir()
+		if err != nil {
+			return ToolErrf("cloud_browser_record: %v", err), nil, nil
+		}
+		name := fmt.Sprintf("recording-%s-%s.avi", id, time.Now().UTC().Format("20060102T150405Z"))
+		rec, err := session.StartRecording(browser.RecordingOptions{
+			Path:        filepath.Join(dir, name),
+			FPS:         input.FPS,
+			MaxWidth:    input.MaxWidth,
+			MaxHeight:   input.MaxHeight,
+			MaxDuration: time.Duration(input.MaxDurationSeconds) * time.Second,
+		})
+		if err != nil {
+			return ToolErrf("cloud_browser_record: %v", err), nil, nil
+		}
+		if r.active == nil {
+			r.active = map[string]*browser.Recording{}
+			r.files = map[string]string{}
+		}
+		r.active[id] = rec
+		r.files[name] = filepath.Join(dir, name)
+		p.logger.Printf("Recording browser session %s to %s", id, r.files[name])
+		b, _ := json.MarshalIndent(map[string]any{
+			"status": "recording",
+			"uri":    recordingResourcePrefix + url.PathEscape(name),
+			"info":   rec.Info(),
+		}, "", "  ")
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: string(b)}},
+		}, nil, nil
+
+	case "stop":
+		r.mu.Lock()
+		rec := r.active[id]
+		delete(r.active, id)
+		r.mu.Unlock()
+		if rec == nil {
+			return ToolErrf("cloud_browser_record: session %s is not being recorded", id), nil, nil
+		}
+		info := rec.Stop()
+		if info.Error != "" {
+			return ToolErrf("cloud_browser_record: recording failed: %s", info.Error), nil, nil
+		}
+		p.logger.Printf("Recorded browser session %s: %d frames (%d unique), %d bytes", id, info.Frames, info.UniqueFrames, info.Size)
+		name := filepath.Base(info.Path)
+		size := info.Size
+		b, _ := json.MarshalIndent(info, "", "  ")
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(b)},
+				&mcp.ResourceLink{
+					URI:         recordingResourcePrefix + url.PathEscape(name),
+					Name:        name,
+					Description: fmt.Sprintf("%.1fs screencast of session %s at %d fps", info.Seconds, id, info.FPS),
+					MIMEType:    recordingResourceTemplate.MIMEType,
+					Size:        &size,
+				},
+			},
+		}, nil, nil
+	}
+	return ToolErrf("cloud_browser_record: unknown action %q (use 'start' or 'stop')", input.Action), nil, nil
+}
+
+// recordingDir is where recordings are written: the download directory if
+// one is configured, a temp directory otherwise.
+func recordingDir() (string, error) {
+	if dir := browser.DownloadDir(); dir != "" {
+		return dir, nil
+	}
+	dir := filepath.Join(os.TempDir(), "scrapfly-mcp-recordings")
+	return dir, os.MkdirAll(dir, 0o755)
+}
+
+// readRecordingResource serves scrapfly://recordings/{name}.
+func (p *ScrapflyToolProvider) readRecordingResource(_ context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	uri := req.Params.URI
+	name, err := url.PathUnescape(strings.TrimPrefix(uri, recordingResourcePrefix))
+	if err != nil {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+	r := &p.recordings
+	r.mu.Lock()
+	path, ok := r.files[name]
+	for _, rec := range r.active {
+		if rec.Info().Path == path {
+			select {
+			case <-rec.Done():
+			default:
+				r.mu.Unlock()
+				return nil, fmt.Errorf("recording %q is still running; stop it with cloud_browser_record first", name)
+			}
+		}
+	}
+	r.mu.Unlock()
+	if !ok {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading recording %q failed: %w", name, err)
+	}
+	return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{
+		{URI: uri, MIMEType: recordingResourceTemplate.MIMEType, Blob: data},
+	}}, nil
+}
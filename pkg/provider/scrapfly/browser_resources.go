@@ -0,0 +1,322 @@
+package scrapflyprovider
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser"
+	"github.com/scrapfly/scrapfly-mcp/pkg/tools"
+)
+
+// Cloud Browser sessions as MCP resources.
+//
+// Each open session is readable under scrapfly://browser/{session_id}/… and
+// subscribable: navigations, AX tree changes, console output, finished
+// requests and completed downloads send notifications/resources/updated for
+// the affected URIs, so a client can follow the page instead of polling
+// cloud_browser_sessions. scrapfly://browser/sessions lists the open sessions
+// and is updated as they come and go.
+//
+// Reading a resource doesn't count as activity: a subscribed UI mustn't keep
+// an abandoned session from being reaped. Like the tools, a caller only sees
+// the sessions opened with its own API key.
+
+const (
+	browserResourcePrefix = "scrapfly://browser/"
+	browserSessionsURI    = browserResourcePrefix + "sessions"
+
+	// browserResourceDebounce coalesces bursts of page events (an AX tree
+	// rebuild, a page's worth of requests) into one notification per URI.
+	browserResourceDebounce = 250 * time.Millisecond
+)
+
+var browserResourceTemplates = []*mcp.ResourceTemplate{
+	{
+		Name:        "cloud_browser_snapshot",
+		URITemplate: browserResourcePrefix + "{session_id}/snapshot",
+		MIMEType:    "text/plain",
+		Description: "Accessibility snapshot of the session's current page, as returned by cloud_browser_snapshot",
+	},
+	{
+		Name:        "cloud_browser_screenshot",
+		URITemplate: browserResourcePrefix + "{session_id}/screenshot",
+		MIMEType:    "image/png",
+		Description: "Viewport screenshot of the session's current page",
+	},
+	{
+		Name:        "cloud_browser_console",
+		URITemplate: browserResourcePrefix + "{session_id}/console",
+		MIMEType:    "application/json",
+		Description: "Recent console messages, browser log entries and uncaught exceptions",
+	},
+	{
+		Name:        "cloud_browser_network",
+		URITemplate: browserResourcePrefix + "{session_id}/network",
+		MIMEType:    "application/json",
+		Description: "Recent network requests with status, type and size",
+	},
+	{
+		Name:        "cloud_browser_downloads",
+		URITemplate: browserResourcePrefix + "{session_id}/downloads",
+		MIMEType:    "application/json",
+		Description: "Files downloaded in the session",
+	},
+	{
+		Name:        "cloud_browser_download",
+		URITemplate: browserResourcePrefix + "{session_id}/downloads/{filename}",
+		Description: "Content of a downloaded file",
+	},
+}
+
+var browserSessionsResource = &mcp.Resource{
+	Name:        "cloud_browser_sessions",
+	URI:         browserSessionsURI,
+	MIMEType:    "application/json",
+	Description: "Open Cloud Browser sessions with their resource URIs",
+}
+
+// browserResources adds the Cloud Browser resources to set.
+func browserResources(p *ScrapflyToolProvider, set tools.HandledResourceSet) {
+	tools.AddResourceToResourceSet(set, browserSessionsResource, p.readBrowserResource)
+	for _, t := range browserResourceTemplates {
+		tools.AddResourceTemplateToResourceSet(set, t, p.readBrowserResource)
+	}
+	tools.AddResourceTemplateToResourceSet(set, recordingResourceTemplate, p.readRecordingResource)
+}
+
+// browserResourceURIs returns the resource URIs of session id.
+func browserResourceURIs(id string) map[string]string {
+	base := browserResourcePrefix + id + "/"
+	return map[string]string{
+		"snapshot":   base + "snapshot",
+		"screenshot": base + "screenshot",
+		"console":    base + "console",
+		"network":    base + "network",
+		"downloads":  base + "downloads",
+	}
+}
+
+// parseBrowserResourceURI splits scrapfly://browser/{id}/{kind}[/{filename}].
+func parseBrowserResourceURI(uri string) (id, kind, filename string, ok bool) {
+	rest, found := strings.CutPrefix(uri, browserResourcePrefix)
+	if !found {
+		return "", "", "", false
+	}
+	parts := strings.SplitN(rest, "/", 3)
+	if len(parts) < 2 || parts[0] == "" {
+		return "", "", "", false
+	}
+	id, kind = parts[0], parts[1]
+	switch kind {
+	case "snapshot", "screenshot", "console", "network":
+		return id, kind, "", len(parts) == 2
+	case "downloads":
+		if len(parts) == 2 {
+			return id, kind, "", true
+		}
+		filename, err := url.PathUnescape(parts[2])
+		if err != nil || filename == "" {
+			return "", "", "", false
+		}
+		return id, "download", filename, true
+	}
+	return "", "", "", false
+}
+
+// lookupBrowserSession returns the live session id of owner ("" for any
+// owner) without touching it.
+func lookupBrowserSession(owner, id string) (*browser.Session, bool) {
+	val, ok := browser.Store.Load(id)
+	if !ok {
+		return nil, false
+	}
+	session := val.(*browser.Session)
+	if owner != "" && session.Owner != owner {
+		return nil, false
+	}
+	return session, session.Alive()
+}
+
+// readBrowserResource serves every scrapfly://browser/ resource.
+func (p *ScrapflyToolProvider) readBrowserResource(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	uri := req.Params.URI
+	owner, err := p.browserOwner(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if uri == browserSessionsURI {
+		return jsonResource(uri, map[string]any{"sessions": browserSessionList(owner)})
+	}
+	id, kind, filename, ok := parseBrowserResourceURI(uri)
+	if !ok {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+	session, ok := lookupBrowserSession(owner, id)
+	if !ok {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+
+	switch kind {
+	case "snapshot":
+		session.Page.Refresh(ctx, session)
+		return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{
+			{URI: uri, MIMEType: "text/plain", Text: session.Page.Snapshot()},
+		}}, nil
+	case "screenshot":
+		data, err := session.Screenshot(false, "")
+		if err != nil {
+			return nil, fmt.Errorf("screenshot failed: %w", err)
+		}
+		png, err := base64.StdEncoding.DecodeString(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("screenshot failed: %w", err)
+		}
+		return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{
+			{URI: uri, MIMEType: "image/png", Blob: png},
+		}}, nil
+	case "console":
+		return jsonResource(uri, map[string]any{"entries": session.ConsoleLog()})
+	case "network":
+		return jsonResource(uri, map[string]any{"requests": session.NetworkLog()})
+	case "downloads":
+		downloads, err := session.ListDownloads(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing downloads failed: %w", err)
+		}
+		files := make([]map[string]any, len(downloads))
+		for i, d := range downloads {
+			files[i] = map[string]any{
+				"filename": d.Filename,
+				"size":     d.Size,
+				"uri":      uri + "/" + url.PathEscape(d.Filename),
+			}
+		}
+		return jsonResource(uri, map[string]any{"downloads": files})
+	default: // "download"
+		d, err := session.FetchDownload(ctx, filename)
+		if err != nil {
+			return nil, fmt.Errorf("reading download %q failed: %w", filename, err)
+		}
+		return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{
+			{URI: uri, MIMEType: d.MIMEType, Blob: d.Data},
+		}}, nil
+	}
+}
+
+func jsonResource(uri string, v any) (*mcp.ReadResourceResult, error) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{
+		{URI: uri, MIMEType: "application/json", Text: string(b)},
+	}}, nil
+}
+
+// browserSessionList describes the open sessions of owner ("" for every
+// owner) for scrapfly://browser/sessions.
+func browserSessionList(owner string) []map[string]any {
+	sessions := []map[string]any{}
+	browser.Store.Range(func(key, value any) bool {
+		s := value.(*browser.Session)
+		if owner != "" && s.Owner != owner {
+			return true
+		}
+		sessions = append(sessions, map[string]any{
+			"session_id": key,
+			"page_url":   s.Page.URL,
+			"expires_at": s.Expiry().Format(time.RFC3339),
+			"resources":  browserResourceURIs(key.(string)),
+		})
+		return true
+	})
+	return sessions
+}
+
+// SubscribeResource implements provider.ResourceSubscriber for the
+// scrapfly://browser/ resources of open sessions.
+func (p *ScrapflyToolProvider) SubscribeResource(ctx context.Context, uri string) error {
+	if uri == browserSessionsURI {
+		return nil
+	}
+	id, _, _, ok := parseBrowserResourceURI(uri)
+	if !ok {
+		return mcp.ResourceNotFoundError(uri)
+	}
+	owner, err := p.browserOwner(ctx)
+	if err != nil {
+		return err
+	}
+	if _, ok := lookupBrowserSession(owner, id); !ok {
+		return mcp.ResourceNotFoundError(uri)
+	}
+	return nil
+}
+
+// UnsubscribeResource implements provider.ResourceSubscriber. The server
+// drops the subscription itself; there is nothing to tear down here.
+func (p *ScrapflyToolProvider) UnsubscribeResource(context.Context, string) error {
+	return nil
+}
+
+// resourceUpdates debounces notifications/resources/updated per URI.
+type resourceUpdates struct {
+	mu      sync.Mutex
+	pending map[string]bool
+}
+
+// browserResourceUpdated tells subscribers of uri that it changed, at most
+// once per browserResourceDebounce.
+func (p *ScrapflyToolProvider) browserResourceUpdated(uri string) {
+	if p.MCPServer == nil {
+		return
+	}
+	u := &p.resourceUpdates
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.pending[uri] {
+		return
+	}
+	if u.pending == nil {
+		u.pending = map[string]bool{}
+	}
+	u.pending[uri] = true
+	time.AfterFunc(browserResourceDebounce, func() {
+		u.mu.Lock()
+		delete(u.pending, uri)
+		u.mu.Unlock()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		p.MCPServer.ResourceUpdated(ctx, &mcp.ResourceUpdatedNotificationParams{URI: uri})
+	})
+}
+
+// watchBrowserResources maps session activity to resource updates. Like
+// watchWebMCPTools, call it before browser.Connect.
+func (p *ScrapflyToolProvider) watchBrowserResources(session *browser.Session) {
+	uris := browserResourceURIs(session.SessionID)
+	session.TrackActivity(func(kind browser.ActivityKind) {
+		switch kind {
+		case browser.ActivityNavigated:
+			p.browserResourceUpdated(uris["snapshot"])
+			p.browserResourceUpdated(uris["screenshot"])
+			p.browserResourceUpdated(browserSessionsURI)
+		case browser.ActivityPageChanged:
+			p.browserResourceUpdated(uris["snapshot"])
+			p.browserResourceUpdated(uris["screenshot"])
+		case browser.ActivityConsole:
+			p.browserResourceUpdated(uris["console"])
+		case browser.ActivityNetwork:
+			p.browserResourceUpdated(uris["network"])
+		case browser.ActivityDownload:
+			p.browserResourceUpdated(uris["downloads"])
+		}
+	})
+}
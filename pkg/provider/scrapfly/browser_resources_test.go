@@ -0,0 +1,57 @@
+package scrapflyprovider
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func readResource(p *ScrapflyToolProvider, uri string) (*mcp.ReadResourceResult, error) {
+	return p.readBrowserResource(context.Background(), &mcp.ReadResourceRequest{Params: &mcp.ReadResourceParams{URI: uri}})
+}
+
+func TestBrowserResourcesAreScopedToOwner(t *testing.T) {
+	p := ownedSessions(t, "key-mine", "key-theirs")
+
+	res, err := readResource(p, browserSessionsURI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var list struct {
+		Sessions []struct {
+			SessionID string `json:"session_id"`
+		} `json:"sessions"`
+	}
+	json.Unmarshal([]byte(res.Contents[0].Text), &list)
+	if len(list.Sessions) != 1 || list.Sessions[0].SessionID != "key-mine" {
+		t.Errorf("sessions resource lists %+v, want only key-mine", list.Sessions)
+	}
+
+	if _, err := readResource(p, browserResourcePrefix+"key-mine/console"); err != nil {
+		t.Errorf("reading own session: %v", err)
+	}
+	if _, err := readResource(p, browserResourcePrefix+"key-theirs/console"); err == nil {
+		t.Error("read another key's session resource")
+	}
+	if err := p.SubscribeResource(context.Background(), browserResourcePrefix+"key-theirs/network"); err == nil {
+		t.Error("subscribed to another key's session resource")
+	}
+	if err := p.SubscribeResource(context.Background(), browserResourcePrefix+"key-mine/network"); err != nil {
+		t.Errorf("subscribing to own session: %v", err)
+	}
+}
+
+func TestCloudBrowserSessionsScopedToOwner(t *testing.T) {
+	p := ownedSessions(t, "key-mine", "key-theirs")
+	res, _, err := p.CloudBrowserSessions(context.Background(), nil, struct{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := res.Content[0].(*mcp.TextContent).Text
+	if !strings.Contains(text, `"key-mine"`) || strings.Contains(text, `"key-theirs"`) {
+		t.Errorf("cloud_browser_sessions = %s, want only key-mine", text)
+	}
+}
@@ -0,0 +1,128 @@
+package scrapflyprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser"
+	"github.com/scrapfly/scrapfly-mcp/pkg/tools"
+)
+
+// Human takeover, agent side. While a human holds a session through
+// /browser/control, every interaction tool except the read-only ones below
+// fails with a HUMAN_IN_CONTROL error; wait_for_human blocks until they
+// hand it back and returns a fresh snapshot.
+
+// humanSafeTools keep working while a human is in control: they only read
+// the page.
+var humanSafeTools = map[string]bool{
+	"wait_for_human":           true,
+	"wait_for":                 true,
+	"assert":                   true,
+	"take_snapshot":            true,
+	"take_screenshot":          true,
+	"get_page_url":             true,
+	"cloud_browser_screenshot": true,
+	"cloud_browser_downloads":  true,
+	"cloud_browser_record":     true,
+	"cloud_browser_extract":    true,
+	"extract_tables":           true,
+	"list_webmcp_tools":        true,
+}
+
+// defaultWaitForHuman bounds wait_for_human when no timeout is given.
+const defaultWaitForHuman = 5 * time.Minute
+
+// guardHumanControl makes every tool in ts that isn't human-safe fail while
+// a human holds its session.
+func guardHumanControl(ts tools.HandledToolSet, provider *ScrapflyToolProvider) {
+	for name, ht := range ts {
+		if humanSafeTools[name] {
+			continue
+		}
+		next := ht.Handler
+		ht.Handler = func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			var args struct {
+				SessionID string `json:"session_id"`
+			}
+			json.Unmarshal(req.Params.Arguments, &args)
+			if session, err := provider.findBrowserSession(ctx, args.SessionID); err == nil {
+				if err := session.CheckAgentControl(); err != nil {
+					return ToolErr("HUMAN_IN_CONTROL", fmt.Sprintf("%s: %v", name, err),
+						"Call wait_for_human, then continue from the snapshot it returns.", 0, ""), nil
+				}
+			}
+			return next(ctx, req)
+		}
+	}
+}
+
+// WaitForHumanInput is the input of wait_for_human.
+type WaitForHumanInput struct {
+	SessionID      string `json:"session_id,omitempty" jsonschema:"Browser session ID. If omitted, uses the most recent session."`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty" jsonschema:"How long to wait for the human to hand control back (default 300)."`
+}
+
+func (p *ScrapflyToolProvider) WaitForHuman(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input WaitForHumanInput,
+) (*mcp.CallToolResult, any, error) {
+	session, err := p.findBrowserSession(ctx, input.SessionID)
+	if err != nil {
+		return ToolErrf("wait_for_human: %v", err), nil, nil
+	}
+	timeout := defaultWaitForHuman
+	if input.TimeoutSeconds > 0 {
+		timeout = time.Duration(input.TimeoutSeconds) * time.Second
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	held, err := session.WaitForAgentControl(waitCtx)
+	if err != nil {
+		if holder, _, ok := session.HumanInControl(); ok {
+			return ToolErr("HUMAN_IN_CONTROL", fmt.Sprintf("wait_for_human: %s still has control after %s", holder, timeout),
+				"Call wait_for_human again, or ask the user whether they are done.", 0, ""), nil, nil
+		}
+		return ToolErrf("wait_for_human: %v", err), nil, nil
+	}
+	session.Touch()
+	session.Page.Refresh(ctx, session)
+	status := "No human was in control of this session."
+	if held {
+		status = "The human handed control back. The page may have changed; continue from this snapshot."
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: status + "\n\n" + session.Page.Snapshot()}},
+	}, nil, nil
+}
+
+// watchHumanControl tells the client that opened session when a human
+// takes or releases it. Like watchWebMCPTools, call it before browser.Connect.
+func (p *ScrapflyToolProvider) watchHumanControl(session *browser.Session) {
+	id := session.SessionID
+	session.OnControlChange(func(holder string, taken bool) {
+		p.browsers.mu.Lock()
+		t := p.browsers.sessions[id]
+		p.browsers.mu.Unlock()
+		if taken {
+			p.logger.Printf("Browser session %s: %s took control", id, holder)
+			if t != nil {
+				p.notifyBrowser(t, "notice", fmt.Sprintf("%s took control of Cloud Browser session %s. Interaction tools return HUMAN_IN_CONTROL until they hand it back; call wait_for_human.", holder, id))
+			}
+			return
+		}
+		p.logger.Printf("Browser session %s: %s released control", id, holder)
+		// The human likely changed the page; refresh what the agent and
+		// resource subscribers see.
+		uris := browserResourceURIs(id)
+		p.browserResourceUpdated(uris["snapshot"])
+		p.browserResourceUpdated(uris["screenshot"])
+		if t != nil {
+			p.notifyBrowser(t, "notice", fmt.Sprintf("%s handed Cloud Browser session %s back. Take a snapshot (or call wait_for_human) before the next action.", holder, id))
+		}
+	})
+}
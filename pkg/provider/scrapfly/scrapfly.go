@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sync"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/scrapfly/go-scrapfly"
@@ -21,6 +23,16 @@ type ScrapflyToolProvider struct {
 	ClientGetter ScrapflyClientGetter
 	MCPServer    *mcp.Server // set during RegisterAll(), used for dynamic tool registration (cloud browser)
 	logger       *log.Logger
+
+	webmcpMu sync.Mutex       // serializes page-tool mirroring onto MCPServer (tools_webmcp.go)
+	browsers browserLifecycle // open Cloud Browser sessions (browser_lifecycle.go)
+
+	resourceUpdates resourceUpdates   // debounced scrapfly://browser/ notifications (browser_resources.go)
+	recordings      browserRecordings // cloud_browser_record state (browser_recording.go)
+
+	cdpEndpoint string // generic CDP browser replacing the Cloud Browser (tools_cloud_browser_cdp.go)
+
+	webmcpTimeouts map[string]time.Duration // per page tool invoke timeouts (tools_webmcp.go)
 }
 
 // if logger is nil, it will use the default logger with opinionated prefix and settings
@@ -32,6 +44,7 @@ func NewScrapflyToolProvider(client *scrapfly.Client, clientGetter ScrapflyClien
 		Client:       client,
 		ClientGetter: clientGetter,
 		logger:       logger,
+		browsers:     browserLifecycle{idleTimeout: DefaultBrowserIdleTimeout},
 	}
 }
 
@@ -70,8 +83,8 @@ func (p *ScrapflyToolProvider) ToolSet() tools.HandledToolSet {
 // browser_unblock) and unmounted by unmountInteractionTools (on
 // cloud_browser_close or session expiry). Each call to this builder
 // returns a FRESH HandledToolSet — handlers close over the provider,
-// not over a specific session, so they look up the active session
-// via browser.FindSession("") at call time.
+// not over a specific session, so they look up the caller's active
+// session via findBrowserSession at call time.
 func (p *ScrapflyToolProvider) dynamicInteractionTools() tools.HandledToolSet {
 	return interactionTools(p)
 }
@@ -127,7 +140,7 @@ var standardPermissionsMeta = mcp.Meta{
 // ToolSet() and registered at MCP server boot via tools/list. These
 // don't require a Cloud Browser session: account info, stateless
 // scraping, antibot classification, the cheat-sheet prompt, and
-// browser-LIFECYCLE tools (open / unblock / close / sessions).
+// browser-LIFECYCLE tools (open / unblock / close / sessions / extend).
 //
 // What's NOT here: every interaction tool (click, fill, take_snapshot,
 // take_screenshot, scroll, evaluate_script, …) AND the cloud-browser
@@ -236,6 +249,21 @@ func staticTools(provider *ScrapflyToolProvider) tools.HandledToolSet {
 		Meta:        standardPermissionsMeta,
 	}, provider.CheckIfBlocked)
 
+	tools.MustAddToolToToolset(HandledTools, &mcp.Tool{
+		Name:        "extract_tables",
+		Title:       "Extract Tables and Lists",
+		Description: "Parse HTML tables into JSON records (keyed by column name) or CSV, locally and free of credits. Handles colspan/rowspan, multi-row headers and nested tables (returned separately with their parent's index). Works on `html` you already have, such as a `web_scrape` result in raw or clean_html format, or on the live DOM of a browser session when html is omitted (optionally scoped to a snapshot `uid`). Pick a table with `index` or a CSS `selector`; kind='list' does the same for <ul>/<ol> items and <dl> term/definition pairs. Prefer this over reading tables row by row in a snapshot or markdown.",
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Extract Tables and Lists",
+			DestructiveHint: &falseBool,
+			IdempotentHint:  true,
+			OpenWorldHint:   &falseBool,
+			ReadOnlyHint:    true,
+		},
+		InputSchema: schemas.MustRefineScrapingToolInputSchema[ExtractTablesInput](),
+		Meta:        standardPermissionsMeta,
+	}, provider.ExtractTables)
+
 	tools.MustAddToolToToolset(HandledTools, &mcp.Tool{
 		Name:        "cloud_browser_open",
 		Title:       "Scrapfly Cloud Browser — Open Session",
@@ -245,7 +273,7 @@ func staticTools(provider *ScrapflyToolProvider) tools.HandledToolSet {
 			"  • Reading: `take_snapshot` (accessibility tree + uids), `take_screenshot` (PNG), `get_page_url`, `evaluate_script` (read-only JS).\n" +
 			"  • Input: `click`, `fill`, `type_text`, `hover`, `press_key`, `scroll`, `drag`, `select_option`.\n" +
 			"  • Page-author API: `list_webmcp_tools`, `call_webmcp_tool` — prefer these when the page exposes a matching tool; they are the author's declared programmatic API and survive DOM refactors.\n" +
-			"  • Navigation in the same session: `cloud_browser_navigate`. Session management: `cloud_browser_sessions`, `cloud_browser_close` (only on explicit user request), `cloud_browser_downloads`, `cloud_browser_performance`.\n\n" +
+			"  • Navigation in the same session: `cloud_browser_navigate`. Session management: `cloud_browser_sessions`, `cloud_browser_extend`, `cloud_browser_close` (only on explicit user request), `cloud_browser_downloads`, `cloud_browser_record`, `cloud_browser_performance`.\n\n" +
 			"If the opened page shows a challenge/captcha, close and retry with `browser_unblock`.",
 		Annotations: &mcp.ToolAnnotations{
 			Title:           "Scrapfly Cloud Browser — Open Session",
@@ -284,13 +312,27 @@ func staticTools(provider *ScrapflyToolProvider) tools.HandledToolSet {
 		},
 		Meta: standardPermissionsMeta,
 	}, provider.CloudBrowserSessions)
+	tools.MustAddToolToToolset(HandledTools, &mcp.Tool{
+		Name:        "cloud_browser_extend",
+		Title:       "Scrapfly Cloud Browser — Extend Session",
+		Description: "Push the cloud-browser session's expiry out by `seconds` (default 300). Sessions can be extended by at most 300 seconds past the `timeout` they were opened with (and never past 1800 seconds in total); longer requests are capped. Call when a long task is still running as the session nears its `expires_at`, or when the client was notified that the session is about to expire. Servers started with an idle timeout also close sessions no tool has used for that long, so an extension only helps a session that is in use.",
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Scrapfly Cloud Browser — Extend Session",
+			DestructiveHint: &falseBool,
+			IdempotentHint:  false,
+			OpenWorldHint:   &falseBool,
+			ReadOnlyHint:    false,
+		},
+		InputSchema: schemas.MustRefineScrapingToolInputSchema[CloudBrowserExtendInput](),
+		Meta:        standardPermissionsMeta,
+	}, provider.CloudBrowserExtend)
 	// WebMCP meta-tools (list_webmcp_tools / call_webmcp_tool) are
 	// always reachable. They no-op when no browser session is open
 	// AND let the model reach page-registered tools across the
 	// dynamic mount/unmount boundary even when the MCP client
 	// (e.g. adk-python) doesn't refetch tools/list on
 	// notifications/tools/list_changed.
-	addWebMCPMetaTools(HandledTools, provider.logger)
+	addWebMCPMetaTools(HandledTools, provider)
 
 	// Interaction tools (click, fill, take_snapshot, take_screenshot,
 	// cloud_browser_navigate, cloud_browser_downloads, …) are also
@@ -328,7 +370,7 @@ func staticTools(provider *ScrapflyToolProvider) tools.HandledToolSet {
 // interactionTools — the dynamic, browser-session-only tool surface.
 // Mounted onto the *mcp.Server when cloud_browser_open / browser_unblock
 // succeed, unmounted on cloud_browser_close. Each handler still does a
-// browser.FindSession("") guard so a tool call that races with an
+// findBrowserSession guard so a tool call that races with an
 // unmount returns a clean error rather than panicking.
 //
 // Includes: cloud_browser_navigate / _screenshot / _eval / _performance
@@ -358,7 +400,7 @@ func interactionTools(provider *ScrapflyToolProvider) tools.HandledToolSet {
 	tools.MustAddToolToToolset(HandledTools, &mcp.Tool{
 		Name:        "cloud_browser_screenshot",
 		Title:       "Scrapfly Cloud Browser — Screenshot",
-		Description: "PNG of the active cloud-browser session. Optional `selector` is a CSS selector (e.g. `img[alt='Scrapfly Logo']`, `#header`, `.logo img`) — NOT a uid from `take_snapshot`. Without `selector`, captures the full viewport (or full page if `full_page: true`). For element-level shots where you only have a uid, prefer `take_screenshot` after `scroll`-ing the element into view; `take_screenshot` is the newer flat-API equivalent and is preferred in general.",
+		Description: "PNG of the active cloud-browser session. Optional `selector` is a CSS selector (e.g. `img[alt='Scrapfly Logo']`, `#header`, `.logo img`) — NOT a uid from `take_snapshot`. Without `selector`, captures the full viewport (or full page if `full_page: true`). For element-level shots where you only have a uid, use `take_screenshot` with `uid`; `take_screenshot` is the newer flat-API equivalent and is preferred in general. `annotate: true` returns a set-of-marks screenshot (interactive elements boxed and labelled with their snapshot uids, plus a legend) for use with `click_at`.",
 		Annotations: &mcp.ToolAnnotations{
 			Title:           "Scrapfly Cloud Browser — Screenshot",
 			DestructiveHint: &falseBool,
@@ -385,10 +427,25 @@ func interactionTools(provider *ScrapflyToolProvider) tools.HandledToolSet {
 		Meta:        standardPermissionsMeta,
 	}, provider.CloudBrowserEval)
 
+	tools.MustAddToolToToolset(HandledTools, &mcp.Tool{
+		Name:        "cloud_browser_extract",
+		Title:       "Scrapfly Cloud Browser — Extract Data",
+		Description: "Run Scrapfly's extraction API against the live page of a browser session: the current DOM (after logins, clicks and client-side rendering) is sent with exactly one of `extraction_prompt`, `extraction_model` or `extraction_template`/`extraction_ephemeral_template`. Pass `uid` from take_snapshot to extract from one element only (a results list, a product card), which is faster and cheaper than the whole page. Returns the structured data with the page URL and title as provenance. Use this instead of evaluate_script or reading the snapshot when you need structured data from a page you navigated to.",
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Scrapfly Cloud Browser — Extract Data",
+			DestructiveHint: &falseBool,
+			IdempotentHint:  true,
+			OpenWorldHint:   &trueBool,
+			ReadOnlyHint:    true,
+		},
+		InputSchema: schemas.MustRefineScrapingToolInputSchema[CloudBrowserExtractInput](),
+		Meta:        standardPermissionsMeta,
+	}, provider.CloudBrowserExtract)
+
 	tools.MustAddToolToToolset(HandledTools, &mcp.Tool{
 		Name:        "cloud_browser_performance",
 		Title:       "Scrapfly Cloud Browser — PageSpeed Lab Run",
-		Description: "PageSpeed Insights-style lab run: cold-cache reload with mobile throttling (Moto G4 + slow 4G + 4× CPU) by default, or desktop wired. Returns Core Web Vitals (LCP, FCP, CLS, TTFB, INP), Speed Index, Total Blocking Time, Time To Interactive, resource waterfall with render-blocking detection, diagnostics (DOM nodes, main-thread ms, total byte weight), Lighthouse-style performance score (0-100), and Good/Needs-Improvement/Poor ratings per PSI thresholds. Use after cloud_browser_open. Inputs: preset ('mobile'|'desktop'), timeout_ms (max 30000).",
+		Description: "PageSpeed Insights-style lab run: cold-cache reload with mobile throttling (Moto G4 + slow 4G + 4× CPU) by default, or desktop wired. Returns Core Web Vitals (LCP, FCP, CLS, TTFB, INP), Speed Index, Total Blocking Time, Time To Interactive, resource waterfall with render-blocking detection, third-party breakdown by entity (transfer KB, requests, main-thread ms, render-blocking count), diagnostics (DOM nodes, main-thread ms, total byte weight), unused JS/CSS coverage with \"Reduce unused JavaScript/CSS\" opportunities and estimated savings, Lighthouse-style performance score (0-100), and Good/Needs-Improvement/Poor ratings per PSI thresholds. Use after cloud_browser_open. Set visuals=true to also get a timestamped filmstrip PNG and a screenshot with the LCP element outlined. Inputs: preset ('mobile'|'desktop'), timeout_ms (max 30000), visuals, filmstrip_interval_ms.",
 		Annotations: &mcp.ToolAnnotations{
 			Title:           "Scrapfly Cloud Browser — Performance Metrics",
 			DestructiveHint: &falseBool,
@@ -403,7 +460,7 @@ func interactionTools(provider *ScrapflyToolProvider) tools.HandledToolSet {
 	tools.MustAddToolToToolset(HandledTools, &mcp.Tool{
 		Name:        "cloud_browser_downloads",
 		Title:       "Scrapfly Cloud Browser — Downloads",
-		Description: "Inspect files that have been downloaded during the current browser session (e.g. after clicking a link that triggered a download, or submitting a form that returned an attachment). Without `filename`, returns metadata for every captured download. With `filename`, returns the file's bytes base64-encoded. Only relevant when a download was triggered inside the session — for fetching a file whose URL you already know, use `web_get_page` / `web_scrape` instead.",
+		Description: "Inspect files that have been downloaded during the current browser session (e.g. after clicking a link that triggered a download, or submitting a form that returned an attachment). Without `filename`, returns metadata for every captured download. With `filename`, returns the file as an embedded resource with its detected MIME type, or as a resource link when it is larger than 1 MiB. Add `save=true` to save it to the server's download directory and get its path and SHA-256 instead. Only relevant when a download was triggered inside the session — for fetching a file whose URL you already know, use `web_get_page` / `web_scrape` instead.",
 		Annotations: &mcp.ToolAnnotations{
 			Title:           "Scrapfly Cloud Browser — Downloads",
 			DestructiveHint: &falseBool,
@@ -415,6 +472,21 @@ func interactionTools(provider *ScrapflyToolProvider) tools.HandledToolSet {
 		Meta:        standardPermissionsMeta,
 	}, provider.CloudBrowserDownloads)
 
+	tools.MustAddToolToToolset(HandledTools, &mcp.Tool{
+		Name:        "cloud_browser_record",
+		Title:       "Scrapfly Cloud Browser — Record",
+		Description: "Record the browser session's screen to a video. Call with action='start' (optional fps, max_width, max_height, max_duration_seconds), run the flow you want to capture, then action='stop' to get an MJPEG AVI as a resource link plus its duration, frame counts, size and SHA-256. Idle stretches cost almost nothing: unchanged frames are stored as repeats. Recording stops by itself after max_duration_seconds (default 600) or when the session closes. Useful for bug reports, audits and showing the user what happened.",
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Scrapfly Cloud Browser — Record",
+			DestructiveHint: &falseBool,
+			IdempotentHint:  false,
+			OpenWorldHint:   &falseBool,
+			ReadOnlyHint:    true,
+		},
+		InputSchema: schemas.MustRefineScrapingToolInputSchema[CloudBrowserRecordInput](),
+		Meta:        standardPermissionsMeta,
+	}, provider.CloudBrowserRecord)
+
 	// Flat interaction tools (click, fill, type_text, hover, press_key,
 	// scroll, select_option, drag, take_snapshot, take_screenshot,
 	// get_page_url, evaluate_script) + WebMCP meta-tools
@@ -425,6 +497,80 @@ func interactionTools(provider *ScrapflyToolProvider) tools.HandledToolSet {
 		HandledTools[name] = ht
 	}
 
+	tools.MustAddToolToToolset(HandledTools, &mcp.Tool{
+		Name:        "fill_form",
+		Title:       "Fill a form in one call",
+		Description: "Fill several form fields in one call instead of one `fill`/`select_option`/`click` per field. Each entry targets a field by `uid` (from `take_snapshot`) or by `label`, and the right action is picked per field: typing for text inputs, option selection for selects, check/uncheck for checkboxes and radios, upload for file inputs (files from the server's download directory), direct value for date/time/color/range. Set `submit=true` to submit afterwards (optionally via `submit_uid`). Returns a per-field result with validation state and the page's alert messages; take a snapshot afterwards if the page navigated.",
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Fill a form in one call",
+			DestructiveHint: &falseBool,
+			OpenWorldHint:   &trueBool,
+		},
+		InputSchema: schemas.MustRefineScrapingToolInputSchema[FillFormInput](),
+		Meta:        standardPermissionsMeta,
+	}, provider.FillForm)
+
+	tools.MustAddToolToToolset(HandledTools, &mcp.Tool{
+		Name:        "assert",
+		Title:       "Assert page checks",
+		Description: "Evaluate QA checks against the live page and report pass/fail with evidence for each: element_exists (role and/or accessible name), text_present, input_value (uid or label, expected value), url_matches (regexp), no_console_errors and request_ok (a request whose URL matches a regexp returned 2xx). The last two can look only at activity after a named mark: call assert with just `mark` before an action, then check with since=<mark>. Set junit=true to also get a JUnit XML rendering for CI dashboards. Failed checks are reported, not raised as tool errors.",
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Assert page checks",
+			DestructiveHint: &falseBool,
+			IdempotentHint:  true,
+			OpenWorldHint:   &falseBool,
+			ReadOnlyHint:    true,
+		},
+		InputSchema: schemas.MustRefineScrapingToolInputSchema[AssertInput](),
+		Meta:        standardPermissionsMeta,
+	}, provider.Assert)
+
+	tools.MustAddToolToToolset(HandledTools, &mcp.Tool{
+		Name:        "harvest",
+		Title:       "Harvest listing items",
+		Description: "Collect every item of a listing in one call instead of dozens of scroll/snapshot/click calls. Give a CSS `item_selector` and a strategy: 'scroll' scrolls to the bottom until the item count stops growing (infinite scroll); 'next' clicks a next-page control (next_uid, next_text or next_selector) after each page. Items are deduplicated by `key_attribute` (default: first link, then text) and returned with their text, first link and attributes. Stops at max_items (default 500), max_pages (default 10), time_budget_seconds (default 60) or 1 MiB of items, whichever comes first, and says why. Sends a progress notification per page. Take a snapshot or use find_elements first to pick the item selector.",
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Harvest listing items",
+			DestructiveHint: &falseBool,
+			IdempotentHint:  false,
+			OpenWorldHint:   &trueBool,
+			ReadOnlyHint:    false,
+		},
+		InputSchema: schemas.MustRefineScrapingToolInputSchema[HarvestInput](),
+		Meta:        standardPermissionsMeta,
+	}, provider.Harvest)
+
+	tools.MustAddToolToToolset(HandledTools, &mcp.Tool{
+		Name:        "wait_for",
+		Title:       "Wait for a page condition",
+		Description: "Wait until the page reaches a state instead of sleeping: text appears/disappears, an element (uid or CSS selector) becomes visible/hidden, the URL matches a regexp, the network has been idle for N ms, the title changes, or a JS expression becomes truthy. Combine several conditions with match='any' (default) or 'all'. Returns which conditions were met and the elapsed time, plus a fresh snapshot with snapshot=true. Times out after timeout_seconds (default 10, max 60) with WAIT_TIMEOUT. Prefer this over `wait` after submitting forms, triggering searches or loading more results.",
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Wait for a page condition",
+			DestructiveHint: &falseBool,
+			IdempotentHint:  true,
+			OpenWorldHint:   &falseBool,
+			ReadOnlyHint:    true,
+		},
+		InputSchema: schemas.MustRefineScrapingToolInputSchema[WaitForInput](),
+		Meta:        standardPermissionsMeta,
+	}, provider.WaitFor)
+
+	tools.MustAddToolToToolset(HandledTools, &mcp.Tool{
+		Name:        "wait_for_human",
+		Title:       "Wait for a human to hand back control",
+		Description: "Block until the human who took over the cloud-browser session (tools return HUMAN_IN_CONTROL meanwhile) hands it back, then return a fresh snapshot. Call it when a tool fails with HUMAN_IN_CONTROL, or after asking the user to solve a captcha or 2FA prompt in the live view. Returns immediately if no human is in control.",
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Wait for a human to hand back control",
+			DestructiveHint: &falseBool,
+			IdempotentHint:  true,
+			OpenWorldHint:   &falseBool,
+			ReadOnlyHint:    true,
+		},
+		InputSchema: schemas.MustRefineScrapingToolInputSchema[WaitForHumanInput](),
+		Meta:        standardPermissionsMeta,
+	}, provider.WaitForHuman)
+
+	guardHumanControl(HandledTools, provider)
 	return HandledTools
 }
 
@@ -475,8 +621,9 @@ var ResourcesList = []*mcp.Resource{
 	},
 }
 
-func standardResources(_ *ScrapflyToolProvider) tools.HandledResourceSet {
+func standardResources(p *ScrapflyToolProvider) tools.HandledResourceSet {
 	HandledResources := tools.NewHandledResourceSet()
+	browserResources(p, HandledResources)
 	if constants.DisableProviderResources {
 		return HandledResources
 	}
@@ -0,0 +1,82 @@
+package scrapflyprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser"
+)
+
+type AssertCheckInput struct {
+	Type  string `json:"type" jsonschema:"One of: element_exists, text_present, input_value, url_matches, no_console_errors, request_ok."`
+	Name  string `json:"name,omitempty" jsonschema:"Label for this check in the report and the JUnit test case name. Defaults to a description of the check."`
+	Role  string `json:"role,omitempty" jsonschema:"element_exists: the element's AX role (button, link, heading, textbox…)."`
+	Label string `json:"label,omitempty" jsonschema:"element_exists: the element's accessible name. input_value: the field's label, when no uid is given."`
+	UID   string `json:"uid,omitempty" jsonschema:"input_value: element id from take_snapshot."`
+	Value string `json:"value,omitempty" jsonschema:"text_present: the text (case-insensitive). input_value: the expected value (true/false for checkboxes). url_matches and request_ok: a regular expression over the URL."`
+	Since string `json:"since,omitempty" jsonschema:"no_console_errors and request_ok: only consider activity after this mark (see the mark field). Defaults to the whole session."`
+}
+
+type AssertInput struct {
+	SessionID string             `json:"session_id,omitempty" jsonschema:"Browser session ID. If omitted, uses the most recent session."`
+	Checks    []AssertCheckInput `json:"checks,omitempty" jsonschema:"Checks to evaluate, in order."`
+	Mark      string             `json:"mark,omitempty" jsonschema:"After evaluating the checks, record a mark with this name. Later no_console_errors and request_ok checks can use since=<mark> to look only at what happened after it. Call with just a mark to set one before an action."`
+	Suite     string             `json:"suite,omitempty" jsonschema:"JUnit test suite name. Default: 'browser'."`
+	JUnit     bool               `json:"junit,omitempty" jsonschema:"Also return the report as JUnit XML."`
+}
+
+func (p *ScrapflyToolProvider) Assert(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input AssertInput,
+) (*mcp.CallToolResult, any, error) {
+	session, err := p.findBrowserSession(ctx, input.SessionID)
+	if err != nil {
+		return ToolErrf("assert: %v", err), nil, nil
+	}
+	if len(input.Checks) == 0 {
+		if input.Mark == "" {
+			return ToolErrf("assert: give checks, a mark, or both"), nil, nil
+		}
+		at := session.SetActivityMark(input.Mark)
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("Mark %q set at %s.", input.Mark, at.Format("15:04:05.000"))}},
+		}, nil, nil
+	}
+
+	checks := make([]browser.AssertCheck, len(input.Checks))
+	for i, c := range input.Checks {
+		checks[i] = browser.AssertCheck{
+			Name: c.Name, Type: c.Type, Role: c.Role, Label: c.Label,
+			UID: c.UID, Value: c.Value, Since: c.Since,
+		}
+	}
+	report, err := session.Assert(ctx, checks)
+	if err != nil {
+		return ToolErrf("assert: %v", err), nil, nil
+	}
+	if input.Mark != "" {
+		session.SetActivityMark(input.Mark)
+	}
+
+	summary := fmt.Sprintf("%d/%d checks passed", report.Passed, len(report.Results))
+	if !report.OK() {
+		summary += fmt.Sprintf(", %d failed", report.Failed)
+	}
+	b, _ := json.MarshalIndent(report, "", "  ")
+	content := []mcp.Content{&mcp.TextContent{Text: summary + "\n\n" + string(b)}}
+	if input.JUnit {
+		suite := input.Suite
+		if suite == "" {
+			suite = "browser"
+		}
+		xml, err := report.JUnitXML(suite)
+		if err != nil {
+			return ToolErrf("assert: junit: %v", err), nil, nil
+		}
+		content = append(content, &mcp.TextContent{Text: string(xml)})
+	}
+	return &mcp.CallToolResult{Content: content}, nil, nil
+}
@@ -0,0 +1,45 @@
+package scrapflyprovider
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser"
+)
+
+type FillFormField struct {
+	UID   string `json:"uid,omitempty" jsonschema:"Element id from take_snapshot. Either uid or label is required."`
+	Label string `json:"label,omitempty" jsonschema:"Accessible name of the field (its label, placeholder or aria-label), matched case-insensitively when no uid is given: exactly, else as the only name containing it."`
+	Value string `json:"value" jsonschema:"Text to enter; option value or visible text for a select ('a|b' for multi-select); true/false for a checkbox or radio; file name(s) in the server's download directory, separated by '|', for a file input."`
+}
+
+type FillFormInput struct {
+	SessionID string          `json:"session_id,omitempty" jsonschema:"Browser session ID. If omitted, uses the most recent session."`
+	Fields    []FillFormField `json:"fields" jsonschema:"Fields to fill, in order."`
+	Submit    bool            `json:"submit,omitempty" jsonschema:"Submit the form after filling: clicks submit_uid, or submits the form of the last field."`
+	SubmitUID string          `json:"submit_uid,omitempty" jsonschema:"Element id of the submit button to click when submit is true."`
+}
+
+func (p *ScrapflyToolProvider) FillForm(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input FillFormInput,
+) (*mcp.CallToolResult, any, error) {
+	session, err := p.findBrowserSession(ctx, input.SessionID)
+	if err != nil {
+		return ToolErrf("fill_form: %v", err), nil, nil
+	}
+	fields := make([]browser.FormField, len(input.Fields))
+	for i, f := range input.Fields {
+		fields[i] = browser.FormField{UID: f.UID, Label: f.Label, Value: f.Value}
+	}
+	res, err := session.FillForm(ctx, fields, browser.FormSubmit{Enabled: input.Submit, UID: input.SubmitUID})
+	if err != nil {
+		return ToolErrf("fill_form: %v", err), nil, nil
+	}
+	b, _ := json.MarshalIndent(res, "", "  ")
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: string(b)}},
+	}, nil, nil
+}
@@ -0,0 +1,91 @@
+package scrapflyprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser"
+)
+
+// harvest limits: defaults and the most a call may ask for.
+const (
+	defaultHarvestPages  = 10
+	maxHarvestPages      = 100
+	defaultHarvestItems  = 500
+	maxHarvestItems      = 5000
+	defaultHarvestBudget = 60 * time.Second
+	maxHarvestBudget     = 300 * time.Second
+	// The JSON size of the items returned, so a large listing can't flood
+	// the client's context.
+	maxHarvestBytes = 1 << 20
+)
+
+type HarvestInput struct {
+	SessionID    string   `json:"session_id,omitempty" jsonschema:"Browser session ID. If omitted, uses the most recent session."`
+	ItemSelector string   `json:"item_selector" jsonschema:"CSS selector matching one item of the listing (a product card, a result row)."`
+	Strategy     string   `json:"strategy" jsonschema:"'scroll': scroll to the bottom until the item count stops growing (infinite scroll, 'load more' on scroll). 'next': click a next-page control after each page."`
+	NextUID      string   `json:"next_uid,omitempty" jsonschema:"For strategy next: element id of the next control from take_snapshot. Found again by its text on later pages."`
+	NextText     string   `json:"next_text,omitempty" jsonschema:"For strategy next: text of the next control (link or button), e.g. 'Next' or '›'."`
+	NextSelector string   `json:"next_selector,omitempty" jsonschema:"For strategy next: CSS selector of the next control."`
+	KeyAttribute string   `json:"key_attribute,omitempty" jsonschema:"Attribute identifying an item across pages, on the item or inside it (e.g. 'data-product-id', 'href'). Default: the item's first link, then its text."`
+	Attributes   []string `json:"attributes,omitempty" jsonschema:"Attributes of the item element to return. Default: all of them."`
+	MaxItems     int      `json:"max_items,omitempty" jsonschema:"Stop after this many unique items (default 500, max 5000)."`
+	MaxPages     int      `json:"max_pages,omitempty" jsonschema:"Stop after this many pages or scrolls (default 10, max 100)."`
+	TimeBudget   int      `json:"time_budget_seconds,omitempty" jsonschema:"Stop after this many seconds (default 60, max 300)."`
+}
+
+func (p *ScrapflyToolProvider) Harvest(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input HarvestInput,
+) (*mcp.CallToolResult, any, error) {
+	session, err := p.findBrowserSession(ctx, input.SessionID)
+	if err != nil {
+		return ToolErrf("harvest: %v", err), nil, nil
+	}
+	opts := browser.HarvestOptions{
+		ItemSelector: input.ItemSelector,
+		Strategy:     input.Strategy,
+		Next:         browser.HarvestNextControl{UID: input.NextUID, Text: input.NextText, Selector: input.NextSelector},
+		KeyAttr:      input.KeyAttribute,
+		Attributes:   input.Attributes,
+		MaxItems:     clampHarvest(input.MaxItems, defaultHarvestItems, maxHarvestItems),
+		MaxPages:     clampHarvest(input.MaxPages, defaultHarvestPages, maxHarvestPages),
+		MaxBytes:     maxHarvestBytes,
+		Budget:       time.Duration(clampHarvest(input.TimeBudget, int(defaultHarvestBudget/time.Second), int(maxHarvestBudget/time.Second))) * time.Second,
+	}
+	if req != nil && req.Params.GetProgressToken() != nil {
+		notifier, err := NewProgressNotifierFromRequest(req, float64(opts.MaxPages))
+		if err == nil {
+			notifier.Start(ctx, "Harvesting "+input.ItemSelector)
+			opts.OnPage = func(page browser.HarvestPage) {
+				msg := fmt.Sprintf("Page %d: %d new items, %d total", page.Page, page.NewItems, page.Total)
+				if err := notifier.Progress(ctx, 1, msg); err != nil {
+					p.logger.Printf("[Harvest] progress: %v", err)
+				}
+			}
+		}
+	}
+
+	res, err := session.Harvest(ctx, opts)
+	if err != nil {
+		return ToolErrf("harvest: %v", err), nil, nil
+	}
+	p.logger.Printf("[Harvest] %s — %d items over %d pages (%s)", session.SessionID, len(res.Items), res.Pages, res.StopReason)
+	b, _ := json.MarshalIndent(res, "", "  ")
+	summary := fmt.Sprintf("%d items over %d pages, stopped: %s", len(res.Items), res.Pages, res.StopReason)
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: summary + "\n\n" + string(b)}},
+	}, nil, nil
+}
+
+// clampHarvest applies a default to unset limits and caps the rest.
+func clampHarvest(v, def, limit int) int {
+	if v <= 0 {
+		return def
+	}
+	return min(v, limit)
+}
@@ -1,14 +1,13 @@
 package scrapflyprovider
 
 // Static browser interaction tools — registered once at startup with flat names.
-// Each tool looks up the active browser session via browser.FindSession("").
+// Each tool looks up the caller's active browser session via findBrowserSession.
 // Follows the Chrome DevTools MCP pattern (flat names, no session prefix).
 
 import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -48,7 +47,7 @@ func browserInteractionTools(provider *ScrapflyToolProvider) tools.HandledToolSe
 		Meta:        standardPermissionsMeta,
 	}, func(ctx context.Context, req *mcp.CallToolRequest, _ DummyInput) (*mcp.CallToolResult, any, error) {
 		args := wrapUidToSelector(req.Params.Arguments)
-		r, err := callActiveAntibot(logger, "clickOn", args)
+		r, err := provider.callActiveAntibot(ctx, logger, "clickOn", args)
 		return r, nil, err
 	})
 
@@ -61,7 +60,7 @@ func browserInteractionTools(provider *ScrapflyToolProvider) tools.HandledToolSe
 		Meta:        standardPermissionsMeta,
 	}, func(ctx context.Context, req *mcp.CallToolRequest, _ DummyInput) (*mcp.CallToolResult, any, error) {
 		args := wrapUidToFillArgs(req.Params.Arguments)
-		r, err := callActiveAntibot(logger, "fill", args)
+		r, err := provider.callActiveAntibot(ctx, logger, "fill", args)
 		return r, nil, err
 	})
 
@@ -79,7 +78,7 @@ func browserInteractionTools(provider *ScrapflyToolProvider) tools.HandledToolSe
 		},
 		Meta: standardPermissionsMeta,
 	}, func(ctx context.Context, req *mcp.CallToolRequest, _ DummyInput) (*mcp.CallToolResult, any, error) {
-		r, err := callActiveAntibot(logger, "typeText", req.Params.Arguments)
+		r, err := provider.callActiveAntibot(ctx, logger, "typeText", req.Params.Arguments)
 		return r, nil, err
 	})
 
@@ -92,7 +91,7 @@ func browserInteractionTools(provider *ScrapflyToolProvider) tools.HandledToolSe
 		Meta:        standardPermissionsMeta,
 	}, func(ctx context.Context, req *mcp.CallToolRequest, _ DummyInput) (*mcp.CallToolResult, any, error) {
 		args := wrapUidToSelector(req.Params.Arguments)
-		r, err := callActiveAntibot(logger, "hover", args)
+		r, err := provider.callActiveAntibot(ctx, logger, "hover", args)
 		return r, nil, err
 	})
 
@@ -110,7 +109,7 @@ func browserInteractionTools(provider *ScrapflyToolProvider) tools.HandledToolSe
 		},
 		Meta: standardPermissionsMeta,
 	}, func(ctx context.Context, req *mcp.CallToolRequest, _ DummyInput) (*mcp.CallToolResult, any, error) {
-		r, err := callActiveAntibot(logger, "pressKey", req.Params.Arguments)
+		r, err := provider.callActiveAntibot(ctx, logger, "pressKey", req.Params.Arguments)
 		return r, nil, err
 	})
 
@@ -146,16 +145,16 @@ func browserInteractionTools(provider *ScrapflyToolProvider) tools.HandledToolSe
 			cdpArgs["delta"] = map[string]any{"x": params.DeltaX, "y": params.DeltaY}
 		}
 		translated, _ := json.Marshal(cdpArgs)
-		r, err := callActiveAntibot(logger, "scroll", translated)
+		r, err := provider.callActiveAntibot(ctx, logger, "scroll", translated)
 		if err != nil {
 			return r, nil, err
 		}
 
 		// Also execute JS scrollBy as fallback — some pages have custom scroll containers
 		// that Antibot.scroll (which uses native wheel events) can't scroll
-		session, _ := browser.FindSession("")
+		session, _ := provider.findBrowserSession(ctx, "")
 		if session != nil && (params.DeltaX != 0 || params.DeltaY != 0) {
-			session.SendCDP("Runtime.evaluate", map[string]any{
+			session.SendCDPCtx(ctx, "Runtime.evaluate", map[string]any{
 				"expression":    fmt.Sprintf("window.scrollBy(%v, %v)", params.DeltaX, params.DeltaY),
 				"returnByValue": true,
 			})
@@ -180,7 +179,7 @@ func browserInteractionTools(provider *ScrapflyToolProvider) tools.HandledToolSe
 		Meta: standardPermissionsMeta,
 	}, func(ctx context.Context, req *mcp.CallToolRequest, _ DummyInput) (*mcp.CallToolResult, any, error) {
 		args := wrapUidToSelector(req.Params.Arguments)
-		r, err := callActiveAntibot(logger, "selectOption", args)
+		r, err := provider.callActiveAntibot(ctx, logger, "selectOption", args)
 		return r, nil, err
 	})
 
@@ -209,7 +208,52 @@ func browserInteractionTools(provider *ScrapflyToolProvider) tools.HandledToolSe
 			"from": map[string]any{"type": "axNodeId", "query": args.FromUID},
 			"to":   map[string]any{"type": "axNodeId", "query": args.ToUID},
 		})
-		r, err := callActiveAntibot(logger, "dragAndDrop", translated)
+		r, err := provider.callActiveAntibot(ctx, logger, "dragAndDrop", translated)
+		return r, nil, err
+	})
+
+	tools.MustAddToolToToolset(ts, &mcp.Tool{
+		Name:        "click_at",
+		Title:       "Click a box or a point",
+		Description: "Click the centre of a numbered box from an annotated screenshot (`take_screenshot` / `cloud_browser_screenshot` with `annotate=true`), or raw viewport coordinates in CSS pixels. Use it when you located the target visually; when you have a uid from `take_snapshot`, `click` is equivalent. Coordinates are the fallback for canvas widgets and elements missing from the accessibility tree.",
+		Annotations: &mcp.ToolAnnotations{Title: "Click a box or a point", DestructiveHint: &falseBool, OpenWorldHint: &trueBool},
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"box": map[string]any{"type": "string", "description": "Box number (snapshot uid) from an annotated screenshot"},
+				"x":   map[string]any{"type": "number", "description": "Viewport X in CSS pixels (when no box is given)"},
+				"y":   map[string]any{"type": "number", "description": "Viewport Y in CSS pixels (when no box is given)"},
+			},
+		},
+		Meta: standardPermissionsMeta,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, _ DummyInput) (*mcp.CallToolResult, any, error) {
+		var args struct {
+			Box string   `json:"box"`
+			X   *float64 `json:"x"`
+			Y   *float64 `json:"y"`
+		}
+		json.Unmarshal(req.Params.Arguments, &args)
+		var x, y float64
+		switch {
+		case args.Box != "":
+			session, err := provider.findBrowserSession(ctx, "")
+			if err != nil {
+				return ToolErrf("click_at: no active browser session. Call cloud_browser_open first."), nil, nil
+			}
+			mark, err := session.MarkFor(ctx, args.Box)
+			if err != nil {
+				return ToolErrf("click_at: %v. Take a fresh annotated screenshot or snapshot.", err), nil, nil
+			}
+			x, y = mark.Center()
+		case args.X != nil && args.Y != nil:
+			x, y = *args.X, *args.Y
+		default:
+			return ToolErrf("click_at: pass either box, or both x and y"), nil, nil
+		}
+		translated, _ := json.Marshal(map[string]any{
+			"selector": map[string]any{"type": "coord", "query": fmt.Sprintf("%.0f,%.0f", x, y)},
+		})
+		r, err := provider.callActiveAntibot(ctx, logger, "clickOn", translated)
 		return r, nil, err
 	})
 
@@ -222,7 +266,7 @@ func browserInteractionTools(provider *ScrapflyToolProvider) tools.HandledToolSe
 		Annotations: &mcp.ToolAnnotations{Title: "Get current page URL", DestructiveHint: &falseBool, ReadOnlyHint: true},
 		Meta:        standardPermissionsMeta,
 	}, func(ctx context.Context, req *mcp.CallToolRequest, _ DummyInput) (*mcp.CallToolResult, any, error) {
-		session, err := browser.FindSession("")
+		session, err := provider.findBrowserSession(ctx, "")
 		if err != nil {
 			return ToolErrf("get_page_url: no active browser session"), nil, nil
 		}
@@ -234,15 +278,48 @@ func browserInteractionTools(provider *ScrapflyToolProvider) tools.HandledToolSe
 	tools.MustAddToolToToolset(ts, &mcp.Tool{
 		Name:        "take_screenshot",
 		Title:       "Take a screenshot",
-		Description: "Capture a PNG of the current cloud-browser page. Use when the user asks for a visual, or when the page's information (charts, diagrams, styled layout) isn't well-represented by the accessibility tree. For structural/text understanding, `take_snapshot` is cheaper and more actionable.",
+		Description: "Capture a PNG of the current cloud-browser page. Use when the user asks for a visual, or when the page's information (charts, diagrams, styled layout) isn't well-represented by the accessibility tree. For structural/text understanding, `take_snapshot` is cheaper and more actionable. Pass a `uid` from `take_snapshot` to capture just that element (scrolled into view, with `padding` pixels around it; works for elements taller than the viewport and inside iframes). Set `annotate=true` for a set-of-marks screenshot: every interactive element in the viewport is boxed and labelled with its snapshot uid, and a legend lists them — use those numbers with `click`/`fill` or `click_at`.",
 		Annotations: &mcp.ToolAnnotations{Title: "Take a screenshot", DestructiveHint: &falseBool, ReadOnlyHint: true},
-		Meta:        standardPermissionsMeta,
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"uid":      map[string]any{"type": "string", "description": "Element id from the page snapshot to capture instead of the viewport (optional)"},
+				"padding":  map[string]any{"type": "number", "description": "Margin around the element in CSS pixels (default 8, only with uid)"},
+				"annotate": map[string]any{"type": "boolean", "description": "Draw numbered boxes (snapshot uids) over interactive elements and return a legend (optional)"},
+			},
+		},
+		Meta: standardPermissionsMeta,
 	}, func(ctx context.Context, req *mcp.CallToolRequest, _ DummyInput) (*mcp.CallToolResult, any, error) {
-		session, err := browser.FindSession("")
+		session, err := provider.findBrowserSession(ctx, "")
 		if err != nil {
 			return ToolErrf("take_screenshot: no active browser session"), nil, nil
 		}
-		result, err := session.SendCDP("Page.captureScreenshot", map[string]any{"format": "png"})
+		var args struct {
+			UID      string   `json:"uid"`
+			Padding  *float64 `json:"padding"`
+			Annotate bool     `json:"annotate"`
+		}
+		json.Unmarshal(req.Params.Arguments, &args)
+		if args.UID != "" {
+			padding := float64(browser.DefaultElementPadding)
+			if args.Padding != nil {
+				padding = *args.Padding
+			}
+			img, box, err := session.ElementScreenshot(ctx, args.UID, padding)
+			if err != nil {
+				return ToolErrf("take_screenshot: %v", err), nil, nil
+			}
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: fmt.Sprintf("Screenshot of element %s captured (PNG, %d bytes; box %.0fx%.0f at %.0f,%.0f).", args.UID, len(img), box.Width, box.Height, box.X, box.Y)},
+					&mcp.ImageContent{Data: img, MIMEType: "image/png"},
+				},
+			}, nil, nil
+		}
+		if args.Annotate {
+			return annotatedScreenshot(ctx, session, "take_screenshot"), nil, nil
+		}
+		result, err := session.SendCDPCtx(ctx, "Page.captureScreenshot", map[string]any{"format": "png"})
 		if err != nil {
 			return ToolErrf("take_screenshot: %v", err), nil, nil
 		}
@@ -266,11 +343,11 @@ func browserInteractionTools(provider *ScrapflyToolProvider) tools.HandledToolSe
 		Annotations: &mcp.ToolAnnotations{Title: "Get page content snapshot", DestructiveHint: &falseBool, ReadOnlyHint: true},
 		Meta:        standardPermissionsMeta,
 	}, func(ctx context.Context, req *mcp.CallToolRequest, _ DummyInput) (*mcp.CallToolResult, any, error) {
-		session, err := browser.FindSession("")
+		session, err := provider.findBrowserSession(ctx, "")
 		if err != nil {
 			return ToolErrf("take_snapshot: no active browser session"), nil, nil
 		}
-		session.Page.Refresh(session)
+		session.Page.Refresh(ctx, session)
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{&mcp.TextContent{Text: session.Page.Snapshot()}},
 		}, nil, nil
@@ -290,7 +367,7 @@ func browserInteractionTools(provider *ScrapflyToolProvider) tools.HandledToolSe
 		},
 		Meta: standardPermissionsMeta,
 	}, func(ctx context.Context, req *mcp.CallToolRequest, _ DummyInput) (*mcp.CallToolResult, any, error) {
-		session, err := browser.FindSession("")
+		session, err := provider.findBrowserSession(ctx, "")
 		if err != nil {
 			return ToolErrf("evaluate_script: no active browser session"), nil, nil
 		}
@@ -301,7 +378,7 @@ func browserInteractionTools(provider *ScrapflyToolProvider) tools.HandledToolSe
 		if args.Expression == "" {
 			return ToolErrf("evaluate_script: expression is required"), nil, nil
 		}
-		result, err := session.SendCDP("Runtime.evaluate", map[string]any{
+		result, err := session.SendCDPCtx(ctx, "Runtime.evaluate", map[string]any{
 			"expression":    args.Expression,
 			"returnByValue": true,
 		})
@@ -339,11 +416,11 @@ func browserInteractionTools(provider *ScrapflyToolProvider) tools.HandledToolSe
 	// — server.AddTool replaces existing tools with the same name, no
 	// duplicate-registration risk.
 
-	addWebMCPMetaTools(ts, logger)
+	addWebMCPMetaTools(ts, provider)
 
 	// ── browser-use parity tools ───────────────────────────────────────────
 	// Convenience tools that close the gap with browser-use's action set.
-	// All gated by browser.FindSession("") so they error cleanly when no
+	// All gated by findBrowserSession so they error cleanly when no
 	// session is open — same contract as the rest of this file.
 
 	tools.MustAddToolToToolset(ts, &mcp.Tool{
@@ -360,7 +437,7 @@ func browserInteractionTools(provider *ScrapflyToolProvider) tools.HandledToolSe
 		},
 		Meta: standardPermissionsMeta,
 	}, func(ctx context.Context, req *mcp.CallToolRequest, _ DummyInput) (*mcp.CallToolResult, any, error) {
-		session, err := browser.FindSession("")
+		session, err := provider.findBrowserSession(ctx, "")
 		if err != nil {
 			return ToolErrf("scroll_to_text: no active browser session"), nil, nil
 		}
@@ -387,7 +464,7 @@ func browserInteractionTools(provider *ScrapflyToolProvider) tools.HandledToolSe
   }
   return JSON.stringify({error: 'not found'});
 })()`, args.Text)
-		evalResult, err := session.SendCDP("Runtime.evaluate", map[string]any{
+		evalResult, err := session.SendCDPCtx(ctx, "Runtime.evaluate", map[string]any{
 			"expression": js, "returnByValue": true,
 		})
 		if err != nil {
@@ -489,7 +566,7 @@ func browserInteractionTools(provider *ScrapflyToolProvider) tools.HandledToolSe
 		},
 		Meta: standardPermissionsMeta,
 	}, func(ctx context.Context, req *mcp.CallToolRequest, _ DummyInput) (*mcp.CallToolResult, any, error) {
-		session, err := browser.FindSession("")
+		session, err := provider.findBrowserSession(ctx, "")
 		if err != nil {
 			return ToolErrf("find_elements: no active browser session"), nil, nil
 		}
@@ -519,7 +596,7 @@ func browserInteractionTools(provider *ScrapflyToolProvider) tools.HandledToolSe
     return {tag: e.tagName.toLowerCase(), text, attrs};
   }));
 })()`, args.Selector, args.MaxResults)
-		evalResult, err := session.SendCDP("Runtime.evaluate", map[string]any{
+		evalResult, err := session.SendCDPCtx(ctx, "Runtime.evaluate", map[string]any{
 			"expression": js, "returnByValue": true,
 		})
 		if err != nil {
@@ -545,12 +622,12 @@ func browserInteractionTools(provider *ScrapflyToolProvider) tools.HandledToolSe
 		},
 		Meta: standardPermissionsMeta,
 	}, func(ctx context.Context, req *mcp.CallToolRequest, _ DummyInput) (*mcp.CallToolResult, any, error) {
-		session, err := browser.FindSession("")
+		session, err := provider.findBrowserSession(ctx, "")
 		if err != nil {
 			return ToolErrf("go_back: no active browser session"), nil, nil
 		}
 		// CDP recipe: read history, navigate to entry currentIndex-1.
-		histResult, err := session.SendCDP("Page.getNavigationHistory", nil)
+		histResult, err := session.SendCDPCtx(ctx, "Page.getNavigationHistory", nil)
 		if err != nil {
 			return ToolErrFromError("go_back", err), nil, nil
 		}
@@ -568,14 +645,14 @@ func browserInteractionTools(provider *ScrapflyToolProvider) tools.HandledToolSe
 			return ToolErrf("go_back: no previous entry in history"), nil, nil
 		}
 		prev := hist.Entries[hist.CurrentIndex-1]
-		_, err = session.SendCDP("Page.navigateToHistoryEntry", map[string]any{
+		_, err = session.SendCDPCtx(ctx, "Page.navigateToHistoryEntry", map[string]any{
 			"entryId": prev.ID,
 		})
 		if err != nil {
 			return ToolErrFromError("go_back", err), nil, nil
 		}
 		// Refresh page state so the next snapshot is current.
-		session.Page.Refresh(session)
+		session.Page.Refresh(ctx, session)
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("Navigated back to %s\n\n%s", prev.URL, session.Page.Snapshot())}},
 		}, nil, nil
@@ -593,7 +670,8 @@ func browserInteractionTools(provider *ScrapflyToolProvider) tools.HandledToolSe
 // `server.AddTool` replaces by name, so double-registration is safe.
 //
 // Both handlers no-op gracefully when no browser session is open.
-func addWebMCPMetaTools(ts tools.HandledToolSet, logger *log.Logger) {
+func addWebMCPMetaTools(ts tools.HandledToolSet, provider *ScrapflyToolProvider) {
+	logger := provider.logger
 	tools.MustAddToolToToolset(ts, &mcp.Tool{
 		Name:        "list_webmcp_tools",
 		Title:       "List page-registered MCP tools",
@@ -601,7 +679,7 @@ func addWebMCPMetaTools(ts tools.HandledToolSet, logger *log.Logger) {
 		Annotations: &mcp.ToolAnnotations{Title: "List page-registered MCP tools", DestructiveHint: &falseBool, ReadOnlyHint: true},
 		Meta:        standardPermissionsMeta,
 	}, func(ctx context.Context, req *mcp.CallToolRequest, _ DummyInput) (*mcp.CallToolResult, any, error) {
-		session, err := browser.FindSession("")
+		session, err := provider.findBrowserSession(ctx, "")
 		if err != nil {
 			return ToolErrf("list_webmcp_tools: no active browser session"), nil, nil
 		}
@@ -625,25 +703,27 @@ func addWebMCPMetaTools(ts tools.HandledToolSet, logger *log.Logger) {
 	tools.MustAddToolToToolset(ts, &mcp.Tool{
 		Name:        "call_webmcp_tool",
 		Title:       "Execute a page-registered MCP tool",
-		Description: "Invoke a WebMCP tool that the current page registered with `navigator.modelContext.registerTool()`. Preferred over clicking/scraping when the page exposes a matching tool — it's the author's declared API for that action and avoids DOM fragility. Input must satisfy the schema returned by `list_webmcp_tools` (also on the open/navigate response). Tool runs in the page's main world.",
+		Description: "Invoke a WebMCP tool that the current page registered with `navigator.modelContext.registerTool()`. Preferred over clicking/scraping when the page exposes a matching tool — it's the author's declared API for that action and avoids DOM fragility. Input must satisfy the schema returned by `list_webmcp_tools` (also on the open/navigate response) — it is validated before the call and schema violations are reported precisely. Tool runs in the page's main world. The call is canceled in the page if it outlives `timeout_ms` (default 30000 unless the server configures the tool otherwise, max 300000) or the MCP request is canceled.",
 		Annotations: &mcp.ToolAnnotations{Title: "Execute a page-registered MCP tool", DestructiveHint: &falseBool},
 		InputSchema: map[string]any{
 			"type": "object",
 			"properties": map[string]any{
-				"tool_name": map[string]any{"type": "string", "description": "Name of the WebMCP tool to call (from list_webmcp_tools)"},
-				"input":     map[string]any{"type": "string", "description": "JSON-stringified parameters to pass to the tool. Omit for tools with no parameters."},
+				"tool_name":  map[string]any{"type": "string", "description": "Name of the WebMCP tool to call (from list_webmcp_tools)"},
+				"input":      map[string]any{"type": "string", "description": "JSON-stringified parameters to pass to the tool. Omit for tools with no parameters."},
+				"timeout_ms": map[string]any{"type": "integer", "description": "Max time to wait for the tool's response before canceling it (default 30000 unless the server configures the tool otherwise, max 300000)."},
 			},
 			"required": []string{"tool_name"},
 		},
 		Meta: standardPermissionsMeta,
 	}, func(ctx context.Context, req *mcp.CallToolRequest, _ DummyInput) (*mcp.CallToolResult, any, error) {
-		session, err := browser.FindSession("")
+		session, err := provider.findBrowserSession(ctx, "")
 		if err != nil {
 			return ToolErrf("call_webmcp_tool: no active browser session"), nil, nil
 		}
 		var args struct {
-			ToolName string `json:"tool_name"`
-			Input    string `json:"input"`
+			ToolName  string `json:"tool_name"`
+			Input     string `json:"input"`
+			TimeoutMs int    `json:"timeout_ms"`
 		}
 		json.Unmarshal(req.Params.Arguments, &args)
 		if args.ToolName == "" {
@@ -657,19 +737,43 @@ func addWebMCPMetaTools(ts tools.HandledToolSet, logger *log.Logger) {
 			inputArgs = json.RawMessage(`{}`)
 		}
 
+		timeout := time.Duration(args.TimeoutMs) * time.Millisecond
+		if timeout <= 0 {
+			timeout = provider.webMCPToolTimeout(args.ToolName)
+		}
+
 		// Use the proper CDP WebMCP.invokeTool flow
-		r, err := browser.InvokeTool(logger, session, args.ToolName, inputArgs)
+		r, err := browser.InvokeTool(ctx, logger, session, args.ToolName, inputArgs, timeout)
 		return r, nil, err
 	})
 }
 
-// callActiveAntibot finds the active session and calls an Antibot CDP tool.
-func callActiveAntibot(logger browser.Logger, toolName string, arguments json.RawMessage) (*mcp.CallToolResult, error) {
-	session, err := browser.FindSession("")
+// callActiveAntibot finds the caller's active session and calls an Antibot
+// CDP tool.
+func (p *ScrapflyToolProvider) callActiveAntibot(ctx context.Context, logger browser.Logger, toolName string, arguments json.RawMessage) (*mcp.CallToolResult, error) {
+	session, err := p.findBrowserSession(ctx, "")
 	if err != nil {
 		return ToolErrf("%s: no active browser session. Call cloud_browser_open first.", toolName), nil
 	}
-	return browser.CallTool(logger, session, toolName, arguments)
+	return browser.CallTool(ctx, logger, session, toolName, arguments)
+}
+
+// annotatedScreenshot refreshes the snapshot and returns a set-of-marks
+// screenshot with its legend.
+func annotatedScreenshot(ctx context.Context, session *browser.Session, toolName string) *mcp.CallToolResult {
+	session.Page.Refresh(ctx, session)
+	img, marks, err := session.AnnotatedScreenshot(ctx)
+	if err != nil {
+		return ToolErrf("%s: %v", toolName, err)
+	}
+	text := fmt.Sprintf("Annotated screenshot (PNG, %d bytes) with %d boxes, labelled with snapshot uids. Act on them with click/fill (uid) or click_at (box):\n\n%s",
+		len(img), len(marks), browser.MarksLegend(marks))
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: text},
+			&mcp.ImageContent{Data: img, MIMEType: "image/png"},
+		},
+	}
 }
 
 // wrapUidToSelector converts a simple {"uid": "183"} to {"selector": {"type": "axNodeId", "query": "183"}}
@@ -0,0 +1,68 @@
+package scrapflyprovider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser"
+)
+
+// wait_for bounds: the default wait and the longest one a call may ask for
+// (the MCP request's own deadline still applies).
+const (
+	defaultWaitFor = 10 * time.Second
+	maxWaitFor     = 60 * time.Second
+)
+
+type WaitForCondition struct {
+	Type  string `json:"type" jsonschema:"One of: text_appears, text_disappears, visible, hidden, url_matches, network_idle, title_changes, js."`
+	Value string `json:"value,omitempty" jsonschema:"text_appears/text_disappears: text (case-insensitive). visible/hidden: a uid from take_snapshot or a CSS selector. url_matches: a regular expression. network_idle: idle time in ms (default 500). js: an expression that becomes truthy. Unused for title_changes."`
+}
+
+type WaitForInput struct {
+	SessionID      string             `json:"session_id,omitempty" jsonschema:"Browser session ID. If omitted, uses the most recent session."`
+	Conditions     []WaitForCondition `json:"conditions" jsonschema:"Conditions to wait for."`
+	Match          string             `json:"match,omitempty" jsonschema:"'any' (default): return as soon as one condition holds. 'all': wait until every condition holds."`
+	TimeoutSeconds float64            `json:"timeout_seconds,omitempty" jsonschema:"Give up after this many seconds (default 10, max 60)."`
+	Snapshot       bool               `json:"snapshot,omitempty" jsonschema:"Also return a fresh page snapshot once the wait is over."`
+}
+
+func (p *ScrapflyToolProvider) WaitFor(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input WaitForInput,
+) (*mcp.CallToolResult, any, error) {
+	session, err := p.findBrowserSession(ctx, input.SessionID)
+	if err != nil {
+		return ToolErrf("wait_for: %v", err), nil, nil
+	}
+	if input.Match != "" && input.Match != "any" && input.Match != "all" {
+		return ToolErrf("wait_for: match must be 'any' or 'all', got %q", input.Match), nil, nil
+	}
+	timeout := defaultWaitFor
+	if input.TimeoutSeconds > 0 {
+		timeout = min(time.Duration(input.TimeoutSeconds*float64(time.Second)), maxWaitFor)
+	}
+	conds := make([]browser.WaitCondition, len(input.Conditions))
+	for i, c := range input.Conditions {
+		conds[i] = browser.WaitCondition{Type: c.Type, Value: c.Value}
+	}
+	res, err := session.WaitFor(ctx, conds, input.Match == "all", timeout)
+	if err != nil {
+		if errors.Is(err, browser.ErrWaitTimeout) {
+			return ToolErr("WAIT_TIMEOUT", "wait_for: "+err.Error(),
+				"Check the condition against a fresh snapshot, or wait longer.", 0, ""), nil, nil
+		}
+		return ToolErrf("wait_for: %v", err), nil, nil
+	}
+	b, _ := json.MarshalIndent(res, "", "  ")
+	content := []mcp.Content{&mcp.TextContent{Text: string(b)}}
+	if input.Snapshot {
+		session.Page.Refresh(ctx, session)
+		content = append(content, &mcp.TextContent{Text: session.Page.Snapshot()})
+	}
+	return &mcp.CallToolResult{Content: content}, nil, nil
+}
@@ -0,0 +1,24 @@
+package scrapflyprovider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestWaitForRejectsUnknownMatch(t *testing.T) {
+	p := ownedSessions(t, "key-mine")
+	res, _, err := p.WaitFor(context.Background(), nil, WaitForInput{
+		SessionID:  "key-mine",
+		Conditions: []WaitForCondition{{Type: "text_appears", Value: "done"}},
+		Match:      "every",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.IsError || !strings.Contains(res.Content[0].(*mcp.TextContent).Text, "match must be") {
+		t.Errorf("wait_for with match=every = %+v, want a match error", res.Content)
+	}
+}
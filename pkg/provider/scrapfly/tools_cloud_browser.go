@@ -2,19 +2,23 @@ package scrapflyprovider
 
 import (
 	"context"
-	"crypto/tls"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"time"
 
-	"github.com/gorilla/websocket"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	scrapfly "github.com/scrapfly/go-scrapfly"
 	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser"
 )
 
+//go:generate go run ../../../internal/cdpgen -mode schemas -protocol ../../../browser_protocol.json -out antibot_schemas_gen.go
+
 // antibotToolOverride holds a schema override and optional description for antibot tools
-// whose schemas Chrome returns as empty.
+// whose schemas Chrome returns as empty. The antibotToolSchemas table is
+// generated from the same protocol source as the typed browser bindings.
 type antibotToolOverride struct {
 	schema      any
 	description string
@@ -41,6 +45,7 @@ type CloudBrowserScreenshotInput struct {
 	SessionID string `json:"session_id,omitempty" jsonschema:"Browser session ID. If omitted, uses the most recent session."`
 	FullPage  bool   `json:"full_page,omitempty" jsonschema:"Capture the full scrollable page, not just the viewport. Default: false."`
 	Selector  string `json:"selector,omitempty" jsonschema:"CSS selector of an element to screenshot. If provided, only that element is captured."`
+	Annotate  bool   `json:"annotate,omitempty" jsonschema:"Set-of-marks: box and label every interactive element in the viewport with its snapshot uid and return a legend. Ignores full_page and selector."`
 }
 
 type CloudBrowserEvalInput struct {
@@ -53,20 +58,32 @@ type CloudBrowserSnapshotInput struct {
 }
 
 type CloudBrowserPerformanceInput struct {
-	SessionID string `json:"session_id,omitempty" jsonschema:"Browser session ID. If omitted, uses the most recent session."`
-	Preset    string `json:"preset,omitempty" jsonschema:"Throttling preset: 'mobile' (default, Moto G4 + slow 4G + 4x CPU) or 'desktop' (1350x940 wired, no CPU throttle). Matches PSI mobile/desktop views."`
-	TimeoutMs int    `json:"timeout_ms,omitempty" jsonschema:"Total budget for the lab run in ms (default 30000, max 45000)."`
+	SessionID           string `json:"session_id,omitempty" jsonschema:"Browser session ID. If omitted, uses the most recent session."`
+	Preset              string `json:"preset,omitempty" jsonschema:"Throttling preset: 'mobile' (default, Moto G4 + slow 4G + 4x CPU) or 'desktop' (1350x940 wired, no CPU throttle). Matches PSI mobile/desktop views."`
+	TimeoutMs           int    `json:"timeout_ms,omitempty" jsonschema:"Total budget for the lab run in ms (default 30000, max 45000)."`
+	Visuals             bool   `json:"visuals,omitempty" jsonschema:"Also return images: a filmstrip of what the user saw during load (one frame per interval, timestamped) and a screenshot with the LCP element outlined. Each PNG is capped at 1 MB."`
+	FilmstripIntervalMs int    `json:"filmstrip_interval_ms,omitempty" jsonschema:"Filmstrip frame spacing in ms (default 1000). Widened automatically to keep at most 12 frames."`
 }
 
 type CloudBrowserCloseInput struct {
 	SessionID string `json:"session_id" jsonschema:"Cloud Browser session ID to terminate."`
 }
 
+type CloudBrowserExtendInput struct {
+	SessionID string `json:"session_id,omitempty" jsonschema:"Browser session ID. If omitted, uses the most recent session."`
+	Seconds   int    `json:"seconds,omitempty" jsonschema:"Seconds to add to the session's expiry (default 300). Capped at the end of the remote browser requested at open: 300 seconds past the session's opening timeout, and never more than 1800 seconds after it was opened."`
+}
+
 type CloudBrowserDownloadsInput struct {
 	SessionID string `json:"session_id,omitempty" jsonschema:"Browser session ID. If omitted, uses the most recent session."`
 	Filename  string `json:"filename,omitempty" jsonschema:"Retrieve a specific file by name. If omitted, lists all downloads."`
+	Save      bool   `json:"save,omitempty" jsonschema:"Save the file to the server's download directory and return its path and SHA-256 instead of the content."`
 }
 
+// InlineDownloadLimit is the largest download cloud_browser_downloads embeds
+// in its result; larger files are returned as resource links.
+var InlineDownloadLimit int64 = 1 << 20
+
 type CloudBrowserNavigateInput struct {
 	SessionID string `json:"session_id" jsonschema:"Active Cloud Browser session ID."`
 	URL       string `json:"url" jsonschema:"URL to navigate to."`
@@ -85,6 +102,9 @@ func (p *ScrapflyToolProvider) CloudBrowserOpen(
 	req *mcp.CallToolRequest,
 	input CloudBrowserOpenInput,
 ) (*mcp.CallToolResult, any, error) {
+	if p.cdpEndpoint != "" {
+		return p.openCDPBrowser(ctx, req, input)
+	}
 	client, err := p.ClientGetter(p, ctx)
 	if err != nil {
 		return ToolErrFromError("cloud_browser_open", err), nil, nil
@@ -92,21 +112,11 @@ func (p *ScrapflyToolProvider) CloudBrowserOpen(
 
 	p.logger.Printf("Opening cloud browser for %s (enable_mcp=true)", input.URL)
 
-	// Close any existing sessions + release from pool before allocating a new one
-	browser.Store.Range(func(key, value any) bool {
-		s := value.(*browser.Session)
-		sid := key.(string)
-		s.Close()
-		if stopErr := client.CloudBrowserSessionStop(sid); stopErr != nil {
-			p.logger.Printf("cloud_browser_open: releasing old session %s failed (non-fatal): %v", sid, stopErr)
-		}
-		return true
-	})
+	// Close the caller's existing sessions + release them from the pool
+	// before allocating a new one
+	p.releaseOwnerBrowsers(browser.OwnerKey(client.APIKey()), "replaced by a new cloud_browser_open")
 
-	timeout := input.Timeout
-	if timeout == 0 {
-		timeout = 900
-	}
+	timeout := clampBrowserTimeout(input.Timeout)
 
 	// Expand optimize_bandwidth shortcut
 	if input.OptimizeBandwidth {
@@ -119,7 +129,13 @@ func (p *ScrapflyToolProvider) CloudBrowserOpen(
 	}
 
 	// Connect to browser via WebSocket CDP, navigate, discover WebMCP tools.
+	// The session is named up front so a dropped socket can resume the
+	// same browser. The requested timeout is enforced by the lifecycle
+	// manager; the remote browser gets browserRemoteMargin more so
+	// cloud_browser_extend can push it out.
+	sessionName := newBrowserSessionName()
 	browserConfig := &scrapfly.CloudBrowserConfig{
+		Session:     sessionName,
 		ProxyPool:   input.ProxyPool,
 		Country:     input.Country,
 		BlockImages: input.BlockImages,
@@ -129,113 +145,30 @@ func (p *ScrapflyToolProvider) CloudBrowserOpen(
 		Blacklist:   input.Blacklist,
 		Cache:       input.Cache,
 		Debug:       input.Debug,
-		Timeout:     timeout,
+		Timeout:     remoteBrowserTimeout(timeout),
 		EnableMCP:   true,
 	}
 	wsURL := client.CloudBrowser(browserConfig)
 	p.logger.Printf("cloud_browser_open: connecting to %s", wsURL)
 
-	// Connect via WebSocket CDP. The handshake covers the full server-side
-	// allocation chain (traefik → cloud-browser → scrape-engine /browser/allocate
-	// → autoscaler → cold-start of a browser pod). On a warm prod pool that's
-	// sub-second; on a cold dev cluster (single replica, autoscaler reconcile cycle)
-	// it can take 30s+. 60s gives cold paths room without holding clients
-	// hostage if the cluster is genuinely down.
-	dialer := websocket.Dialer{
-		TLSClientConfig:  &tls.Config{InsecureSkipVerify: true},
-		HandshakeTimeout: 60 * time.Second,
-	}
-	conn, _, err := dialer.Dial(wsURL, nil)
-	if err != nil {
-		p.logger.Printf("cloud_browser_open: WebSocket connect failed: %v", err)
-		return ToolErrFromError("cloud_browser_open", fmt.Errorf("browser connection failed: %w", err)), nil, nil
-	}
-
-	p.logger.Printf("cloud_browser_open: connected (local=%s remote=%s)", conn.LocalAddr(), conn.RemoteAddr())
+	// Connect via WebSocket CDP, retrying cold starts. WebMCP +
+	// Accessibility are enabled before navigation so the domain is active
+	// when page JavaScript registers tools via
+	// navigator.modelContext.registerTool().
 	session := &browser.Session{
+		SessionID: sessionName,
 		WSURL:     wsURL,
-		ExpiresAt: time.Now().Add(time.Duration(timeout) * time.Second),
-		CdpConn:   conn,
-	}
-
-	// Start the CDP multiplexer — must be before any SendCDP calls
-	session.StartReader()
-
-	// Get browser targets to find the page
-	targetsResult, err := session.SendCDP("Target.getTargets", nil)
-	if err != nil {
-		conn.Close()
-		return ToolErrFromError("cloud_browser_open", fmt.Errorf("get targets failed: %w", err)), nil, nil
-	}
-	var targets struct {
-		TargetInfos []struct {
-			TargetID string `json:"targetId"`
-			Type     string `json:"type"`
-			URL      string `json:"url"`
-		} `json:"targetInfos"`
-	}
-	json.Unmarshal(targetsResult, &targets)
-	var pageTargetID string
-	for _, t := range targets.TargetInfos {
-		if t.Type == "page" {
-			pageTargetID = t.TargetID
-			break
-		}
+		Owner:     browser.OwnerKey(client.APIKey()),
 	}
-	if pageTargetID == "" {
-		conn.Close()
-		return ToolErrFromError("cloud_browser_open", fmt.Errorf("no page target found")), nil, nil
+	session.SetExpiry(time.Now().Add(time.Duration(timeout) * time.Second))
+	if err := p.connectBrowser(ctx, client, session, wsURL); err != nil {
+		p.logger.Printf("cloud_browser_open: %v", err)
+		return ToolErrFromError("cloud_browser_open", err), nil, nil
 	}
-
-	// Attach to the page target
-	attachResult, err := session.SendCDP("Target.attachToTarget", map[string]any{
-		"targetId": pageTargetID,
-		"flatten":  true,
-	})
-	if err != nil {
-		conn.Close()
-		return ToolErrFromError("cloud_browser_open", fmt.Errorf("attach failed: %w", err)), nil, nil
-	}
-	var attach struct {
-		SessionId string `json:"sessionId"`
-	}
-	json.Unmarshal(attachResult, &attach)
-	session.SessionID = attach.SessionId
-	session.CdpPageSessionID = attach.SessionId
-	p.logger.Printf("cloud_browser_open: attached to page target %s, sessionId=%s", pageTargetID, attach.SessionId)
-
-	// Enable WebMCP + Accessibility before navigation so the domain is active
-	// when page JavaScript registers tools via navigator.modelContext.registerTool().
-	// Register persistent event handlers to store/remove tools on the PageState.
-	session.OnEvent("WebMCP.toolsAdded", func(method string, params json.RawMessage) bool {
-		var event struct {
-			Tools []browser.WebMCPToolInfo `json:"tools"`
-		}
-		if json.Unmarshal(params, &event) == nil {
-			session.Page.AddWebMCPTools(event.Tools)
-			p.logger.Printf("[WebMCP] toolsAdded: %d tools", len(event.Tools))
-		}
-		return true // keep listening
-	})
-	session.OnEvent("WebMCP.toolsRemoved", func(method string, params json.RawMessage) bool {
-		var event struct {
-			Tools []struct{ Name string `json:"name"` } `json:"tools"`
-		}
-		if json.Unmarshal(params, &event) == nil {
-			names := make([]string, len(event.Tools))
-			for i, t := range event.Tools {
-				names[i] = t.Name
-			}
-			session.Page.RemoveWebMCPTools(names)
-			p.logger.Printf("[WebMCP] toolsRemoved: %d tools", len(names))
-		}
-		return true
-	})
-	session.SendCDP("WebMCP.enable", nil)
-	session.SendCDP("Accessibility.enable", nil)
+	p.logger.Printf("cloud_browser_open: session %s attached to page target %s", sessionName, session.PageTargetID)
 
 	// Navigate to the target URL
-	_, err = session.SendCDP("Page.navigate", map[string]any{
+	_, err = session.SendCDPCtx(ctx, "Page.navigate", map[string]any{
 		"url": input.URL,
 	})
 	if err != nil {
@@ -245,32 +178,26 @@ func (p *ScrapflyToolProvider) CloudBrowserOpen(
 	// Wait for page load + JS execution
 	time.Sleep(2 * time.Second)
 
-	// Store session — static tools (click, fill, etc.) use browser.FindSession("") to locate it
-	browser.Store.Store(pageTargetID, session)
-	p.logger.Printf("cloud_browser_open: session %s stored for %s", pageTargetID, input.URL)
-
-	// Auto-cleanup: close the session when the timeout expires.
-	cleanupTimer := time.AfterFunc(time.Until(session.ExpiresAt), func() {
-		if val, ok := browser.Store.Load(pageTargetID); ok {
-			s := val.(*browser.Session)
-			s.Close()
-			p.logger.Printf("Auto-closed expired browser session %s", pageTargetID)
-		}
-	})
-	session.CancelCleanup = func() { cleanupTimer.Stop() }
+	// Store session — static tools (click, fill, etc.) use findBrowserSession to locate it.
+	// The lifecycle manager releases it on expiry / idle.
+	browser.Store.Store(sessionName, session)
+	p.trackBrowser(req, client, session)
+	p.logger.Printf("cloud_browser_open: session %s stored for %s", sessionName, input.URL)
 
 	// Build response
 	response := map[string]any{
-		"session_id": pageTargetID,
+		"session_id": sessionName,
 		"status":     "connected",
 		"url":        input.URL,
 		"mode":       "direct",
+		"expires_at": session.Expiry().Format(time.RFC3339),
+		"resources":  browserResourceURIs(session.SessionID),
 	}
 	response["instructions"] = fmt.Sprintf(
 		"[BROWSER MODE ACTIVE on %s] "+
 			"FIRST: check the page snapshot below — if the page title or content looks like a challenge/captcha/block page (e.g. 'Just a moment', 'Verify you are human', 'Access denied'), close this session with cloud_browser_close and retry with cloud_browser_open(url, unblock=true). "+
 			"Use click/fill/type_text/hover/press_key/scroll for interaction. "+
-			"Use list_webmcp_tools to discover page-specific actions, then call_webmcp_tool to execute them. "+
+			"Page-registered WebMCP tools are exposed directly as webmcp_* tools (also reachable via list_webmcp_tools / call_webmcp_tool). "+
 			"Use take_snapshot for page content, take_screenshot for visual capture. "+
 			"NEVER use standalone screenshot/web_scrape/web_get_page during browser session. "+
 			"KEEP THE SESSION OPEN across follow-up turns — the user may ask more questions about this page. "+
@@ -278,7 +205,7 @@ func (p *ScrapflyToolProvider) CloudBrowserOpen(
 		input.URL)
 
 	// Refresh page state and include snapshot in response
-	session.Page.Refresh(session)
+	session.Page.Refresh(ctx, session)
 
 	// Make the per-session interaction tools (click, fill,
 	// take_snapshot, evaluate_script, ...) visible in tools/list
@@ -298,31 +225,35 @@ func (p *ScrapflyToolProvider) CloudBrowserClose(
 	req *mcp.CallToolRequest,
 	input CloudBrowserCloseInput,
 ) (*mcp.CallToolResult, any, error) {
-	client, err := p.ClientGetter(p, ctx)
-	if err != nil {
-		return ToolErrFromError("cloud_browser_close", err), nil, nil
-	}
-
 	p.logger.Printf("Closing cloud browser session %s", input.SessionID)
 
-	// Close WebSocket and clean up session
-	if val, ok := browser.Store.Load(input.SessionID); ok {
-		session := val.(*browser.Session)
-		session.SendCDP("WebMCP.disable", nil)
-		session.Close() // closes WebSocket, removes from Store, cancels cleanup timer
-		p.logger.Printf("Closed session %s", input.SessionID)
-	}
-
-	// Best-effort: also call the API stop endpoint
-	if err := client.CloudBrowserSessionStop(input.SessionID); err != nil {
-		p.logger.Printf("cloud_browser_close: API stop call failed (non-fatal, WebSocket already closed): %v", err)
+	// Release: unmounts the session's tools (and the interaction tools
+	// once no browser is left, firing notifications/tools/list_changed),
+	// closes the WebSocket and frees the pool slot.
+	session, err := p.findBrowserSession(ctx, input.SessionID)
+	if err == nil {
+		session.WebMCPDisable(ctx)
+		p.releaseBrowser(input.SessionID, "closed")
+	} else if owner, local := p.localBrowserOwner(input.SessionID); local {
+		// Known here: ours but disconnected, or another API key's.
+		if caller, cerr := p.browserOwner(ctx); cerr != nil || (caller != "" && owner != caller) {
+			return ToolErrf("cloud_browser_close: %v", err), nil, nil
+		}
+		p.releaseBrowser(input.SessionID, "closed")
+	} else if p.cdpEndpoint != "" {
+		return ToolErrf("cloud_browser_close: %v", err), nil, nil
+	} else {
+		// Not tracked here (anymore) — best-effort stop with the caller's
+		// key so the slot is freed anyway.
+		client, err := p.ClientGetter(p, ctx)
+		if err != nil {
+			return ToolErrFromError("cloud_browser_close", err), nil, nil
+		}
+		if err := client.CloudBrowserSessionStop(input.SessionID); err != nil {
+			p.logger.Printf("cloud_browser_close: API stop call failed (non-fatal): %v", err)
+		}
 	}
 
-	// Hide the per-session interaction tools from tools/list now
-	// that no browser is open. Fires notifications/tools/list_changed
-	// so connected MCP clients refetch and stop offering them.
-	p.unmountInteractionTools()
-
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("Session %s closed successfully.", input.SessionID)}},
 	}, nil, nil
@@ -333,15 +264,24 @@ func (p *ScrapflyToolProvider) CloudBrowserSessions(
 	req *mcp.CallToolRequest,
 	input struct{},
 ) (*mcp.CallToolResult, any, error) {
+	owner, err := p.browserOwner(ctx)
+	if err != nil {
+		return ToolErrFromError("cloud_browser_sessions", err), nil, nil
+	}
 	var sessions []map[string]any
 	browser.Store.Range(func(key, value any) bool {
 		s := value.(*browser.Session)
+		if owner != "" && s.Owner != owner {
+			return true
+		}
 		sessions = append(sessions, map[string]any{
 			"session_id": key,
 			"ws_url":     s.WSURL,
 			"page_url":   s.Page.URL,
-			"expires_at": s.ExpiresAt.Format(time.RFC3339),
-			"active":     time.Now().Before(s.ExpiresAt),
+			"expires_at": s.Expiry().Format(time.RFC3339),
+			"idle_for":   s.IdleFor().Round(time.Second).String(),
+			"active":     !s.Expired(),
+			"resources":  browserResourceURIs(key.(string)),
 		})
 		return true
 	})
@@ -351,15 +291,49 @@ func (p *ScrapflyToolProvider) CloudBrowserSessions(
 	}, nil, nil
 }
 
+func (p *ScrapflyToolProvider) CloudBrowserExtend(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input CloudBrowserExtendInput,
+) (*mcp.CallToolResult, any, error) {
+	session, err := p.findBrowserSession(ctx, input.SessionID)
+	if err != nil {
+		return ToolErrf("cloud_browser_extend: %v", err), nil, nil
+	}
+	seconds := input.Seconds
+	if seconds <= 0 {
+		seconds = 300
+	}
+	expiresAt, capped, err := p.extendBrowser(session.SessionID, time.Duration(seconds)*time.Second)
+	if err != nil {
+		return ToolErrf("cloud_browser_extend: %v", err), nil, nil
+	}
+	response := map[string]any{
+		"session_id": session.SessionID,
+		"expires_at": expiresAt.Format(time.RFC3339),
+		"remaining":  time.Until(expiresAt).Round(time.Second).String(),
+	}
+	if capped {
+		response["note"] = fmt.Sprintf("Extension capped: the remote browser was opened for at most %d seconds past the requested timeout. Open a new session to continue past expires_at.", browserRemoteMargin)
+	}
+	b, _ := json.MarshalIndent(response, "", "  ")
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: string(b)}},
+	}, nil, nil
+}
+
 func (p *ScrapflyToolProvider) CloudBrowserScreenshot(
 	ctx context.Context,
 	req *mcp.CallToolRequest,
 	input CloudBrowserScreenshotInput,
 ) (*mcp.CallToolResult, any, error) {
-	session, err := browser.FindSession(input.SessionID)
+	session, err := p.findBrowserSession(ctx, input.SessionID)
 	if err != nil {
 		return ToolErrf("cloud_browser_screenshot: %v", err), nil, nil
 	}
+	if input.Annotate {
+		return annotatedScreenshot(ctx, session, "cloud_browser_screenshot"), nil, nil
+	}
 
 	// CDP Page.captureScreenshot
 	params := map[string]any{
@@ -372,7 +346,7 @@ func (p *ScrapflyToolProvider) CloudBrowserScreenshot(
 
 	// Element screenshot: get bounding box via JS, then set clip
 	if input.Selector != "" {
-		boxResult, boxErr := session.SendCDP("Runtime.evaluate", map[string]any{
+		boxResult, boxErr := session.SendCDPCtx(ctx, "Runtime.evaluate", map[string]any{
 			"expression": fmt.Sprintf(`JSON.stringify((function() {
 				var el = document.querySelector(%q);
 				if (!el) return null;
@@ -393,7 +367,7 @@ func (p *ScrapflyToolProvider) CloudBrowserScreenshot(
 		}
 	}
 
-	result, err := session.SendCDP("Page.captureScreenshot", params)
+	result, err := session.SendCDPCtx(ctx, "Page.captureScreenshot", params)
 	if err != nil {
 		return ToolErrf("cloud_browser_screenshot: %v", err), nil, nil
 	}
@@ -431,11 +405,11 @@ func (p *ScrapflyToolProvider) CloudBrowserEval(
 	req *mcp.CallToolRequest,
 	input CloudBrowserEvalInput,
 ) (*mcp.CallToolResult, any, error) {
-	session, err := browser.FindSession(input.SessionID)
+	session, err := p.findBrowserSession(ctx, input.SessionID)
 	if err != nil {
 		return ToolErrf("cloud_browser_eval: %v", err), nil, nil
 	}
-	result, err := session.SendCDP("Runtime.evaluate", map[string]any{
+	result, err := session.SendCDPCtx(ctx, "Runtime.evaluate", map[string]any{
 		"expression":    input.Expression,
 		"returnByValue": true,
 	})
@@ -466,11 +440,11 @@ func (p *ScrapflyToolProvider) CloudBrowserSnapshot(
 	req *mcp.CallToolRequest,
 	input CloudBrowserSnapshotInput,
 ) (*mcp.CallToolResult, any, error) {
-	session, err := browser.FindSession(input.SessionID)
+	session, err := p.findBrowserSession(ctx, input.SessionID)
 	if err != nil {
 		return ToolErrf("cloud_browser_snapshot: %v", err), nil, nil
 	}
-	session.Page.Refresh(session)
+	session.Page.Refresh(ctx, session)
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{&mcp.TextContent{Text: session.Page.Snapshot()}},
 	}, nil, nil
@@ -481,21 +455,31 @@ func (p *ScrapflyToolProvider) CloudBrowserPerformance(
 	req *mcp.CallToolRequest,
 	input CloudBrowserPerformanceInput,
 ) (*mcp.CallToolResult, any, error) {
-	session, err := browser.FindSession(input.SessionID)
+	session, err := p.findBrowserSession(ctx, input.SessionID)
 	if err != nil {
 		return ToolErrf("cloud_browser_performance: %v", err), nil, nil
 	}
-	report, err := browser.CollectPSI(session, browser.PSIOptions{
-		Preset:    browser.Preset(input.Preset),
-		TimeoutMs: input.TimeoutMs,
+	report, err := browser.CollectPSI(ctx, session, browser.PSIOptions{
+		Preset:              browser.Preset(input.Preset),
+		TimeoutMs:           input.TimeoutMs,
+		Visuals:             input.Visuals,
+		FilmstripIntervalMs: input.FilmstripIntervalMs,
 	})
 	if err != nil {
 		return ToolErrf("cloud_browser_performance: %v", err), nil, nil
 	}
 	p.logger.Printf("[PSI] %s — %s", session.SessionID, browser.SummarizeReport(report))
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{&mcp.TextContent{Text: browser.FormatReport(report)}},
-	}, nil, nil
+	// The JSON report doubles as the text sidecar for the images.
+	content := []mcp.Content{&mcp.TextContent{Text: browser.FormatReport(report)}}
+	if v := report.Visuals; v != nil {
+		if len(v.FilmstripPNG) > 0 {
+			content = append(content, &mcp.ImageContent{Data: v.FilmstripPNG, MIMEType: "image/png"})
+		}
+		if len(v.LCPScreenshotPNG) > 0 {
+			content = append(content, &mcp.ImageContent{Data: v.LCPScreenshotPNG, MIMEType: "image/png"})
+		}
+	}
+	return &mcp.CallToolResult{Content: content}, nil, nil
 }
 
 func (p *ScrapflyToolProvider) CloudBrowserDownloads(
@@ -503,31 +487,17 @@ func (p *ScrapflyToolProvider) CloudBrowserDownloads(
 	req *mcp.CallToolRequest,
 	input CloudBrowserDownloadsInput,
 ) (*mcp.CallToolResult, any, error) {
-	session, err := browser.FindSession(input.SessionID)
+	session, err := p.findBrowserSession(ctx, input.SessionID)
 	if err != nil {
 		return ToolErrf("cloud_browser_downloads: %v", err), nil, nil
 	}
 
 	if input.Filename != "" {
-		// Retrieve a specific file
-		data, err := session.GetDownload(input.Filename)
-		if err != nil {
-			return ToolErrf("cloud_browser_downloads: %v", err), nil, nil
-		}
-		// Return as a resource/blob for the client to download
-		result := map[string]any{
-			"filename": input.Filename,
-			"data":     data,
-			"encoding": "base64",
-		}
-		b, _ := json.MarshalIndent(result, "", "  ")
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{&mcp.TextContent{Text: string(b)}},
-		}, nil, nil
+		return p.cloudBrowserDownload(ctx, session, input)
 	}
 
 	// List all downloads
-	downloads, err := session.ListDownloads()
+	downloads, err := session.ListDownloads(ctx)
 	if err != nil {
 		return ToolErrf("cloud_browser_downloads: %v", err), nil, nil
 	}
@@ -544,27 +514,77 @@ func (p *ScrapflyToolProvider) CloudBrowserDownloads(
 	}, nil, nil
 }
 
+// cloudBrowserDownload returns one downloaded file: embedded as a blob up to
+// InlineDownloadLimit, as a link to its scrapfly://browser/ resource above
+// it, or saved to the download directory when input.Save is set.
+func (p *ScrapflyToolProvider) cloudBrowserDownload(ctx context.Context, session *browser.Session, input CloudBrowserDownloadsInput) (*mcp.CallToolResult, any, error) {
+	uri := browserResourceURIs(session.SessionID)["downloads"] + "/" + url.PathEscape(input.Filename)
+
+	if !input.Save {
+		downloads, err := session.ListDownloads(ctx)
+		if err != nil {
+			return ToolErrf("cloud_browser_downloads: %v", err), nil, nil
+		}
+		for _, d := range downloads {
+			if d.Filename == input.Filename && d.Size > InlineDownloadLimit {
+				size := d.Size
+				return &mcp.CallToolResult{
+					Content: []mcp.Content{&mcp.ResourceLink{
+						URI:         uri,
+						Name:        d.Filename,
+						Description: fmt.Sprintf("%d bytes; too large to inline, read the resource or call again with save=true", d.Size),
+						MIMEType:    browser.DetectMIMEType(d.Filename, nil),
+						Size:        &size,
+					}},
+				}, nil, nil
+			}
+		}
+	}
+
+	d, err := session.FetchDownload(ctx, input.Filename)
+	if err != nil {
+		return ToolErrf("cloud_browser_downloads: %v", err), nil, nil
+	}
+	if input.Save {
+		path, err := session.SaveDownload(d)
+		if err != nil {
+			return ToolErrf("cloud_browser_downloads: %v", err), nil, nil
+		}
+		p.logger.Printf("Saved download %s (%d bytes) to %s", d.Filename, d.Size, path)
+		b, _ := json.MarshalIndent(map[string]any{
+			"filename":  d.Filename,
+			"path":      path,
+			"size":      d.Size,
+			"mime_type": d.MIMEType,
+			"sha256":    d.SHA256,
+		}, "", "  ")
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: string(b)}},
+		}, nil, nil
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.EmbeddedResource{
+			Resource: &mcp.ResourceContents{URI: uri, MIMEType: d.MIMEType, Blob: d.Data},
+		}},
+	}, nil, nil
+}
+
 func (p *ScrapflyToolProvider) CloudBrowserNavigate(
 	ctx context.Context,
 	req *mcp.CallToolRequest,
 	input CloudBrowserNavigateInput,
 ) (*mcp.CallToolResult, any, error) {
-	_, err := p.ClientGetter(p, ctx)
+	session, err := p.findBrowserSession(ctx, input.SessionID)
 	if err != nil {
-		return ToolErrFromError("cloud_browser_navigate", err), nil, nil
-	}
-
-	session, err2 := browser.FindSession(input.SessionID)
-	if err2 != nil {
-		return ToolErrf("cloud_browser_navigate: %v", err2), nil, nil
+		return ToolErrf("cloud_browser_navigate: %v", err), nil, nil
 	}
 
 	p.logger.Printf("Navigating session %s to %s", input.SessionID, input.URL)
 
 	// Navigate via CDP
-	_, err2 = session.SendCDP("Page.navigate", map[string]any{"url": input.URL})
-	if err2 != nil {
-		return ToolErrf("cloud_browser_navigate: navigation failed: %v", err2), nil, nil
+	_, err = session.SendCDPCtx(ctx, "Page.navigate", map[string]any{"url": input.URL})
+	if err != nil {
+		return ToolErrf("cloud_browser_navigate: navigation failed: %v", err), nil, nil
 	}
 
 	// Wait for page load + JS execution
@@ -573,17 +593,18 @@ func (p *ScrapflyToolProvider) CloudBrowserNavigate(
 	// Clear old page tools + re-enable WebMCP on the new page
 	// (toolsAdded event handler from cloud_browser_open will repopulate)
 	session.Page.ClearWebMCPTools()
-	session.SendCDP("WebMCP.disable", nil)
-	session.SendCDP("WebMCP.enable", nil)
+	p.syncWebMCPTools(session)
+	session.WebMCPDisable(ctx)
+	session.WebMCPEnable(ctx)
 
 	// Refresh page state and include snapshot
-	session.Page.Refresh(session)
+	session.Page.Refresh(ctx, session)
 	navigateResult := map[string]any{
 		"session_id": input.SessionID,
 		"url":        input.URL,
 		"status":     "navigated",
 	}
-	navigateResult["instructions"] = "Use list_webmcp_tools to discover page-specific actions on the new page."
+	navigateResult["instructions"] = "The previous page's webmcp_* tools were removed; tools the new page registers appear as webmcp_* tools (or via list_webmcp_tools)."
 	b, _ := json.MarshalIndent(navigateResult, "", "  ")
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{&mcp.TextContent{Text: string(b) + "\n\n" + session.Page.Snapshot()}},
@@ -595,35 +616,24 @@ func (p *ScrapflyToolProvider) BrowserUnblock(
 	req *mcp.CallToolRequest,
 	input BrowserUnblockInput,
 ) (*mcp.CallToolResult, any, error) {
+	if p.cdpEndpoint != "" {
+		return ToolErr("UNSUPPORTED",
+			"browser_unblock needs the Scrapfly Cloud Browser; this server is configured with a CDP endpoint (-cdp-url)",
+			"Use cloud_browser_open to open the page in the configured browser.", 0, ""), nil, nil
+	}
 	client, err := p.ClientGetter(p, ctx)
 	if err != nil {
 		return ToolErrFromError("browser_unblock", err), nil, nil
 	}
 
-	timeout := input.Timeout
-	if timeout == 0 {
-		timeout = 900
-	}
+	timeout := clampBrowserTimeout(input.Timeout)
 
 	p.logger.Printf("[browser_unblock] START url=%s country=%s timeout=%d", input.URL, input.Country, timeout)
 
-	// Step 0: Close any existing browser session AND release from pool.
-	// Must call API stop endpoint to free the pool slot, not just close WebSocket.
-	sessionCount := 0
-	browser.Store.Range(func(key, value any) bool {
-		s := value.(*browser.Session)
-		sid := key.(string)
-		p.logger.Printf("[browser_unblock] Step 0: closing + releasing session %s", sid)
-		s.Close()
-		if err := client.CloudBrowserSessionStop(sid); err != nil {
-			p.logger.Printf("[browser_unblock] Step 0: stop API call for %s failed (non-fatal): %v", sid, err)
-		} else {
-			p.logger.Printf("[browser_unblock] Step 0: session %s released from pool", sid)
-		}
-		sessionCount++
-		return true
-	})
-	p.logger.Printf("[browser_unblock] Step 0: closed %d existing sessions", sessionCount)
+	// Step 0: Close the caller's existing browser sessions AND release them
+	// from the pool. Must call API stop endpoint to free the pool slot, not
+	// just close WebSocket.
+	p.releaseOwnerBrowsers(browser.OwnerKey(client.APIKey()), "replaced by browser_unblock")
 	// Give the pool a moment to reclaim the slot
 	time.Sleep(1 * time.Second)
 
@@ -632,7 +642,7 @@ func (p *ScrapflyToolProvider) BrowserUnblock(
 	result, err := client.CloudBrowserUnblock(scrapfly.UnblockConfig{
 		URL:            input.URL,
 		Country:        input.Country,
-		BrowserTimeout: timeout,
+		BrowserTimeout: remoteBrowserTimeout(timeout), // timeout enforced locally, see trackBrowser
 		EnableMCP:      true,
 	})
 	if err != nil {
@@ -645,104 +655,27 @@ func (p *ScrapflyToolProvider) BrowserUnblock(
 	// Use the same internal cloud-browser service as cloud_browser_open.
 	browserConfig := &scrapfly.CloudBrowserConfig{
 		Session: result.SessionID,
-		Timeout: timeout,
+		Timeout: remoteBrowserTimeout(timeout),
 	}
 	internalWSURL := client.CloudBrowser(browserConfig)
 	p.logger.Printf("[browser_unblock] Step 2: connecting CDP WebSocket to %s (internal, bypassing proxy)", internalWSURL)
-	dialer := websocket.Dialer{
-		TLSClientConfig:  &tls.Config{InsecureSkipVerify: true},
-		HandshakeTimeout: 15 * time.Second,
-	}
-	conn, _, err := dialer.Dial(internalWSURL, nil)
-	if err != nil {
-		p.logger.Printf("[browser_unblock] Step 2 FAILED: %v", err)
-		return ToolErrFromError("browser_unblock", fmt.Errorf("browser connection failed: %w", err)), nil, nil
-	}
-	p.logger.Printf("[browser_unblock] Step 2 OK: WebSocket connected (local=%s remote=%s)", conn.LocalAddr(), conn.RemoteAddr())
-
 	session := &browser.Session{
 		SessionID: result.SessionID,
 		WSURL:     result.WSURL,
-		ExpiresAt: time.Now().Add(time.Duration(timeout) * time.Second),
-		CdpConn:   conn,
-	}
-	session.StartReader()
-
-	// Step 3: Attach to page target
-	p.logger.Printf("[browser_unblock] Step 3: finding page target")
-	targetsResult, err := session.SendCDP("Target.getTargets", nil)
-	if err != nil {
-		p.logger.Printf("[browser_unblock] Step 3 FAILED: getTargets: %v", err)
-		conn.Close()
-		return ToolErrFromError("browser_unblock", fmt.Errorf("get targets failed: %w", err)), nil, nil
-	}
-	var targets struct {
-		TargetInfos []struct {
-			TargetID string `json:"targetId"`
-			Type     string `json:"type"`
-		} `json:"targetInfos"`
-	}
-	json.Unmarshal(targetsResult, &targets)
-	var pageTargetID string
-	for _, t := range targets.TargetInfos {
-		if t.Type == "page" {
-			pageTargetID = t.TargetID
-			break
-		}
-	}
-	if pageTargetID == "" {
-		p.logger.Printf("[browser_unblock] Step 3 FAILED: no page target found in %d targets", len(targets.TargetInfos))
-		conn.Close()
-		return ToolErrFromError("browser_unblock", fmt.Errorf("no page target found")), nil, nil
+		Owner:     browser.OwnerKey(client.APIKey()),
 	}
-	p.logger.Printf("[browser_unblock] Step 3 OK: page target %s", pageTargetID)
+	session.SetExpiry(time.Now().Add(time.Duration(timeout) * time.Second))
 
-	attachResult, err := session.SendCDP("Target.attachToTarget", map[string]any{
-		"targetId": pageTargetID,
-		"flatten":  true,
-	})
-	if err != nil {
-		conn.Close()
-		return ToolErrFromError("browser_unblock", fmt.Errorf("attach failed: %w", err)), nil, nil
-	}
-	var attach struct {
-		SessionId string `json:"sessionId"`
+	// Steps 3-4: attach to the page target, enable WebMCP + Accessibility
+	if err := p.connectBrowser(ctx, client, session, internalWSURL); err != nil {
+		p.logger.Printf("[browser_unblock] Step 2-4 FAILED: %v", err)
+		return ToolErrFromError("browser_unblock", err), nil, nil
 	}
-	json.Unmarshal(attachResult, &attach)
-	session.CdpPageSessionID = attach.SessionId
-	p.logger.Printf("[browser_unblock] Step 3 OK: attached sessionId=%s", attach.SessionId)
-
-	// Step 4: Enable WebMCP + Accessibility
-	p.logger.Printf("[browser_unblock] Step 4: enabling WebMCP + Accessibility")
-	session.OnEvent("WebMCP.toolsAdded", func(method string, params json.RawMessage) bool {
-		var event struct {
-			Tools []browser.WebMCPToolInfo `json:"tools"`
-		}
-		if json.Unmarshal(params, &event) == nil {
-			session.Page.AddWebMCPTools(event.Tools)
-			p.logger.Printf("[WebMCP] toolsAdded: %d tools", len(event.Tools))
-		}
-		return true
-	})
-	session.OnEvent("WebMCP.toolsRemoved", func(method string, params json.RawMessage) bool {
-		var event struct {
-			Tools []struct{ Name string `json:"name"` } `json:"tools"`
-		}
-		if json.Unmarshal(params, &event) == nil {
-			names := make([]string, len(event.Tools))
-			for i, t := range event.Tools {
-				names[i] = t.Name
-			}
-			session.Page.RemoveWebMCPTools(names)
-		}
-		return true
-	})
-	session.SendCDP("WebMCP.enable", nil)
-	session.SendCDP("Accessibility.enable", nil)
+	p.logger.Printf("[browser_unblock] Step 4 OK: attached page target %s", session.PageTargetID)
 
 	// Navigate to the target URL — the browser starts on a blank tab with cookies pre-loaded
 	p.logger.Printf("[browser_unblock] Step 4: navigating to %s", input.URL)
-	_, err = session.SendCDP("Page.navigate", map[string]any{"url": input.URL})
+	_, err = session.SendCDPCtx(ctx, "Page.navigate", map[string]any{"url": input.URL})
 	if err != nil {
 		p.logger.Printf("[browser_unblock] Step 4: navigate failed (non-fatal): %v", err)
 	}
@@ -751,27 +684,22 @@ func (p *ScrapflyToolProvider) BrowserUnblock(
 	p.logger.Printf("[browser_unblock] Step 4 OK: waiting for page load")
 	time.Sleep(2 * time.Second)
 
-	// Step 5: Store session + auto-cleanup
+	// Step 5: Store session + hand it to the lifecycle manager
 	p.logger.Printf("[browser_unblock] Step 5: storing session %s", result.SessionID)
 	browser.Store.Store(result.SessionID, session)
-	cleanupTimer := time.AfterFunc(time.Until(session.ExpiresAt), func() {
-		if val, ok := browser.Store.Load(result.SessionID); ok {
-			s := val.(*browser.Session)
-			s.Close()
-			p.logger.Printf("Auto-closed expired browser session %s", result.SessionID)
-		}
-	})
-	session.CancelCleanup = func() { cleanupTimer.Stop() }
+	p.trackBrowser(req, client, session)
 
 	// Step 6: Build response with snapshot
 	p.logger.Printf("[browser_unblock] Step 6: refreshing page state and building response")
-	session.Page.Refresh(session)
+	session.Page.Refresh(ctx, session)
 	p.logger.Printf("[browser_unblock] DONE: session=%s url=%s title=%s", result.SessionID, session.Page.URL, session.Page.Title)
 	response := map[string]any{
 		"session_id": result.SessionID,
 		"status":     "connected",
 		"url":        input.URL,
 		"mode":       "unblock",
+		"expires_at": session.Expiry().Format(time.RFC3339),
+		"resources":  browserResourceURIs(session.SessionID),
 	}
 	response["instructions"] = fmt.Sprintf(
 		"[BROWSER MODE ACTIVE on %s — anti-bot bypassed] "+
@@ -793,6 +721,69 @@ func (p *ScrapflyToolProvider) BrowserUnblock(
 	}, nil, nil
 }
 
+// connectBrowser connects session to wsURL through browser.Connect. A
+// dropped socket resumes the same remote browser (session.SessionID) with
+// the WebMCP watchers intact; if it can't, the session is released. A nil
+// client is a CDP endpoint browser, resumed by dialing wsURL again.
+func (p *ScrapflyToolProvider) connectBrowser(ctx context.Context, client *scrapfly.Client, session *browser.Session, wsURL string) error {
+	p.watchWebMCPTools(session)
+	p.watchBrowserResources(session)
+	p.watchHumanControl(session)
+	reconnectURL := wsURL
+	if client != nil {
+		reconnectURL = client.CloudBrowser(&scrapfly.CloudBrowserConfig{
+			Session: session.SessionID,
+			Timeout: remoteBrowserTimeout(int(time.Until(session.Expiry()) / time.Second)),
+		})
+	}
+	return browser.Connect(ctx, session, browser.ConnectOptions{
+		URL:          wsURL,
+		ReconnectURL: reconnectURL,
+		Setup:        p.enableBrowserDomains,
+		OnLost: func(s *browser.Session, err error) {
+			p.releaseBrowser(s.SessionID, fmt.Sprintf("connection lost (%v)", err))
+		},
+	})
+}
+
+// clampBrowserTimeout applies the default and the Cloud Browser ceiling to
+// a requested session timeout in seconds.
+func clampBrowserTimeout(timeout int) int {
+	if timeout <= 0 {
+		return defaultBrowserTimeout
+	}
+	return min(timeout, maxBrowserTimeout)
+}
+
+// enableBrowserDomains enables the CDP domains the WebMCP watchers, the
+// snapshot and the session's resources rely on, on connect and again after a reconnect. The page may
+// have moved on while disconnected, so a reconnect drops its WebMCP tools
+// first; WebMCP.enable re-announces the live ones. WebMCP is optional — a
+// standard CDP browser doesn't have it — the other domains are not.
+func (p *ScrapflyToolProvider) enableBrowserDomains(ctx context.Context, session *browser.Session, reconnected bool) error {
+	if reconnected {
+		session.Page.ClearWebMCPTools()
+		p.syncWebMCPTools(session)
+	}
+	if _, err := session.SendCDPCtx(ctx, "Page.enable", nil); err != nil {
+		return fmt.Errorf("Page.enable: %w", err)
+	}
+	session.WebMCPEnable(ctx)
+	for _, method := range []string{"Accessibility.enable", "Runtime.enable", "Log.enable", "Network.enable"} {
+		if _, err := session.SendCDPCtx(ctx, method, nil); err != nil {
+			return fmt.Errorf("%s: %w", method, err)
+		}
+	}
+	return nil
+}
+
+// newBrowserSessionName returns a fresh Cloud Browser session name.
+func newBrowserSessionName() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "mcp-" + hex.EncodeToString(b)
+}
+
 // proxyWebMCPToolCallCDP dispatches WebMCP tool calls via CDP.
 // - Antibot tools (fill, clickOn, etc.) use WebMCP.callTool (Scrapium's custom handler)
 // - Page-registered tools (searchProducts, etc.) use WebMCP.invokeTool + toolResponded event
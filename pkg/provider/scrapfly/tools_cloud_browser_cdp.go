@@ -0,0 +1,106 @@
+package scrapflyprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser"
+)
+
+// Generic CDP backend.
+//
+// With a CDP endpoint configured (-cdp-url / SCRAPFLY_CDP_URL),
+// cloud_browser_open connects to that browser — typically a local Chrome
+// started with --remote-debugging-port — instead of allocating a Scrapfly
+// Cloud Browser, and no API key is needed for it. Every browser tool works
+// on the session; the ones that rely on the Cloud Browser's own CDP domains
+// fall back to standard CDP or report that they are unavailable (see
+// browser/fallback.go).
+
+// SetCDPEndpoint points cloud_browser_open at a CDP endpoint: a ws:// URL,
+// or the http:// address (or host:port) of a Chrome debugging port. Empty
+// restores the Scrapfly Cloud Browser.
+func (p *ScrapflyToolProvider) SetCDPEndpoint(endpoint string) {
+	p.cdpEndpoint = endpoint
+}
+
+// cdpFallbackNote tells the agent what changes on a browser without the
+// Cloud Browser domains.
+const cdpFallbackNote = "This browser is a standard CDP endpoint, not a Scrapfly Cloud Browser. " +
+	"click/fill/type_text/press_key/hover/scroll/select_option use plain CDP input events (no human-like timing, main frame only). " +
+	"Anti-bot bypass, captcha solving, downloads and page WebMCP tools are not available."
+
+// openCDPBrowser is cloud_browser_open against the configured CDP
+// endpoint. The Cloud Browser options (proxy, country, resource stubbing,
+// recording) don't apply there.
+func (p *ScrapflyToolProvider) openCDPBrowser(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input CloudBrowserOpenInput,
+) (*mcp.CallToolResult, any, error) {
+	// Sessions on the CDP endpoint have no owner.
+	p.releaseOwnerBrowsers("", "replaced by a new cloud_browser_open")
+
+	wsURL, err := browser.ResolveCDPEndpoint(ctx, p.cdpEndpoint)
+	if err != nil {
+		return ToolErrf("cloud_browser_open: %v", err), nil, nil
+	}
+	p.logger.Printf("cloud_browser_open: connecting to CDP endpoint %s", wsURL)
+
+	sessionName := newBrowserSessionName()
+	session := &browser.Session{
+		SessionID: sessionName,
+		WSURL:     wsURL,
+	}
+	session.SetExpiry(time.Now().Add(time.Duration(clampBrowserTimeout(input.Timeout)) * time.Second))
+	if err := p.connectBrowser(ctx, nil, session, wsURL); err != nil {
+		p.logger.Printf("cloud_browser_open: %v", err)
+		return ToolErrf("cloud_browser_open: %v", err), nil, nil
+	}
+	missing := session.MissingDomains(ctx)
+	p.logger.Printf("cloud_browser_open: session %s attached to page target %s (missing domains: %v)", sessionName, session.PageTargetID, missing)
+
+	if _, err := session.SendCDPCtx(ctx, "Page.navigate", map[string]any{"url": input.URL}); err != nil {
+		p.logger.Printf("cloud_browser_open: navigate failed (non-fatal): %v", err)
+	}
+	time.Sleep(2 * time.Second)
+
+	browser.Store.Store(sessionName, session)
+	p.trackBrowser(req, nil, session)
+
+	response := map[string]any{
+		"session_id": sessionName,
+		"status":     "connected",
+		"url":        input.URL,
+		"mode":       "cdp",
+		"endpoint":   p.cdpEndpoint,
+		"expires_at": session.Expiry().Format(time.RFC3339),
+		"resources":  browserResourceURIs(session.SessionID),
+	}
+	if len(missing) > 0 {
+		response["missing_domains"] = missing
+		response["note"] = cdpFallbackNote
+	}
+	if input.Country != "" || input.ProxyPool != "" || input.OptimizeBandwidth || input.BlockImages || input.BlockStyles ||
+		input.BlockFonts || input.BlockMedia || input.Blacklist || input.Cache || input.Debug {
+		response["ignored"] = "Proxy, country, resource stubbing, cache and debug options only apply to the Scrapfly Cloud Browser."
+	}
+	response["instructions"] = fmt.Sprintf(
+		"[BROWSER MODE ACTIVE on %s] "+
+			"Use click/fill/type_text/hover/press_key/scroll for interaction. "+
+			"Use take_snapshot for page content, take_screenshot for visual capture. "+
+			"KEEP THE SESSION OPEN across follow-up turns — the user may ask more questions about this page. "+
+			"Only call cloud_browser_close when the user explicitly asks to close, navigates to an unrelated site, or says they are done.",
+		input.URL)
+
+	session.Page.Refresh(ctx, session)
+	p.mountInteractionTools()
+
+	b, _ := json.MarshalIndent(response, "", "  ")
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: string(b) + "\n\n" + session.Page.Snapshot()}},
+	}, nil, nil
+}
@@ -0,0 +1,53 @@
+package scrapflyprovider
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser/browsertest"
+)
+
+// A CDP endpoint needs no API key: the provider below has no client.
+func TestCDPEndpointOpenAndNavigate(t *testing.T) {
+	srv := browsertest.NewServer()
+	defer srv.Close()
+	p := newTestProvider()
+	p.SetCDPEndpoint(srv.HTTPURL)
+	defer p.Shutdown()
+	ctx := context.Background()
+
+	res, _, err := p.CloudBrowserOpen(ctx, nil, CloudBrowserOpenInput{URL: "https://example.com/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := res.Content[0].(*mcp.TextContent).Text
+	if res.IsError {
+		t.Fatalf("cloud_browser_open = %s", text)
+	}
+	var opened struct {
+		SessionID string `json:"session_id"`
+	}
+	json.NewDecoder(strings.NewReader(text)).Decode(&opened)
+
+	res, _, err = p.CloudBrowserNavigate(ctx, nil, CloudBrowserNavigateInput{SessionID: opened.SessionID, URL: "https://example.com/next"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("cloud_browser_navigate = %s", res.Content[0].(*mcp.TextContent).Text)
+	}
+	var urls []string
+	for _, c := range srv.Calls("Page.navigate") {
+		var params struct {
+			URL string `json:"url"`
+		}
+		json.Unmarshal(c.Params, &params)
+		urls = append(urls, params.URL)
+	}
+	if len(urls) != 2 || urls[1] != "https://example.com/next" {
+		t.Errorf("Page.navigate calls = %v, want the open's URL then https://example.com/next", urls)
+	}
+}
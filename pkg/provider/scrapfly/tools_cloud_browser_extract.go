@@ -0,0 +1,86 @@
+package scrapflyprovider
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	scrapfly "github.com/scrapfly/go-scrapfly"
+	"github.com/scrapfly/scrapfly-mcp/internal/sanitizer"
+)
+
+type CloudBrowserExtractInput struct {
+	SessionID                   string                   `json:"session_id,omitempty" jsonschema:"Browser session ID. If omitted, uses the most recent session."`
+	UID                         string                   `json:"uid,omitempty" jsonschema:"Element id from take_snapshot. Only this element's HTML is sent for extraction. Default: the whole document."`
+	ExtractionPrompt            string                   `json:"extraction_prompt,omitempty" jsonschema:"required one of and exclusive with extraction_template and extraction_model, AI prompt to guide data extraction."`
+	ExtractionModel             scrapfly.ExtractionModel `json:"extraction_model,omitempty" jsonschema:"required one of and exclusive with extraction_template and extraction_prompt, The AI model to use for extraction."`
+	ExtractionTemplate          string                   `json:"extraction_template,omitempty" jsonschema:"required one of and exclusive with extraction_prompt and extraction_model, An extraction template to get structured data from the page."`
+	ExtractionEphemeralTemplate map[string]any           `json:"extraction_ephemeral_template,omitempty" jsonschema:"required one of and exclusive with extraction_prompt, An ephemeral extraction template to get structured data from the page."`
+}
+
+// CloudBrowserExtractResult is the extracted data with the page it came from.
+type CloudBrowserExtractResult struct {
+	URL         string `json:"url"`
+	Title       string `json:"title"`
+	UID         string `json:"uid,omitempty"`
+	HTMLBytes   int    `json:"html_bytes"`
+	ContentType string `json:"content_type"`
+	Data        any    `json:"data"`
+	DataQuality any    `json:"data_quality,omitempty"`
+}
+
+func (p *ScrapflyToolProvider) CloudBrowserExtract(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input CloudBrowserExtractInput,
+) (*mcp.CallToolResult, any, error) {
+	session, err := p.findBrowserSession(ctx, input.SessionID)
+	if err != nil {
+		return ToolErrf("cloud_browser_extract: %v", err), nil, nil
+	}
+	if input.ExtractionPrompt == "" && input.ExtractionModel == "" && input.ExtractionTemplate == "" && input.ExtractionEphemeralTemplate == nil {
+		return ToolErrf("cloud_browser_extract: one of extraction_prompt, extraction_model, extraction_template or extraction_ephemeral_template is required"), nil, nil
+	}
+	client, err := p.ClientGetter(p, ctx)
+	if err != nil {
+		return ToolErrFromError("cloud_browser_extract", err), nil, nil
+	}
+
+	// Refresh first: the uid is resolved against the snapshot, and the URL
+	// and title are the result's provenance.
+	session.Page.Refresh(ctx, session)
+	html, err := session.OuterHTML(ctx, input.UID)
+	if err != nil {
+		return ToolErrf("cloud_browser_extract: %v", err), nil, nil
+	}
+	pageURL, title := session.Page.URL, session.Page.Title
+
+	p.logger.Printf("[Extract] %s — %d bytes from %s", session.SessionID, len(html), pageURL)
+	extraction, err := client.Extract(&scrapfly.ExtractionConfig{
+		Body:                        []byte(html),
+		ContentType:                 "text/html",
+		Charset:                     "utf-8",
+		URL:                         pageURL,
+		ExtractionPrompt:            input.ExtractionPrompt,
+		ExtractionModel:             input.ExtractionModel,
+		ExtractionTemplate:          input.ExtractionTemplate,
+		ExtractionEphemeralTemplate: input.ExtractionEphemeralTemplate,
+	})
+	if err != nil {
+		return ToolErrFromError("cloud_browser_extract", err), nil, nil
+	}
+	sanitizer.BasicSanitizeNils(extraction)
+
+	b, _ := json.MarshalIndent(CloudBrowserExtractResult{
+		URL:         pageURL,
+		Title:       title,
+		UID:         input.UID,
+		HTMLBytes:   len(html),
+		ContentType: extraction.ContentType,
+		Data:        extraction.Data,
+		DataQuality: extraction.DataQuality,
+	}, "", "  ")
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: string(b)}},
+	}, nil, nil
+}
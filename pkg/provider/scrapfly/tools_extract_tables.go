@@ -0,0 +1,110 @@
+package scrapflyprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/scrapfly/scrapfly-mcp/internal/tables"
+)
+
+// maxExtractTables bounds the tables returned when no index is given.
+const maxExtractTables = 20
+
+type ExtractTablesInput struct {
+	HTML      string `json:"html,omitempty" jsonschema:"HTML to parse, e.g. the content of a web_scrape result in raw or clean_html format. If omitted, the live DOM of a browser session is used."`
+	SessionID string `json:"session_id,omitempty" jsonschema:"Browser session ID, when html is omitted. If omitted, uses the most recent session."`
+	UID       string `json:"uid,omitempty" jsonschema:"Browser session only: element id from take_snapshot. The table itself, or an element whose tables to parse."`
+	Selector  string `json:"selector,omitempty" jsonschema:"CSS selector: the tables it matches, or the tables inside the elements it matches."`
+	Index     int    `json:"index,omitempty" jsonschema:"1-based position of one table among the selected ones. Default: all of them (at most 20)."`
+	Kind      string `json:"kind,omitempty" jsonschema:"'table' (default) for <table>s, or 'list' for <ul>/<ol> items and <dl> term/definition pairs."`
+	Format    string `json:"format,omitempty" jsonschema:"'json' (default): records keyed by column name, in column order. 'csv': one CSV document per table."`
+	MaxRows   int    `json:"max_rows,omitempty" jsonschema:"Rows kept per table (default 1000)."`
+}
+
+// extractedTable is a table in the JSON result.
+type extractedTable struct {
+	tables.Table
+	RowCount int             `json:"row_count"`
+	Records  []tables.Record `json:"records"`
+}
+
+func (p *ScrapflyToolProvider) ExtractTables(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input ExtractTablesInput,
+) (*mcp.CallToolResult, any, error) {
+	source, doc := "html", input.HTML
+	if doc == "" {
+		session, err := p.findBrowserSession(ctx, input.SessionID)
+		if err != nil {
+			return ToolErrf("extract_tables: no html given and %v", err), nil, nil
+		}
+		session.Page.Refresh(ctx, session)
+		if doc, err = session.OuterHTML(ctx, input.UID); err != nil {
+			return ToolErrf("extract_tables: %v", err), nil, nil
+		}
+		source = session.Page.URL
+	} else if input.UID != "" {
+		return ToolErrf("extract_tables: uid only applies to a browser session; use selector with html"), nil, nil
+	}
+
+	opts := tables.Options{Selector: input.Selector, Index: input.Index, MaxRows: input.MaxRows}
+	parse, what := tables.Parse, "table(s)"
+	switch input.Kind {
+	case "", "table":
+	case "list":
+		parse, what = tables.ParseLists, "list(s)"
+	default:
+		return ToolErrf("extract_tables: unknown kind %q (use table or list)", input.Kind), nil, nil
+	}
+	found, err := parse(doc, opts)
+	if err != nil {
+		return ToolErrf("extract_tables: %v", err), nil, nil
+	}
+	if len(found) == 0 {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("Nothing found in %s.", source)}},
+		}, nil, nil
+	}
+	total := len(found)
+	if len(found) > maxExtractTables {
+		found = found[:maxExtractTables]
+	}
+	summary := fmt.Sprintf("%d %s found in %s", total, what, source)
+	if total > len(found) {
+		summary += fmt.Sprintf(", showing the first %d: pick one with index or narrow with selector", len(found))
+	}
+
+	switch input.Format {
+	case "", "json":
+		out := make([]extractedTable, len(found))
+		for i := range found {
+			out[i] = extractedTable{Table: found[i], RowCount: len(found[i].Rows), Records: found[i].Records()}
+		}
+		b, _ := json.MarshalIndent(map[string]any{"source": source, "tables": out}, "", "  ")
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: summary + "\n\n" + string(b)}},
+		}, nil, nil
+	case "csv":
+		content := []mcp.Content{&mcp.TextContent{Text: summary}}
+		for _, t := range found {
+			csv, err := t.CSV()
+			if err != nil {
+				return ToolErrf("extract_tables: %v", err), nil, nil
+			}
+			header := fmt.Sprintf("# %d", t.Index)
+			if t.Caption != "" {
+				header += " " + t.Caption
+			}
+			header += fmt.Sprintf(" (%d rows", len(t.Rows))
+			if t.Truncated {
+				header += ", truncated"
+			}
+			content = append(content, &mcp.TextContent{Text: header + ")\n" + csv})
+		}
+		return &mcp.CallToolResult{Content: content}, nil, nil
+	}
+	return ToolErrf("extract_tables: unknown format %q (use json or csv)", input.Format), nil, nil
+}
@@ -0,0 +1,216 @@
+package scrapflyprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser"
+)
+
+// Page-registered WebMCP tools as first-class MCP tools.
+//
+// Every tool a page registers via navigator.modelContext.registerTool() is
+// mirrored onto p.MCPServer as webmcp_<session>_<name> with the page's own
+// input schema, so clients get typed arguments and regular tool discovery
+// instead of going through call_webmcp_tool with a JSON string. The mirror
+// follows WebMCP.toolsAdded / toolsRemoved and main-frame navigations;
+// AddTool / RemoveTools fire notifications/tools/list_changed for us.
+//
+// call_webmcp_tool / list_webmcp_tools stay registered: clients that don't
+// refetch tools/list on list_changed (adk-python) still need a path in.
+
+// watchWebMCPTools registers the WebMCP + navigation event handlers that
+// keep session.Page's tool list current and mirrored onto the MCP server.
+// Call once per session, before browser.Connect: the handlers live on the
+// session and survive reconnects.
+func (p *ScrapflyToolProvider) watchWebMCPTools(session *browser.Session) {
+	session.OnEvent(browser.EventNameWebMCPToolsAdded, func(method string, params json.RawMessage) bool {
+		var event struct {
+			Tools []browser.WebMCPToolInfo `json:"tools"`
+		}
+		if json.Unmarshal(params, &event) == nil {
+			session.Page.AddWebMCPTools(event.Tools)
+			p.logger.Printf("[WebMCP] toolsAdded: %d tools", len(event.Tools))
+			go p.syncWebMCPTools(session)
+		}
+		return true // keep listening
+	})
+	session.OnWebMCPToolsRemoved(func(event *browser.EventWebMCPToolsRemoved) bool {
+		names := make([]string, len(event.Tools))
+		for i, t := range event.Tools {
+			names[i] = t.Name
+		}
+		session.Page.RemoveWebMCPTools(names)
+		p.logger.Printf("[WebMCP] toolsRemoved: %d tools", len(names))
+		go p.syncWebMCPTools(session)
+		return true
+	})
+	// Page tools are scoped to the document. A main-frame navigation the
+	// agent triggered by clicking (not via cloud_browser_navigate) doesn't
+	// always produce toolsRemoved, so drop them here; the new document's
+	// toolsAdded repopulates.
+	session.OnEvent("Page.frameNavigated", func(method string, params json.RawMessage) bool {
+		var event struct {
+			Frame struct {
+				ParentID string `json:"parentId"`
+			} `json:"frame"`
+		}
+		if json.Unmarshal(params, &event) == nil && event.Frame.ParentID == "" {
+			session.Page.ClearWebMCPTools()
+			go p.syncWebMCPTools(session)
+		}
+		return true
+	})
+}
+
+// syncWebMCPTools reconciles the MCP server's webmcp_* tools for session
+// with session.Page's current tool list. It always converges on the current
+// state, so concurrent calls from racing events are harmless.
+func (p *ScrapflyToolProvider) syncWebMCPTools(session *browser.Session) {
+	if p.MCPServer == nil {
+		return
+	}
+	p.webmcpMu.Lock()
+	defer p.webmcpMu.Unlock()
+
+	pageTools := session.Page.GetWebMCPTools()
+	want := make(map[string]browser.WebMCPToolInfo, len(pageTools))
+	for _, t := range pageTools {
+		name := browser.NamespacedToolName(session.SessionID, t.Name)
+		if prev, ok := want[name]; ok && prev.Name != t.Name {
+			p.logger.Printf("[WebMCP] page tools %q and %q both map to %s; keeping the first", prev.Name, t.Name, name)
+			continue
+		}
+		want[name] = t
+	}
+
+	var stale, kept []string
+	have := map[string]bool{}
+	for _, name := range session.ToolNames {
+		if _, ok := want[name]; ok {
+			kept = append(kept, name)
+			have[name] = true
+		} else {
+			stale = append(stale, name)
+		}
+	}
+	if len(stale) > 0 {
+		p.MCPServer.RemoveTools(stale...)
+	}
+	added := 0
+	for name, t := range want {
+		if have[name] {
+			continue
+		}
+		p.MCPServer.AddTool(webMCPTool(name, t), p.webMCPToolHandler(session, t.Name))
+		kept = append(kept, name)
+		added++
+	}
+	session.ToolNames = kept
+	if added > 0 || len(stale) > 0 {
+		p.logger.Printf("[WebMCP] page tools on MCP server: +%d -%d (%d mounted)", added, len(stale), len(kept))
+	}
+}
+
+// unmountWebMCPTools removes every webmcp_* tool mirrored for session. Used
+// on close / expiry, where no toolsRemoved event will arrive.
+func (p *ScrapflyToolProvider) unmountWebMCPTools(session *browser.Session) {
+	session.Page.ClearWebMCPTools()
+	p.syncWebMCPTools(session)
+}
+
+// webMCPTool builds the MCP tool definition for a page tool. The page's
+// schema is passed through verbatim when it is a JSON object schema; the
+// MCP server rejects anything else, so those fall back to an open object.
+func webMCPTool(name string, t browser.WebMCPToolInfo) *mcp.Tool {
+	var schema map[string]any
+	if json.Unmarshal(t.InputSchema, &schema) != nil || schema["type"] != "object" {
+		schema = map[string]any{"type": "object"}
+	}
+	readOnly := t.Annotations != nil && t.Annotations.ReadOnly
+	description := t.Description
+	if description == "" {
+		description = "Page-registered WebMCP tool " + t.Name + "."
+	}
+	return &mcp.Tool{
+		Name:        name,
+		Title:       t.Name,
+		Description: description + "\n\n(Registered by the current page via navigator.modelContext; disappears when the page navigates away.)",
+		InputSchema: schema,
+		Annotations: &mcp.ToolAnnotations{Title: t.Name, ReadOnlyHint: readOnly, OpenWorldHint: &trueBool},
+		Meta:        standardPermissionsMeta,
+	}
+}
+
+// SetWebMCPToolTimeouts sets how long invocations of page WebMCP tools
+// wait for a response, by page tool name; "*" applies to tools without an
+// entry of their own. Unlisted tools keep browser.DefaultInvokeTimeout, and
+// every value is capped at browser.MaxInvokeTimeout. Call before serving.
+func (p *ScrapflyToolProvider) SetWebMCPToolTimeouts(timeouts map[string]time.Duration) {
+	p.webmcpTimeouts = timeouts
+}
+
+// ParseWebMCPToolTimeouts parses a comma-separated list of TOOL=DURATION
+// pairs ("checkout=2m,*=45s") for SetWebMCPToolTimeouts.
+func ParseWebMCPToolTimeouts(spec string) (map[string]time.Duration, error) {
+	timeouts := map[string]time.Duration{}
+	for _, pair := range strings.Split(spec, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%q: want TOOL=DURATION", pair)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%q: %v", pair, err)
+		}
+		if d <= 0 || d > browser.MaxInvokeTimeout {
+			return nil, fmt.Errorf("%q: timeout must be positive and at most %s", pair, browser.MaxInvokeTimeout)
+		}
+		timeouts[name] = d
+	}
+	return timeouts, nil
+}
+
+// webMCPToolTimeout is the configured invoke timeout of page tool name, or
+// 0 for the default.
+func (p *ScrapflyToolProvider) webMCPToolTimeout(name string) time.Duration {
+	if d, ok := p.webmcpTimeouts[name]; ok {
+		return d
+	}
+	return p.webmcpTimeouts["*"]
+}
+
+// webMCPToolHandler forwards a webmcp_* call to the page. The wait bound is
+// the tool's configured timeout; the arguments belong to the page tool's own
+// schema, so a per-call override comes from the request's _meta
+// ("scrapfly/timeout_ms") rather than an argument.
+//
+// Page tools are mounted on the MCP server every API key shares, so the
+// call is refused unless session was opened with the caller's key.
+func (p *ScrapflyToolProvider) webMCPToolHandler(session *browser.Session, toolName string) mcp.ToolHandler {
+	return func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if found, err := p.findBrowserSession(ctx, session.SessionID); err != nil || found != session {
+			if err == nil {
+				err = fmt.Errorf("session %s not found", session.SessionID)
+			}
+			return ToolErrf("%s: %v", req.Params.Name, err), nil
+		}
+		args := req.Params.Arguments
+		if len(args) == 0 {
+			args = json.RawMessage(`{}`)
+		}
+		timeout := p.webMCPToolTimeout(toolName)
+		if ms, ok := req.Params.Meta["scrapfly/timeout_ms"].(float64); ok && ms > 0 {
+			timeout = time.Duration(ms) * time.Millisecond
+		}
+		return browser.InvokeTool(ctx, p.logger, session, toolName, args, timeout)
+	}
+}
@@ -0,0 +1,135 @@
+package scrapflyprovider
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	scrapfly "github.com/scrapfly/go-scrapfly"
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser"
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser/browsertest"
+)
+
+func TestParseWebMCPToolTimeouts(t *testing.T) {
+	got, err := ParseWebMCPToolTimeouts(" checkout=2m, *=45s ,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["checkout"] != 2*time.Minute || got["*"] != 45*time.Second || len(got) != 2 {
+		t.Errorf("ParseWebMCPToolTimeouts = %v", got)
+	}
+	for _, spec := range []string{"checkout", "=1m", "checkout=soon", "checkout=0s", "checkout=10m"} {
+		if _, err := ParseWebMCPToolTimeouts(spec); err == nil {
+			t.Errorf("ParseWebMCPToolTimeouts(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+func TestWebMCPToolTimeoutLookup(t *testing.T) {
+	p := newTestProvider()
+	if d := p.webMCPToolTimeout("search"); d != 0 {
+		t.Errorf("unconfigured timeout = %s, want 0 (the default)", d)
+	}
+	p.SetWebMCPToolTimeouts(map[string]time.Duration{"checkout": 2 * time.Minute, "*": 45 * time.Second})
+	for name, want := range map[string]time.Duration{"checkout": 2 * time.Minute, "search": 45 * time.Second} {
+		if d := p.webMCPToolTimeout(name); d != want {
+			t.Errorf("webMCPToolTimeout(%q) = %s, want %s", name, d, want)
+		}
+	}
+}
+
+// hangingToolSession connects to a page whose "slow" WebMCP tool never
+// responds, as a session of p's API key (key-mine unless p has a client).
+func hangingToolSession(t *testing.T, p *ScrapflyToolProvider) *browser.Session {
+	t.Helper()
+	if p.Client == nil {
+		client, err := scrapfly.New("key-mine")
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Client = client
+	}
+	srv := browsertest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.AddWebMCPTool(browser.WebMCPTool{Name: "slow"}, nil)
+	srv.Handle(browser.CommandWebMCPInvokeTool, func(c *browsertest.Call) (any, error) {
+		return map[string]any{"invocationId": "inv-1"}, nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	session, err := srv.Connect(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session.SessionID, session.Owner = "hanging-"+t.Name(), browser.OwnerKey(p.Client.APIKey())
+	browser.Store.Store(session.SessionID, session)
+	t.Cleanup(func() {
+		browser.Store.Delete(session.SessionID)
+		session.Close()
+	})
+	p.watchWebMCPTools(session)
+	session.WebMCPEnable(ctx)
+	for len(session.Page.GetWebMCPTools()) == 0 {
+		if ctx.Err() != nil {
+			t.Fatal("page tool never announced")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return session
+}
+
+func callPageTool(t *testing.T, handler mcp.ToolHandler, meta mcp.Meta) (*mcp.CallToolResult, time.Duration) {
+	t.Helper()
+	start := time.Now()
+	res, err := handler(context.Background(), &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{
+		Name:      "webmcp_slow",
+		Arguments: json.RawMessage(`{}`),
+		Meta:      meta,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res, time.Since(start)
+}
+
+func TestWebMCPToolHandlerUsesConfiguredTimeout(t *testing.T) {
+	p := newTestProvider()
+	p.SetWebMCPToolTimeouts(map[string]time.Duration{"slow": 100 * time.Millisecond})
+	handler := p.webMCPToolHandler(hangingToolSession(t, p), "slow")
+
+	res, took := callPageTool(t, handler, nil)
+	if !res.IsError {
+		t.Fatal("hanging tool call succeeded")
+	}
+	if took > 5*time.Second {
+		t.Errorf("call took %s, want it bounded by the configured 100ms", took)
+	}
+
+	// _meta still overrides one call.
+	_, took = callPageTool(t, handler, mcp.Meta{"scrapfly/timeout_ms": float64(300)})
+	if took < 300*time.Millisecond {
+		t.Errorf("call with a 300ms _meta timeout took %s", took)
+	}
+}
+
+func TestWebMCPToolHandlerScopedToOwner(t *testing.T) {
+	p := newTestProvider()
+	session := hangingToolSession(t, p)
+
+	other := newTestProvider()
+	client, err := scrapfly.New("key-theirs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.Client = client
+	res, took := callPageTool(t, other.webMCPToolHandler(session, "slow"), nil)
+	if !res.IsError || !strings.Contains(res.Content[0].(*mcp.TextContent).Text, "not found") {
+		t.Errorf("another key's call = %+v, want the session not found", res.Content)
+	}
+	if took > time.Second {
+		t.Errorf("another key's call took %s, want it refused before invoking the page", took)
+	}
+}
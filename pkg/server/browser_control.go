@@ -0,0 +1,86 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/gorilla/websocket"
+	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser"
+)
+
+// controlConns numbers takeover connections so each holds the lock under
+// its own name.
+var controlConns atomic.Int64
+
+// controlMessage is what /browser/control sends back.
+type controlMessage struct {
+	Type      string `json:"type"` // "control" or "error"
+	Holder    string `json:"holder,omitempty"`
+	InControl bool   `json:"in_control"`
+	Error     string `json:"error,omitempty"`
+}
+
+// handleBrowserControl is the human takeover channel. The client sends
+// JSON messages:
+//
+//	{"type": "take"}     — take control; agent interaction tools get
+//	                       HUMAN_IN_CONTROL until released
+//	{"type": "release"}  — hand control back (also done on disconnect)
+//	{"type": "mouse", "event": "mousePressed", "x": 120, "y": 48,
+//	 "frame_width": 1280, "frame_height": 720, "button": "left"}
+//	{"type": "scroll", "x": 640, "y": 360, "delta_y": 300, ...}
+//	{"type": "key", "event": "keyDown", "key": "a", "code": "KeyA", "text": "a"}
+//
+// Coordinates are screencast frame pixels (see browser.HumanInput). The
+// server answers take/release with {"type": "control", "holder", "in_control"}
+// and a failed message with {"type": "error", "error"}.
+func (e *browserEndpoints) handleBrowserControl(w http.ResponseWriter, r *http.Request) {
+	session, err := e.findSession(r, r.URL.Query().Get("session_id"))
+	if err != nil {
+		writeJSONErr(w, err, http.StatusNotFound)
+		return
+	}
+	upgrader := websocket.Upgrader{CheckOrigin: e.opts.CheckOrigin}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return // Upgrade already replied
+	}
+	defer conn.Close()
+
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		name = "human"
+	}
+	holder := fmt.Sprintf("%s#%d", name, controlConns.Add(1))
+	defer session.ReleaseControl(holder)
+
+	ctx := r.Context()
+	for {
+		var ev browser.HumanInput
+		if err := conn.ReadJSON(&ev); err != nil {
+			return
+		}
+		var reply *controlMessage
+		switch ev.Type {
+		case "take":
+			if err := session.TakeControl(holder); err != nil {
+				reply = &controlMessage{Type: "error", Error: err.Error()}
+				break
+			}
+			reply = &controlMessage{Type: "control", Holder: holder, InControl: true}
+		case "release":
+			session.ReleaseControl(holder)
+			reply = &controlMessage{Type: "control", Holder: holder, InControl: false}
+		default:
+			if err := session.DispatchHumanInput(ctx, holder, ev); err != nil {
+				reply = &controlMessage{Type: "error", Error: err.Error()}
+			}
+		}
+		if reply != nil {
+			if err := conn.WriteJSON(reply); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -3,7 +3,9 @@ package server
 import (
 	"encoding/json"
 	"fmt"
+	"mime"
 	"net/http"
+	"strconv"
 	"sync/atomic"
 
 	"github.com/scrapfly/scrapfly-mcp/pkg/provider/scrapfly/browser"
@@ -14,19 +16,29 @@ import (
 // expects, but use the in-process browser.Session store (no gRPC, no
 // browser-proxy, no internal-token auth). Trust boundary is the
 // process — designed for the "1 agent = 1 browser" agent-ai stack
-// where the playground and MCP run on host loopback.
+// where the playground and MCP run on host loopback. Shared deployments
+// use RegisterBrowserEndpointsWith to authenticate callers and scope them
+// to their own sessions.
 //
 // Routes:
 //
 //	GET /browser/screencast?session_id=...   — SSE: JPEG frames as `event: frame`
 //	GET /browser/downloads?session_id=...    — JSON: download manifest
-//	GET /browser/download?session_id=&filename=...  — JSON: base64 file payload
+//	GET /browser/download?session_id=&filename=...  — the file itself, with
+//	    its sniffed Content-Type and an X-Content-SHA256 header.
+//	    `&save=1` writes it to the -download-dir instead and returns JSON
+//	    {"filename", "path", "size", "mime_type", "sha256"};
+//	    `&encoding=base64` returns the legacy JSON {"filename", "data",
+//	    "encoding": "base64"} payload.
 //	GET /browser/captchas?session_id=...     — JSON: empty list (captcha records
 //	    are a browser-proxy-only feature; we always return {"records": []}
 //	    so the UI's polling path doesn't error in OSS / agent-ai mode).
 //	GET /browser/active                      — JSON: {"session_id": "...", "url": "..."}
 //	    or {} if no session — used by the playground UI to reattach to an
 //	    in-progress session after a page reload.
+//	GET /browser/control?session_id=&name=   — WebSocket: human takeover;
+//	    mouse / keyboard / scroll input replayed through CDP while the
+//	    agent is locked out. See handleBrowserControl.
 //	GET /browser/screenshot?session_id=...   — JSON: {"data": "<base64 PNG>",
 //	    "mime": "image/png"}; one-shot capture via CDP Page.captureScreenshot.
 //	    Skips the LLM round-trip used by the in-tool cloud_browser_screenshot,
@@ -37,12 +49,54 @@ import (
 // the right behavior for the agent-ai stack, where there is at most
 // one concurrent session per MCP process.
 func RegisterBrowserEndpoints(mux *http.ServeMux) {
-	mux.HandleFunc("/browser/screencast", handleBrowserScreencast)
-	mux.HandleFunc("/browser/downloads", handleBrowserDownloads)
-	mux.HandleFunc("/browser/download", handleBrowserDownload)
-	mux.HandleFunc("/browser/captchas", handleBrowserCaptchas)
-	mux.HandleFunc("/browser/active", handleBrowserActive)
-	mux.HandleFunc("/browser/screenshot", handleBrowserScreenshot)
+	RegisterBrowserEndpointsWith(mux, BrowserEndpointsOptions{})
+}
+
+// BrowserEndpointsOptions configures RegisterBrowserEndpointsWith for
+// deployments where the process is not the trust boundary.
+type BrowserEndpointsOptions struct {
+	// Middleware wraps every route — authentication, CORS.
+	Middleware func(http.Handler) http.Handler
+	// Owner returns the browser.OwnerKey of the caller; routes only see
+	// sessions opened with it. nil, or an empty key, sees every session.
+	Owner func(r *http.Request) string
+	// CheckOrigin vets the Origin of /browser/control WebSocket upgrades.
+	// nil only accepts same-origin requests.
+	CheckOrigin func(r *http.Request) bool
+}
+
+// RegisterBrowserEndpointsWith is RegisterBrowserEndpoints with
+// authentication and per-caller session scoping.
+func RegisterBrowserEndpointsWith(mux *http.ServeMux, opts BrowserEndpointsOptions) {
+	e := &browserEndpoints{opts: opts}
+	for path, handler := range map[string]http.HandlerFunc{
+		"/browser/screencast": e.handleBrowserScreencast,
+		"/browser/downloads":  e.handleBrowserDownloads,
+		"/browser/download":   e.handleBrowserDownload,
+		"/browser/captchas":   e.handleBrowserCaptchas,
+		"/browser/active":     e.handleBrowserActive,
+		"/browser/screenshot": e.handleBrowserScreenshot,
+		"/browser/control":    e.handleBrowserControl,
+	} {
+		var h http.Handler = handler
+		if opts.Middleware != nil {
+			h = opts.Middleware(h)
+		}
+		mux.Handle(path, h)
+	}
+}
+
+type browserEndpoints struct {
+	opts BrowserEndpointsOptions
+}
+
+// findSession is browser.FindSession scoped to the caller.
+func (e *browserEndpoints) findSession(r *http.Request, sessionID string) (*browser.Session, error) {
+	owner := ""
+	if e.opts.Owner != nil {
+		owner = e.opts.Owner(r)
+	}
+	return browser.FindSessionFor(owner, sessionID)
 }
 
 func writeJSONErr(w http.ResponseWriter, err error, status int) {
@@ -50,7 +104,7 @@ func writeJSONErr(w http.ResponseWriter, err error, status int) {
 	http.Error(w, string(body), status)
 }
 
-func handleBrowserScreencast(w http.ResponseWriter, r *http.Request) {
+func (e *browserEndpoints) handleBrowserScreencast(w http.ResponseWriter, r *http.Request) {
 	flusher, ok := w.(http.Flusher)
 	if !ok {
 		http.Error(w, `{"error":"streaming not supported"}`, http.StatusInternalServerError)
@@ -61,27 +115,27 @@ func handleBrowserScreencast(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Connection", "keep-alive")
 	w.Header().Set("X-Accel-Buffering", "no")
 
-	session, err := browser.FindSession(r.URL.Query().Get("session_id"))
+	session, err := e.findSession(r, r.URL.Query().Get("session_id"))
 	if err != nil {
 		writeJSONErr(w, err, http.StatusNotFound)
 		return
 	}
 
 	// CDP screencast frames arrive on a goroutine the Session owns —
-	// it keeps producing even after this handler returns (StopScreencast
-	// is called below, but it's racy with in-flight frames). Without a
+	// it keeps producing even after this handler returns (stop is called
+	// below, but it's racy with in-flight frames). Without a
 	// guard, a frame that lands while the http.ResponseWriter is being
 	// torn down hits a nil bufio.Writer and crashes the whole process.
 	//
 	// Three protections:
-	//   1) Atomic `stopped` flag flipped before StopScreencast — frames
+	//   1) Atomic `stopped` flag flipped before stop — frames
 	//      that win the race do nothing.
 	//   2) ctx.Err() check — never write after the client disconnects.
 	//   3) `defer recover()` — last line of defense; we'd rather drop a
 	//      frame than panic the MCP server.
 	ctx := r.Context()
 	var stopped atomic.Bool
-	session.StartScreencast("jpeg", 100, func(frame browser.ScreencastFrame) {
+	stop := session.StartScreencast("jpeg", 100, func(frame browser.ScreencastFrame) {
 		if stopped.Load() || ctx.Err() != nil {
 			return
 		}
@@ -98,16 +152,16 @@ func handleBrowserScreencast(w http.ResponseWriter, r *http.Request) {
 	})
 	<-ctx.Done()
 	stopped.Store(true)
-	session.StopScreencast()
+	stop()
 }
 
-func handleBrowserDownloads(w http.ResponseWriter, r *http.Request) {
-	session, err := browser.FindSession(r.URL.Query().Get("session_id"))
+func (e *browserEndpoints) handleBrowserDownloads(w http.ResponseWriter, r *http.Request) {
+	session, err := e.findSession(r, r.URL.Query().Get("session_id"))
 	if err != nil {
 		writeJSONErr(w, err, http.StatusNotFound)
 		return
 	}
-	downloads, err := session.ListDownloads()
+	downloads, err := session.ListDownloads(r.Context())
 	if err != nil {
 		writeJSONErr(w, err, http.StatusInternalServerError)
 		return
@@ -116,27 +170,57 @@ func handleBrowserDownloads(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(downloads)
 }
 
-func handleBrowserDownload(w http.ResponseWriter, r *http.Request) {
+func (e *browserEndpoints) handleBrowserDownload(w http.ResponseWriter, r *http.Request) {
 	filename := r.URL.Query().Get("filename")
 	if filename == "" {
 		http.Error(w, `{"error":"filename is required"}`, http.StatusBadRequest)
 		return
 	}
-	session, err := browser.FindSession(r.URL.Query().Get("session_id"))
+	session, err := e.findSession(r, r.URL.Query().Get("session_id"))
 	if err != nil {
 		writeJSONErr(w, err, http.StatusNotFound)
 		return
 	}
-	data, err := session.GetDownload(filename)
+	q := r.URL.Query()
+	if q.Get("encoding") == "base64" {
+		data, err := session.GetDownload(r.Context(), filename)
+		if err != nil {
+			writeJSONErr(w, err, http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"filename": filename, "data": data, "encoding": "base64"})
+		return
+	}
+	d, err := session.FetchDownload(r.Context(), filename)
 	if err != nil {
 		writeJSONErr(w, err, http.StatusInternalServerError)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(map[string]string{"filename": filename, "data": data, "encoding": "base64"})
+	if save, _ := strconv.ParseBool(q.Get("save")); save {
+		path, err := session.SaveDownload(d)
+		if err != nil {
+			writeJSONErr(w, err, http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"filename":  d.Filename,
+			"path":      path,
+			"size":      d.Size,
+			"mime_type": d.MIMEType,
+			"sha256":    d.SHA256,
+		})
+		return
+	}
+	w.Header().Set("Content-Type", d.MIMEType)
+	w.Header().Set("Content-Length", strconv.Itoa(d.Size))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": d.Filename}))
+	w.Header().Set("X-Content-SHA256", d.SHA256)
+	_, _ = w.Write(d.Data)
 }
 
-func handleBrowserCaptchas(w http.ResponseWriter, r *http.Request) {
+func (e *browserEndpoints) handleBrowserCaptchas(w http.ResponseWriter, r *http.Request) {
 	// Captcha records require the Antibot CDP domain plumbed through
 	// browser-proxy — not available in the OSS browser package the
 	// agent-ai stack uses. Returning an empty list keeps the UI's
@@ -150,9 +234,9 @@ func handleBrowserCaptchas(w http.ResponseWriter, r *http.Request) {
 // "Capture frame" button calls this directly — no LLM in the loop, so
 // it's instant and doesn't burn agent tokens. Mirrors the in-tool
 // `take_screenshot` CDP path exactly.
-func handleBrowserScreenshot(w http.ResponseWriter, r *http.Request) {
+func (e *browserEndpoints) handleBrowserScreenshot(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	session, err := browser.FindSession(r.URL.Query().Get("session_id"))
+	session, err := e.findSession(r, r.URL.Query().Get("session_id"))
 	if err != nil {
 		writeJSONErr(w, err, http.StatusNotFound)
 		return
@@ -177,9 +261,9 @@ func handleBrowserScreenshot(w http.ResponseWriter, r *http.Request) {
 // session, or an empty object when none. The playground polls this on
 // page load so a refresh mid-session reattaches the screencast and
 // captured-downloads pane without losing context.
-func handleBrowserActive(w http.ResponseWriter, r *http.Request) {
+func (e *browserEndpoints) handleBrowserActive(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	session, err := browser.FindSession("")
+	session, err := e.findSession(r, "")
 	if err != nil || session == nil {
 		_ = json.NewEncoder(w).Encode(map[string]any{})
 		return
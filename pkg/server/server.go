@@ -139,14 +139,16 @@ func newServer(toolProviders ...provider.ToolProvider) *mcp.Server {
 	log.Printf("[SCRAPFLY-MCP] Bootstraping MCP server...\n")
 	log.Printf("[SCRAPFLY-MCP] Server version: %s\n", ServerVersion)
 
+	options := &mcp.ServerOptions{
+		Instructions: "always ensure assistant has read the scraping_instruction_enhanced tool before using any scraping",
+	}
+	withResourceSubscriptions(options, toolProviders)
+
 	server := mcp.NewServer(&mcp.Implementation{
 		Name:    "scrapfly-tools",
 		Title:   "Scrapfly MCP Server",
 		Version: ServerVersion,
-	},
-		&mcp.ServerOptions{
-			Instructions: "always ensure assistant has read the scraping_instruction_enhanced tool before using any scraping",
-		})
+	}, options)
 
 	if len(toolProviders) > 0 {
 		for _, toolProvider := range toolProviders {
@@ -160,3 +162,34 @@ func newServer(toolProviders ...provider.ToolProvider) *mcp.Server {
 
 	return server
 }
+
+// withResourceSubscriptions enables resources/subscribe when at least one
+// provider implements provider.ResourceSubscriber. A subscription is accepted
+// by the first provider that recognizes the URI; unsubscribing is forwarded
+// to all of them.
+func withResourceSubscriptions(options *mcp.ServerOptions, toolProviders []provider.ToolProvider) {
+	var subscribers []provider.ResourceSubscriber
+	for _, toolProvider := range toolProviders {
+		if rs, ok := toolProvider.ResourceSubscriber(); ok {
+			subscribers = append(subscribers, rs)
+		}
+	}
+	if len(subscribers) == 0 {
+		return
+	}
+	options.SubscribeHandler = func(ctx context.Context, req *mcp.SubscribeRequest) error {
+		err := mcp.ResourceNotFoundError(req.Params.URI)
+		for _, rs := range subscribers {
+			if err = rs.SubscribeResource(ctx, req.Params.URI); err == nil {
+				return nil
+			}
+		}
+		return err
+	}
+	options.UnsubscribeHandler = func(ctx context.Context, req *mcp.UnsubscribeRequest) error {
+		for _, rs := range subscribers {
+			rs.UnsubscribeResource(ctx, req.Params.URI)
+		}
+		return nil
+	}
+}
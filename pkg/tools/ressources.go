@@ -6,8 +6,11 @@ import (
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
+// HandledResource is a resource, or a resource template when Template is
+// set, with its handler.
 type HandledResource struct {
 	Resource *mcp.Resource
+	Template *mcp.ResourceTemplate
 	Handler  mcp.ResourceHandler
 }
 
@@ -30,9 +33,22 @@ func AddResourceToResourceSet(s HandledResourceSet, t *mcp.Resource, h mcp.Resou
 	return nil
 }
 
+func AddResourceTemplateToResourceSet(s HandledResourceSet, t *mcp.ResourceTemplate, h mcp.ResourceHandler) error {
+	if t.URITemplate == "" {
+		return fmt.Errorf("AddResourceTemplateToResourceSet: template %q: empty URI template", t.Name)
+	}
+	s[t.Name] = &HandledResource{Template: t, Handler: h}
+	return nil
+}
+
 func (s HandledResourceSet) RegisterResources(server *mcp.Server) []string {
 	resourceNames := make([]string, 0, len(s))
 	for _, ht := range s {
+		if ht.Template != nil {
+			resourceNames = append(resourceNames, ht.Template.Name)
+			server.AddResourceTemplate(ht.Template, ht.Handler)
+			continue
+		}
 		resourceNames = append(resourceNames, ht.Resource.Name)
 		server.AddResource(ht.Resource, ht.Handler)
 	}